@@ -3,7 +3,10 @@ package marp
 import (
 	"context"
 	"fmt"
+	"os"
+	"slices"
 
+	"github.com/grokify/slidekit/internal/deckfile"
 	"github.com/grokify/slidekit/model"
 )
 
@@ -15,12 +18,26 @@ type Backend struct {
 
 // NewBackend creates a new Marp backend.
 func NewBackend() *Backend {
+	return NewBackendWithOptions(ReaderOptions{})
+}
+
+// NewBackendWithOptions creates a Marp backend whose every read, including
+// those behind Plan and Apply, is configured by opts.
+func NewBackendWithOptions(opts ReaderOptions) *Backend {
 	return &Backend{
-		reader: NewReader(),
+		reader: NewReaderWithOptions(opts),
 		writer: NewWriter(),
 	}
 }
 
+// WithSectionRules returns a Marp backend that groups slides into sections
+// by rules, implementing model.SectionGrouper.
+func (b *Backend) WithSectionRules(rules model.SectionRules) model.Backend {
+	opts := b.reader.opts
+	opts.Sections = rules
+	return NewBackendWithOptions(opts)
+}
+
 // Info returns backend metadata.
 func (b *Backend) Info() model.BackendInfo {
 	return model.BackendInfo{
@@ -40,32 +57,81 @@ func (b *Backend) Read(ctx context.Context, ref model.Ref) (*model.Deck, error)
 	return b.reader.Read(ctx, ref)
 }
 
+// ReadDiagnostics loads a Marp presentation from a file and reports
+// malformed source found while reading it.
+func (b *Backend) ReadDiagnostics(ctx context.Context, ref model.Ref) (*model.Deck, []model.Diagnostic, error) {
+	return b.reader.ReadDiagnostics(ctx, ref)
+}
+
+// StreamSlides calls fn with each slide of a Marp file as it is read,
+// without building the whole deck.
+func (b *Backend) StreamSlides(ctx context.Context, ref model.Ref, fn func(section model.Section, slide model.Slide) error) error {
+	return b.reader.StreamSlides(ctx, ref, fn)
+}
+
 // Plan computes changes needed to reach desired state.
-func (b *Backend) Plan(_ context.Context, ref model.Ref, desired *model.Deck) (*model.Diff, error) {
+func (b *Backend) Plan(ctx context.Context, ref model.Ref, desired *model.Deck) (*model.Diff, error) {
 	// Read current state
-	current, err := b.reader.ReadFile(ref.Path)
+	current, err := b.reader.Read(ctx, ref)
 	if err != nil {
 		return nil, fmt.Errorf("reading current deck: %w", err)
 	}
 
-	return computeDiff(current, desired), nil
+	return model.ComputeDiff(current, desired), nil
 }
 
-// Apply writes the diff to the file. For Marp, this means rewriting the file.
-func (b *Backend) Apply(_ context.Context, ref model.Ref, diff *model.Diff) error {
+// Apply writes the diff to the file. Only the regions of the file touched
+// by the diff are rewritten; everything else is kept byte-for-byte. The
+// file is rewritten in full when the edited one would not read back as the
+// updated deck, and left alone with an error when no Marp file would.
+func (b *Backend) Apply(ctx context.Context, ref model.Ref, diff *model.Diff) error {
 	if diff.IsEmpty() {
 		return nil
 	}
 
-	// For Marp files, we regenerate the whole file from the desired state.
-	// A more sophisticated implementation could do surgical edits.
-	current, err := b.reader.ReadFile(ref.Path)
+	data, err := os.ReadFile(ref.Path)
 	if err != nil {
 		return fmt.Errorf("reading current deck: %w", err)
 	}
 
-	applyDiff(current, diff)
-	return b.writer.WriteFile(current, ref.Path)
+	// Parse twice: the document keeps the original slides to compare
+	// against, while the diff is applied to an independent copy.
+	_, doc := b.reader.parseDocument(string(data))
+	desired, _ := b.reader.parseDocument(string(data))
+	applyThemeFile(doc.deck, ref.Path)
+	applyThemeFile(desired, ref.Path)
+	if err := desired.ApplyDiff(diff); err != nil {
+		return fmt.Errorf("applying diff: %w", err)
+	}
+
+	// The patched file must read back as the desired deck. When it does not,
+	// say because a moved lead slide starts a section of its own, the deck
+	// is written out in full, and if that reads back differently too the
+	// change cannot be made in Marp at all.
+	writer := b.writer.withThemeFiles(ref.Path, doc.deck, desired)
+	content := writer.patch(doc, desired)
+	if drift := b.drift(content, ref.Path, desired); !drift.IsEmpty() {
+		content = writer.Encode(desired)
+		if drift := b.drift(content, ref.Path, desired); !drift.IsEmpty() {
+			c := drift.Changes[0]
+			return fmt.Errorf("applying diff: the result cannot be written as Marp, it would read back with %d changes, starting with %s %s",
+				drift.ChangeCount(), c.Op, c.Path)
+		}
+	}
+	return deckfile.WriteApplied(ctx, ref.Path, []byte(content))
+}
+
+// drift returns the changes that remain between desired and the deck read
+// back from content, written for the file at path. Sections without slides
+// leave nothing behind in Marp, so they are not missed.
+func (b *Backend) drift(content, path string, desired *model.Deck) *model.Diff {
+	got, _ := b.reader.parseDocument(content)
+	applyThemeFile(got, path)
+	want := *desired
+	want.Sections = slices.DeleteFunc(slices.Clone(desired.Sections), func(s model.Section) bool {
+		return len(s.Slides) == 0
+	})
+	return model.ComputeDiff(got, &want)
 }
 
 // Create creates a new Marp presentation file.
@@ -76,7 +142,7 @@ func (b *Backend) Create(_ context.Context, deck *model.Deck) (model.Ref, error)
 		path = deck.ID + ".md"
 	}
 
-	if err := b.writer.WriteFile(deck, path); err != nil {
+	if err := b.writer.withThemeFiles(path, deck).WriteFile(deck, path); err != nil {
 		return model.Ref{}, fmt.Errorf("writing deck: %w", err)
 	}
 
@@ -85,84 +151,3 @@ func (b *Backend) Create(_ context.Context, deck *model.Deck) (model.Ref, error)
 		Path:    path,
 	}, nil
 }
-
-// computeDiff compares two decks and produces a diff.
-func computeDiff(current, desired *model.Deck) *model.Diff {
-	diff := model.NewDiff(current.ID)
-
-	// Compare title
-	if current.Title != desired.Title {
-		diff.AddChange(model.NewUpdateChange("title", current.Title, desired.Title))
-	}
-
-	// Compare sections
-	currentSections := make(map[string]*model.Section)
-	for i := range current.Sections {
-		currentSections[current.Sections[i].ID] = &current.Sections[i]
-	}
-
-	for _, ds := range desired.Sections {
-		cs, exists := currentSections[ds.ID]
-		if !exists {
-			diff.AddChange(model.NewAddChange("sections/"+ds.ID, ds))
-			continue
-		}
-		// Compare slides within section
-		compareSectionSlides(diff, cs, &ds)
-	}
-
-	// Check for removed sections
-	desiredSections := make(map[string]bool)
-	for _, s := range desired.Sections {
-		desiredSections[s.ID] = true
-	}
-	for _, s := range current.Sections {
-		if !desiredSections[s.ID] {
-			diff.AddChange(model.NewRemoveChange("sections/"+s.ID, s))
-		}
-	}
-
-	return diff
-}
-
-// compareSectionSlides compares slides between two sections.
-func compareSectionSlides(diff *model.Diff, current, desired *model.Section) {
-	currentSlides := make(map[string]*model.Slide)
-	for i := range current.Slides {
-		currentSlides[current.Slides[i].ID] = &current.Slides[i]
-	}
-
-	for _, ds := range desired.Slides {
-		cs, exists := currentSlides[ds.ID]
-		if !exists {
-			diff.AddChange(model.NewAddChange(
-				fmt.Sprintf("sections/%s/slides/%s", current.ID, ds.ID), ds))
-			continue
-		}
-		// Compare slide content
-		if cs.Title != ds.Title {
-			diff.AddChange(model.NewUpdateChange(
-				fmt.Sprintf("sections/%s/slides/%s/title", current.ID, ds.ID),
-				cs.Title, ds.Title))
-		}
-	}
-}
-
-// applyDiff applies changes from a diff to a deck (in-place).
-func applyDiff(deck *model.Deck, diff *model.Diff) {
-	for _, change := range diff.Changes {
-		switch change.Op {
-		case model.ChangeUpdate:
-			if change.Path == "title" {
-				if v, ok := change.NewValue.(string); ok {
-					deck.Title = v
-				}
-			}
-			// Additional path-based updates would go here
-		case model.ChangeAdd:
-			// Handle additions
-		case model.ChangeRemove:
-			// Handle removals
-		}
-	}
-}
@@ -0,0 +1,240 @@
+package marp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestBackendApplyWritesChanges(t *testing.T) {
+	content := `---
+marp: true
+---
+
+# First
+
+---
+
+# Second
+
+- Old point
+`
+	path := filepath.Join(t.TempDir(), "deck.md")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/second/body", nil,
+		[]model.Block{model.NewBullet("New point", 0)}))
+
+	backend := NewBackend()
+	ref := model.Ref{Backend: "marp", Path: path}
+	if err := backend.Apply(context.Background(), ref, diff); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+
+	deck, err := backend.Read(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	slide := deck.FindSlide("second")
+	if slide == nil || len(slide.Body) != 1 || slide.Body[0].Text != "New point" {
+		t.Errorf("expected updated body, got %+v", slide)
+	}
+
+	bad := model.NewDiff("test")
+	bad.AddChange(model.NewUpdateChange("slides/missing/title", "", "x"))
+	if err := backend.Apply(context.Background(), ref, bad); err == nil {
+		t.Error("expected error for unresolvable change")
+	}
+}
+
+func TestBackendPlanApplyReorder(t *testing.T) {
+	content := `---
+marp: true
+---
+
+# First
+
+<!--
+First notes.
+-->
+
+---
+
+# Second
+
+---
+
+# Third
+`
+	path := filepath.Join(t.TempDir(), "deck.md")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	ctx := context.Background()
+	backend := NewBackend()
+	ref := model.Ref{Backend: "marp", Path: path}
+
+	desired, err := backend.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	slides := desired.Sections[0].Slides
+	desired.Sections[0].Slides = []model.Slide{slides[1], slides[2], slides[0]}
+
+	diff, err := backend.Plan(ctx, ref, desired)
+	if err != nil {
+		t.Fatalf("Plan error: %v", err)
+	}
+	if len(diff.MoveChanges()) != 1 || diff.ChangeCount() != 1 {
+		t.Fatalf("expected a single move, got %+v", diff.Changes)
+	}
+	if err := backend.Apply(ctx, ref, diff); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+
+	deck, err := backend.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	got := deck.AllSlides()
+	if len(got) != 3 || got[0].Title != "Second" || got[2].Title != "First" {
+		t.Fatalf("unexpected order: %+v", got)
+	}
+	if got[2].NotesText() != "First notes." {
+		t.Errorf("expected notes to move with the slide, got %q", got[2].NotesText())
+	}
+}
+
+func TestBackendIDsSurviveEdits(t *testing.T) {
+	content := `---
+marp: true
+---
+
+# Intro
+
+---
+
+<!-- _id: custom-id -->
+
+# Details
+
+---
+
+# Summary
+`
+	path := filepath.Join(t.TempDir(), "deck.md")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	ctx := context.Background()
+	backend := NewBackend()
+	ref := model.Ref{Backend: "marp", Path: path}
+
+	deck, err := backend.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if got := slideIDs(deck); !equalIDs(got, []string{"intro", "custom-id", "summary"}) {
+		t.Fatalf("unexpected initial IDs %v", got)
+	}
+
+	// Rename a slide and insert another Summary slide at the top
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/summary/title", "Summary", "Wrap-up"))
+	diff.AddChange(model.NewAddChange("sections/default/slides/summary-2",
+		model.Slide{ID: "summary-2", Layout: model.LayoutTitleBody, Title: "Summary"}).
+		At(model.Position{SectionID: "default", Index: 0}))
+	if err := backend.Apply(ctx, ref, diff); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+
+	deck, err = backend.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	want := []string{"summary-2", "intro", "custom-id", "summary"}
+	if got := slideIDs(deck); !equalIDs(got, want) {
+		t.Errorf("expected IDs %v after edit, got %v", want, got)
+	}
+	if deck.Sections[0].ID != "default" {
+		t.Errorf("expected section ID default, got %q", deck.Sections[0].ID)
+	}
+}
+
+func slideIDs(deck *model.Deck) []string {
+	var ids []string
+	for _, slide := range deck.AllSlides() {
+		ids = append(ids, slide.ID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestBackendApplyMoveLeadSlide(t *testing.T) {
+	content := "---\nmarp: true\n---\n\n# Intro\n\n---\n\n<!-- _class: lead -->\n\n# Welcome\n\n---\n\n# Agenda\n"
+	path := filepath.Join(t.TempDir(), "deck.md")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	ctx := context.Background()
+	backend := NewBackend()
+	ref := model.Ref{Backend: "marp", Path: path}
+	apply := func(desired *model.Deck) error {
+		t.Helper()
+		diff, err := backend.Plan(ctx, ref, desired)
+		if err != nil {
+			t.Fatalf("Plan error: %v", err)
+		}
+		return backend.Apply(ctx, ref, diff)
+	}
+
+	desired, err := backend.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	intro, welcome := desired.Sections[0].Slides[0], desired.Sections[1].Slides
+
+	// A lead slide always starts a section in Marp, so it cannot follow
+	// another slide of its own section
+	desired.Sections[1].Slides = []model.Slide{welcome[1], welcome[0]}
+	if err := apply(desired); err == nil || !strings.Contains(err.Error(), "cannot be written as Marp") {
+		t.Errorf("expected an error, got %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("expected the file to be left alone, got:\n%s", data)
+	}
+
+	// Moving the slides before it into its section reads back as planned,
+	// with the deck title kept in the frontmatter
+	desired.Sections[0].Slides = nil
+	desired.Sections[1].Slides = append(welcome, intro)
+	if err := apply(desired); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	desired.Sections = desired.Sections[1:] // The emptied section is gone
+	diff, err := backend.Plan(ctx, ref, desired)
+	if data, _ := os.ReadFile(path); err != nil || !diff.IsEmpty() || !strings.Contains(string(data), "title: Intro\n") {
+		t.Errorf("expected no changes after Apply, got %+v, %v in:\n%s", diff, err, data)
+	}
+}
@@ -0,0 +1,113 @@
+package marp
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// problem is malformed source found while parsing a slide, located by byte
+// offset within the slide chunk.
+type problem struct {
+	at       int
+	severity model.Severity
+	message  string
+}
+
+// reYAMLKey matches a line that starts a YAML mapping entry.
+var reYAMLKey = regexp.MustCompile(`^[A-Za-z_][\w-]*\s*:`)
+
+// diagnose converts the problems found in each slide of doc into
+// diagnostics with file positions, and adds any found in the frontmatter.
+func diagnose(content string, doc *document) []model.Diagnostic {
+	var diags []model.Diagnostic
+	if line, ok := unclosedFrontmatter(content, doc.head); ok {
+		diags = append(diags, model.Diagnostic{
+			Severity: model.SeverityError,
+			Line:     line,
+			Column:   1,
+			Message:  "frontmatter is never closed with ---, so it is read as slide content",
+		})
+	}
+
+	if doc.frontmatterErr != nil {
+		diags = append(diags, frontmatterDiagnostic(doc.head, doc.frontmatterErr))
+	}
+
+	for _, src := range doc.slides {
+		chunk := doc.chunks[src.chunk]
+		for _, p := range src.spans.problems {
+			line, column := position(chunk, p.at)
+			diags = append(diags, model.Diagnostic{
+				Severity: p.severity,
+				Line:     doc.starts[src.chunk] + line - 1,
+				Column:   column,
+				SlideID:  src.slide.ID,
+				Message:  p.message,
+			})
+		}
+	}
+	return diags
+}
+
+// reYAMLLine matches a line number in a YAML error message.
+var reYAMLLine = regexp.MustCompile(`line (\d+)`)
+
+// frontmatterDiagnostic converts an error parsing the frontmatter block head
+// into a diagnostic. YAML counts lines from the opening delimiter, so line
+// numbers are moved by the lines before it.
+func frontmatterDiagnostic(head string, err error) model.Diagnostic {
+	offset := strings.Count(head[:strings.Index(head, "---")], "\n")
+	if yerr := errors.Unwrap(err); yerr != nil {
+		err = yerr
+	}
+
+	// Keep the first error of a list, without the line it starts with
+	message := strings.TrimPrefix(err.Error(), "yaml: ")
+	message = strings.TrimSpace(strings.TrimPrefix(message, "unmarshal errors:"))
+	message, _, _ = strings.Cut(message, "\n")
+	line := offset + 1
+	if at, rest, ok := strings.Cut(message, ": "); ok && reYAMLLine.MatchString(at) {
+		n, _ := strconv.Atoi(strings.TrimPrefix(at, "line "))
+		line, message = n+offset, rest
+	}
+	message = reYAMLLine.ReplaceAllStringFunc(message, func(at string) string {
+		n, _ := strconv.Atoi(strings.TrimPrefix(at, "line "))
+		return "line " + strconv.Itoa(n+offset)
+	})
+	return model.Diagnostic{
+		Severity: model.SeverityError,
+		Line:     line,
+		Column:   1,
+		Message:  "frontmatter is not valid YAML (" + message + "), so it is ignored",
+	}
+}
+
+// unclosedFrontmatter reports whether content opens a frontmatter block
+// that splitFrontmatter could not find the end of, returning the line of
+// the opening delimiter. A leading separator only counts as frontmatter
+// when a YAML key follows it.
+func unclosedFrontmatter(content, head string) (int, bool) {
+	if head != "" {
+		return 0, false
+	}
+	lines := strings.Split(content, "\n")
+	for i, l := range lines {
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+		if strings.TrimSpace(l) != "---" {
+			return 0, false
+		}
+		for _, next := range lines[i+1:] {
+			if strings.TrimSpace(next) != "" {
+				return i + 1, reYAMLKey.MatchString(next)
+			}
+		}
+		return 0, false
+	}
+	return 0, false
+}
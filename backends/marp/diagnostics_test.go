@@ -0,0 +1,120 @@
+package marp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestParseDiagnostics(t *testing.T) {
+	input := `---
+marp: true
+---
+
+# Clean
+
+- Fine
+
+---
+
+# Broken
+
+  ![missing url]
+</div>
+
+<div class="box">
+
+Text
+`
+	deck, diags, err := NewReader().ParseDiagnostics(input)
+	if err != nil {
+		t.Fatalf("ParseDiagnostics error: %v", err)
+	}
+
+	want := []struct {
+		line, column int
+		message      string
+	}{
+		{13, 3, "malformed image, expected ![alt](url)"},
+		{14, 1, "</div> has no matching opening tag"},
+		{16, 1, "<div> is never closed; the rest of the slide is kept as raw HTML"},
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("expected %d diagnostics, got %+v", len(want), diags)
+	}
+	for i, w := range want {
+		d := diags[i]
+		if d.Line != w.line || d.Column != w.column || d.Message != w.message ||
+			d.Severity != model.SeverityWarning || d.SlideID != "broken" {
+			t.Errorf("diagnostic %d = %+v, want %d:%d %q", i, d, w.line, w.column, w.message)
+		}
+	}
+
+	// The unclosed div is kept rather than dropped
+	body := deck.Sections[0].Slides[1].Body
+	if last := body[len(body)-1]; !strings.HasPrefix(last.Text, `<div class="box">`) || !strings.Contains(last.Text, "Text") {
+		t.Errorf("expected unclosed div kept as raw HTML, got %+v", last)
+	}
+}
+
+func TestParseDiagnosticsUnclosedFence(t *testing.T) {
+	input := "# One\n\n```go\nfunc main() {}\n\n---\n\n# Two\n"
+	deck, diags, _ := NewReader().ParseDiagnostics(input)
+
+	if len(diags) != 1 || diags[0].Severity != model.SeverityError || diags[0].Line != 3 || diags[0].SlideID != "one" {
+		t.Fatalf("expected one error on line 3, got %+v", diags)
+	}
+	if got := diags[0].String(); !strings.HasPrefix(got, "3:1: error: code fence") || !strings.HasSuffix(got, "[slide one]") {
+		t.Errorf("unexpected diagnostic string %q", got)
+	}
+
+	// The fence swallows the separator, and its content is kept
+	slides := deck.AllSlides()
+	if len(slides) != 1 || len(slides[0].Body) != 1 || !strings.Contains(slides[0].Body[0].Text, "# Two") {
+		t.Errorf("expected the rest of the deck in one code block, got %+v", slides)
+	}
+}
+
+func TestParseDiagnosticsUnclosedFrontmatter(t *testing.T) {
+	_, diags, _ := NewReader().ParseDiagnostics("\n---\nmarp: true\ntheme: gaia\n\n# Title\n")
+	if len(diags) != 1 || diags[0].Line != 2 || diags[0].SlideID != "" || diags[0].Severity != model.SeverityError {
+		t.Errorf("expected unclosed frontmatter error on line 2, got %+v", diags)
+	}
+
+	// A leading separator before ordinary content is not frontmatter
+	_, diags, _ = NewReader().ParseDiagnostics("---\n\n# Title\n")
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %+v", diags)
+	}
+}
+
+func TestParseDiagnosticsInvalidFrontmatter(t *testing.T) {
+	input := "\n---\nmarp: true\ntitle: Demo\ntitle: Again\n---\n\n# Title\n\n---\n\n# Next\n"
+	deck, diags, err := NewReader().ParseDiagnostics(input)
+	if err != nil {
+		t.Fatalf("ParseDiagnostics error: %v", err)
+	}
+	want := `frontmatter is not valid YAML (mapping key "title" already defined at line 4), so it is ignored`
+	if len(diags) != 1 || diags[0].Line != 5 || diags[0].Severity != model.SeverityError || diags[0].Message != want {
+		t.Errorf("expected invalid frontmatter error on line 5, got %+v", diags)
+	}
+	if len(deck.AllSlides()) != 2 || deck.Title != "Title" {
+		t.Errorf("expected slides read without the frontmatter, got %+v", deck)
+	}
+
+	_, diags, _ = NewReader().ParseDiagnostics("---\nmarp: true\nsize: x\n  bad: 1\n---\n\n# Title\n")
+	if len(diags) != 1 || diags[0].Line != 4 || diags[0].Severity != model.SeverityError {
+		t.Errorf("expected invalid frontmatter error on line 4, got %+v", diags)
+	}
+}
+
+func TestParseDiagnosticsClean(t *testing.T) {
+	_, diags, err := NewReader().ParseDiagnostics(sampleMarp)
+	if err != nil {
+		t.Fatalf("ParseDiagnostics error: %v", err)
+	}
+	if len(diags) != 0 {
+		t.Errorf("expected no diagnostics for the sample deck, got %+v", diags)
+	}
+}
@@ -0,0 +1,91 @@
+package marp
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// setHeaderFooter reads a header, footer or paginate directive into *h,
+// creating it if needed. It reports false for other keys, and for paginate
+// values other than true and false, such as Marp's "hold" and "skip",
+// which stay ordinary directives.
+func setHeaderFooter(h **model.HeaderFooter, key, value string) bool {
+	set := func() *model.HeaderFooter {
+		if *h == nil {
+			*h = &model.HeaderFooter{}
+		}
+		return *h
+	}
+	switch key {
+	case "header":
+		text := directiveText(value)
+		set().Header = &text
+	case "footer":
+		text := directiveText(value)
+		set().Footer = &text
+	case "paginate":
+		if value != "true" && value != "false" {
+			return false
+		}
+		on := value == "true"
+		set().PageNumber = &on
+	default:
+		return false
+	}
+	return true
+}
+
+// headerFooterDirectives adds the directives for the settings in h to
+// directives, with keys prefixed by prefix: "_" for a single slide, ""
+// for a change that carries on to later slides.
+func headerFooterDirectives(directives map[string]string, h *model.HeaderFooter, prefix string) {
+	if h == nil {
+		return
+	}
+	if h.Header != nil {
+		directives[prefix+"header"] = directiveValue(*h.Header)
+	}
+	if h.Footer != nil {
+		directives[prefix+"footer"] = directiveValue(*h.Footer)
+	}
+	if h.PageNumber != nil {
+		directives[prefix+"paginate"] = "false"
+		if *h.PageNumber {
+			directives[prefix+"paginate"] = "true"
+		}
+	}
+}
+
+// directiveText returns the text of a directive value. Marp reads
+// directive values as YAML, so a quoted value such as "" or '**Bold**'
+// stands for the string inside the quotes; anything else is taken as
+// written.
+func directiveText(value string) string {
+	if !strings.HasPrefix(value, `"`) && !strings.HasPrefix(value, "'") {
+		return value
+	}
+	var text string
+	if err := yaml.Unmarshal([]byte(value), &text); err != nil {
+		return value
+	}
+	return text
+}
+
+// directiveValue returns how to write text as a directive value: as is
+// when YAML reads it back as the same string, and quoted otherwise, as
+// for "", "true" or "**Bold**".
+func directiveValue(text string) string {
+	var parsed any
+	if !strings.Contains(text, "\n") && yaml.Unmarshal([]byte(text), &parsed) == nil && parsed == text {
+		return text
+	}
+	node := yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: text, Style: yaml.DoubleQuotedStyle}
+	out, err := yaml.Marshal(&node)
+	if err != nil {
+		return text
+	}
+	return strings.TrimSuffix(string(out), "\n")
+}
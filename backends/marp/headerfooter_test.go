@@ -0,0 +1,141 @@
+package marp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestDirectiveValue(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Acme Corp", "Acme Corp"},
+		{"", `""`},
+		{"true", `"true"`},
+		{"**Bold**", `"**Bold**"`},
+		{"Page: 1", `"Page: 1"`},
+		{"two\nlines", `"two\nlines"`},
+	}
+	for _, tt := range tests {
+		got := directiveValue(tt.text)
+		if got != tt.want {
+			t.Errorf("directiveValue(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+		if back := directiveText(got); back != tt.text {
+			t.Errorf("directiveText(%q) = %q, want %q", got, back, tt.text)
+		}
+	}
+}
+
+func TestParseHeaderFooter(t *testing.T) {
+	input := `---
+marp: true
+header: Module 1
+paginate: true
+---
+
+<!-- _paginate: false -->
+
+# Welcome
+
+---
+
+<!-- footer: 'Part **2**' -->
+<!-- _header: "" -->
+
+# Part Two
+
+---
+
+<!-- _paginate: hold -->
+
+# Still Part Two
+`
+	deck, err := NewReader().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	if h := deck.HeaderFooter; h.HeaderText() != "Module 1" || !h.ShowPageNumber() || h.Footer != nil {
+		t.Errorf("unexpected deck settings %+v", h)
+	}
+	if _, ok := deck.Meta.Custom["paginate"]; ok {
+		t.Errorf("expected paginate moved off Meta.Custom, got %v", deck.Meta.Custom)
+	}
+
+	slides := deck.AllSlides()
+	if slides[2].Directives["_paginate"] != "hold" {
+		t.Errorf("expected _paginate: hold kept as a directive, got %v", slides[2].Directives)
+	}
+
+	want := []struct {
+		header, footer string
+		pageNumber     bool
+	}{
+		{"Module 1", "", false},
+		{"", "Part **2**", true},
+		{"Module 1", "Part **2**", true},
+	}
+	for i, hf := range deck.HeaderFooters() {
+		if hf.HeaderText() != want[i].header || hf.FooterText() != want[i].footer || hf.ShowPageNumber() != want[i].pageNumber {
+			t.Errorf("slide %d: got header %q footer %q page number %v, want %+v",
+				i, hf.HeaderText(), hf.FooterText(), hf.ShowPageNumber(), want[i])
+		}
+	}
+}
+
+func TestWriteHeaderFooter(t *testing.T) {
+	module, bold, empty := "Module 1", "**Bold**", ""
+	on, off := true, false
+	deck := &model.Deck{
+		Title:        "Deck",
+		HeaderFooter: &model.HeaderFooter{Header: &module, PageNumber: &on},
+		Sections: []model.Section{{ID: "default", Title: "default", Slides: []model.Slide{
+			{ID: "deck", Layout: model.LayoutTitle, Title: "Deck", HeaderFooter: &model.HeaderFooter{PageNumber: &off}},
+			{ID: "next", Layout: model.LayoutTitleBody, Title: "Next",
+				HeaderFooter:     &model.HeaderFooter{Header: &empty},
+				HeaderFooterFrom: &model.HeaderFooter{Footer: &bold}},
+		}}},
+	}
+
+	output := NewWriter().Encode(deck)
+	for _, want := range []string{
+		"header: Module 1\npaginate: true\n",
+		"<!-- _paginate: false -->",
+		"<!-- _header: \"\" -->",
+		"<!-- footer: \"**Bold**\" -->",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output:\n%s", want, output)
+		}
+	}
+
+	got, err := NewReader().Parse(output)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if !reflect.DeepEqual(got.HeaderFooters(), deck.HeaderFooters()) {
+		t.Errorf("settings did not survive a round trip: %+v", got.HeaderFooters())
+	}
+}
+
+func TestPatchHeaderFooter(t *testing.T) {
+	input := "---\nmarp: true\nfooter: Old\n---\n\n# One\n\n---\n\n<!-- _footer: 'Quoted' -->\n\n# Two\n"
+	deck, _ := NewReader().Parse(input)
+	desired, _ := NewReader().Parse(input)
+
+	// An unchanged quoted value is left as written
+	if got := applyPatch(t, input, model.ComputeDiff(deck, desired)); got != input {
+		t.Errorf("expected untouched file, got:\n%s", got)
+	}
+
+	footer := "New"
+	desired.HeaderFooter.Footer = &footer
+	got := applyPatch(t, input, model.ComputeDiff(deck, desired))
+	if !strings.Contains(got, "footer: New\n") || !strings.Contains(got, "<!-- _footer: 'Quoted' -->") {
+		t.Errorf("expected only the deck footer changed:\n%s", got)
+	}
+}
@@ -0,0 +1,261 @@
+package marp
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// imageFilters are the CSS filters Marp accepts as image keywords.
+var imageFilters = map[string]bool{
+	"blur":        true,
+	"brightness":  true,
+	"contrast":    true,
+	"drop-shadow": true,
+	"grayscale":   true,
+	"hue-rotate":  true,
+	"invert":      true,
+	"opacity":     true,
+	"saturate":    true,
+	"sepia":       true,
+}
+
+var rePercent = regexp.MustCompile(`^\d+(\.\d+)?%$`)
+
+// parseImage reads a Markdown image line into an image block. Marp image
+// keywords in the alt text (bg, w:300, blur, left:40%, ...) become
+// structured attributes; the remaining words are the alt text. Images
+// without keywords keep their alt text exactly as written.
+func parseImage(line string) (model.Block, bool) {
+	alt, url := parseImageLine(line)
+	if url == "" {
+		return model.Block{}, false
+	}
+	block := model.NewImage(url, alt)
+
+	words := strings.Fields(alt)
+	attrs := &model.ImageAttrs{}
+	for _, w := range words {
+		if w == "bg" {
+			attrs.Bg = true
+		}
+	}
+
+	var rest []string
+	found := false
+	for _, w := range words {
+		if parseImageKeyword(attrs, w) {
+			found = true
+		} else {
+			rest = append(rest, w)
+		}
+	}
+	if found {
+		block.Alt = strings.Join(rest, " ")
+		block.Image = attrs
+	}
+	return block, true
+}
+
+// parseImageKeyword applies a single alt text word to attrs, returning
+// false if the word is not a keyword. Background keywords only count when
+// attrs.Bg is already set.
+func parseImageKeyword(attrs *model.ImageAttrs, word string) bool {
+	name, value, hasValue := strings.Cut(word, ":")
+	switch {
+	case word == "bg":
+		return true
+	case (name == "w" || name == "width") && hasValue && value != "":
+		attrs.Width = value
+		return true
+	case (name == "h" || name == "height") && hasValue && value != "":
+		attrs.Height = value
+		return true
+	case imageFilters[name] && (!hasValue || value != ""):
+		attrs.Filters = append(attrs.Filters, model.ImageFilter{Name: name, Value: value})
+		return true
+	case !attrs.Bg:
+		return false
+	case !hasValue && (word == "contain" || word == "cover" || word == "fit" || word == "auto" ||
+		rePercent.MatchString(word)):
+		attrs.BgSize = word
+		return true
+	case (name == model.SplitLeft || name == model.SplitRight) && (!hasValue || rePercent.MatchString(value)):
+		attrs.Split, attrs.SplitSize = name, value
+		return true
+	case word == "vertical":
+		attrs.Vertical = true
+		return true
+	}
+	return false
+}
+
+// renderImage renders an image block as Markdown, writing its attributes
+// back as Marp keywords ahead of the alt text.
+func renderImage(block *model.Block) string {
+	var words []string
+	for _, k := range imageKeywords(block.Image) {
+		words = append(words, k.word)
+	}
+	if block.Alt != "" {
+		words = append(words, block.Alt)
+	}
+	return "![" + strings.Join(words, " ") + "](" + block.URL + ")"
+}
+
+// imageKeyword is a Marp keyword with the attribute it sets: "bg", "size",
+// "split", "vertical", "w", "h" or the name of a filter.
+type imageKeyword struct {
+	field, word string
+}
+
+// imageKeywords returns the keywords that write attrs back, in the order
+// renderImage writes them.
+func imageKeywords(a *model.ImageAttrs) []imageKeyword {
+	if a == nil {
+		return nil
+	}
+	var keywords []imageKeyword
+	if a.Bg {
+		keywords = append(keywords, imageKeyword{"bg", "bg"})
+		if a.BgSize != "" {
+			keywords = append(keywords, imageKeyword{"size", a.BgSize})
+		}
+		if a.Split != "" {
+			split := a.Split
+			if a.SplitSize != "" {
+				split += ":" + a.SplitSize
+			}
+			keywords = append(keywords, imageKeyword{"split", split})
+		}
+		if a.Vertical {
+			keywords = append(keywords, imageKeyword{"vertical", "vertical"})
+		}
+	}
+	if a.Width != "" {
+		keywords = append(keywords, imageKeyword{"w", "w:" + a.Width})
+	}
+	if a.Height != "" {
+		keywords = append(keywords, imageKeyword{"h", "h:" + a.Height})
+	}
+	for _, f := range a.Filters {
+		if f.Value != "" {
+			keywords = append(keywords, imageKeyword{f.Name, f.Name + ":" + f.Value})
+		} else {
+			keywords = append(keywords, imageKeyword{f.Name, f.Name})
+		}
+	}
+	return keywords
+}
+
+// rewriteImage rewrites the image in line, as parsed by parseImage, to
+// match block. Keywords whose attribute is unchanged keep their spelling
+// and place, changed ones are rewritten where they stood, and new ones
+// follow the last kept keyword. The alt text keeps its place unless it
+// changed, in which case the new text goes last.
+func rewriteImage(line string, block *model.Block) string {
+	loc := reImage.FindStringSubmatchIndex(line)
+	old, ok := parseImage(line)
+	if loc == nil || !ok {
+		return renderImage(block)
+	}
+	alt := line[loc[2]:loc[3]]
+	if old.Image == nil && block.Image == nil {
+		// Without keywords the alt text is kept exactly as written
+		if old.Alt != block.Alt {
+			alt = block.Alt
+		}
+		return line[:loc[2]] + alt + line[loc[3]:loc[4]] + block.URL + line[loc[5]:]
+	}
+
+	// Queue the wanted keywords by attribute, so that each written keyword
+	// takes the next one for its attribute
+	wanted := make(map[string][]string)
+	var order []string
+	for _, k := range imageKeywords(block.Image) {
+		if len(wanted[k.field]) == 0 {
+			order = append(order, k.field)
+		}
+		wanted[k.field] = append(wanted[k.field], k.word)
+	}
+	// Keywords are written back the way the old attributes render them
+	written := make(map[string][]string)
+	for _, k := range imageKeywords(old.Image) {
+		written[k.field] = append(written[k.field], k.word)
+	}
+
+	sameAlt := old.Alt == block.Alt
+	var words []string
+	last := 0 // Number of words up to the last keyword
+	bg := old.Image != nil && old.Image.Bg
+	for _, word := range strings.Fields(alt) {
+		field := keywordField(word, bg)
+		switch {
+		case field == "" || old.Image == nil:
+			if sameAlt {
+				words = append(words, word)
+			}
+			continue
+		case len(wanted[field]) == 0:
+			continue
+		}
+		want, was := wanted[field][0], written[field][0]
+		wanted[field], written[field] = wanted[field][1:], written[field][1:]
+		switch {
+		case want == was:
+			words = append(words, word)
+		case field == "w" || field == "h":
+			// Keep the long form of width: and height:
+			name, _, _ := strings.Cut(word, ":")
+			_, value, _ := strings.Cut(want, ":")
+			words = append(words, name+":"+value)
+		default:
+			words = append(words, want)
+		}
+		last = len(words)
+	}
+	var added []string
+	for _, field := range order {
+		added = append(added, wanted[field]...)
+	}
+	words = append(words[:last], append(added, words[last:]...)...)
+	if !sameAlt && block.Alt != "" {
+		words = append(words, block.Alt)
+	}
+	return line[:loc[2]] + strings.Join(words, " ") + line[loc[3]:loc[4]] + block.URL + line[loc[5]:]
+}
+
+// keywordField returns the attribute a word of alt text sets as a keyword,
+// as listed for imageKeyword, or "" if it is not a keyword. bg tells
+// whether the image is a background.
+func keywordField(word string, bg bool) string {
+	attrs := &model.ImageAttrs{Bg: bg}
+	if !parseImageKeyword(attrs, word) {
+		return ""
+	}
+	switch {
+	case word == "bg":
+		return "bg"
+	case attrs.Width != "":
+		return "w"
+	case attrs.Height != "":
+		return "h"
+	case len(attrs.Filters) > 0:
+		return attrs.Filters[0].Name
+	case attrs.BgSize != "":
+		return "size"
+	case attrs.Split != "":
+		return "split"
+	}
+	return "vertical"
+}
+
+// renderBackgrounds renders background images one per line.
+func renderBackgrounds(images []model.Block) string {
+	lines := make([]string, len(images))
+	for i := range images {
+		lines[i] = renderImage(&images[i])
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,181 @@
+package marp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestParseImage(t *testing.T) {
+	tests := []struct {
+		line  string
+		alt   string
+		attrs *model.ImageAttrs
+	}{
+		{"![Logo](logo.png)", "Logo", nil},
+		{"![A cover photo](a.png)", "A cover photo", nil},
+		{"![w:300 Logo](logo.png)", "Logo", &model.ImageAttrs{Width: "300"}},
+		{"![width:50% height:200px](a.png)", "", &model.ImageAttrs{Width: "50%", Height: "200px"}},
+		{"![blur:10px sepia](a.png)", "", &model.ImageAttrs{
+			Filters: []model.ImageFilter{{Name: "blur", Value: "10px"}, {Name: "sepia"}},
+		}},
+		{"![bg](a.png)", "", &model.ImageAttrs{Bg: true}},
+		{"![bg contain](a.png)", "", &model.ImageAttrs{Bg: true, BgSize: "contain"}},
+		{"![Sunset bg right:40% grayscale](a.png)", "Sunset", &model.ImageAttrs{
+			Bg: true, Split: model.SplitRight, SplitSize: "40%",
+			Filters: []model.ImageFilter{{Name: "grayscale"}},
+		}},
+		{"![bg left vertical 80%](a.png)", "", &model.ImageAttrs{
+			Bg: true, BgSize: "80%", Split: model.SplitLeft, Vertical: true,
+		}},
+		// Background keywords only apply to background images
+		{"![w:100 left](a.png)", "left", &model.ImageAttrs{Width: "100"}},
+	}
+
+	for _, tt := range tests {
+		block, ok := parseImage(tt.line)
+		if !ok {
+			t.Errorf("parseImage(%q) failed", tt.line)
+			continue
+		}
+		if block.Alt != tt.alt || !reflect.DeepEqual(block.Image, tt.attrs) {
+			t.Errorf("parseImage(%q) = alt %q, attrs %+v; want %q, %+v",
+				tt.line, block.Alt, block.Image, tt.alt, tt.attrs)
+		}
+	}
+}
+
+func TestRenderImage(t *testing.T) {
+	tests := []string{
+		"![Logo](logo.png)",
+		"![w:300 Logo](logo.png)",
+		"![bg contain](a.png)",
+		"![bg right:40% w:50% blur:10px sepia Sunset](a.png)",
+		"![bg left vertical](a.png)",
+	}
+	for _, line := range tests {
+		block, _ := parseImage(line)
+		if got := renderImage(&block); got != line {
+			t.Errorf("renderImage(parseImage(%q)) = %q", line, got)
+		}
+	}
+}
+
+func TestParseBackground(t *testing.T) {
+	input := `<!-- _backgroundColor: #123 -->
+<!-- _backgroundImage: url(grid.png) -->
+
+# Photos
+
+![bg left:30%](a.png)
+![bg](b.png)
+
+![w:200 Chart](chart.png)
+`
+	deck, err := NewReader().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	slide := deck.Sections[0].Slides[0]
+
+	bg := slide.Background
+	if bg == nil || bg.Color != "#123" || bg.Image != "url(grid.png)" {
+		t.Fatalf("unexpected background %+v", bg)
+	}
+	if len(bg.Images) != 2 || bg.Images[0].URL != "a.png" || bg.Images[0].Image.SplitSize != "30%" {
+		t.Errorf("unexpected background images %+v", bg.Images)
+	}
+	if len(slide.Directives) != 0 {
+		t.Errorf("expected background directives moved off Directives, got %v", slide.Directives)
+	}
+	if len(slide.Body) != 1 || slide.Body[0].Image == nil || slide.Body[0].Image.Width != "200" {
+		t.Errorf("expected sized inline image in body, got %+v", slide.Body)
+	}
+}
+
+func TestWriteBackground(t *testing.T) {
+	chart := model.NewImage("chart.png", "Chart")
+	chart.Image = &model.ImageAttrs{Width: "200"}
+	split := model.NewBackgroundImage("a.png")
+	split.Image.Split = model.SplitRight
+	slide := model.Slide{
+		ID:     "photos",
+		Layout: model.LayoutTitleBody,
+		Title:  "Photos",
+		Body:   []model.Block{chart},
+		Background: &model.Background{
+			Color:  "navy",
+			Images: []model.Block{split, model.NewBackgroundImage("b.png")},
+		},
+	}
+
+	var b strings.Builder
+	NewWriter().writeSlide(&b, &slide, nil, idDirectives{})
+	want := `<!-- _backgroundColor: navy -->
+
+# Photos
+
+![bg right](a.png)
+![bg](b.png)
+
+![w:200 Chart](chart.png)
+`
+	if got := b.String(); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	deck, err := NewReader().Parse(want)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	got := deck.Sections[0].Slides[0]
+	if !reflect.DeepEqual(got.Background, slide.Background) || !reflect.DeepEqual(got.Body, slide.Body) {
+		t.Errorf("background did not survive a round trip: %+v", got.Background)
+	}
+}
+
+func TestPatchBackgroundImage(t *testing.T) {
+	input := "# Photos\n\n![bg](a.png)\n\nCaption.\n"
+	deck, _ := NewReader().Parse(input)
+	desired, _ := NewReader().Parse(input)
+	slide := &desired.Sections[0].Slides[0]
+	slide.Background.Images[0].Image.Filters = []model.ImageFilter{{Name: "sepia"}}
+	slide.Background.Color = "#000"
+
+	diff := model.ComputeDiff(deck, desired)
+	got := applyPatch(t, input, diff)
+	for _, want := range []string{"<!-- _backgroundColor: #000 -->", "\n![bg sepia](a.png)\n\nCaption.\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected %q in output:\n%s", want, got)
+		}
+	}
+}
+
+func TestPatchImageKeepsKeywords(t *testing.T) {
+	input := "# Photos\n\n![bg contain blur:2px](a.png)\n\n![Sales chart height:200px sepia width:300px](chart.png)\n"
+	deck, _ := NewReader().Parse(input)
+	desired, _ := NewReader().Parse(input)
+	slide := &desired.Sections[0].Slides[0]
+	slide.Background.Images[0].Image.Filters[0].Value = "4px"
+	chart := slide.Body[0].Image
+	chart.Width = "400px"
+	chart.Filters = nil
+	chart.Height = "200px"
+
+	// Changed keywords are rewritten where they stood, in their own
+	// spelling, and the others are kept as written
+	want := "# Photos\n\n![bg contain blur:4px](a.png)\n\n![Sales chart height:200px width:400px](chart.png)\n"
+	if got := applyPatch(t, input, model.ComputeDiff(deck, desired)); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	// New keywords follow the last one kept, and new alt text goes last
+	chart.Filters = []model.ImageFilter{{Name: "grayscale"}}
+	slide.Body[0].Alt = "Revenue"
+	want = "# Photos\n\n![bg contain blur:4px](a.png)\n\n![height:200px width:400px grayscale Revenue](chart.png)\n"
+	if got := applyPatch(t, input, model.ComputeDiff(deck, desired)); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
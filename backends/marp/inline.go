@@ -0,0 +1,407 @@
+package marp
+
+import (
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// withInline parses the Markdown inline markup in block.Text, leaving the
+// plain text in Text and the styled runs in Runs. Runs stay nil for text
+// without any markup.
+func withInline(block model.Block) model.Block {
+	runs := parseInline(block.Text)
+	if text := model.RunsText(runs); text != block.Text {
+		block.Text = text
+		block.Runs = runs
+	}
+	return block
+}
+
+// inlineText returns the Markdown for a block's text: its runs rendered
+// back to markup, or Text as-is when there are no usable runs.
+func inlineText(block *model.Block) string {
+	if block.HasRuns() {
+		return renderInline(block.Runs)
+	}
+	return block.Text
+}
+
+// parseInline parses Markdown inline markup (emphasis, strong, inline code,
+// strikethrough, links, inline math and backslash escapes) into styled runs.
+func parseInline(s string) []model.Span {
+	var p inlineParser
+	p.parse(s, model.Span{})
+	return p.runs
+}
+
+type inlineParser struct {
+	runs []model.Span
+}
+
+// add appends text with the given style, merging it into the previous run
+// when the styles match.
+func (p *inlineParser) add(text string, style model.Span) {
+	if text == "" {
+		return
+	}
+	if n := len(p.runs); n > 0 && p.runs[n-1].SameStyle(style) {
+		p.runs[n-1].Text += text
+		return
+	}
+	style.Text = text
+	p.runs = append(p.runs, style)
+}
+
+func (p *inlineParser) parse(s string, style model.Span) {
+	var literal strings.Builder
+	flush := func() {
+		p.add(literal.String(), style)
+		literal.Reset()
+	}
+
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch c {
+		case '\\':
+			if i+1 < len(s) && isASCIIPunct(s[i+1]) {
+				literal.WriteByte(s[i+1])
+				i += 2
+				continue
+			}
+		case '`':
+			if code, n, ok := parseCodeSpan(s[i:]); ok {
+				flush()
+				codeStyle := style
+				codeStyle.Code = true
+				p.add(code, codeStyle)
+				i += n
+				continue
+			}
+		case '$':
+			if tex, n, ok := parseMathSpan(s, i); ok {
+				flush()
+				mathStyle := style
+				mathStyle.Math = true
+				p.add(tex, mathStyle)
+				i += n
+				continue
+			}
+		case '[':
+			if i > 0 && s[i-1] == '!' {
+				break // Inline image, kept as text
+			}
+			if label, href, n, ok := parseLink(s[i:]); ok {
+				flush()
+				linkStyle := style
+				linkStyle.Href = href
+				p.parse(label, linkStyle)
+				i += n
+				continue
+			}
+		case '*', '_', '~':
+			if inner, n, delim, ok := parseEmphasis(s, i); ok {
+				flush()
+				emphasis := style
+				switch {
+				case delim == "~~":
+					emphasis.Strike = true
+				case len(delim) == 2:
+					emphasis.Bold = true
+				default:
+					emphasis.Italic = true
+				}
+				p.parse(inner, emphasis)
+				i += n
+				continue
+			}
+		}
+		literal.WriteByte(c)
+		i++
+	}
+	flush()
+}
+
+// parseCodeSpan parses a code span starting at s[0]. It returns the code,
+// the number of bytes consumed and whether a closing backtick run was found.
+func parseCodeSpan(s string) (string, int, bool) {
+	n := len(s) - len(strings.TrimLeft(s, "`"))
+	delim := s[:n]
+	for i := n; i < len(s); {
+		j := strings.Index(s[i:], delim)
+		if j < 0 {
+			return "", 0, false
+		}
+		end := i + j
+		run := len(s[end:]) - len(strings.TrimLeft(s[end:], "`"))
+		if run == n {
+			return s[n:end], end + n, true
+		}
+		i = end + run
+	}
+	return "", 0, false
+}
+
+// parseMathSpan parses inline math such as $x^2$ starting at s[i], by the
+// rules of Marp's math plugin: the opening $ is not followed by whitespace
+// and the closing one is not preceded by whitespace or followed by a
+// digit, so that prices such as "$5 or $10" stay text. It returns the TeX
+// and the number of bytes consumed.
+func parseMathSpan(s string, i int) (string, int, bool) {
+	start := i + 1
+	if start >= len(s) || isSpace(s[start]) || s[start] == '$' {
+		return "", 0, false
+	}
+	for j := start; j < len(s); j++ {
+		switch {
+		case s[j] == '\\':
+			j++
+		case s[j] != '$':
+		case isSpace(s[j-1]) || j+1 < len(s) && s[j+1] >= '0' && s[j+1] <= '9':
+		default:
+			return s[start:j], j + 1 - i, true
+		}
+	}
+	return "", 0, false
+}
+
+// parseLink parses [label](href) starting at s[0].
+func parseLink(s string) (label, href string, n int, ok bool) {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if i+1 >= len(s) || s[i+1] != '(' {
+				return "", "", 0, false
+			}
+			end := strings.IndexByte(s[i+2:], ')')
+			if end < 0 {
+				return "", "", 0, false
+			}
+			href = strings.TrimSpace(s[i+2 : i+2+end])
+			if href == "" || strings.ContainsAny(href, " \t\n") {
+				return "", "", 0, false
+			}
+			return s[1:i], href, i + 3 + end, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// parseEmphasis parses emphasis starting at s[i]: *x* or _x_ for italics,
+// **x** or __x__ for bold, and ~~x~~ for strikethrough. The content must
+// not begin or end with whitespace, and underscores only count at word
+// boundaries so that snake_case stays plain.
+func parseEmphasis(s string, i int) (inner string, n int, delim string, ok bool) {
+	c := s[i]
+	delim = s[i : i+1]
+	if i+1 < len(s) && s[i+1] == c {
+		delim = s[i : i+2]
+	}
+	if c == '~' && len(delim) != 2 {
+		return "", 0, "", false
+	}
+	if c == '_' && i > 0 && isWordByte(s[i-1]) {
+		return "", 0, "", false
+	}
+
+	start := i + len(delim)
+	for j := start; j < len(s); j++ {
+		switch {
+		case s[j] == '\\':
+			j++
+			continue
+		case s[j] == '`':
+			if _, skip, ok := parseCodeSpan(s[j:]); ok {
+				j += skip - 1
+			}
+			continue
+		case !strings.HasPrefix(s[j:], delim):
+			continue
+		}
+
+		// A lone delimiter inside double ones (or the reverse) is nested
+		// emphasis, not the closer.
+		run := len(s[j:]) - len(strings.TrimLeft(s[j:], string(c)))
+		if len(delim) == 1 && run == 2 {
+			j++
+			continue
+		}
+		// Prefer the last closer in a run such as the *** in ***x***
+		end := j + run - len(delim)
+		content := s[start:end]
+		if content == "" || isSpace(content[0]) || isSpace(content[len(content)-1]) {
+			j += run - 1
+			continue
+		}
+		if c == '_' && end+len(delim) < len(s) && isWordByte(s[end+len(delim)]) {
+			j += run - 1
+			continue
+		}
+		return content, end + len(delim) - i, delim, true
+	}
+	return "", 0, "", false
+}
+
+// renderInline renders styled runs back to Markdown inline markup.
+// Adjacent runs that share a style share its markers, and markers are kept
+// off whitespace so that the result parses back to the same runs.
+func renderInline(runs []model.Span) string {
+	var b strings.Builder
+	var open []inlineMarker
+	pending := "" // Whitespace waiting to be placed around markers
+
+	for _, run := range runs {
+		lead, core, trail := "", run.Text, ""
+		if !run.Code && !run.Math {
+			trimmed := strings.TrimLeft(core, " \t\n")
+			lead, core = core[:len(core)-len(trimmed)], trimmed
+			trimmed = strings.TrimRight(core, " \t\n")
+			core, trail = trimmed, core[len(trimmed):]
+		}
+		if core == "" {
+			pending += lead + trail
+			continue
+		}
+
+		// Close markers the run does not have, along with those inside them
+		want := markersFor(run)
+		keep := 0
+		for keep < len(open) && keep < len(want) && open[keep] == want[keep] {
+			keep++
+		}
+		for k := len(open) - 1; k >= keep; k-- {
+			b.WriteString(open[k].closer())
+		}
+		b.WriteString(pending + lead)
+		for _, m := range want[keep:] {
+			b.WriteString(m.opener())
+		}
+		open = want
+
+		switch {
+		case run.Code:
+			b.WriteString(codeSpan(core))
+		case run.Math:
+			b.WriteString(mathSpan(core))
+		default:
+			b.WriteString(escapeInline(core))
+		}
+		pending = trail
+	}
+	for k := len(open) - 1; k >= 0; k-- {
+		b.WriteString(open[k].closer())
+	}
+	b.WriteString(pending)
+	return b.String()
+}
+
+// inlineMarker is a piece of markup wrapping inline text: a link or one of
+// the emphasis styles.
+type inlineMarker struct {
+	delim string // "**", "*", "~~", or "" for a link
+	href  string
+}
+
+func (m inlineMarker) opener() string {
+	if m.delim == "" {
+		return "["
+	}
+	return m.delim
+}
+
+func (m inlineMarker) closer() string {
+	if m.delim == "" {
+		return "](" + m.href + ")"
+	}
+	return m.delim
+}
+
+// markersFor returns the markers for a run's style, outermost first.
+func markersFor(run model.Span) []inlineMarker {
+	var markers []inlineMarker
+	if run.Href != "" {
+		markers = append(markers, inlineMarker{href: run.Href})
+	}
+	if run.Bold {
+		markers = append(markers, inlineMarker{delim: "**"})
+	}
+	if run.Italic {
+		markers = append(markers, inlineMarker{delim: "*"})
+	}
+	if run.Strike {
+		markers = append(markers, inlineMarker{delim: "~~"})
+	}
+	return markers
+}
+
+// codeSpan wraps code in a backtick fence longer than any run inside it.
+func codeSpan(code string) string {
+	longest, run := 0, 0
+	for i := 0; i < len(code); i++ {
+		if code[i] == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", longest+1)
+	return fence + code + fence
+}
+
+// mathSpan wraps TeX in $ delimiters, or escapes it as plain text if it
+// would not read back as math, e.g. because it starts with a space.
+func mathSpan(tex string) string {
+	span := "$" + tex + "$"
+	if t, n, ok := parseMathSpan(span, 0); ok && t == tex && n == len(span) {
+		return span
+	}
+	return escapeInline(tex)
+}
+
+// escapeInline escapes characters that would otherwise be read as markup.
+func escapeInline(text string) string {
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch c {
+		case '\\', '*', '`', '[', ']':
+			b.WriteByte('\\')
+		case '_':
+			if i == 0 || i == len(text)-1 || !isWordByte(text[i-1]) || !isWordByte(text[i+1]) {
+				b.WriteByte('\\')
+			}
+		case '~':
+			if i+1 < len(text) && text[i+1] == '~' {
+				b.WriteByte('\\')
+			}
+		case '$':
+			if _, _, ok := parseMathSpan(text, i); ok {
+				b.WriteByte('\\')
+			}
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func isASCIIPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
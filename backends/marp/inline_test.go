@@ -0,0 +1,125 @@
+package marp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestParseInline(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []model.Span
+	}{
+		{"plain text", []model.Span{{Text: "plain text"}}},
+		{"a **bold** word", []model.Span{{Text: "a "}, {Text: "bold", Bold: true}, {Text: " word"}}},
+		{"__bold__ and _it_", []model.Span{{Text: "bold", Bold: true}, {Text: " and "}, {Text: "it", Italic: true}}},
+		{"*it* `x*y*` ~~old~~", []model.Span{
+			{Text: "it", Italic: true}, {Text: " "}, {Text: "x*y*", Code: true}, {Text: " "}, {Text: "old", Strike: true},
+		}},
+		{"see [the **docs**](https://x.dev)", []model.Span{
+			{Text: "see "}, {Text: "the ", Href: "https://x.dev"}, {Text: "docs", Bold: true, Href: "https://x.dev"},
+		}},
+		{"***both***", []model.Span{{Text: "both", Bold: true, Italic: true}}},
+		{"**bold *and it***", []model.Span{{Text: "bold ", Bold: true}, {Text: "and it", Bold: true, Italic: true}}},
+		{"snake_case_name", []model.Span{{Text: "snake_case_name"}}},
+		{"2 * 3 * 4", []model.Span{{Text: "2 * 3 * 4"}}},
+		{`\*not italic\*`, []model.Span{{Text: "*not italic*"}}},
+		{"![alt](img.png)", []model.Span{{Text: "![alt](img.png)"}}},
+		{"unclosed **bold", []model.Span{{Text: "unclosed **bold"}}},
+		{"area $\\pi r^2$ here", []model.Span{{Text: "area "}, {Text: "\\pi r^2", Math: true}, {Text: " here"}}},
+		{"**$x$**", []model.Span{{Text: "x", Bold: true, Math: true}}},
+		{"costs $5 or $10", []model.Span{{Text: "costs $5 or $10"}}},
+		{"$ x$ and $y $", []model.Span{{Text: "$ x$ and $y $"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := parseInline(tt.input); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseInline(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderInline(t *testing.T) {
+	tests := []struct {
+		runs []model.Span
+		want string
+	}{
+		{[]model.Span{{Text: "a "}, {Text: "bold", Bold: true}, {Text: " word"}}, "a **bold** word"},
+		{[]model.Span{{Text: "bold", Bold: true}, {Text: " "}, {Text: "word", Italic: true}}, "**bold** *word*"},
+		{[]model.Span{{Text: "x", Bold: true}, {Text: "y", Bold: true, Italic: true}}, "**x*y***"},
+		{[]model.Span{{Text: "a`b", Code: true}}, "``a`b``"},
+		{[]model.Span{{Text: "go ", Href: "https://go.dev"}, {Text: "now", Href: "https://go.dev", Bold: true}}, "[go **now**](https://go.dev)"},
+		{[]model.Span{{Text: "2*3 [x] "}, {Text: "y", Italic: true}}, `2\*3 \[x\] *y*`},
+		{[]model.Span{{Text: "so "}, {Text: "e^{i\\pi}", Math: true}}, `so $e^{i\pi}$`},
+		{[]model.Span{{Text: "not $math$ here"}}, `not \$math$ here`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := renderInline(tt.runs); got != tt.want {
+				t.Errorf("renderInline(%+v) = %q, want %q", tt.runs, got, tt.want)
+			}
+			if back := parseInline(tt.want); !reflect.DeepEqual(back, tt.runs) {
+				t.Errorf("parseInline(%q) = %+v, want %+v", tt.want, back, tt.runs)
+			}
+		})
+	}
+}
+
+func TestInlineRunsRoundTrip(t *testing.T) {
+	input := `---
+marp: true
+---
+
+# Inline
+
+- Use **bold** and *italic*
+- Run ` + "`go test`" + ` with [docs](https://go.dev)
+
+Plain paragraph with ~~old~~ text.
+`
+	reader := NewReader()
+	deck, err := reader.Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	body := deck.Sections[0].Slides[0].Body
+	if body[0].Text != "Use bold and italic" || len(body[0].Runs) != 4 {
+		t.Errorf("unexpected first bullet: %+v", body[0])
+	}
+	if body[2].Text != "Plain paragraph with old text." || !body[2].Runs[1].Strike {
+		t.Errorf("unexpected paragraph: %+v", body[2])
+	}
+
+	output := NewWriter().Encode(deck)
+	reparsed, err := reader.Parse(output)
+	if err != nil {
+		t.Fatalf("Parse error on output: %v", err)
+	}
+	clearSources(deck)
+	clearSources(reparsed)
+	if !reflect.DeepEqual(deck, reparsed) {
+		t.Errorf("round trip changed the deck:\n%s", output)
+	}
+}
+
+func TestPatchInlineText(t *testing.T) {
+	input := "# Title\n\n- Keep __this__ style\n- Old *text*\n"
+	deck, _ := NewReader().Parse(input)
+	body := append([]model.Block(nil), deck.Sections[0].Slides[0].Body...)
+	body[1].Text = "New text"
+	body[1].Runs = []model.Span{{Text: "New "}, {Text: "text", Bold: true}}
+
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/title/body", nil, body))
+
+	want := "# Title\n\n- Keep __this__ style\n- New **text**\n"
+	if got := applyPatch(t, input, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
@@ -0,0 +1,87 @@
+package marp
+
+import "github.com/grokify/slidekit/model"
+
+// layoutClasses maps the _class directives that mark slide layouts onto
+// them. lead and section-divider are Marp conventions; the others are
+// written by the writer so that every layout reads back as written.
+var layoutClasses = map[string]model.Layout{
+	"lead":            model.LayoutTitle,
+	"section-divider": model.LayoutSection,
+	"image":           model.LayoutImage,
+	"blank":           model.LayoutBlank,
+	"comparison":      model.LayoutComparison,
+}
+
+// isLayoutClass reports whether a _class directive value is one the reader
+// maps onto a slide layout.
+func isLayoutClass(class string) bool {
+	_, ok := layoutClasses[class]
+	return ok
+}
+
+// sectionMarker is the comment key of explicit section markers, as in
+// <!-- section: intro Introduction -->.
+const sectionMarker = "section"
+
+// isSectionClass reports whether a _class directive value starts a new
+// section by Marp convention.
+func isSectionClass(class string) bool {
+	return class == "section-divider" || class == "lead"
+}
+
+// layoutClass returns the _class directive the writer marks layout with,
+// or "" for layouts that need none.
+func layoutClass(layout model.Layout) string {
+	for class, l := range layoutClasses {
+		if l == layout {
+			return class
+		}
+	}
+	return ""
+}
+
+// classifyLayout infers the layout of slide, read from content carrying
+// the _class directive class, by the first rule that matches:
+//
+//   - a layout class gives its layout
+//   - two columns each opening with a heading make a comparison
+//   - any other columns make a two-column slide
+//   - background images and no other content make an image slide
+//   - a slide with no content at all is blank
+//
+// Everything else is a title and body slide. columns reports whether the
+// content has columns the reader could not read into slots.
+func classifyLayout(slide *model.Slide, class string, columns bool) model.Layout {
+	if layout, ok := layoutClasses[class]; ok {
+		return layout
+	}
+	empty := slide.Title == "" && slide.Subtitle == "" && len(slide.Body) == 0 && !slide.HasSlots()
+	switch {
+	case isComparison(slide.Slots):
+		return model.LayoutComparison
+	case columns || len(slide.Slots.Columns()) > 0:
+		return model.LayoutTitleTwoCol
+	case empty && (len(backgroundImages(slide)) > 0 || slide.Background != nil && slide.Background.Image != ""):
+		return model.LayoutImage
+	case empty:
+		return model.LayoutBlank
+	}
+	return model.LayoutTitleBody
+}
+
+// isComparison reports whether slots lay out two columns side by side,
+// each opening with a heading naming what it compares.
+func isComparison(slots model.Slots) bool {
+	columns := slots.Columns()
+	if len(columns) != 2 {
+		return false
+	}
+	for _, name := range columns {
+		blocks := slots[name]
+		if len(blocks) == 0 || blocks[0].Kind != model.BlockHeading {
+			return false
+		}
+	}
+	return true
+}
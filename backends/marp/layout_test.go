@@ -0,0 +1,73 @@
+package marp
+
+import (
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestClassifyLayout(t *testing.T) {
+	comparison := "# Options\n\n<div class=\"columns\">\n<div>\n\n## Build\n\n- Control\n\n</div>\n<div>\n\n## Buy\n\n- Speed\n\n</div>\n</div>\n"
+	tests := []struct {
+		name, input string
+		want        model.Layout
+	}{
+		{"title and body", "# Title\n\n- Point\n", model.LayoutTitleBody},
+		{"lead", "<!-- _class: lead -->\n\n# Welcome\n", model.LayoutTitle},
+		{"section divider", "<!-- _class: section-divider -->\n\n# Part 1\n", model.LayoutSection},
+		{"full-bleed image", "![bg](photo.jpg)\n", model.LayoutImage},
+		{"split background", "![bg left](a.jpg)\n![bg right](b.jpg)\n\n<!-- A note -->\n", model.LayoutImage},
+		{"background directive", "<!-- _backgroundImage: url('bg.png') -->\n", model.LayoutImage},
+		{"image with text", "![bg](photo.jpg)\n\n# Caption\n", model.LayoutTitleBody},
+		{"blank", "<!-- _backgroundColor: black -->\n", model.LayoutBlank},
+		{"comparison", comparison, model.LayoutComparison},
+		{"columns", "# Two\n\n<div class=\"columns\">\n<div>\n\n- A\n\n</div>\n<div>\n\n- B\n\n</div>\n</div>\n", model.LayoutTitleTwoCol},
+		{"explicit class", "<!-- _class: comparison -->\n\n# Versus\n", model.LayoutComparison},
+	}
+	for _, tt := range tests {
+		deck, err := NewReader().Parse(tt.input)
+		if err != nil {
+			t.Fatalf("%s: Parse error: %v", tt.name, err)
+		}
+		if got := deck.AllSlides()[0].Layout; got != tt.want {
+			t.Errorf("%s: layout = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLayoutRoundTrip(t *testing.T) {
+	for _, layout := range model.Layouts() {
+		slide := model.Slide{ID: "s", Layout: layout, Title: "Title"}
+		switch layout {
+		case model.LayoutBlank:
+			slide.Title = ""
+		case model.LayoutImage:
+			slide.Title = ""
+			slide.Background = &model.Background{Images: []model.Block{model.NewBackgroundImage("photo.jpg")}}
+		case model.LayoutTitleTwoCol, model.LayoutComparison:
+			slide.Slots = model.Slots{
+				model.SlotLeft:  {model.NewBullet("A", 0)},
+				model.SlotRight: {model.NewBullet("B", 0)},
+			}
+		}
+		deck := &model.Deck{Sections: []model.Section{{ID: "default", Slides: []model.Slide{slide}}}}
+
+		out := NewWriter().Encode(deck)
+		back, err := NewReader().Parse(out)
+		if err != nil {
+			t.Fatalf("%s: Parse error: %v", layout, err)
+		}
+		if got := back.AllSlides(); len(got) != 1 || got[0].Layout != layout {
+			t.Errorf("%s: layout did not round-trip:\n%s", layout, out)
+		}
+	}
+
+	// A class of the slide's own wins over the layout class, and the
+	// layout is inferred from the content instead
+	slide := model.Slide{ID: "s", Layout: model.LayoutBlank, Directives: map[string]string{"_class": "invert"}}
+	deck := &model.Deck{Sections: []model.Section{{ID: "default", Slides: []model.Slide{slide}}}}
+	back, _ := NewReader().Parse(NewWriter().Encode(deck))
+	if got := back.AllSlides()[0]; got.Layout != model.LayoutBlank || got.Directives["_class"] != "invert" {
+		t.Errorf("unexpected slide %+v", got)
+	}
+}
@@ -0,0 +1,58 @@
+package marp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestParseMathAndDiagrams(t *testing.T) {
+	input := "# Formulas\n\n$$ E = mc^2 $$\n\n$$\n\\int_0^1 x\\,dx\n  = \\frac{1}{2}\n$$\n\n" +
+		"```mermaid\ngraph TD\n  A --> B\n```\n\n```go\nfmt.Println()\n```\n"
+	deck, err := NewReader().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	want := []model.Block{
+		model.NewMath("E = mc^2"),
+		model.NewMath("\\int_0^1 x\\,dx\n  = \\frac{1}{2}"),
+		model.NewDiagram("graph TD\n  A --> B", model.DiagramMermaid),
+		model.NewCode("fmt.Println()", "go"),
+	}
+	if got := deck.Sections[0].Slides[0].Body; !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected body:\n got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestParseUnclosedMath(t *testing.T) {
+	_, diags, _ := NewReader().ParseDiagnostics("# Oops\n\n$$\nx^2\n")
+	if len(diags) != 1 || diags[0].Line != 3 || !strings.Contains(diags[0].Message, "never closed") {
+		t.Errorf("expected unclosed math warning on line 3, got %+v", diags)
+	}
+}
+
+func TestWriteMathAndDiagrams(t *testing.T) {
+	slide := model.Slide{
+		Layout: model.LayoutTitleBody,
+		Title:  "Formulas",
+		Body: []model.Block{
+			model.NewMath("a^2 + b^2 = c^2"),
+			model.NewDiagram("digraph { a -> b }", model.DiagramGraphviz),
+		},
+	}
+
+	var b strings.Builder
+	NewWriter().writeSlide(&b, &slide, nil, idDirectives{})
+	want := "# Formulas\n\n$$\na^2 + b^2 = c^2\n$$\n\n```graphviz\ndigraph { a -> b }\n```\n"
+	if got := b.String(); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	deck, _ := NewReader().Parse(want)
+	if got := deck.Sections[0].Slides[0].Body; !reflect.DeepEqual(got, slide.Body) {
+		t.Errorf("blocks did not survive a round trip: %+v", got)
+	}
+}
@@ -0,0 +1,145 @@
+package marp
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grokify/slidekit/model"
+)
+
+var (
+	// Matches HTML comments, single- or multi-line
+	reComment = regexp.MustCompile(`(?s)<!--(.*?)-->`)
+
+	// Matches pause cues: [PAUSE:...] markers and <break time="..."/> SSML
+	// breaks, in milliseconds or seconds. A PAUSE marker without a unit is
+	// in milliseconds.
+	reCue = regexp.MustCompile(`\[PAUSE:(\d+(?:\.\d+)?)(ms|s)?\]|<break\s+time="(\d+(?:\.\d+)?)(ms|s)"\s*/?>`)
+)
+
+// findNotes returns the spans of the speaker note comments in content: the
+// HTML comments that sit on lines of their own outside fenced code. Marp
+// treats every comment that is not a directive as a presenter note.
+func findNotes(content string) []span {
+	fences := fencedCode(content)
+	var notes []span
+	for _, loc := range reComment.FindAllStringIndex(content, -1) {
+		s := span{start: loc[0], end: loc[1]}
+		if strings.TrimSpace(content[lineStart(content, s.start):s.start]) != "" ||
+			strings.TrimSpace(content[s.end:lineEnd(content, s.end)]) != "" {
+			continue
+		}
+		inCode := false
+		for _, f := range fences {
+			inCode = inCode || s.start >= f.start && s.start < f.end
+		}
+		// Skip if it looks like a script
+		if inCode || strings.Contains(content[s.start:s.end], "<script") {
+			continue
+		}
+		notes = append(notes, s)
+	}
+	return notes
+}
+
+// fencedCode returns the spans of the fenced code blocks in content.
+func fencedCode(content string) []span {
+	var fences []span
+	start := -1
+	pos := 0
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			if start < 0 {
+				start = pos
+			} else {
+				fences = append(fences, span{start: start, end: pos + len(line)})
+				start = -1
+			}
+		}
+		pos += len(line)
+	}
+	if start >= 0 {
+		fences = append(fences, span{start: start, end: len(content)})
+	}
+	return fences
+}
+
+// parseNote parses the text of a note comment into blocks: paragraphs,
+// lists and the other Markdown blocks, with pause cues between paragraphs
+// as pause blocks. Cues inside list items stay in the item text. It also
+// returns where in text each block was found; the parts of a paragraph
+// split by pause cues all share the paragraph's span.
+func parseNote(text string) ([]model.Block, []span) {
+	var scratch model.Slide
+	spans := parseContent(&scratch, text, false)
+	blocks := scratch.Body
+	at := make([]span, len(spans.blocks))
+	for i, s := range spans.blocks {
+		at[i] = s.full
+	}
+	if scratch.HasSlots() || scratch.Background != nil {
+		// Layout markup means nothing in notes; keep the text as written
+		blocks = []model.Block{model.NewParagraph(strings.TrimSpace(text))}
+		at = []span{{start: 0, end: len(text)}}
+	}
+
+	var notes []model.Block
+	var noteSpans []span
+	add := func(block model.Block, s span) {
+		notes = append(notes, block)
+		noteSpans = append(noteSpans, s)
+	}
+	for i, block := range blocks {
+		if block.Kind != model.BlockParagraph || !reCue.MatchString(block.Text) {
+			add(block, at[i])
+			continue
+		}
+		last := 0
+		for _, loc := range reCue.FindAllStringSubmatchIndex(block.Text, -1) {
+			if before := strings.TrimSpace(block.Text[last:loc[0]]); before != "" {
+				add(withInline(model.NewParagraph(before)), at[i])
+			}
+			add(parseCue(block.Text, loc), at[i])
+			last = loc[1]
+		}
+		if after := strings.TrimSpace(block.Text[last:]); after != "" {
+			add(withInline(model.NewParagraph(after)), at[i])
+		}
+	}
+	return notes, noteSpans
+}
+
+// parseCue converts a reCue match into a pause block, remembering whether
+// the cue was written in seconds.
+func parseCue(text string, loc []int) model.Block {
+	ssml := loc[2] < 0
+	if ssml {
+		loc = loc[4:]
+	}
+	n, _ := strconv.ParseFloat(text[loc[2]:loc[3]], 64)
+	unit := time.Millisecond
+	seconds := loc[4] >= 0 && text[loc[4]:loc[5]] == "s"
+	if seconds {
+		unit = time.Second
+	}
+	pause := &model.Pause{Duration: time.Duration(n * float64(unit)), SSML: ssml, Seconds: seconds}
+	return model.Block{Kind: model.BlockPause, Pause: pause}
+}
+
+// renderPause renders a pause block as the cue it was read from.
+func renderPause(block *model.Block) string {
+	if block.Pause == nil {
+		return ""
+	}
+	d := strconv.FormatInt(block.Pause.Duration.Milliseconds(), 10) + "ms"
+	if block.Pause.Seconds {
+		d = strconv.FormatFloat(block.Pause.Duration.Seconds(), 'f', -1, 64) + "s"
+	}
+	if block.Pause.SSML {
+		return fmt.Sprintf(`<break time="%s"/>`, d)
+	}
+	return fmt.Sprintf("[PAUSE:%s]", strings.TrimSuffix(d, "ms"))
+}
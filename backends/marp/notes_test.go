@@ -0,0 +1,91 @@
+package marp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestParseNote(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []model.Block
+	}{
+		{"Simple note.", []model.Block{model.NewParagraph("Simple note.")}},
+		{" single line ", []model.Block{model.NewParagraph("single line")}},
+		{"Hello world. [PAUSE:1000] Next sentence.", []model.Block{
+			model.NewParagraph("Hello world."),
+			model.NewPause(time.Second),
+			model.NewParagraph("Next sentence."),
+		}},
+		{"Intro.\n<break time=\"1.5s\"/>", []model.Block{
+			model.NewParagraph("Intro."),
+			{Kind: model.BlockPause, Pause: &model.Pause{Duration: 1500 * time.Millisecond, SSML: true, Seconds: true}},
+		}},
+		{"Breathe. [PAUSE:2s]", []model.Block{
+			model.NewParagraph("Breathe."),
+			{Kind: model.BlockPause, Pause: &model.Pause{Duration: 2 * time.Second, Seconds: true}},
+		}},
+		{"First line\nsecond line.\n\n- Point\n    - Detail\n1. Step", []model.Block{
+			model.NewParagraph("First line\nsecond line."),
+			model.NewBullet("Point", 0),
+			model.NewBullet("Detail", 1),
+			model.NewNumbered("Step", 0),
+		}},
+		// Cues inside list items are not split out
+		{"- Wait [PAUSE:200] here", []model.Block{model.NewBullet("Wait [PAUSE:200] here", 0)}},
+		{"   ", nil},
+	}
+
+	for _, tt := range tests {
+		if got, _ := parseNote(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseNote(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestSingleLineNotes(t *testing.T) {
+	input := "<!-- _class: lead -->\n<!-- Mention the demo -->\n\n# Title\n\nText <!-- inline --> here.\n\n" +
+		"```html\n<!-- example -->\n```\n"
+	deck, err := NewReader().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	slide := deck.Sections[0].Slides[0]
+
+	want := []model.Block{model.NewParagraph("Mention the demo")}
+	if !reflect.DeepEqual(slide.Notes, want) {
+		t.Errorf("expected single-line comment read as a note, got %+v", slide.Notes)
+	}
+	if len(slide.Body) != 2 || !strings.Contains(slide.Body[0].Text, "<!-- inline -->") ||
+		!strings.Contains(slide.Body[1].Text, "<!-- example -->") {
+		t.Errorf("expected inline and code comments kept in the body, got %+v", slide.Body)
+	}
+}
+
+func TestWriteNotes(t *testing.T) {
+	notes := []model.Block{
+		model.NewParagraph("Welcome."),
+		model.NewPause(800 * time.Millisecond),
+		model.NewParagraph("Agenda:"),
+		model.NewBullet("Goals", 0),
+		model.NewBullet("Plan", 0),
+		model.NewBreak(2 * time.Second),
+		{Kind: model.BlockPause, Pause: &model.Pause{Duration: 2 * time.Second, Seconds: true}},
+		{Kind: model.BlockPause, Pause: &model.Pause{Duration: 1500 * time.Millisecond, SSML: true, Seconds: true}},
+	}
+
+	var b strings.Builder
+	NewWriter().writeNotes(&b, notes)
+	want := "<!--\nWelcome.\n[PAUSE:800]\nAgenda:\n\n- Goals\n- Plan\n\n<break time=\"2000ms\"/>\n[PAUSE:2s]\n<break time=\"1.5s\"/>\n-->\n"
+	if got := b.String(); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	if got := parseRawSlide(want, isDirective).notes[0].blocks; !reflect.DeepEqual(got, notes) {
+		t.Errorf("notes did not survive a round trip: %+v", got)
+	}
+}
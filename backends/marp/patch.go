@@ -0,0 +1,875 @@
+package marp
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// document records where each part of a parsed Marp file came from, so that
+// edits can be written back without regenerating untouched regions.
+type document struct {
+	deck   *model.Deck   // Deck as parsed from the source
+	head   string        // Frontmatter including both delimiters, verbatim
+	chunks []string      // Text between separators, including blank chunks
+	seps   []string      // Separator lines; seps[i] follows chunks[i]
+	starts []int         // File line each chunk starts on
+	slides []slideSource // Non-blank chunks in deck order
+
+	diagnostics    []model.Diagnostic // Malformed source found while parsing
+	frontmatterErr error              // Why the frontmatter was ignored, if it was
+}
+
+// slideSource ties a parsed slide to the chunk it was read from.
+type slideSource struct {
+	chunk          int               // Index into document.chunks
+	slide          model.Slide       // Slide as parsed from the chunk
+	directives     map[string]string // Directives as written in the chunk
+	directiveSpans map[string]span   // Directive comments
+	notes          []noteBlock       // Speaker note comments
+	spans          contentSpans      // Title, subtitle and body blocks
+}
+
+// span is a byte range within a slide chunk.
+type span struct {
+	start, end int
+}
+
+// noSpan marks an element whose text cannot be located in the source.
+var noSpan = span{start: -1, end: -1}
+
+func (s span) valid() bool {
+	return s.start >= 0
+}
+
+// contentSpans records where parseSlideContent found each element.
+type contentSpans struct {
+	title       span
+	subtitle    span
+	blocks      []blockSpan // Parallel to Slide.Body
+	slots       []span      // HTML blocks read into Slide.Slots
+	backgrounds []span      // Lines of background images
+	problems    []problem   // Malformed source found while parsing
+}
+
+// blockSpan locates a body block. full covers every source line of the
+// block; text covers just its Text when that appears verbatim in the source.
+// For tables, cells covers the text of each cell, header row first.
+type blockSpan struct {
+	full  span
+	text  span
+	cells [][]span
+}
+
+// edit replaces a span of a chunk with new text. Insertions have an empty span.
+type edit struct {
+	span
+	text string
+}
+
+// patch renders deck by editing the source recorded in doc. Frontmatter and
+// slides whose rendering is unchanged are kept byte-for-byte; changed slides
+// are edited in place where possible and regenerated otherwise. IDs the
+// reader would no longer derive from the titles are written out, so they
+// survive the edit, and IDs the source already spelled out are kept.
+func (w *Writer) patch(doc *document, deck *model.Deck) string {
+	var b strings.Builder
+
+	// Keep the original frontmatter unless its rendering changes, and then
+	// edit just the fields that changed where possible
+	if w.renderFrontmatter(doc.deck) == w.renderFrontmatter(deck) {
+		b.WriteString(doc.head)
+	} else if head, ok := w.patchFrontmatter(doc.head, doc.deck, deck); ok {
+		b.WriteString(head)
+	} else {
+		b.WriteString(strings.TrimSuffix(w.renderFrontmatter(deck), "\n"))
+	}
+
+	slides := deck.AllSlides()
+	oldOpens, newOpens := sectionOpeners(doc.deck), sectionOpeners(deck)
+	keep := doc.explicitIDs(oldOpens)
+	oldIDs, newIDs := w.explicitIDs(doc.deck, keep), w.explicitIDs(deck, keep)
+	sources := make(map[string]*slideSource, len(doc.slides))
+	sameOrder := len(slides) == len(doc.slides)
+	for i := range doc.slides {
+		src := &doc.slides[i]
+		sources[src.slide.ID] = src
+		if sameOrder && slides[i].ID != src.slide.ID {
+			sameOrder = false
+		}
+	}
+
+	// With the slide order unchanged, every chunk and separator stays put
+	if sameOrder {
+		k := 0
+		for i, chunk := range doc.chunks {
+			if i > 0 {
+				b.WriteString("\n" + doc.seps[i-1] + "\n")
+			}
+			if k < len(doc.slides) && doc.slides[k].chunk == i {
+				src := &doc.slides[k]
+				chunk = w.patchSlide(chunk, src, oldOpens[src.slide.ID],
+					&slides[k], newOpens[slides[k].ID], oldIDs, newIDs)
+				k++
+			}
+			b.WriteString(chunk)
+		}
+		return b.String()
+	}
+
+	// Otherwise reassemble the slides in their new order
+	for i := range slides {
+		src, ok := sources[slides[i].ID]
+		if i > 0 {
+			sep := "---"
+			if ok && src.chunk > 0 {
+				sep = doc.seps[src.chunk-1]
+			}
+			b.WriteString("\n" + sep + "\n")
+		}
+		opens := newOpens[slides[i].ID]
+		var chunk string
+		if ok {
+			chunk = w.patchSlide(doc.chunks[src.chunk], src, oldOpens[src.slide.ID],
+				&slides[i], opens, oldIDs, newIDs)
+		} else {
+			chunk = w.renderSlide(&slides[i], opens, newIDs)
+		}
+
+		// A slide moving to or from the top of a file without frontmatter
+		// gains or loses the blank line that follows a separator
+		if b.Len() == 0 {
+			chunk = strings.TrimLeft(chunk, "\n")
+		} else if !strings.HasPrefix(chunk, "\n") {
+			chunk = "\n" + chunk
+		}
+		b.WriteString(chunk)
+	}
+	return b.String()
+}
+
+// patchSlide returns the chunk text for slide, editing the original text
+// only where it differs from the parsed source slide. oldOpens and opens
+// are the sections the slide started before and after the change, and
+// oldIDs and ids the IDs written out before and after. A slide that renders
+// the same as before is kept verbatim.
+func (w *Writer) patchSlide(text string, src *slideSource, oldOpens *model.Section,
+	slide *model.Slide, opens *model.Section, oldIDs, ids idDirectives) string {
+	old := &src.slide
+	if w.renderSlide(old, oldOpens, oldIDs) == w.renderSlide(slide, opens, ids) {
+		return text
+	}
+	if old.Layout != slide.Layout {
+		return w.regenerateSlide(text, slide, opens, ids)
+	}
+
+	edits := directiveEdits(text, src, slideDirectives(slide, opens, ids))
+	for _, heading := range []struct {
+		old, new string
+		at       span
+	}{
+		{old.Title, slide.Title, src.spans.title},
+		{old.Subtitle, slide.Subtitle, src.spans.subtitle},
+	} {
+		if heading.old == heading.new {
+			continue
+		}
+		if !heading.at.valid() || heading.new == "" {
+			return w.regenerateSlide(text, slide, opens, ids)
+		}
+		edits = append(edits, edit{span: heading.at, text: heading.new})
+	}
+
+	if !reflect.DeepEqual(old.Notes, slide.Notes) {
+		notes, ok := w.noteEdits(text, src, slide)
+		if !ok {
+			return w.regenerateSlide(text, slide, opens, ids)
+		}
+		edits = append(edits, notes...)
+	}
+
+	if !reflect.DeepEqual(old.Body, slide.Body) {
+		body, ok := w.bodyEdits(text, src, slide.Body)
+		if !ok {
+			return w.regenerateSlide(text, slide, opens, ids)
+		}
+		edits = append(edits, body...)
+	}
+
+	if !reflect.DeepEqual(old.Slots, slide.Slots) {
+		if len(src.spans.slots) == 0 {
+			return w.regenerateSlide(text, slide, opens, ids)
+		}
+		edits = append(edits, w.slotEdits(text, src, slide.Slots)...)
+	}
+
+	if !reflect.DeepEqual(backgroundImages(old), backgroundImages(slide)) {
+		if len(src.spans.backgrounds) == 0 {
+			return w.regenerateSlide(text, slide, opens, ids)
+		}
+		edits = append(edits, backgroundEdits(text, src, backgroundImages(slide))...)
+	}
+
+	return applyEdits(text, edits)
+}
+
+// backgroundImages returns the background images of a slide.
+func backgroundImages(slide *model.Slide) []model.Block {
+	if slide.Background == nil {
+		return nil
+	}
+	return slide.Background.Images
+}
+
+// backgroundEdits replaces the background image lines of a slide with the
+// new images, written where the first one stood. When the number of images
+// is unchanged, each line is rewritten in place instead.
+func backgroundEdits(text string, src *slideSource, images []model.Block) []edit {
+	var edits []edit
+	if len(images) == len(src.spans.backgrounds) {
+		for i, at := range src.spans.backgrounds {
+			edits = append(edits, edit{span: at, text: rewriteImage(text[at.start:at.end], &images[i])})
+		}
+		return edits
+	}
+	for i, at := range src.spans.backgrounds {
+		if i == 0 && len(images) > 0 {
+			edits = append(edits, edit{span: at, text: renderBackgrounds(images)})
+		} else {
+			edits = append(edits, removal(text, at))
+		}
+	}
+	return edits
+}
+
+// slotEdits replaces the HTML the slots were read from with the scaffold
+// for the new slots, written where the first slot block stood.
+func (w *Writer) slotEdits(text string, src *slideSource, slots model.Slots) []edit {
+	var edits []edit
+	for i, at := range src.spans.slots {
+		if i == 0 && len(slots) > 0 {
+			edits = append(edits, edit{span: at, text: w.renderSlots(slots)})
+		} else {
+			edits = append(edits, removal(text, at))
+		}
+	}
+	return edits
+}
+
+// directiveEdits rewrites the directive comments of a slide to match want.
+// Changed directives are rewritten in place, dropped ones removed, and new
+// ones inserted after the existing directives or at the top of the slide.
+func directiveEdits(text string, src *slideSource, want map[string]string) []edit {
+	var edits []edit
+	insertAt, blankAfter := -1, false
+	for key, at := range src.directiveSpans {
+		value := src.directives[key]
+		// Layout classes are handled with the layout itself
+		if key == "_class" && isLayoutClass(value) {
+			continue
+		}
+		if end := lineEnd(text, at.end); end >= insertAt {
+			insertAt = min(end+1, len(text))
+		}
+		switch wanted, ok := want[key]; {
+		case !ok:
+			edits = append(edits, removal(text, at))
+		case directiveText(wanted) != directiveText(value):
+			edits = append(edits, edit{span: at, text: directiveComment(key, wanted)})
+		}
+	}
+	if at, ok := src.directiveSpans["_class"]; ok && isLayoutClass(src.directives["_class"]) {
+		insertAt = max(insertAt, min(lineEnd(text, at.end)+1, len(text)))
+	}
+	if insertAt < 0 {
+		// No directives yet: start a block of them above the content
+		insertAt = lineStart(text, len(text)-len(strings.TrimLeft(text, " \t\r\n")))
+		blankAfter = true
+	}
+
+	var added []string
+	for key := range want {
+		if _, ok := src.directives[key]; !ok {
+			added = append(added, key)
+		}
+	}
+	sort.Strings(added)
+	var b strings.Builder
+	for _, key := range added {
+		b.WriteString(directiveComment(key, want[key]) + "\n")
+	}
+	if b.Len() > 0 {
+		if blankAfter {
+			b.WriteString("\n")
+		}
+		edits = append(edits, edit{span: span{start: insertAt, end: insertAt}, text: b.String()})
+	}
+	return edits
+}
+
+// noteEdits returns edits that bring the speaker note comments in line with
+// the slide's notes. A single comment has only the blocks that changed
+// rewritten, as noteBlockEdits does; otherwise the first comment is
+// rewritten with all the notes and any others are dropped. A slide without
+// note comments gets one above its title.
+func (w *Writer) noteEdits(text string, src *slideSource, slide *model.Slide) ([]edit, bool) {
+	var b strings.Builder
+	w.writeNotes(&b, slide.Notes)
+	notes := strings.TrimSuffix(b.String(), "\n")
+
+	if len(src.notes) == 0 {
+		if notes == "" {
+			return nil, true
+		}
+		if !src.spans.title.valid() {
+			return nil, false
+		}
+		at := lineStart(text, src.spans.title.start)
+		return []edit{{span: span{start: at, end: at}, text: notes + "\n\n"}}, true
+	}
+
+	if len(src.notes) == 1 && len(slide.Notes) > 0 {
+		if edits, ok := w.noteBlockEdits(src.notes[0], slide.Notes); ok {
+			return edits, true
+		}
+	}
+
+	var edits []edit
+	for i, nb := range src.notes {
+		if i == 0 && notes != "" {
+			edits = append(edits, edit{span: nb.span, text: notes})
+		} else {
+			edits = append(edits, removal(text, nb.span))
+		}
+	}
+	return edits, true
+}
+
+// noteBlockEdits aligns the blocks of a note comment with the new notes and
+// returns edits that rewrite only the source paragraphs that changed, so
+// the spacing of the rest is kept. The blocks of one paragraph, such as
+// narration broken up by pause cues, are kept or rewritten together. It
+// returns false when no paragraph can be kept.
+func (w *Writer) noteBlockEdits(nb noteBlock, notes []model.Block) ([]edit, bool) {
+	// Group the old blocks by the paragraph they were read from
+	type group struct {
+		first, end int // Old blocks
+		at         span
+	}
+	var groups []group
+	for i, s := range nb.blockSpans {
+		if n := len(groups); n > 0 && groups[n-1].at == s {
+			groups[n-1].end = i + 1
+		} else {
+			groups = append(groups, group{first: i, end: i + 1, at: s})
+		}
+	}
+
+	// A paragraph is kept when all its blocks match consecutive new ones
+	match := make([]int, len(nb.blocks)) // New index for each old block, or -1
+	for i := range match {
+		match[i] = -1
+	}
+	for _, m := range longestCommonBlocks(nb.blocks, notes) {
+		match[m[0]] = m[1]
+	}
+	type keptGroup struct {
+		group    int
+		from, to int // New blocks
+	}
+	var kept []keptGroup
+	for i, g := range groups {
+		ok := true
+		for k := g.first; k < g.end; k++ {
+			ok = ok && match[k] >= 0 && match[k] == match[g.first]+k-g.first
+		}
+		if ok {
+			kept = append(kept, keptGroup{group: i, from: match[g.first], to: match[g.end-1] + 1})
+		}
+	}
+	if len(kept) == 0 {
+		return nil, false
+	}
+
+	// Replace the paragraphs between kept ones with the new blocks there
+	var edits []edit
+	for i := 0; i <= len(kept); i++ {
+		prev, next := keptGroup{group: -1}, keptGroup{group: len(groups), from: len(notes)}
+		if i > 0 {
+			prev = kept[i-1]
+		}
+		if i < len(kept) {
+			next = kept[i]
+		}
+		removed, added := groups[prev.group+1:next.group], notes[prev.to:next.from]
+		switch {
+		case len(removed) > 0 && len(added) > 0:
+			at := span{start: removed[0].at.start, end: removed[len(removed)-1].at.end}
+			edits = append(edits, edit{span: at, text: w.renderBlocks(added)})
+		case len(removed) > 0 && i < len(kept):
+			// The blank lines after the paragraphs go with them
+			edits = append(edits, edit{span: span{start: removed[0].at.start, end: groups[next.group].at.start}})
+		case len(removed) > 0:
+			edits = append(edits, edit{span: span{start: groups[prev.group].at.end, end: removed[len(removed)-1].at.end}})
+		case len(added) > 0 && i < len(kept):
+			sep := "\n"
+			if needsBlankLine(&added[len(added)-1], &notes[next.from]) {
+				sep = "\n\n"
+			}
+			at := groups[next.group].at.start
+			edits = append(edits, edit{span: span{start: at, end: at}, text: w.renderBlocks(added) + sep})
+		case len(added) > 0:
+			sep := "\n"
+			if needsBlankLine(&notes[prev.to-1], &added[0]) {
+				sep = "\n\n"
+			}
+			at := groups[prev.group].at.end
+			edits = append(edits, edit{span: span{start: at, end: at}, text: sep + w.renderBlocks(added)})
+		}
+	}
+	return edits, true
+}
+
+// renderBlocks renders blocks as writeBlocks does, without the final
+// newline.
+func (w *Writer) renderBlocks(blocks []model.Block) string {
+	var b strings.Builder
+	w.writeBlocks(&b, blocks)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// bodyEdits aligns the old and new body blocks and returns edits that
+// remove, replace or insert only the blocks that differ.
+func (w *Writer) bodyEdits(text string, src *slideSource, body []model.Block) ([]edit, bool) {
+	oldBody := src.slide.Body
+	spans := src.spans.blocks
+
+	// Pair up old and new blocks: kept blocks come from the longest common
+	// subsequence, and blocks between kept ones are updated pairwise.
+	source := make([]int, len(body)) // Old index for each new block, or -1
+	survives := make([]bool, len(oldBody))
+	var edits []edit
+	matches := longestCommonBlocks(oldBody, body)
+	oi, nj := 0, 0
+	for _, m := range append(matches, [2]int{len(oldBody), len(body)}) {
+		for oi < m[0] || nj < m[1] {
+			switch {
+			case oi < m[0] && nj < m[1]:
+				edits = append(edits, w.blockUpdate(text, spans[oi], &oldBody[oi], &body[nj])...)
+				source[nj], survives[oi] = oi, true
+				oi++
+				nj++
+			case oi < m[0]:
+				edits = append(edits, removal(text, spans[oi].full))
+				oi++
+			default:
+				source[nj] = -1
+				nj++
+			}
+		}
+		if m[0] < len(oldBody) {
+			source[nj], survives[oi] = oi, true
+			oi++
+			nj++
+		}
+	}
+
+	// Insert added blocks after the nearest surviving block before them,
+	// or before the first surviving block, or after the slide headings.
+	prev := -1
+	for j := range body {
+		if source[j] >= 0 {
+			prev = source[j]
+			continue
+		}
+		var anchor int
+		var after bool
+		switch {
+		case prev >= 0:
+			anchor, after = spans[prev].full.end, true
+		case firstSurviving(survives) >= 0:
+			anchor, after = spans[firstSurviving(survives)].full.start, false
+		case src.spans.subtitle.valid():
+			anchor, after = lineEnd(text, src.spans.subtitle.end), true
+		case src.spans.title.valid():
+			anchor, after = lineEnd(text, src.spans.title.end), true
+		default:
+			return nil, false
+		}
+		rendered := w.renderBlock(&body[j])
+		var ins strings.Builder
+		if after {
+			ins.WriteString("\n")
+			if j == 0 || needsBlankLine(&body[j-1], &body[j]) {
+				ins.WriteString("\n")
+			}
+			ins.WriteString(rendered)
+			next := j + 1
+			if next < len(body) && source[next] >= 0 && needsBlankLine(&body[j], &body[next]) &&
+				!blankLineAt(text, anchor+1) {
+				ins.WriteString("\n")
+			}
+		} else {
+			ins.WriteString(rendered + "\n")
+			if j+1 < len(body) && needsBlankLine(&body[j], &body[j+1]) {
+				ins.WriteString("\n")
+			}
+		}
+		edits = append(edits, edit{span: span{start: anchor, end: anchor}, text: ins.String()})
+	}
+	return edits, true
+}
+
+// blockUpdate rewrites an old block as a new one. When only the text changed
+// and it can be located, just the text is replaced so that list markers,
+// indentation and heading styles survive; likewise only changed table cells
+// are replaced, keeping the table's padding and delimiter row, and images
+// keep the keywords whose attributes did not change.
+func (w *Writer) blockUpdate(text string, at blockSpan, old, block *model.Block) []edit {
+	withText := *old
+	withText.Text, withText.Runs = block.Text, block.Runs
+	if at.text.valid() && reflect.DeepEqual(&withText, block) {
+		return []edit{{span: at.text, text: inlineText(block)}}
+	}
+	if edits, ok := cellEdits(at, old, block); ok {
+		return edits
+	}
+	if old.Kind == model.BlockImage && block.Kind == model.BlockImage {
+		return []edit{{span: at.full, text: rewriteImage(text[at.full.start:at.full.end], block)}}
+	}
+	return []edit{{span: at.full, text: w.renderBlock(block)}}
+}
+
+// cellEdits returns edits replacing the cells that differ between two
+// tables of the same shape and alignment. It returns false when the tables
+// differ in anything else or a changed cell cannot be located.
+func cellEdits(at blockSpan, old, block *model.Block) ([]edit, bool) {
+	if old.Table == nil || block.Table == nil || at.cells == nil {
+		return nil, false
+	}
+	withTable := *old
+	withTable.Table = block.Table
+	if !reflect.DeepEqual(&withTable, block) || !reflect.DeepEqual(old.Table.Align, block.Table.Align) ||
+		len(old.Table.Rows) != len(block.Table.Rows) {
+		return nil, false
+	}
+
+	oldRows := append([][]model.TableCell{old.Table.Header}, old.Table.Rows...)
+	newRows := append([][]model.TableCell{block.Table.Header}, block.Table.Rows...)
+	var edits []edit
+	for i := range newRows {
+		if len(newRows[i]) != len(oldRows[i]) || len(at.cells[i]) != len(oldRows[i]) {
+			return nil, false
+		}
+		for j := range newRows[i] {
+			if reflect.DeepEqual(oldRows[i][j], newRows[i][j]) {
+				continue
+			}
+			if !at.cells[i][j].valid() {
+				return nil, false
+			}
+			// Blank cells are replaced with their padding
+			text := cellText(&newRows[i][j])
+			if oldRows[i][j].Text == "" {
+				text = " " + text + " "
+			}
+			edits = append(edits, edit{span: at.cells[i][j], text: text})
+		}
+	}
+	return edits, true
+}
+
+// removal returns an edit deleting s. When s occupies whole lines those
+// lines go too, along with one neighbouring blank line if blank lines would
+// otherwise be doubled.
+func removal(text string, s span) edit {
+	start, end := lineStart(text, s.start), lineEnd(text, s.end)
+	if strings.TrimSpace(text[start:s.start]) != "" || strings.TrimSpace(text[s.end:end]) != "" {
+		return edit{span: s}
+	}
+	if end < len(text) {
+		end++
+	}
+	if !blankLineAt(text, end) {
+		return edit{span: span{start: start, end: end}}
+	}
+	if start > 0 && blankLineAt(text, lineStart(text, start-1)) {
+		start = lineStart(text, start-1)
+	} else if start == 0 && end < len(text) {
+		end = lineEnd(text, end)
+		if end < len(text) {
+			end++
+		}
+	}
+	return edit{span: span{start: start, end: end}}
+}
+
+// patchFrontmatter returns the frontmatter block head, as read for old,
+// with the fields whose rendering differs for deck rewritten in place,
+// removed, or added after the last field. Comments, the order of the keys
+// and the formatting of the other fields are kept. It returns false when
+// head is not a YAML mapping that can be edited this way.
+func (w *Writer) patchFrontmatter(head string, old, deck *model.Deck) (string, bool) {
+	lines := strings.SplitAfter(head, "\n")
+	open, end := -1, -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "---" {
+			if open >= 0 {
+				end = i
+				break
+			}
+			open = i
+		}
+	}
+	if end < 0 {
+		return "", false
+	}
+	content := lines[open+1 : end]
+	var root yaml.Node
+	err := yaml.Unmarshal([]byte(strings.Join(content, "")), &root)
+	if err != nil || len(root.Content) != 1 || root.Content[0].Kind != yaml.MappingNode {
+		return "", false
+	}
+
+	// Each field runs from its key to the line before the next one at the
+	// margin, which starts another field or a comment, less blank lines
+	fields := make(map[string]span) // Line ranges in content
+	last := 0
+	mapping := root.Content[0]
+	for i := 0; i < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		if key.Column != 1 {
+			return "", false
+		}
+		start := key.Line - 1
+		stop := start + 1
+		for stop < len(content) && (strings.TrimSpace(content[stop]) == "" || strings.ContainsAny(content[stop][:1], " \t")) {
+			stop++
+		}
+		for stop > start+1 && strings.TrimSpace(content[stop-1]) == "" {
+			stop--
+		}
+		fields[key.Value] = span{start: start, end: stop}
+		last = max(last, stop)
+	}
+
+	_, oldText := fieldTexts(w.frontmatterFields(old))
+	newKeys, newText := fieldTexts(w.frontmatterFields(deck))
+	edits := make(map[int]edit) // By first line
+	var added strings.Builder
+	for _, key := range newKeys {
+		if newText[key] == oldText[key] {
+			continue
+		}
+		if at, ok := fields[key]; ok {
+			edits[at.start] = edit{span: at, text: newText[key]}
+		} else {
+			added.WriteString(newText[key])
+		}
+	}
+	for key, at := range fields {
+		if _, ok := newText[key]; ok {
+			continue
+		}
+		// A title left out because the first slide gives it must not be
+		// kept if it no longer matches
+		if _, ok := oldText[key]; ok || key == "title" && old.Title != deck.Title {
+			edits[at.start] = edit{span: at}
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Join(lines[:open+1], ""))
+	for i := 0; i <= len(content); {
+		if i == last {
+			b.WriteString(added.String())
+		}
+		if i == len(content) {
+			break
+		}
+		if e, ok := edits[i]; ok {
+			b.WriteString(e.text)
+			i = e.end
+			continue
+		}
+		b.WriteString(content[i])
+		i++
+	}
+	b.WriteString(strings.Join(lines[end:], ""))
+	return b.String(), true
+}
+
+// fieldTexts returns the keys of a YAML mapping in order, and each field
+// encoded on its own.
+func fieldTexts(fields *yaml.Node) ([]string, map[string]string) {
+	var keys []string
+	texts := make(map[string]string)
+	for i := 0; i+1 < len(fields.Content); i += 2 {
+		key := fields.Content[i].Value
+		if text, ok := encodeYAML(&yaml.Node{Kind: yaml.MappingNode, Content: fields.Content[i : i+2]}); ok {
+			keys = append(keys, key)
+			texts[key] = text
+		}
+	}
+	return keys, texts
+}
+
+// regenerateSlide renders slide from scratch, keeping the blank lines that
+// surrounded the original chunk.
+func (w *Writer) regenerateSlide(text string, slide *model.Slide, opens *model.Section, ids idDirectives) string {
+	body := strings.TrimSpace(text)
+	start := strings.Index(text, body)
+	return text[:start] + strings.TrimRight(w.renderSlide(slide, opens, ids), "\n") + text[start+len(body):]
+}
+
+func (w *Writer) renderFrontmatter(deck *model.Deck) string {
+	var b strings.Builder
+	w.writeFrontmatter(&b, deck)
+	return b.String()
+}
+
+func (w *Writer) renderSlide(slide *model.Slide, opens *model.Section, ids idDirectives) string {
+	var b strings.Builder
+	w.writeSlide(&b, slide, opens, ids)
+	return b.String()
+}
+
+// explicitIDs returns the slide and section IDs the source spells out
+// with _id and _sectionId directives. opens maps slides to the sections
+// they start, as returned by sectionOpeners.
+func (doc *document) explicitIDs(opens map[string]*model.Section) idDirectives {
+	ids := idDirectives{slides: make(map[string]bool), sections: make(map[string]bool)}
+	for i := range doc.slides {
+		src := &doc.slides[i]
+		if _, ok := src.directives["_id"]; ok {
+			ids.slides[src.slide.ID] = true
+		}
+		if _, ok := src.directives["_sectionId"]; ok && opens[src.slide.ID] != nil {
+			ids.sections[opens[src.slide.ID].ID] = true
+		}
+	}
+	return ids
+}
+
+// sectionOpeners maps the ID of each section's first slide to its section.
+func sectionOpeners(deck *model.Deck) map[string]*model.Section {
+	opens := make(map[string]*model.Section, len(deck.Sections))
+	for i := range deck.Sections {
+		if section := &deck.Sections[i]; len(section.Slides) > 0 {
+			opens[section.Slides[0].ID] = section
+		}
+	}
+	return opens
+}
+
+func (w *Writer) renderBlock(block *model.Block) string {
+	var b strings.Builder
+	w.writeBlock(&b, block)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// applyEdits applies non-overlapping edits to text.
+func applyEdits(text string, edits []edit) string {
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		return edits[i].end < edits[j].end
+	})
+	var b strings.Builder
+	pos := 0
+	for _, e := range edits {
+		b.WriteString(text[pos:e.start])
+		b.WriteString(e.text)
+		pos = e.end
+	}
+	b.WriteString(text[pos:])
+	return b.String()
+}
+
+// longestCommonBlocks returns index pairs of a longest common subsequence
+// of equal blocks, in order.
+func longestCommonBlocks(a, b []model.Block) [][2]int {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if reflect.DeepEqual(a[i], b[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var matches [][2]int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case reflect.DeepEqual(a[i], b[j]):
+			matches = append(matches, [2]int{i, j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return matches
+}
+
+func firstSurviving(survives []bool) int {
+	for i, ok := range survives {
+		if ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// needsBlankLine reports whether two adjacent blocks must be separated by a
+// blank line. Only consecutive list items, and pause cues next to
+// paragraphs, may sit on adjacent lines.
+func needsBlankLine(a, b *model.Block) bool {
+	if isListItem(a) && isListItem(b) {
+		return false
+	}
+	// Pause cues sit on their own line next to the narration they break up
+	return !(a.Kind == model.BlockPause && isProse(b) || isProse(a) && b.Kind == model.BlockPause)
+}
+
+func isProse(block *model.Block) bool {
+	return block.Kind == model.BlockParagraph || block.Kind == model.BlockPause
+}
+
+func isListItem(block *model.Block) bool {
+	return block.Kind == model.BlockBullet || block.Kind == model.BlockNumbered
+}
+
+// lineStart returns the offset of the start of the line containing pos.
+func lineStart(text string, pos int) int {
+	return strings.LastIndexByte(text[:pos], '\n') + 1
+}
+
+// lineEnd returns the offset of the newline ending the line containing pos,
+// or len(text) for the last line.
+func lineEnd(text string, pos int) int {
+	if i := strings.IndexByte(text[pos:], '\n'); i >= 0 {
+		return pos + i
+	}
+	return len(text)
+}
+
+// blankLineAt reports whether the line starting at pos is blank. The end of
+// text counts as blank.
+func blankLineAt(text string, pos int) bool {
+	if pos >= len(text) {
+		return true
+	}
+	return strings.TrimSpace(text[pos:lineEnd(text, pos)]) == ""
+}
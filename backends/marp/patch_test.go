@@ -0,0 +1,245 @@
+package marp
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+const patchSample = `---
+marp: true
+theme: default
+---
+
+<!-- _class: lead -->
+
+# Welcome
+
+---
+
+<!--
+Intro notes.
+[PAUSE:500]
+Keep this pause.
+-->
+
+# Agenda
+
+* First point
+* Second point
+    * Nested point
+
+Closing   remark.
+
+---
+
+# Details
+
+<div class="box">
+  <p>Spacing   kept</p>
+</div>
+
+1) not a list
+`
+
+// applyPatch applies diff to content the way Backend.Apply does.
+func applyPatch(t *testing.T, content string, diff *model.Diff) string {
+	t.Helper()
+	reader := NewReader()
+	_, doc := reader.parseDocument(content)
+	desired, _ := reader.parseDocument(content)
+	if err := desired.ApplyDiff(diff); err != nil {
+		t.Fatalf("ApplyDiff error: %v", err)
+	}
+	return NewWriter().patch(doc, desired)
+}
+
+func TestPatchUnchanged(t *testing.T) {
+	reader := NewReader()
+	_, doc := reader.parseDocument(patchSample)
+	desired, _ := reader.parseDocument(patchSample)
+
+	if got := NewWriter().patch(doc, desired); got != patchSample {
+		t.Errorf("expected identical output, got:\n%s", got)
+	}
+}
+
+func TestPatchIgnoresUnwrittenFields(t *testing.T) {
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/agenda/transition", nil, "fade"))
+
+	if got := applyPatch(t, patchSample, diff); got != patchSample {
+		t.Errorf("expected identical output, got:\n%s", got)
+	}
+}
+
+func TestPatchTitle(t *testing.T) {
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/agenda/title", "Agenda", "Plan"))
+
+	want := strings.Replace(patchSample, "# Agenda", "# Plan", 1)
+	want = strings.Replace(want, "<!--\nIntro", "<!-- _id: agenda -->\n\n<!--\nIntro", 1)
+	if got := applyPatch(t, patchSample, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
+
+func TestPatchBlockTextKeepsMarkers(t *testing.T) {
+	deck, _ := NewReader().Parse(patchSample)
+	body := append([]model.Block(nil), deck.FindSlide("agenda").Body...)
+	body[2].Text = "Deeper point"
+
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/agenda/body", nil, body))
+
+	want := strings.Replace(patchSample, "    * Nested point", "    * Deeper point", 1)
+	if got := applyPatch(t, patchSample, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
+
+func TestPatchAddAndRemoveBlocks(t *testing.T) {
+	deck, _ := NewReader().Parse(patchSample)
+	old := deck.FindSlide("agenda").Body
+	body := []model.Block{old[0], model.NewBullet("Inserted point", 0), old[1], old[2]}
+
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/agenda/body", nil, body))
+
+	want := strings.Replace(patchSample,
+		"* First point\n* Second point\n    * Nested point\n\nClosing   remark.\n\n---",
+		"* First point\n- Inserted point\n* Second point\n    * Nested point\n\n---", 1)
+	if got := applyPatch(t, patchSample, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
+
+func TestPatchNotes(t *testing.T) {
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/welcome/notes", nil,
+		[]model.Block{model.NewParagraph("Say hello.")}))
+
+	want := strings.Replace(patchSample, "<!-- _class: lead -->\n\n# Welcome",
+		"<!-- _class: lead -->\n\n<!--\nSay hello.\n-->\n\n# Welcome", 1)
+	if got := applyPatch(t, patchSample, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
+
+func TestPatchReorderAndAddSlides(t *testing.T) {
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewMoveChange("sections/welcome/slides/details",
+		"sections/welcome/slides/details").At(model.Position{SectionID: "welcome", Index: 1}))
+	diff.AddChange(model.NewAddChange("sections/welcome/slides/new",
+		model.Slide{ID: "new", Layout: model.LayoutTitleBody, Title: "New"}))
+
+	got := applyPatch(t, patchSample, diff)
+
+	details := patchSample[strings.Index(patchSample, "\n# Details"):]
+	agenda := patchSample[strings.Index(patchSample, "\n<!--\nIntro"):strings.Index(patchSample, "---\n\n# Details")]
+	for _, part := range []string{details, agenda, "<!-- _class: lead -->\n\n# Welcome\n"} {
+		if !strings.Contains(got, part) {
+			t.Errorf("expected output to keep %q, got:\n%s", part, got)
+		}
+	}
+	if !strings.HasSuffix(got, "\n---\n\n# New\n") {
+		t.Errorf("expected new slide at end, got:\n%s", got)
+	}
+	if strings.Index(got, "# Details") > strings.Index(got, "# Agenda") {
+		t.Errorf("expected Details before Agenda, got:\n%s", got)
+	}
+}
+
+func TestPatchLayoutChangeRegeneratesSlide(t *testing.T) {
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/details/layout",
+		model.LayoutTitleBody, model.LayoutSection))
+
+	got := applyPatch(t, patchSample, diff)
+
+	head := patchSample[:strings.Index(patchSample, "# Details")]
+	if !strings.HasPrefix(got, head) {
+		t.Errorf("expected earlier slides untouched, got:\n%s", got)
+	}
+	if !strings.Contains(got, "<!-- _class: section-divider -->") {
+		t.Errorf("expected regenerated directives, got:\n%s", got)
+	}
+}
+
+func TestPatchReorderWritesDerivedIDs(t *testing.T) {
+	input := "# Intro\n\n---\n\n# Step\n\nOne\n\n---\n\n# Step\n\nTwo\n\n---\n\n<!-- _id: wrap -->\n\n# Wrap Up\n"
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewMoveChange("sections/default/slides/step-2",
+		"sections/default/slides/step-2").At(model.Position{SectionID: "default", Index: 1}))
+	diff.AddChange(model.NewUpdateChange("slides/wrap/body", nil, []model.Block{model.NewParagraph("Done")}))
+
+	// The moved slide only had its suffixed ID by following the other Step
+	// slide, so it is written out; the explicit ID stays
+	want := "# Intro\n\n---\n\n<!-- _id: step-2 -->\n\n# Step\n\nTwo\n\n---\n\n# Step\n\nOne\n\n---\n\n" +
+		"<!-- _id: wrap -->\n\n# Wrap Up\n\nDone\n"
+	got := applyPatch(t, input, diff)
+	if got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+	deck, err := NewReader().Parse(got)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	var ids []string
+	for _, slide := range deck.AllSlides() {
+		ids = append(ids, slide.ID)
+	}
+	if strings.Join(ids, " ") != "intro step-2 step wrap" {
+		t.Errorf("slide IDs = %v", ids)
+	}
+}
+
+func TestPatchReorderFirstSlide(t *testing.T) {
+	input := "# Step\n\nOne\n\n---\n\n# Step\n\nTwo\n"
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewMoveChange("sections/default/slides/step-2",
+		"sections/default/slides/step-2").At(model.Position{SectionID: "default", Index: 0}))
+
+	// Without frontmatter the slide moved to the top has no blank line
+	// before it, and the one moved down gets one after its separator
+	want := "<!-- _id: step-2 -->\n\n# Step\n\nTwo\n\n---\n\n# Step\n\nOne\n"
+	if got := applyPatch(t, input, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
+
+func TestPatchFrontmatterKeepsComments(t *testing.T) {
+	input := "---\n# Deck settings\ntheme: gaia\nauthor: Ann  # lead author\n\nmarp: true\n---\n\n# Intro\n"
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("meta/author", "Ann", "Bea"))
+	diff.AddChange(model.NewUpdateChange("meta/date", nil, "2026-01-01"))
+
+	want := "---\n# Deck settings\ntheme: gaia\nauthor: Bea\n\nmarp: true\ndate: \"2026-01-01\"\n---\n\n# Intro\n"
+	if got := applyPatch(t, input, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
+
+func TestPatchNotesKeepsSpacing(t *testing.T) {
+	input := "<!--\nIntro notes.\n\n[PAUSE:500]\n\nKeep this pause.\n\n- Aside\n-->\n\n# Agenda\n"
+	deck, _ := NewReader().Parse(input)
+	notes := append([]model.Block(nil), deck.FindSlide("agenda").Notes...)
+	notes[2] = model.NewParagraph("Keep going.")
+	notes = append(notes[:3], model.NewParagraph("Wrap up."))
+
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/agenda/notes", nil, notes))
+
+	want := "<!--\nIntro notes.\n\n[PAUSE:500]\n\nKeep going.\n\nWrap up.\n-->\n\n# Agenda\n"
+	if got := applyPatch(t, input, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	diff = model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/agenda/notes", nil, deck.FindSlide("agenda").Notes[2:]))
+	want = "<!--\nKeep this pause.\n\n- Aside\n-->\n\n# Agenda\n"
+	if got := applyPatch(t, input, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
@@ -2,273 +2,559 @@
 package marp
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/grokify/slidekit/model"
 )
 
 // Reader parses Marp Markdown files into the canonical slide model.
-type Reader struct{}
+type Reader struct {
+	opts ReaderOptions
+}
+
+// ReaderOptions configure a Reader.
+type ReaderOptions struct {
+	// Sections are the rules that group slides into sections. Slides with
+	// the section-divider or lead class always start one, unless the
+	// rules ask for a single section per file.
+	Sections model.SectionRules
+}
 
 // NewReader creates a new Marp reader.
 func NewReader() *Reader {
 	return &Reader{}
 }
 
+// NewReaderWithOptions creates a Marp reader configured by opts.
+func NewReaderWithOptions(opts ReaderOptions) *Reader {
+	return &Reader{opts: opts}
+}
+
 // ReadFile reads a Marp Markdown file and returns a Deck.
 func (r *Reader) ReadFile(path string) (*model.Deck, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("reading file %s: %w", path, err)
-	}
-	return r.Parse(string(data))
+	return r.readFile(context.Background(), path)
 }
 
-// Read implements the Backend interface for reading from a Ref.
+// Read implements the Backend interface for reading from a Ref. The file
+// is parsed as it is read, and reading stops once ctx is done.
 func (r *Reader) Read(ctx context.Context, ref model.Ref) (*model.Deck, error) {
 	if ref.Path == "" {
 		return nil, fmt.Errorf("marp backend requires a file path")
 	}
-	return r.ReadFile(ref.Path)
+	return r.readFile(ctx, ref.Path)
+}
+
+// readFile parses the file at path with ParseReader, taking theme colors
+// and fonts the deck's style leaves unset from the theme's CSS file, if
+// there is one next to it.
+func (r *Reader) readFile(ctx context.Context, path string) (*model.Deck, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %w", path, err)
+	}
+	defer f.Close()
+	deck, err := r.ParseReader(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	applyThemeFile(deck, path)
+	return deck, nil
 }
 
 // Parse parses Marp Markdown content into a Deck.
 func (r *Reader) Parse(content string) (*model.Deck, error) {
-	// Parse frontmatter
-	frontmatter, body := parseFrontmatter(content)
+	deck, _ := r.parseDocument(content)
+	return deck, nil
+}
+
+// ReadDiagnostics reads a Marp file like Read and also returns diagnostics
+// for malformed source, such as unclosed code fences or HTML blocks.
+func (r *Reader) ReadDiagnostics(ctx context.Context, ref model.Ref) (*model.Deck, []model.Diagnostic, error) {
+	if ref.Path == "" {
+		return nil, nil, fmt.Errorf("marp backend requires a file path")
+	}
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading file %s: %w", ref.Path, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+	deck, diagnostics, err := r.ParseDiagnostics(string(data))
+	if err != nil {
+		return nil, nil, err
+	}
+	applyThemeFile(deck, ref.Path)
+	return deck, diagnostics, nil
+}
 
-	// Split into raw slides
-	rawSlides := splitSlides(body)
+// ParseDiagnostics parses content like Parse and also returns diagnostics
+// for malformed source that was read on a best-effort basis.
+func (r *Reader) ParseDiagnostics(content string) (*model.Deck, []model.Diagnostic, error) {
+	deck, doc := r.parseDocument(content)
+	return deck, doc.diagnostics, nil
+}
 
-	// Parse each raw slide
+// parseDocument parses content into a Deck and also returns the source
+// layout it was read from, which Apply uses to rewrite files in place.
+// Malformed source never fails: it is read on a best-effort basis and
+// reported in the document's diagnostics.
+func (r *Reader) parseDocument(content string) (*model.Deck, *document) {
+	// Parse frontmatter, falling back to none if it is malformed; diagnose
+	// reports why
+	head, body := splitFrontmatter(content)
+	frontmatter, fmErr := parseFrontmatterBlock(head)
+
+	// Split into chunks between separators, keeping blank ones so the
+	// original text can be reassembled exactly.
+	chunks, seps, starts := splitChunks(body, strings.Count(head, "\n")+1)
+
+	// Parse each non-blank chunk as a slide
 	var slides []parsedSlide
-	for _, raw := range rawSlides {
-		slides = append(slides, parseRawSlide(raw))
+	var slideChunks []int
+	for i, chunk := range chunks {
+		if strings.TrimSpace(chunk) == "" {
+			continue
+		}
+		slides = append(slides, parseRawSlide(chunk, r.isDirective))
+		slideChunks = append(slideChunks, i)
 	}
 
 	// Group into sections based on section-divider slides
-	deck := buildDeck(frontmatter, slides)
+	deck := buildDeck(frontmatter, slides, r.opts.Sections)
 
-	return deck, nil
+	doc := &document{
+		deck:   deck,
+		head:   head,
+		chunks: chunks,
+		seps:   seps,
+		starts: starts,
+
+		frontmatterErr: fmErr,
+	}
+	for i, slide := range deck.AllSlides() {
+		var scratch model.Slide
+		src := slideSource{
+			chunk:          slideChunks[i],
+			slide:          slide,
+			directives:     slides[i].directives,
+			directiveSpans: slides[i].directiveSpans,
+			notes:          slides[i].notes,
+			spans:          parseSlideContent(&scratch, slides[i].content),
+		}
+		doc.slides = append(doc.slides, src)
+	}
+	doc.diagnostics = diagnose(content, doc)
+
+	// Record where each slide was read from
+	k := 0
+	for i := range deck.Sections {
+		for j := range deck.Sections[i].Slides {
+			src := &doc.slides[k]
+			deck.Sections[i].Slides[j].Source = sourceMap(chunks[src.chunk], starts[src.chunk], src.spans, slides[k].notes)
+			k++
+		}
+	}
+
+	return deck, doc
 }
 
 // parsedSlide holds intermediate parsed state for a single slide.
 type parsedSlide struct {
-	directives map[string]string
-	notes      []noteBlock
-	content    string // Remaining markdown/HTML content
-	rawContent string // Original raw content
+	directives     map[string]string
+	directiveSpans map[string]span // Location of each directive comment
+	notes          []noteBlock
+	content        string // Remaining markdown/HTML content, comments blanked out
+	rawContent     string // Original raw content
 }
 
-// noteBlock represents a speaker note.
+// noteBlock represents a speaker note comment.
 type noteBlock struct {
-	text string
+	blocks     []model.Block
+	blockSpans []span // Location of each block within the raw slide
+	span       span   // Location of the whole comment within the raw slide
 }
 
-// Frontmatter holds parsed YAML frontmatter.
+// Frontmatter holds parsed YAML frontmatter. Marp's standard global
+// directives have their own fields; everything else is kept in Custom with
+// the type YAML gave it.
 type Frontmatter struct {
-	Marp     bool
-	Theme    string
-	Paginate bool
-	Style    string
-	Custom   map[string]string
+	Marp        bool
+	Theme       string
+	Paginate    bool
+	Style       string
+	Title       string
+	Author      string
+	Date        string
+	Description string
+	Keywords    []string
+	URL         string
+	Image       string
+	Size        string
+	Transition  string
+	Custom      map[string]any
+
+	// HeaderFooter holds the header, footer and paginate directives
+	HeaderFooter model.HeaderFooter
 }
 
 // parseFrontmatter extracts YAML frontmatter from content.
-func parseFrontmatter(content string) (Frontmatter, string) {
-	fm := Frontmatter{Custom: make(map[string]string)}
+func parseFrontmatter(content string) (Frontmatter, string, error) {
+	head, body := splitFrontmatter(content)
+	fm, err := parseFrontmatterBlock(head)
+	return fm, body, err
+}
 
-	content = strings.TrimSpace(content)
-	if !strings.HasPrefix(content, "---") {
-		return fm, content
+// splitFrontmatter splits content into the frontmatter block, including both
+// --- delimiters and the newline after the closing one, and the body that
+// follows. Content without frontmatter is returned entirely as body.
+func splitFrontmatter(content string) (head, body string) {
+	line, rest, ok := strings.Cut(strings.TrimLeft(content, " \t\r\n"), "\n")
+	if !ok || strings.TrimSpace(line) != "---" {
+		return "", content
 	}
-
-	// Find closing ---
-	rest := content[3:]
-	idx := strings.Index(rest, "\n---")
-	if idx < 0 {
-		return fm, content
+	for rest != "" {
+		line, after, _ := strings.Cut(rest, "\n")
+		if strings.TrimSpace(line) == "---" {
+			end := len(content) - len(after)
+			return content[:end], after
+		}
+		rest = after
 	}
+	return "", content
+}
 
-	fmContent := strings.TrimSpace(rest[:idx])
-	body := rest[idx+4:] // Skip past \n---
-
-	// Parse YAML-like frontmatter (simple key: value parsing)
-	lines := strings.Split(fmContent, "\n")
-	i := 0
-	for i < len(lines) {
-		line := lines[i]
-		trimmed := strings.TrimSpace(line)
-
-		if trimmed == "" {
-			i++
-			continue
-		}
+// parseFrontmatterBlock parses the fields of a frontmatter block as returned
+// by splitFrontmatter. Malformed YAML yields empty frontmatter along with
+// the error.
+func parseFrontmatterBlock(head string) (Frontmatter, error) {
+	fm := Frontmatter{Custom: make(map[string]any)}
 
-		parts := strings.SplitN(trimmed, ":", 2)
-		if len(parts) != 2 {
-			i++
-			continue
-		}
+	fmContent := strings.TrimSpace(head)
+	if fmContent == "" {
+		return fm, nil
+	}
+	fmContent = strings.TrimPrefix(fmContent, "---")
+	fmContent = strings.TrimSuffix(fmContent, "---")
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
+	var fields map[string]any
+	if err := yaml.Unmarshal([]byte(fmContent), &fields); err != nil {
+		return fm, fmt.Errorf("parsing frontmatter: %w", err)
+	}
 
+	for key, value := range fields {
 		switch key {
 		case "marp":
-			fm.Marp = value == "true"
+			fm.Marp, _ = value.(bool)
 		case "theme":
-			fm.Theme = value
+			fm.Theme = scalarString(value)
 		case "paginate":
-			fm.Paginate = value == "true"
+			on, ok := value.(bool)
+			if !ok {
+				// Values such as "hold" have no place in the model
+				fm.Custom[key] = value
+				continue
+			}
+			fm.Paginate = on
+			fm.HeaderFooter.PageNumber = &on
+		case "header":
+			text := scalarString(value)
+			fm.HeaderFooter.Header = &text
+		case "footer":
+			text := scalarString(value)
+			fm.HeaderFooter.Footer = &text
 		case "style":
-			// Handle multiline style block (indicated by |)
-			if value == "|" {
-				var styleLines []string
-				i++
-				for i < len(lines) {
-					sl := lines[i]
-					// Multiline block continues while indented
-					if len(sl) > 0 && (sl[0] == ' ' || sl[0] == '\t') {
-						styleLines = append(styleLines, sl)
-					} else {
-						break
-					}
-					i++
-				}
-				fm.Style = strings.Join(styleLines, "\n")
+			fm.Style = scalarString(value)
+		case "title":
+			fm.Title = scalarString(value)
+		case "author":
+			fm.Author = scalarString(value)
+		case "date":
+			fm.Date = scalarString(value)
+		case "description":
+			fm.Description = scalarString(value)
+		case "keywords":
+			fm.Keywords = parseKeywords(value)
+		case "url":
+			fm.URL = scalarString(value)
+		case "image":
+			fm.Image = scalarString(value)
+		case "size":
+			fm.Size = scalarString(value)
+		case "transition":
+			name, ok := value.(string)
+			if !ok {
+				fm.Custom[key] = value
 				continue
 			}
-			fm.Style = value
+			fm.Transition = name
 		default:
 			fm.Custom[key] = value
 		}
-		i++
 	}
 
-	return fm, body
+	return fm, nil
 }
 
-// splitSlides splits the body into raw slide strings on --- separators.
-// It respects code blocks (``` fences) and does not split within them.
-func splitSlides(body string) []string {
-	lines := strings.Split(body, "\n")
-	var slides []string
-	var current []string
-	inCodeBlock := false
-
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
+// scalarString converts a YAML scalar to its string form.
+func scalarString(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
 
-		// Track code block state
-		if strings.HasPrefix(trimmed, "```") {
-			inCodeBlock = !inCodeBlock
+// parseKeywords accepts keywords either as Marp's comma-separated string or
+// as a YAML list.
+func parseKeywords(value any) []string {
+	var keywords []string
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			if k := strings.TrimSpace(scalarString(item)); k != "" {
+				keywords = append(keywords, k)
+			}
 		}
-
-		// Check for slide separator (only when not in code block)
-		if !inCodeBlock && trimmed == "---" {
-			slideContent := strings.Join(current, "\n")
-			if strings.TrimSpace(slideContent) != "" {
-				slides = append(slides, slideContent)
+	default:
+		for _, k := range strings.Split(scalarString(v), ",") {
+			if k = strings.TrimSpace(k); k != "" {
+				keywords = append(keywords, k)
 			}
-			current = nil
-			continue
 		}
+	}
+	return keywords
+}
 
-		current = append(current, line)
+// splitSlides splits the body into raw slide strings on --- separators.
+// It respects code blocks (``` fences) and does not split within them.
+func splitSlides(body string) []string {
+	chunks, _, _ := splitChunks(body, 1)
+	var slides []string
+	for _, chunk := range chunks {
+		if strings.TrimSpace(chunk) != "" {
+			slides = append(slides, chunk)
+		}
 	}
+	return slides
+}
 
-	// Don't forget the last slide
-	if len(current) > 0 {
-		slideContent := strings.Join(current, "\n")
-		if strings.TrimSpace(slideContent) != "" {
-			slides = append(slides, slideContent)
+// splitChunks splits the body on --- separator lines, outside code fences.
+// Unlike splitSlides it keeps blank chunks and the separator lines
+// themselves: joining chunks[0], seps[0], chunks[1], ... with newlines
+// reproduces body exactly. It also returns the line each chunk starts on,
+// counting the body's first line as first.
+func splitChunks(body string, first int) (chunks, seps []string, starts []int) {
+	c := chunker{line: first, start: first}
+	for _, line := range strings.Split(body, "\n") {
+		if chunk, start, ok := c.add(line); ok {
+			chunks = append(chunks, chunk)
+			starts = append(starts, start)
+			seps = append(seps, line)
 		}
 	}
 
-	return slides
+	// Don't forget the last chunk
+	chunk, start := c.flush()
+	return append(chunks, chunk), seps, append(starts, start)
 }
 
-var (
-	// Matches directive comments like <!-- _class: section-divider -->
-	reDirective = regexp.MustCompile(`<!--\s*(_\w+)\s*:\s*(.+?)\s*-->`)
+// chunker splits a slide body into chunks on --- separator lines outside
+// code fences. It is fed one line at a time, so that slides can be read
+// before the rest of the file has arrived.
+type chunker struct {
+	line   int      // File line of the next line fed in
+	start  int      // File line the current chunk starts on
+	lines  []string // Lines of the current chunk
+	inCode bool
+}
 
-	// Matches speaker note comments (multi-line)
-	reNoteBlock = regexp.MustCompile(`(?s)<!--\s*\n(.*?)\n\s*-->`)
+// add feeds the next line to c. When the line is a separator, it returns
+// the chunk the separator ends and the line that chunk started on.
+func (c *chunker) add(line string) (chunk string, start int, ok bool) {
+	c.line++
+	trimmed := strings.TrimSpace(line)
 
-	// Matches [PAUSE:ms] markers
-	rePause = regexp.MustCompile(`\[PAUSE:(\d+)\]`)
+	// Track code block state
+	if strings.HasPrefix(trimmed, "```") {
+		c.inCode = !c.inCode
+	}
 
-	// Matches <break time="ms"/> SSML markers
-	reBreak = regexp.MustCompile(`<break\s+time="(\d+)ms"\s*/?>`)
-)
+	// Check for slide separator (only when not in code block)
+	if !c.inCode && trimmed == "---" {
+		chunk, start = c.flush()
+		c.start = c.line
+		return chunk, start, true
+	}
+
+	c.lines = append(c.lines, line)
+	return "", 0, false
+}
+
+// flush returns the current chunk and the line it started on, and starts
+// a new one.
+func (c *chunker) flush() (string, int) {
+	chunk := strings.Join(c.lines, "\n")
+	c.lines = c.lines[:0]
+	return chunk, c.start
+}
+
+// Matches directive comments like <!-- _class: section-divider -->
+// or <!-- paginate: false -->; see isDirective for which keys count.
+var reDirective = regexp.MustCompile(`<!--\s*(_?[A-Za-z]\w*)\s*:\s*(.+?)\s*-->`)
 
-// parseRawSlide parses directives, notes, and content from a raw slide string.
-func parseRawSlide(raw string) parsedSlide {
+// parseRawSlide parses directives, notes, and content from a raw slide
+// string, reading comments whose key satisfies directive as directives.
+// Directive and note comments are blanked out of the content rather than
+// removed, so byte offsets into content are also offsets into raw.
+func parseRawSlide(raw string, directive func(key string) bool) parsedSlide {
 	ps := parsedSlide{
-		directives: make(map[string]string),
-		rawContent: raw,
+		directives:     make(map[string]string),
+		directiveSpans: make(map[string]span),
+		rawContent:     raw,
 	}
 
 	remaining := raw
 
-	// Extract directives (<!-- _key: value -->)
-	for _, match := range reDirective.FindAllStringSubmatch(remaining, -1) {
-		key := match[1]
-		value := match[2]
-		ps.directives[key] = value
-	}
-	remaining = reDirective.ReplaceAllString(remaining, "")
-
-	// Extract speaker notes (multi-line <!-- ... --> that are not directives)
-	for _, match := range reNoteBlock.FindAllStringSubmatch(remaining, -1) {
-		noteText := match[1]
-		// Skip if it looks like a script or HTML tag
-		if strings.Contains(noteText, "<script") {
+	// Extract directives (<!-- _key: value --> or <!-- key: value -->)
+	var b strings.Builder
+	last := 0
+	for _, loc := range reDirective.FindAllStringSubmatchIndex(raw, -1) {
+		key := raw[loc[2]:loc[3]]
+		if !directive(key) {
 			continue
 		}
-		nb := noteBlock{text: cleanNoteText(noteText)}
-		ps.notes = append(ps.notes, nb)
+		ps.directives[key] = raw[loc[4]:loc[5]]
+		ps.directiveSpans[key] = span{start: loc[0], end: loc[1]}
+		b.WriteString(raw[last:loc[0]])
+		b.WriteString(blankOut(raw[loc[0]:loc[1]]))
+		last = loc[1]
 	}
-	remaining = reNoteBlock.ReplaceAllString(remaining, "")
+	b.WriteString(raw[last:])
+	remaining = b.String()
+
+	// Extract speaker notes (comments that are not directives)
+	b.Reset()
+	last = 0
+	for _, at := range findNotes(remaining) {
+		textStart := at.start + len("<!--")
+		blocks, spans := parseNote(remaining[textStart : at.end-len("-->")])
+		for i := range spans {
+			spans[i].start += textStart
+			spans[i].end += textStart
+		}
+		ps.notes = append(ps.notes, noteBlock{blocks: blocks, blockSpans: spans, span: at})
+		b.WriteString(remaining[last:at.start])
+		b.WriteString(blankOut(remaining[at.start:at.end]))
+		last = at.end
+	}
+	b.WriteString(remaining[last:])
+	remaining = b.String()
 
-	ps.content = strings.TrimSpace(remaining)
+	ps.content = remaining
 	return ps
 }
 
-// cleanNoteText removes pause/break markers and cleans whitespace from note text.
-func cleanNoteText(text string) string {
-	// Remove PAUSE markers
-	text = rePause.ReplaceAllString(text, "")
-	// Remove SSML break markers
-	text = reBreak.ReplaceAllString(text, "")
-	// Clean up whitespace
-	lines := strings.Split(text, "\n")
-	var cleaned []string
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if trimmed != "" {
-			cleaned = append(cleaned, trimmed)
+// marpDirectives lists the Marp global and local directive names that may
+// appear in HTML comments without an underscore prefix.
+var marpDirectives = map[string]bool{
+	"theme": true, "style": true, "headingDivider": true, "lang": true,
+	"size": true, "math": true, "title": true, "author": true,
+	"description": true, "keywords": true, "url": true, "image": true,
+	"marp": true, "paginate": true, "header": true, "footer": true,
+	"class": true, "backgroundColor": true, "backgroundImage": true,
+	"backgroundPosition": true, "backgroundRepeat": true,
+	"backgroundSize": true, "color": true, "transition": true,
+}
+
+// isDirective reports whether a comment key is a Marp directive. Scoped
+// directives (_key) are always accepted; other keys must be known, so that
+// comments such as <!-- Note: ... --> stay ordinary comments.
+func isDirective(key string) bool {
+	return strings.HasPrefix(key, "_") || marpDirectives[key]
+}
+
+// isDirective reports whether a comment key is a directive for r: a Marp
+// directive, or the section marker when r reads section markers.
+func (r *Reader) isDirective(key string) bool {
+	return isDirective(key) || key == sectionMarker && r.opts.Sections.Markers
+}
+
+// blankOut replaces every byte of s except newlines with a space.
+func blankOut(s string) string {
+	b := []byte(s)
+	for i := range b {
+		if b[i] != '\n' {
+			b[i] = ' '
 		}
 	}
-	return strings.Join(cleaned, " ")
+	return string(b)
 }
 
 // buildDeck constructs a Deck from frontmatter and parsed slides.
-func buildDeck(fm Frontmatter, slides []parsedSlide) *model.Deck {
+func buildDeck(fm Frontmatter, slides []parsedSlide, rules model.SectionRules) *model.Deck {
+	b := newDeckBuilder(fm, rules)
+	for _, ps := range slides {
+		slide, _ := convertToSlide(ps)
+		b.collect(slide, b.add(ps, &slide))
+	}
+	return b.finish()
+}
+
+// deckBuilder groups slides into sections as they are read, starting a new
+// section wherever the section rules say so, and assigns slide and section
+// IDs. IDs are claimed in file order, so an explicit _id that repeats an
+// ID generated for an earlier slide is suffixed unless reserve announced
+// it; the slides collected into the deck are passed on to a
+// model.DeckBuilder, which settles IDs over the whole deck instead, giving
+// explicit IDs priority wherever they are.
+type deckBuilder struct {
+	deck    *model.Deck
+	rules   model.SectionRules
+	section model.Section // Section of the last slide added, without slides
+	slides  int           // Slides added so far
+	builder *model.DeckBuilder
+
+	usedSlides, usedSections       map[string]bool
+	pendingSlides, pendingSections map[string]bool // Reserved IDs not yet claimed
+	slideID, sectionID             string          // Explicit IDs of the last slide added and its section
+}
+
+// newDeckBuilder starts a deck with the metadata and theme from fm, to be
+// grouped into sections by rules.
+func newDeckBuilder(fm Frontmatter, rules model.SectionRules) *deckBuilder {
 	deck := &model.Deck{
+		Title:      fm.Title,
+		Transition: fm.Transition,
 		Meta: model.Meta{
-			Custom: make(map[string]string),
+			Author:      fm.Author,
+			Date:        fm.Date,
+			Description: fm.Description,
+			Keywords:    fm.Keywords,
+			URL:         fm.URL,
+			Image:       fm.Image,
+			Size:        fm.Size,
+			Custom:      make(map[string]any),
 		},
 	}
 
 	// Apply frontmatter
-	if fm.Theme != "" {
-		deck.Theme = &model.Theme{Name: fm.Theme}
+	if fm.Theme != "" || fm.Style != "" {
+		theme := themeFromCSS(fm.Style)
+		theme.Name = fm.Theme
+		deck.Theme = &theme
 		if fm.Style != "" {
 			deck.Theme.SetCustom("style", fm.Style)
 		}
@@ -276,60 +562,172 @@ func buildDeck(fm Frontmatter, slides []parsedSlide) *model.Deck {
 	for k, v := range fm.Custom {
 		deck.Meta.Custom[k] = v
 	}
+	if !fm.HeaderFooter.IsEmpty() {
+		hf := fm.HeaderFooter
+		deck.HeaderFooter = &hf
+	}
 
-	// Group slides into sections based on section-divider slides
-	type sectionGroup struct {
-		title  string
-		slides []parsedSlide
+	return &deckBuilder{
+		deck:            deck,
+		rules:           rules,
+		builder:         model.NewDeckBuilder(deck),
+		usedSlides:      make(map[string]bool),
+		usedSections:    make(map[string]bool),
+		pendingSlides:   make(map[string]bool),
+		pendingSections: make(map[string]bool),
 	}
+}
 
-	var groups []sectionGroup
-	currentGroup := sectionGroup{title: "default"}
+// reserve sets aside the explicit slide and section IDs of slides still to
+// be added, as collected by Reader.scanIDs, so that IDs are claimed as
+// finish would settle them.
+func (b *deckBuilder) reserve(ids idDirectives) {
+	for id := range ids.slides {
+		b.usedSlides[id], b.pendingSlides[id] = true, true
+	}
+	for id := range ids.sections {
+		b.usedSections[id], b.pendingSections[id] = true, true
+	}
+}
 
-	for _, ps := range slides {
-		class := ps.directives["_class"]
+// add places slide, converted from ps, in the current section and gives it
+// an ID. It reports whether ps started a new section, which b.section then
+// describes.
+func (b *deckBuilder) add(ps parsedSlide, slide *model.Slide) bool {
+	// A slide that starts a section becomes its first slide; slides
+	// before the first such slide go in a default section, named after the
+	// deck when each file is a section. An _sectionId directive, which the
+	// Writer puts on the first slide of every section it cannot otherwise
+	// tell apart, starts a section named after its slide.
+	starts, marked, title := b.startsSection(ps, slide)
+	sectionID, hasSectionID := ps.directives["_sectionId"]
+	newSection := starts || b.slides == 0 || hasSectionID && !b.rules.PerFile
+	if newSection {
+		switch {
+		case starts:
+		case b.slides == 0 && b.rules.PerFile:
+			title = cmp.Or(b.deck.Title, slide.Title, "default")
+		case b.slides == 0:
+			title = "default"
+		default:
+			title = cmp.Or(slide.Title, sectionID)
+		}
 
-		if class == "section-divider" || class == "lead" {
-			// This slide starts a new section
-			// Save current group if it has slides
-			if len(currentGroup.slides) > 0 {
-				groups = append(groups, currentGroup)
-			}
-			// Extract section title from the slide content
-			title := extractSectionTitle(ps.content)
-			currentGroup = sectionGroup{title: title}
-			// The section-divider slide itself becomes the first slide in this section
-			currentGroup.slides = append(currentGroup.slides, ps)
-		} else {
-			currentGroup.slides = append(currentGroup.slides, ps)
+		// Section IDs come from an _sectionId directive on the section's
+		// first slide, a section marker, or its title
+		b.sectionID = cmp.Or(sectionID, marked)
+		b.section = model.Section{
+			ID:    claimID(b.usedSections, b.pendingSections, b.sectionID, title, "section"),
+			Title: title,
 		}
 	}
-	// Don't forget the last group
-	if len(currentGroup.slides) > 0 {
-		groups = append(groups, currentGroup)
+	if hasSectionID {
+		delete(slide.Directives, "_sectionId")
+		if len(slide.Directives) == 0 {
+			slide.Directives = nil
+		}
 	}
 
-	// Convert groups to sections
-	for i, group := range groups {
-		section := model.Section{
-			ID:    fmt.Sprintf("section-%d", i),
-			Title: group.title,
-		}
+	// Slide IDs come from an _id directive, or from the slide title
+	b.slideID = ps.directives["_id"]
+	slide.ID = claimID(b.usedSlides, b.pendingSlides, b.slideID, slide.Title, "slide")
 
-		for j, ps := range group.slides {
-			slide := convertToSlide(ps, i, j)
-			section.Slides = append(section.Slides, slide)
-		}
+	// Without a title directive, take the title of the first slide
+	if b.slides == 0 && b.deck.Title == "" {
+		b.deck.Title = slide.Title
+	}
+	b.slides++
+	return newSection
+}
 
-		deck.Sections = append(deck.Sections, section)
+// startsSection reports whether ps, converted to slide, starts a new
+// section, with the ID given by its section marker, if any, and the
+// section's title. Slides starting a section by their class or headings
+// alone get the section layout.
+func (b *deckBuilder) startsSection(ps parsedSlide, slide *model.Slide) (starts bool, id, title string) {
+	rules := b.rules
+	if rules.PerFile {
+		return false, "", ""
+	}
+	if marker, ok := ps.directives[sectionMarker]; ok && rules.Markers {
+		id, title, _ = strings.Cut(strings.TrimSpace(marker), " ")
+		return true, id, cmp.Or(strings.TrimSpace(title), slide.Title, id)
 	}
 
-	// Set deck title from first title slide
-	if len(deck.Sections) > 0 && len(deck.Sections[0].Slides) > 0 {
-		deck.Title = deck.Sections[0].Slides[0].Title
+	class := ps.directives["_class"]
+	if isSectionClass(class) {
+		return true, "", extractSectionTitle(ps.content)
 	}
+	if slices.Contains(rules.Classes, class) {
+		title = extractSectionTitle(ps.content)
+	} else if heading, ok := headingOnly(slide, rules.HeadingLevel); ok {
+		title = heading
+	} else {
+		return false, "", ""
+	}
+	if slide.Layout == model.LayoutTitleBody {
+		slide.Layout = model.LayoutSection
+	}
+	return true, "", title
+}
+
+// headingOnly returns the text of the first heading of slide when the
+// slide holds nothing but headings and that one is at level, which must
+// be set.
+func headingOnly(slide *model.Slide, level int) (string, bool) {
+	if level == 0 || slide.HasSlots() {
+		return "", false
+	}
+	for _, block := range slide.Body {
+		if block.Kind != model.BlockHeading {
+			return "", false
+		}
+	}
+	switch {
+	case slide.Title != "":
+		return slide.Title, level == 1
+	case slide.Subtitle != "":
+		return slide.Subtitle, level == 2
+	case len(slide.Body) > 0:
+		return slide.Body[0].Text, slide.Body[0].Level == level
+	}
+	return "", false
+}
 
-	return deck
+// collect appends slide, as returned by add, to the deck being built.
+// Header and footer settings are read from directives as written, so none
+// are passed on to be settled.
+func (b *deckBuilder) collect(slide model.Slide, newSection bool) {
+	if newSection {
+		b.builder.StartSection(b.sectionID, b.section.Title)
+	}
+	b.builder.Add(slide, b.slideID, model.HeaderFooter{})
+}
+
+// finish returns the collected deck with its IDs settled.
+func (b *deckBuilder) finish() *model.Deck {
+	return b.builder.Finish()
+}
+
+// claimID returns an ID for an element read in file order and marks it
+// used: the explicit ID, or the slug of title without one, suffixed with
+// a number if it is already taken. A reserved explicit ID, still listed in
+// pending, is taken as is the first time it is claimed.
+func claimID(used, pending map[string]bool, explicit, title, fallback string) string {
+	if explicit != "" && pending[explicit] {
+		delete(pending, explicit)
+		return explicit
+	}
+	base := explicit
+	if base == "" {
+		base = model.Slugify(title, fallback)
+	}
+	id := base
+	for n := 2; used[id]; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	used[id] = true
+	return id
 }
 
 // extractSectionTitle extracts the title from a section-divider slide.
@@ -356,42 +754,90 @@ func extractSectionTitle(content string) string {
 	return "untitled"
 }
 
-// convertToSlide converts a parsedSlide into a model.Slide.
-func convertToSlide(ps parsedSlide, sectionIdx, slideIdx int) model.Slide {
-	slide := model.Slide{
-		ID: fmt.Sprintf("s%d-%d", sectionIdx, slideIdx),
-	}
-
-	// Determine layout from directives
-	class := ps.directives["_class"]
-	switch class {
-	case "section-divider":
-		slide.Layout = model.LayoutSection
-	case "lead":
-		slide.Layout = model.LayoutTitle
-	default:
-		slide.Layout = model.LayoutTitleBody
+// convertToSlide converts a parsedSlide into a model.Slide, and returns where
+// in the slide's content its title and blocks were read from. The slide ID
+// is assigned by deckBuilder.
+func convertToSlide(ps parsedSlide) (model.Slide, contentSpans) {
+	var slide model.Slide
+
+	// Keep directives other than the ID, the layout classes, the slide
+	// background and the header, footer and pagination, which have fields
+	// of their own
+	for key, value := range ps.directives {
+		switch {
+		case key == "_id" || key == "_class" && isLayoutClass(value):
+			continue
+		case key == "_backgroundColor":
+			slideBackground(&slide).Color = value
+			continue
+		case key == "_backgroundImage":
+			slideBackground(&slide).Image = value
+			continue
+		case key[0] == '_' && setHeaderFooter(&slide.HeaderFooter, key[1:], value):
+			continue
+		case key[0] != '_' && setHeaderFooter(&slide.HeaderFooterFrom, key, value):
+			continue
+		}
+		if slide.Directives == nil {
+			slide.Directives = make(map[string]string)
+		}
+		slide.Directives[key] = value
 	}
 
 	// Parse content into blocks
-	parseSlideContent(&slide, ps.content)
+	spans := parseSlideContent(&slide, ps.content)
 
 	// Add speaker notes
 	for _, nb := range ps.notes {
-		if nb.text != "" {
-			slide.Notes = append(slide.Notes, model.Block{
-				Kind: model.BlockParagraph,
-				Text: nb.text,
-			})
-		}
+		slide.Notes = append(slide.Notes, nb.blocks...)
 	}
 
-	// Detect layout from HTML structure
-	if containsColumns(ps.content) {
-		slide.Layout = model.LayoutTitleTwoCol
+	// Infer the layout from the class and the content
+	slide.Layout = classifyLayout(&slide, ps.directives["_class"], containsColumns(ps.content))
+
+	return slide, spans
+}
+
+// slideBackground returns the slide's background, creating it if needed.
+func slideBackground(slide *model.Slide) *model.Background {
+	if slide.Background == nil {
+		slide.Background = &model.Background{}
 	}
+	return slide.Background
+}
 
-	return slide
+// overlaps reports whether any slot in b is already present in a.
+func overlaps(a, b model.Slots) bool {
+	for name := range b {
+		if _, ok := a[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// cellSpans returns where each cell of a table stands in the content, a
+// row at a time from the header down, skipping the delimiter row. starts
+// holds the offset of each table line; cells missing from short rows get
+// noSpan.
+func cellSpans(lines []string, starts []int, width int) [][]span {
+	var rows [][]span
+	for i, line := range lines {
+		if i == 1 {
+			continue
+		}
+		row := make([]span, width)
+		for j := range row {
+			row[j] = noSpan
+		}
+		for j, at := range tableCellSpans(line) {
+			if j < width {
+				row[j] = span{start: starts[i] + at.start, end: starts[i] + at.end}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
 }
 
 // containsColumns checks if the content has multi-column HTML layout.
@@ -400,15 +846,67 @@ func containsColumns(content string) bool {
 		strings.Contains(content, "grid-template-columns")
 }
 
-// parseSlideContent parses markdown/HTML content into a slide's title, subtitle, and body.
-func parseSlideContent(slide *model.Slide, content string) {
+// parseSlideContent parses markdown/HTML content into a slide's title, subtitle, body
+// and slots. It returns the byte ranges within content that each element was read from.
+func parseSlideContent(slide *model.Slide, content string) contentSpans {
+	return parseContent(slide, content, true)
+}
+
+// parseContent parses content into slide. Unless titled is set, headings
+// are read as body blocks rather than as the title and subtitle.
+func parseContent(slide *model.Slide, content string, titled bool) contentSpans {
+	spans := contentSpans{title: noSpan, subtitle: noSpan}
 	lines := strings.Split(content, "\n")
-	var bodyLines []string
+	starts := make([]int, len(lines))
+	for i, offset := 0, 0; i < len(lines); i++ {
+		starts[i] = offset
+		offset += len(lines[i]) + 1
+	}
+
+	// lineSpan covers lines first through last; textSpan covers text found
+	// at offset bytes past the indentation of line i.
+	lineSpan := func(first, last int) span {
+		return span{start: starts[first], end: starts[last] + len(lines[last])}
+	}
+	textSpan := func(i, offset int, text string) span {
+		start := starts[i] + len(lines[i]) - len(strings.TrimLeft(lines[i], " \t")) + offset
+		return span{start: start, end: start + len(text)}
+	}
+	addBlock := func(block model.Block, full, text span) {
+		slide.Body = append(slide.Body, block)
+		spans.blocks = append(spans.blocks, blockSpan{full: full, text: text})
+	}
+	report := func(i, offset int, severity model.Severity, message string) {
+		at := textSpan(i, offset, "").start
+		spans.problems = append(spans.problems, problem{at: at, severity: severity, message: message})
+	}
+
+	var paragraphLines []string
+	paragraphStart := 0
+	flushParagraph := func(last int) {
+		if len(paragraphLines) == 0 {
+			return
+		}
+		text := strings.Join(paragraphLines, "\n")
+		full := lineSpan(paragraphStart, last)
+		// The text maps back onto the source only if no line was trimmed
+		textRange := noSpan
+		if content[full.start:full.end] == text {
+			textRange = full
+		} else if len(paragraphLines) == 1 {
+			textRange = textSpan(paragraphStart, 0, text)
+		}
+		addBlock(withInline(model.NewParagraph(text)), full, textRange)
+		paragraphLines = nil
+	}
+
 	inCodeBlock := false
 	codeBlockLang := ""
+	codeBlockStart := 0
 	var codeBlockLines []string
 	inHTMLBlock := false
 	var htmlBlockLines []string
+	htmlBlockStart := 0
 	htmlBlockDepth := 0
 
 	for i := 0; i < len(lines); i++ {
@@ -418,18 +916,20 @@ func parseSlideContent(slide *model.Slide, content string) {
 		// Handle code blocks
 		if strings.HasPrefix(trimmed, "```") {
 			if !inCodeBlock {
+				flushParagraph(i - 1)
 				inCodeBlock = true
 				codeBlockLang = strings.TrimPrefix(trimmed, "```")
+				codeBlockStart = i
 				codeBlockLines = nil
 				continue
 			}
-			// End of code block
+			// End of code block; diagram languages get blocks of their own
 			inCodeBlock = false
-			slide.Body = append(slide.Body, model.Block{
-				Kind: model.BlockCode,
-				Text: strings.Join(codeBlockLines, "\n"),
-				Lang: codeBlockLang,
-			})
+			block := model.NewCode(strings.Join(codeBlockLines, "\n"), codeBlockLang)
+			if isDiagramDialect(codeBlockLang) {
+				block = model.NewDiagram(block.Text, codeBlockLang)
+			}
+			addBlock(block, lineSpan(codeBlockStart, i), noSpan)
 			continue
 		}
 		if inCodeBlock {
@@ -438,128 +938,231 @@ func parseSlideContent(slide *model.Slide, content string) {
 		}
 
 		// Handle HTML blocks (div, script, etc.)
+		if !inHTMLBlock && strings.HasPrefix(trimmed, "</") && countHTMLCloses(line) > countHTMLOpens(line) {
+			report(i, 0, model.SeverityWarning, reHTMLClose.FindString(line)+" has no matching opening tag")
+		}
 		if !inHTMLBlock && isHTMLBlockStart(trimmed) {
+			flushParagraph(i - 1)
 			inHTMLBlock = true
 			htmlBlockDepth = 1
+			htmlBlockStart = i
 			htmlBlockLines = []string{line}
 			// Check if self-closing on same line
 			htmlBlockDepth += countHTMLOpens(line) - 1 // -1 for the one we already counted
 			htmlBlockDepth -= countHTMLCloses(line)
-			if htmlBlockDepth <= 0 {
-				inHTMLBlock = false
-				slide.Body = append(slide.Body, model.Block{
-					Kind: model.BlockParagraph,
-					Text: strings.Join(htmlBlockLines, "\n"),
-				})
-				htmlBlockLines = nil
-			}
-			continue
-		}
-		if inHTMLBlock {
+		} else if inHTMLBlock {
 			htmlBlockLines = append(htmlBlockLines, line)
 			htmlBlockDepth += countHTMLOpens(line)
 			htmlBlockDepth -= countHTMLCloses(line)
+		}
+		if inHTMLBlock {
 			if htmlBlockDepth <= 0 {
 				inHTMLBlock = false
-				slide.Body = append(slide.Body, model.Block{
-					Kind: model.BlockParagraph,
-					Text: strings.Join(htmlBlockLines, "\n"),
-				})
+				full := lineSpan(htmlBlockStart, i)
+				if slots, ok := parseSlots(htmlBlockLines); ok && !overlaps(slide.Slots, slots) {
+					if slide.Slots == nil {
+						slide.Slots = make(model.Slots)
+					}
+					for name, blocks := range slots {
+						slide.Slots[name] = blocks
+					}
+					spans.slots = append(spans.slots, full)
+				} else {
+					addBlock(model.Block{
+						Kind: model.BlockParagraph,
+						Text: strings.Join(htmlBlockLines, "\n"),
+					}, full, full)
+				}
 				htmlBlockLines = nil
 			}
 			continue
 		}
 
-		// Skip empty lines
+		// Handle display math, on one line or across several
+		if strings.HasPrefix(trimmed, "$$") {
+			if end, tex, ok := displayMath(lines, i); ok {
+				flushParagraph(i - 1)
+				addBlock(model.NewMath(tex), lineSpan(i, end), noSpan)
+				i = end
+				continue
+			}
+			report(i, 0, model.SeverityWarning, "display math is never closed with $$")
+		}
+
+		// Empty lines end paragraphs
 		if trimmed == "" {
+			flushParagraph(i - 1)
 			continue
 		}
 
 		// Handle headings
 		if strings.HasPrefix(trimmed, "# ") {
+			flushParagraph(i - 1)
 			title := strings.TrimPrefix(trimmed, "# ")
-			if slide.Title == "" {
+			if titled && slide.Title == "" {
 				slide.Title = title
+				spans.title = textSpan(i, 2, title)
 			} else {
-				slide.Body = append(slide.Body, model.NewHeading(title, 1))
+				addBlock(withInline(model.NewHeading(title, 1)), lineSpan(i, i), textSpan(i, 2, title))
 			}
 			continue
 		}
 		if strings.HasPrefix(trimmed, "## ") {
+			flushParagraph(i - 1)
 			subtitle := strings.TrimPrefix(trimmed, "## ")
-			if slide.Title == "" {
+			if titled && slide.Title == "" {
 				slide.Title = subtitle
-			} else if slide.Subtitle == "" {
+				spans.title = textSpan(i, 3, subtitle)
+			} else if titled && slide.Subtitle == "" {
 				slide.Subtitle = subtitle
+				spans.subtitle = textSpan(i, 3, subtitle)
 			} else {
-				slide.Body = append(slide.Body, model.NewHeading(subtitle, 2))
+				addBlock(withInline(model.NewHeading(subtitle, 2)), lineSpan(i, i), textSpan(i, 3, subtitle))
 			}
 			continue
 		}
 		if strings.HasPrefix(trimmed, "### ") {
-			slide.Body = append(slide.Body, model.NewHeading(
-				strings.TrimPrefix(trimmed, "### "), 3))
+			flushParagraph(i - 1)
+			text := strings.TrimPrefix(trimmed, "### ")
+			addBlock(withInline(model.NewHeading(text, 3)), lineSpan(i, i), textSpan(i, 4, text))
 			continue
 		}
 
-		// Handle bullet points
+		// Handle bullet points; Marp reveals "*" items one at a time
 		if strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* ") {
+			flushParagraph(i - 1)
 			level := countIndentLevel(line)
 			text := strings.TrimSpace(trimmed[2:])
-			slide.Body = append(slide.Body, model.NewBullet(text, level))
+			offset := 2 + len(trimmed[2:]) - len(strings.TrimLeft(trimmed[2:], " \t"))
+			block := withInline(model.NewBullet(text, level))
+			block.Fragment = trimmed[0] == '*'
+			addBlock(block, lineSpan(i, i), textSpan(i, offset, text))
 			continue
 		}
 
-		// Handle numbered lists
-		if isNumberedItem(trimmed) {
+		// Handle numbered lists; "1)" items are fragments, as with "*"
+		if loc := reNumbered.FindStringSubmatchIndex(trimmed); loc != nil {
+			flushParagraph(i - 1)
 			level := countIndentLevel(line)
-			text := extractNumberedText(trimmed)
-			slide.Body = append(slide.Body, model.NewNumbered(text, level))
+			text := trimmed[loc[6]:loc[7]]
+			block := withInline(model.NewNumbered(text, level))
+			block.Fragment = trimmed[loc[4]] == ')'
+			addBlock(block, lineSpan(i, i), textSpan(i, loc[6], text))
 			continue
 		}
 
 		// Handle blockquotes
 		if strings.HasPrefix(trimmed, "> ") {
+			flushParagraph(i - 1)
 			text := strings.TrimPrefix(trimmed, "> ")
-			slide.Body = append(slide.Body, model.NewQuote(text))
+			addBlock(withInline(model.NewQuote(text)), lineSpan(i, i), textSpan(i, 2, text))
 			continue
 		}
 
 		// Handle images
 		if strings.HasPrefix(trimmed, "![") {
-			alt, url := parseImageLine(trimmed)
-			if url != "" {
-				slide.Body = append(slide.Body, model.NewImage(url, alt))
+			if image, ok := parseImage(trimmed); ok {
+				flushParagraph(i - 1)
+				if image.IsBackground() {
+					bg := slideBackground(slide)
+					bg.Images = append(bg.Images, image)
+					spans.backgrounds = append(spans.backgrounds, lineSpan(i, i))
+				} else {
+					addBlock(image, lineSpan(i, i), noSpan)
+				}
 				continue
 			}
+			report(i, 0, model.SeverityWarning, "malformed image, expected ![alt](url)")
 		}
 
 		// Handle tables (collect all table lines as a single block)
 		if strings.HasPrefix(trimmed, "|") {
+			flushParagraph(i - 1)
+			first := i
 			var tableLines []string
 			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), "|") {
 				tableLines = append(tableLines, lines[i])
 				i++
 			}
 			i-- // Back up one since the loop will increment
-			slide.Body = append(slide.Body, model.Block{
+			full := lineSpan(first, i)
+			if table, ok := parseTable(tableLines); ok {
+				addBlock(model.Block{Kind: model.BlockTable, Table: table}, full, noSpan)
+				spans.blocks[len(spans.blocks)-1].cells = cellSpans(tableLines, starts[first:], len(table.Header))
+				continue
+			}
+			// Pipe lines without a delimiter row are kept as written
+			addBlock(model.Block{
 				Kind: model.BlockParagraph,
 				Text: strings.Join(tableLines, "\n"),
-			})
+			}, full, full)
 			continue
 		}
 
 		// Default: paragraph text
-		bodyLines = append(bodyLines, trimmed)
+		if len(paragraphLines) == 0 {
+			paragraphStart = i
+		}
+		paragraphLines = append(paragraphLines, trimmed)
+	}
+
+	// Flush remaining body lines as a paragraph, and keep blocks left open
+	// at the end rather than dropping them
+	flushParagraph(len(lines) - 1)
+	last := len(lines) - 1
+	if inCodeBlock {
+		report(codeBlockStart, 0, model.SeverityError,
+			"code fence is never closed, so it takes in the rest of the deck")
+		addBlock(model.Block{
+			Kind: model.BlockCode,
+			Text: strings.Join(codeBlockLines, "\n"),
+			Lang: codeBlockLang,
+		}, lineSpan(codeBlockStart, last), noSpan)
+	}
+	if inHTMLBlock {
+		tag := reHTMLOpen.FindString(lines[htmlBlockStart]) + ">"
+		report(htmlBlockStart, 0, model.SeverityWarning,
+			tag+" is never closed; the rest of the slide is kept as raw HTML")
+		full := lineSpan(htmlBlockStart, last)
+		addBlock(model.Block{
+			Kind: model.BlockParagraph,
+			Text: strings.Join(htmlBlockLines, "\n"),
+		}, full, full)
 	}
 
-	// Flush remaining body lines as paragraphs
-	if len(bodyLines) > 0 {
-		text := strings.Join(bodyLines, "\n")
-		if text != "" {
-			slide.Body = append(slide.Body, model.NewParagraph(text))
+	return spans
+}
+
+// isDiagramDialect reports whether a code fence language is a diagram
+// dialect, read into a diagram block rather than code.
+func isDiagramDialect(lang string) bool {
+	return slices.Contains(model.DiagramDialects(), lang)
+}
+
+// displayMath reads display math opening with $$ on line i. The TeX may
+// share its first and last lines with the $$ delimiters, as in $$ x^2 $$.
+// It returns the line of the closing delimiter and the TeX between them.
+func displayMath(lines []string, i int) (int, string, bool) {
+	first := strings.TrimPrefix(strings.TrimSpace(lines[i]), "$$")
+	if strings.HasSuffix(first, "$$") {
+		return i, strings.TrimSpace(strings.TrimSuffix(first, "$$")), true
+	}
+	var tex []string
+	if strings.TrimSpace(first) != "" {
+		tex = append(tex, strings.TrimSpace(first))
+	}
+	for j := i + 1; j < len(lines); j++ {
+		trimmed := strings.TrimSpace(lines[j])
+		if !strings.HasSuffix(trimmed, "$$") {
+			tex = append(tex, lines[j])
+			continue
 		}
+		if last := strings.TrimSpace(strings.TrimSuffix(trimmed, "$$")); last != "" {
+			tex = append(tex, last)
+		}
+		return j, strings.Join(tex, "\n"), true
 	}
+	return 0, "", false
 }
 
 var reHTMLOpen = regexp.MustCompile(`<(div|section|script|table|ol|ul)\b`)
@@ -600,21 +1203,7 @@ func countIndentLevel(line string) int {
 	return spaces / 4 // 4 spaces per level
 }
 
-var reNumbered = regexp.MustCompile(`^(\d+)\.\s+(.+)$`)
-
-// isNumberedItem checks if a line is a numbered list item.
-func isNumberedItem(line string) bool {
-	return reNumbered.MatchString(line)
-}
-
-// extractNumberedText extracts the text from a numbered list item.
-func extractNumberedText(line string) string {
-	match := reNumbered.FindStringSubmatch(line)
-	if len(match) >= 3 {
-		return match[2]
-	}
-	return line
-}
+var reNumbered = regexp.MustCompile(`^(\d+)([.)])\s+(.+)$`)
 
 var reImage = regexp.MustCompile(`!\[([^\]]*)\]\(([^)]+)\)`)
 
@@ -1,7 +1,10 @@
 package marp
 
 import (
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/grokify/slidekit/model"
 )
@@ -129,7 +132,10 @@ Final thoughts
 `
 
 func TestParseFrontmatter(t *testing.T) {
-	fm, body := parseFrontmatter(sampleMarp)
+	fm, body, err := parseFrontmatter(sampleMarp)
+	if err != nil {
+		t.Fatalf("parseFrontmatter error: %v", err)
+	}
 
 	if !fm.Marp {
 		t.Error("expected marp: true")
@@ -151,8 +157,74 @@ func TestParseFrontmatter(t *testing.T) {
 	}
 }
 
+func TestParseFrontmatterYAML(t *testing.T) {
+	content := `---
+# A comment
+marp: true
+title: "Deck: The Sequel"
+author: Ada
+description: >
+  Folded
+  text
+keywords: [go, marp]
+url: https://example.com
+image: og.png
+size: 4:3
+headingDivider: 2
+transition:
+  name: fade
+---
+
+# First Slide
+`
+	deck, err := NewReader().Parse(content)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	if deck.Title != "Deck: The Sequel" {
+		t.Errorf("expected title from frontmatter, got %q", deck.Title)
+	}
+	meta := deck.Meta
+	if meta.Author != "Ada" || meta.Description != "Folded text\n" ||
+		meta.URL != "https://example.com" || meta.Image != "og.png" || meta.Size != "4:3" {
+		t.Errorf("unexpected meta: %+v", meta)
+	}
+	if len(meta.Keywords) != 2 || meta.Keywords[0] != "go" || meta.Keywords[1] != "marp" {
+		t.Errorf("expected keywords [go marp], got %v", meta.Keywords)
+	}
+	if meta.Custom["headingDivider"] != 2 {
+		t.Errorf("expected typed headingDivider 2, got %#v", meta.Custom["headingDivider"])
+	}
+	if transition, ok := meta.Custom["transition"].(map[string]any); !ok || transition["name"] != "fade" {
+		t.Errorf("expected nested transition map, got %#v", meta.Custom["transition"])
+	}
+
+	// Invalid YAML is ignored, and the slides are still read
+	deck, err = NewReader().Parse("---\nmarp: [true\n---\n\n# Slide\n")
+	if err != nil {
+		t.Fatalf("Parse error for invalid YAML frontmatter: %v", err)
+	}
+	if len(deck.AllSlides()) != 1 || deck.Title != "Slide" {
+		t.Errorf("expected the slide read despite invalid frontmatter, got %+v", deck)
+	}
+}
+
+func TestFrontmatterTransition(t *testing.T) {
+	deck, err := NewReader().Parse("---\nmarp: true\ntransition: fade\n---\n\n# Slide\n")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if deck.Transition != "fade" || deck.Meta.Custom["transition"] != nil {
+		t.Errorf("expected the deck's transition, got %q and %v", deck.Transition, deck.Meta.Custom)
+	}
+	if output := NewWriter().Encode(deck); !strings.Contains(output, "\ntransition: fade\n") {
+		t.Errorf("expected the transition written back:\n%s", output)
+	}
+}
+
 func TestSplitSlides(t *testing.T) {
-	_, body := parseFrontmatter(sampleMarp)
+	_, body, _ := parseFrontmatter(sampleMarp)
 	slides := splitSlides(body)
 
 	if len(slides) < 8 {
@@ -171,7 +243,7 @@ func TestSplitSlidesRespectsCodeBlocks(t *testing.T) {
 
 func TestParseDirectives(t *testing.T) {
 	raw := "<!-- _class: section-divider -->\n<!-- _paginate: false -->\n\n# Title"
-	ps := parseRawSlide(raw)
+	ps := parseRawSlide(raw, isDirective)
 
 	if ps.directives["_class"] != "section-divider" {
 		t.Errorf("expected _class 'section-divider', got %q", ps.directives["_class"])
@@ -190,17 +262,18 @@ This is the introduction.
 
 # Title`
 
-	ps := parseRawSlide(raw)
+	ps := parseRawSlide(raw, isDirective)
 
 	if len(ps.notes) == 0 {
 		t.Fatal("expected at least one note block")
 	}
-	if !contains(ps.notes[0].text, "Welcome to the presentation") {
-		t.Errorf("expected note to contain 'Welcome', got %q", ps.notes[0].text)
+	want := []model.Block{
+		model.NewParagraph("Welcome to the presentation."),
+		model.NewPause(time.Second),
+		model.NewParagraph("This is the introduction."),
 	}
-	// PAUSE markers should be stripped from text
-	if contains(ps.notes[0].text, "[PAUSE") {
-		t.Error("expected PAUSE markers to be stripped from note text")
+	if !reflect.DeepEqual(ps.notes[0].blocks, want) {
+		t.Errorf("expected PAUSE marker between paragraphs, got %+v", ps.notes[0].blocks)
 	}
 }
 
@@ -212,16 +285,18 @@ More text here.
 
 # Title`
 
-	ps := parseRawSlide(raw)
+	ps := parseRawSlide(raw, isDirective)
 
 	if len(ps.notes) == 0 {
 		t.Fatal("expected at least one note block")
 	}
-	if contains(ps.notes[0].text, "<break") {
-		t.Error("expected <break> markers to be stripped from note text")
+	want := []model.Block{
+		model.NewParagraph("Section intro."),
+		model.NewBreak(600 * time.Millisecond),
+		model.NewParagraph("More text here."),
 	}
-	if !contains(ps.notes[0].text, "Section intro") {
-		t.Errorf("expected note to contain 'Section intro', got %q", ps.notes[0].text)
+	if !reflect.DeepEqual(ps.notes[0].blocks, want) {
+		t.Errorf("expected SSML break between paragraphs, got %+v", ps.notes[0].blocks)
 	}
 }
 
@@ -492,33 +567,6 @@ func TestSlideCount(t *testing.T) {
 	}
 }
 
-func TestCleanNoteText(t *testing.T) {
-	tests := []struct {
-		input    string
-		expected string
-	}{
-		{
-			input:    "Hello world.\n[PAUSE:1000]\nNext sentence.",
-			expected: "Hello world. Next sentence.",
-		},
-		{
-			input:    "Intro. <break time=\"600ms\"/>\nMore text.",
-			expected: "Intro. More text.",
-		},
-		{
-			input:    "Simple note.",
-			expected: "Simple note.",
-		},
-	}
-
-	for _, tt := range tests {
-		result := cleanNoteText(tt.input)
-		if result != tt.expected {
-			t.Errorf("cleanNoteText(%q) = %q, want %q", tt.input, result, tt.expected)
-		}
-	}
-}
-
 func TestEmptyInput(t *testing.T) {
 	reader := NewReader()
 	deck, err := reader.Parse("")
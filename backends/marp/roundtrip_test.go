@@ -0,0 +1,96 @@
+package marp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// TestRoundTripCorpus reads each deck in testdata/roundtrip, writes it back
+// out and checks that nothing was lost. Decks already in the writer's
+// canonical form must come back byte-for-byte; the rest must parse to the
+// same model.
+func TestRoundTripCorpus(t *testing.T) {
+	tests := []struct {
+		file  string
+		exact bool
+	}{
+		{"directives.md", true},
+		{"blocks.md", true},
+		{"course.md", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", "roundtrip", tt.file))
+			if err != nil {
+				t.Fatalf("failed to read corpus file: %v", err)
+			}
+
+			reader := NewReader()
+			deck, err := reader.Parse(string(data))
+			if err != nil {
+				t.Fatalf("Parse error: %v", err)
+			}
+			output := NewWriter().Encode(deck)
+
+			if tt.exact && output != string(data) {
+				t.Errorf("expected byte-identical output, got:\n%s", output)
+			}
+
+			reparsed, err := reader.Parse(output)
+			if err != nil {
+				t.Fatalf("Parse error on output: %v", err)
+			}
+			// Source positions move when a deck is rewritten
+			clearSources(deck)
+			clearSources(reparsed)
+			if !reflect.DeepEqual(deck, reparsed) {
+				t.Errorf("round trip changed the deck:\noriginal: %+v\nreparsed: %+v", deck, reparsed)
+			}
+		})
+	}
+}
+
+func clearSources(deck *model.Deck) {
+	for i := range deck.Sections {
+		for j := range deck.Sections[i].Slides {
+			deck.Sections[i].Slides[j].Source = nil
+		}
+	}
+}
+
+func TestDirectivesKept(t *testing.T) {
+	deck, err := NewReader().Parse(`---
+marp: true
+---
+
+<!-- _class: lead invert -->
+<!-- _backgroundColor: #000 -->
+<!-- footer: Course -->
+<!-- Note: not a directive -->
+
+# Title
+`)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	slide := deck.Sections[0].Slides[0]
+	want := map[string]string{"_class": "lead invert"}
+	if !reflect.DeepEqual(slide.Directives, want) {
+		t.Errorf("expected directives %v, got %v", want, slide.Directives)
+	}
+	if h := slide.HeaderFooterFrom; h == nil || h.Footer == nil || *h.Footer != "Course" {
+		t.Errorf("expected footer Course from this slide on, got %+v", h)
+	}
+	if slide.Background == nil || slide.Background.Color != "#000" {
+		t.Errorf("expected background color #000, got %+v", slide.Background)
+	}
+	if len(slide.Body) != 0 || slide.NotesText() != "Note: not a directive" {
+		t.Errorf("expected plain comment read as a note, got body %+v and notes %+v", slide.Body, slide.Notes)
+	}
+}
@@ -0,0 +1,156 @@
+package marp
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+const chapterDeck = `---
+marp: true
+title: Handbook
+---
+
+# Handbook
+
+---
+
+<!-- _class: chapter -->
+
+# Getting Started
+
+Setup notes.
+
+---
+
+# Install
+
+- Download
+
+---
+
+# Configuration
+
+---
+
+<!-- section: faq Questions -->
+
+# FAQ
+
+---
+
+<!-- _class: section-divider -->
+
+# Part 2
+## Appendix
+`
+
+// sectionsOf returns the IDs of the sections of deck with the IDs of their
+// slides.
+func sectionsOf(deck *model.Deck) map[string][]string {
+	sections := make(map[string][]string)
+	for _, section := range deck.Sections {
+		for _, slide := range section.Slides {
+			sections[section.ID] = append(sections[section.ID], slide.ID)
+		}
+	}
+	return sections
+}
+
+func TestSectionRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules model.SectionRules
+		want  map[string][]string
+	}{
+		{"default", model.SectionRules{}, map[string][]string{
+			"default":  {"handbook", "getting-started", "install", "configuration", "faq"},
+			"appendix": {"part-2"},
+		}},
+		{"class", model.SectionRules{Classes: []string{"chapter"}}, map[string][]string{
+			"default":         {"handbook"},
+			"getting-started": {"getting-started", "install", "configuration", "faq"},
+			"appendix":        {"part-2"},
+		}},
+		{"heading", model.SectionRules{HeadingLevel: 1}, map[string][]string{
+			"handbook":      {"handbook", "getting-started", "install"},
+			"configuration": {"configuration"},
+			"faq":           {"faq"},
+			"appendix":      {"part-2"},
+		}},
+		{"markers", model.SectionRules{Markers: true}, map[string][]string{
+			"default":  {"handbook", "getting-started", "install", "configuration"},
+			"faq":      {"faq"},
+			"appendix": {"part-2"},
+		}},
+		{"per file", model.SectionRules{PerFile: true, Markers: true}, map[string][]string{
+			"handbook": {"handbook", "getting-started", "install", "configuration", "faq", "part-2"},
+		}},
+	}
+	for _, tt := range tests {
+		deck, err := NewReaderWithOptions(ReaderOptions{Sections: tt.rules}).Parse(chapterDeck)
+		if err != nil {
+			t.Fatalf("%s: Parse error: %v", tt.name, err)
+		}
+		if got := sectionsOf(deck); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: sections = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSectionRuleDetails(t *testing.T) {
+	reader := NewReaderWithOptions(ReaderOptions{Sections: model.SectionRules{
+		Classes: []string{"chapter"}, HeadingLevel: 1, Markers: true,
+	}})
+	deck, err := reader.Parse(chapterDeck)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	// Slides starting a section by class or heading become dividers
+	slides := deck.AllSlides()
+	for i, want := range []model.Layout{model.LayoutSection, model.LayoutSection, model.LayoutTitleBody, model.LayoutSection} {
+		if slides[i].Layout != want {
+			t.Errorf("slide %s: layout = %q, want %q", slides[i].ID, slides[i].Layout, want)
+		}
+	}
+
+	// A marker names its section and stays on the slide, so the writer
+	// keeps it
+	faq := deck.Sections[len(deck.Sections)-2]
+	if faq.ID != "faq" || faq.Title != "Questions" || faq.Slides[0].Directives["section"] != "faq Questions" {
+		t.Errorf("unexpected marked section %+v", faq)
+	}
+	if out := NewWriter().Encode(deck); !strings.Contains(out, "<!-- section: faq Questions -->") {
+		t.Errorf("expected the marker written back, got:\n%s", out)
+	}
+
+	// Without markers enabled the comment is a speaker note
+	plain, _ := NewReader().Parse(chapterDeck)
+	if notes := plain.AllSlides()[4].Notes; len(notes) != 1 || notes[0].Text != "section: faq Questions" {
+		t.Errorf("expected the marker read as a note, got %+v", notes)
+	}
+
+	// Streaming applies the same rules
+	var streamed []string
+	err = reader.ScanSlides(context.Background(), strings.NewReader(chapterDeck),
+		func(section model.Section, _ model.Slide) error {
+			streamed = append(streamed, section.ID)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("ScanSlides error: %v", err)
+	}
+	var want []string
+	for _, section := range deck.Sections {
+		for range section.Slides {
+			want = append(want, section.ID)
+		}
+	}
+	if !reflect.DeepEqual(streamed, want) {
+		t.Errorf("ScanSlides sections = %v, want %v", streamed, want)
+	}
+}
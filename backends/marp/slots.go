@@ -0,0 +1,183 @@
+package marp
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+var (
+	reDivOpen  = regexp.MustCompile(`^<div(\s[^>]*)?>$`)
+	reDivClass = regexp.MustCompile(`\bclass\s*=\s*"([^"]*)"`)
+	reDivStyle = regexp.MustCompile(`\bstyle\s*=\s*"([^"]*)"`)
+)
+
+// parseSlots reads an HTML block as content slots. Two patterns are
+// recognized: a columns container (class "columns", or a style with
+// grid-template-columns) whose child divs become column slots, and a div
+// with class "caption", which becomes the caption slot. Each div tag must sit
+// on a line of its own. It returns false for any other HTML, which is then
+// kept as written.
+func parseSlots(lines []string) (model.Slots, bool) {
+	if len(lines) < 2 || strings.TrimSpace(lines[len(lines)-1]) != "</div>" {
+		return nil, false
+	}
+	attrs, ok := divAttrs(lines[0])
+	if !ok {
+		return nil, false
+	}
+	inner := lines[1 : len(lines)-1]
+
+	if hasClass(attrs, model.SlotCaption) {
+		blocks, ok := parseSlotContent(inner)
+		if !ok {
+			return nil, false
+		}
+		return model.Slots{model.SlotCaption: blocks}, true
+	}
+	if !hasClass(attrs, "columns") && !strings.Contains(divStyle(attrs), "grid-template-columns") {
+		return nil, false
+	}
+
+	slots := make(model.Slots)
+	for i := 0; i < len(inner); i++ {
+		if strings.TrimSpace(inner[i]) == "" {
+			continue
+		}
+		childAttrs, ok := divAttrs(inner[i])
+		if !ok {
+			return nil, false
+		}
+
+		// Find the matching close tag
+		depth, end := 1, -1
+		for j := i + 1; j < len(inner) && end < 0; j++ {
+			depth += countHTMLOpens(inner[j]) - countHTMLCloses(inner[j])
+			if depth == 0 {
+				end = j
+			}
+		}
+		if end < 0 || strings.TrimSpace(inner[end]) != "</div>" {
+			return nil, false
+		}
+
+		name := columnName(childAttrs, len(slots))
+		if _, taken := slots[name]; taken || name == model.SlotCaption {
+			return nil, false
+		}
+		blocks, ok := parseSlotContent(inner[i+1 : end])
+		if !ok {
+			return nil, false
+		}
+		slots[name] = blocks
+		i = end
+	}
+	if len(slots) == 0 {
+		return nil, false
+	}
+	return slots, true
+}
+
+// parseSlotContent parses the Markdown inside a slot div. Content that is
+// itself laid out in slots, or that sets the slide background, cannot be
+// represented and is rejected.
+func parseSlotContent(lines []string) ([]model.Block, bool) {
+	var scratch model.Slide
+	parseContent(&scratch, strings.Join(lines, "\n"), false)
+	if scratch.HasSlots() || scratch.Background != nil {
+		return nil, false
+	}
+	return scratch.Body, true
+}
+
+// divAttrs returns the attributes of a line holding only an opening div tag.
+func divAttrs(line string) (string, bool) {
+	m := reDivOpen.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+func hasClass(attrs, class string) bool {
+	m := reDivClass.FindStringSubmatch(attrs)
+	if m == nil {
+		return false
+	}
+	for _, c := range strings.Fields(m[1]) {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+func divStyle(attrs string) string {
+	if m := reDivStyle.FindStringSubmatch(attrs); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// columnName names a column slot after its class: "column-left" and "left"
+// both give left, and "column-notes" gives notes. Columns without such a
+// class are named by position: left, right, then column3, column4, ...
+func columnName(attrs string, index int) string {
+	if m := reDivClass.FindStringSubmatch(attrs); m != nil {
+		for _, c := range strings.Fields(m[1]) {
+			if name, ok := strings.CutPrefix(c, "column-"); ok && name != "" {
+				return name
+			}
+		}
+		for _, c := range strings.Fields(m[1]) {
+			switch c {
+			case model.SlotLeft, model.SlotCenter, model.SlotRight:
+				return c
+			}
+		}
+	}
+	switch index {
+	case 0:
+		return model.SlotLeft
+	case 1:
+		return model.SlotRight
+	}
+	return fmt.Sprintf("column%d", index+1)
+}
+
+// renderSlots renders content slots as the HTML scaffold parseSlots reads:
+// a columns container with one div per column, followed by the caption.
+func (w *Writer) renderSlots(slots model.Slots) string {
+	var parts []string
+	if columns := slots.Columns(); len(columns) > 0 {
+		var b strings.Builder
+		b.WriteString(`<div class="columns">` + "\n")
+		for _, name := range columns {
+			fmt.Fprintf(&b, `<div class="column-%s">`+"\n", name)
+			w.writeSlotContent(&b, slots[name])
+			b.WriteString("</div>\n")
+		}
+		b.WriteString("</div>")
+		parts = append(parts, b.String())
+	}
+	if caption, ok := slots[model.SlotCaption]; ok {
+		var b strings.Builder
+		b.WriteString(`<div class="caption">` + "\n")
+		w.writeSlotContent(&b, caption)
+		b.WriteString("</div>")
+		parts = append(parts, b.String())
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// writeSlotContent writes the blocks of a slot, surrounded by blank lines so
+// that Marp renders them as Markdown.
+func (w *Writer) writeSlotContent(b *strings.Builder, blocks []model.Block) {
+	b.WriteString("\n")
+	if len(blocks) > 0 {
+		w.writeBlocks(b, blocks)
+		b.WriteString("\n")
+	}
+}
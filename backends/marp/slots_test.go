@@ -0,0 +1,174 @@
+package marp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestParseColumnsIntoSlots(t *testing.T) {
+	deck, err := NewReader().Parse(sampleMarp)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	var slide *model.Slide
+	slides := deck.AllSlides()
+	for i := range slides {
+		if slides[i].Title == "Two Column Layout" {
+			slide = &slides[i]
+		}
+	}
+	if slide == nil {
+		t.Fatal("expected to find 'Two Column Layout' slide")
+	}
+	if len(slide.Body) != 0 {
+		t.Errorf("expected columns moved out of the body, got %+v", slide.Body)
+	}
+	if got := slide.Slots.Names(); !reflect.DeepEqual(got, []string{"left", "right"}) {
+		t.Fatalf("expected left and right slots, got %v", got)
+	}
+	right := slide.Slots[model.SlotRight]
+	if len(right) != 3 || right[0].Text != "Right Side" || right[2].Text != "Item D" {
+		t.Errorf("unexpected right slot: %+v", right)
+	}
+}
+
+func TestParseSlotPatterns(t *testing.T) {
+	input := `# Grid
+
+<div style="display: grid; grid-template-columns: 1fr 1fr 1fr;">
+<div>
+
+## Plan
+
+</div>
+<div class="column-middle">
+
+- Build
+
+</div>
+<div>
+
+Ship
+
+</div>
+</div>
+
+<div class="caption">
+
+Figures are estimates.
+
+</div>
+`
+	deck, err := NewReader().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	slide := deck.Sections[0].Slides[0]
+
+	// Unknown slot names sort between the columns and the caption
+	want := []string{"left", "column3", "middle", "caption"}
+	if got := slide.Slots.Names(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected slot names %v, got %v", want, got)
+	}
+	for _, name := range want {
+		if len(slide.Slots[name]) != 1 {
+			t.Errorf("expected one block in slot %s, got %+v", name, slide.Slots[name])
+		}
+	}
+	if h := slide.Slots[model.SlotLeft][0]; h.Kind != model.BlockHeading || h.Level != 2 {
+		t.Errorf("expected headings inside a slot to stay blocks, got %+v", h)
+	}
+	if slide.Title != "Grid" || slide.Subtitle != "" {
+		t.Errorf("unexpected title %q / subtitle %q", slide.Title, slide.Subtitle)
+	}
+}
+
+func TestUnrecognizedColumnsKeptAsHTML(t *testing.T) {
+	input := "# Mixed\n\n<div class=\"columns\">\n<p>inline</p>\n</div>\n"
+	deck, _ := NewReader().Parse(input)
+	slide := deck.Sections[0].Slides[0]
+
+	if slide.HasSlots() || len(slide.Body) != 1 || slide.Body[0].Kind != model.BlockParagraph {
+		t.Errorf("expected raw HTML body block, got slots %v and body %+v", slide.Slots, slide.Body)
+	}
+	if slide.Layout != model.LayoutTitleTwoCol {
+		t.Errorf("expected layout title_two_col, got %q", slide.Layout)
+	}
+}
+
+func TestWriteSlots(t *testing.T) {
+	slide := model.Slide{
+		ID:     "compare",
+		Layout: model.LayoutComparison,
+		Title:  "Compare",
+		Slots: model.Slots{
+			model.SlotRight:   {model.NewBullet("After", 0)},
+			model.SlotLeft:    {model.NewBullet("Before", 0), model.NewBullet("Slow", 1)},
+			model.SlotCaption: {model.NewParagraph("Measured in 2025.")},
+		},
+	}
+
+	var b strings.Builder
+	NewWriter().writeSlide(&b, &slide, nil, idDirectives{})
+	want := `<!-- _class: comparison -->
+
+# Compare
+
+<div class="columns">
+<div class="column-left">
+
+- Before
+    - Slow
+
+</div>
+<div class="column-right">
+
+- After
+
+</div>
+</div>
+
+<div class="caption">
+
+Measured in 2025.
+
+</div>
+`
+	if got := b.String(); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	deck, err := NewReader().Parse(want)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if got := deck.Sections[0].Slides[0].Layout; got != model.LayoutComparison {
+		t.Errorf("expected the comparison layout to survive a round trip, got %q", got)
+	}
+	if got := deck.Sections[0].Slides[0].Slots; !reflect.DeepEqual(got, slide.Slots) {
+		t.Errorf("slots did not survive a round trip: %+v", got)
+	}
+}
+
+func TestPatchSlotBlock(t *testing.T) {
+	input := "# Two\n\nIntro.\n\n<div class=\"columns\">\n<div>\n\n- A\n\n</div>\n<div>\n\n- B\n\n</div>\n</div>\n\nOutro.\n"
+	deck, _ := NewReader().Parse(input)
+	desired, _ := NewReader().Parse(input)
+	desired.Sections[0].Slides[0].Slots[model.SlotRight][0].Text = "C"
+
+	diff := model.ComputeDiff(deck, desired)
+	if diff.ChangeCount() != 1 || !strings.HasSuffix(diff.Changes[0].Path, "/slots/right/0") {
+		t.Fatalf("expected a single slot block change, got %+v", diff.Changes)
+	}
+
+	got := applyPatch(t, input, diff)
+	want := "Intro.\n\n<div class=\"columns\">\n<div class=\"column-left\">\n\n- A\n\n</div>\n" +
+		"<div class=\"column-right\">\n\n- C\n\n</div>\n</div>\n\nOutro.\n"
+	if !strings.HasSuffix(got, want) {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
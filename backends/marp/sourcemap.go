@@ -0,0 +1,55 @@
+package marp
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// position converts a byte offset within text to a 1-based line and
+// column, counting columns in characters.
+func position(text string, offset int) (line, column int) {
+	before := text[:offset]
+	start := strings.LastIndexByte(before, '\n') + 1
+	return strings.Count(before, "\n") + 1, utf8.RuneCountInString(before[start:]) + 1
+}
+
+// sourceRange converts a span of a chunk starting on file line first into
+// a file range.
+func sourceRange(chunk string, first int, s span) model.SourceRange {
+	startLine, startColumn := position(chunk, s.start)
+	endLine, endColumn := position(chunk, s.end)
+	return model.SourceRange{
+		Start: model.SourcePos{Line: first + startLine - 1, Column: startColumn},
+		End:   model.SourcePos{Line: first + endLine - 1, Column: endColumn},
+	}
+}
+
+// sourceMap locates a slide read from chunk, which starts on file line
+// first. The slide itself covers the chunk without its surrounding blank
+// lines.
+func sourceMap(chunk string, first int, spans contentSpans, notes []noteBlock) *model.SourceMap {
+	trimmed := strings.TrimSpace(chunk)
+	start := strings.Index(chunk, trimmed)
+	sm := &model.SourceMap{
+		Slide: sourceRange(chunk, first, span{start: start, end: start + len(trimmed)}),
+	}
+	if s := spans.title; s.valid() {
+		r := sourceRange(chunk, first, s)
+		sm.Title = &r
+	}
+	if s := spans.subtitle; s.valid() {
+		r := sourceRange(chunk, first, s)
+		sm.Subtitle = &r
+	}
+	for _, b := range spans.blocks {
+		sm.Body = append(sm.Body, sourceRange(chunk, first, b.full))
+	}
+	for _, nb := range notes {
+		for _, s := range nb.blockSpans {
+			sm.Notes = append(sm.Notes, sourceRange(chunk, first, s))
+		}
+	}
+	return sm
+}
@@ -0,0 +1,82 @@
+package marp
+
+import (
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestSourceMap(t *testing.T) {
+	input := `---
+marp: true
+---
+
+# First
+
+---
+
+<!--
+Hello. [PAUSE:500]
+- Point
+-->
+
+# Second
+## Ünïcode subtitle
+
+  - Item
+`
+	deck, err := NewReader().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	slides := deck.AllSlides()
+
+	pos := func(line, column int) model.SourcePos {
+		return model.SourcePos{Line: line, Column: column}
+	}
+	first := slides[0].Source
+	if first == nil || first.Slide.Start != pos(5, 1) || first.Slide.End != pos(5, 8) {
+		t.Fatalf("unexpected first slide range %+v", first)
+	}
+
+	sm := slides[1].Source
+	if sm.Slide.Start != pos(9, 1) || sm.Slide.End != pos(17, 9) {
+		t.Errorf("unexpected slide range %+v", sm.Slide)
+	}
+	if sm.Title == nil || sm.Title.Start != pos(14, 3) || sm.Title.End != pos(14, 9) {
+		t.Errorf("unexpected title range %+v", sm.Title)
+	}
+	// Columns count characters, not bytes
+	if sm.Subtitle == nil || sm.Subtitle.End != pos(15, 20) {
+		t.Errorf("unexpected subtitle range %+v", sm.Subtitle)
+	}
+	if len(sm.Body) != 1 || sm.Body[0].Start != pos(17, 1) {
+		t.Errorf("unexpected body ranges %+v", sm.Body)
+	}
+
+	// The paragraph split by the pause cue shares one range
+	if len(slides[1].Notes) != 3 || len(sm.Notes) != 3 {
+		t.Fatalf("expected 3 note ranges, got %+v for %+v", sm.Notes, slides[1].Notes)
+	}
+	if sm.Notes[0] != sm.Notes[1] || sm.Notes[0].Start != pos(10, 1) || sm.Notes[2].Start != pos(11, 1) {
+		t.Errorf("unexpected note ranges %+v", sm.Notes)
+	}
+}
+
+func TestSourceMapEmptyChunks(t *testing.T) {
+	// Back-to-back separators leave an empty chunk that must not shift
+	// the lines of the slides after it
+	deck, err := NewReader().Parse("# One\n\n---\n---\n\n# Two\n")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	slides := deck.AllSlides()
+	if len(slides) != 2 {
+		t.Fatalf("expected 2 slides, got %d", len(slides))
+	}
+	for i, want := range []int{1, 6} {
+		if title := slides[i].Source.Title; title == nil || title.Start.Line != want {
+			t.Errorf("slide %d: expected title on line %d, got %+v", i, want, title)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package marp
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// ParseReader parses Marp Markdown from rd into a Deck like Parse, reading
+// it one slide at a time. It stops with the context's error once ctx is
+// done.
+func (r *Reader) ParseReader(ctx context.Context, rd io.Reader) (*model.Deck, error) {
+	b, err := r.scan(ctx, rd, idDirectives{}, func(b *deckBuilder, slide model.Slide, newSection bool) error {
+		b.collect(slide, newSection)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b.finish(), nil
+}
+
+// ScanSlides parses Marp Markdown from rd and calls fn with each slide as
+// soon as it has been read, together with the section it belongs to. The
+// section is passed without its slides. No deck is built, so large files
+// can be listed without holding them in memory.
+//
+// Slide and section IDs are the ones Parse would assign. Parse gives
+// explicit _id and _sectionId directives priority over IDs generated for
+// earlier slides, so when rd is an io.Seeker ScanSlides first reads it
+// through to collect them, looking at nothing but the directives.
+// Otherwise an explicit ID that repeats an ID already generated for an
+// earlier slide is suffixed instead.
+//
+// Scanning stops at the first error returned by fn, which ScanSlides
+// returns, or with the context's error once ctx is done.
+func (r *Reader) ScanSlides(ctx context.Context, rd io.Reader, fn func(section model.Section, slide model.Slide) error) error {
+	var reserved idDirectives
+	if rs, ok := rd.(io.ReadSeeker); ok {
+		start, err := rs.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return fmt.Errorf("seeking marp source: %w", err)
+		}
+		reserved, err = r.scanIDs(ctx, rs)
+		if err != nil {
+			return err
+		}
+		if _, err := rs.Seek(start, io.SeekStart); err != nil {
+			return fmt.Errorf("seeking marp source: %w", err)
+		}
+	}
+	_, err := r.scan(ctx, rd, reserved, func(b *deckBuilder, slide model.Slide, _ bool) error {
+		return fn(b.section, slide)
+	})
+	return err
+}
+
+// StreamSlides implements model.SlideStreamer by scanning the file at
+// ref.Path with ScanSlides, so its IDs match Parse.
+func (r *Reader) StreamSlides(ctx context.Context, ref model.Ref, fn func(section model.Section, slide model.Slide) error) error {
+	if ref.Path == "" {
+		return fmt.Errorf("marp backend requires a file path")
+	}
+	f, err := os.Open(ref.Path)
+	if err != nil {
+		return fmt.Errorf("reading file %s: %w", ref.Path, err)
+	}
+	defer f.Close()
+	return r.ScanSlides(ctx, f, fn)
+}
+
+// scan reads the frontmatter and then each slide from rd, calling fn with
+// the deck builder, the slide and whether it starts a new section. The
+// explicit IDs in reserved, collected by scanIDs from the same source, are
+// set aside for the slides and sections that claim them. It returns the
+// builder, which holds the deck's metadata.
+func (r *Reader) scan(ctx context.Context, rd io.Reader, reserved idDirectives,
+	fn func(b *deckBuilder, slide model.Slide, newSection bool) error) (*deckBuilder, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	lr := &lineReader{r: bufio.NewReader(rd)}
+
+	// Malformed frontmatter is ignored, as Parse does
+	head, pending := readFrontmatter(lr)
+	frontmatter, _ := parseFrontmatterBlock(head)
+	b := newDeckBuilder(frontmatter, r.opts.Sections)
+	b.reserve(reserved)
+
+	err := readChunks(ctx, lr, head, pending, func(chunk string, start int) error {
+		ps := parseRawSlide(chunk, r.isDirective)
+		slide, spans := convertToSlide(ps)
+		slide.Source = sourceMap(chunk, start, spans, ps.notes)
+		return fn(b, slide, b.add(ps, &slide))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// scanIDs reads rd through and returns the explicit slide and section IDs
+// that deckBuilder.add would claim: those of _id directives, of _sectionId
+// directives on slides that start a section, and of section markers on
+// slides without _sectionId. Only the directives of each slide are read.
+func (r *Reader) scanIDs(ctx context.Context, rd io.Reader) (idDirectives, error) {
+	ids := idDirectives{slides: make(map[string]bool), sections: make(map[string]bool)}
+	lr := &lineReader{r: bufio.NewReader(rd)}
+	head, pending := readFrontmatter(lr)
+	rules := r.opts.Sections
+	first := true
+	err := readChunks(ctx, lr, head, pending, func(chunk string, _ int) error {
+		directives := make(map[string]string)
+		for _, m := range reDirective.FindAllStringSubmatch(chunk, -1) {
+			if r.isDirective(m[1]) {
+				directives[m[1]] = m[2]
+			}
+		}
+		ids.slides[directives["_id"]] = true
+
+		// Every slide with _sectionId starts a section, except past the
+		// first slide when each file is a section, which also ignores
+		// section markers
+		if first || !rules.PerFile {
+			section := directives["_sectionId"]
+			if marker, ok := directives[sectionMarker]; ok && section == "" && !rules.PerFile {
+				section, _, _ = strings.Cut(strings.TrimSpace(marker), " ")
+			}
+			ids.sections[section] = true
+		}
+		first = false
+		return nil
+	})
+	delete(ids.slides, "")
+	delete(ids.sections, "")
+	return ids, err
+}
+
+// readChunks calls fn with each slide chunk read from lr, which is past
+// the frontmatter head, and the file line it starts on. pending holds the
+// lines readFrontmatter read that belong to the body. Blank chunks are
+// skipped. It stops at the first error from fn, or with the context's
+// error once ctx is done.
+func readChunks(ctx context.Context, lr *lineReader, head string, pending []string,
+	fn func(chunk string, start int) error) error {
+	emit := func(chunk string, start int) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if strings.TrimSpace(chunk) == "" {
+			return nil
+		}
+		return fn(chunk, start)
+	}
+
+	first := strings.Count(head, "\n") + 1
+	c := chunker{line: first, start: first}
+	feed := func(line string) error {
+		if chunk, start, ok := c.add(line); ok {
+			return emit(chunk, start)
+		}
+		return nil
+	}
+	for _, line := range pending {
+		if err := feed(line); err != nil {
+			return err
+		}
+	}
+	for line, ok := lr.next(); ok; line, ok = lr.next() {
+		if err := feed(line); err != nil {
+			return err
+		}
+	}
+	if lr.err != nil {
+		return fmt.Errorf("reading marp source: %w", lr.err)
+	}
+
+	// Don't forget the last chunk
+	return emit(c.flush())
+}
+
+// readFrontmatter reads the frontmatter block from the start of lr, as
+// splitFrontmatter does for a whole file. It returns the block and the
+// lines it read that turned out to belong to the body: all of them when
+// the file does not start with frontmatter, or when it is never closed.
+func readFrontmatter(lr *lineReader) (head string, pending []string) {
+	var lines []string
+	opened := false
+	for {
+		line, ok := lr.next()
+		if !ok {
+			// No closing delimiter, so no frontmatter
+			return "", lines
+		}
+		lines = append(lines, line)
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case !opened && trimmed == "":
+		case !opened && trimmed == "---":
+			opened = true
+		case !opened:
+			return "", lines
+		case trimmed == "---":
+			return strings.Join(lines, "\n") + "\n", nil
+		}
+	}
+}
+
+// lineReader reads lines the way strings.Split(content, "\n") splits
+// them: without their newline, and with a last line after the final
+// newline even when it is empty.
+type lineReader struct {
+	r    *bufio.Reader
+	done bool
+	err  error // Read error other than io.EOF
+}
+
+// next returns the next line, or false once all lines have been read or
+// reading failed.
+func (lr *lineReader) next() (string, bool) {
+	if lr.done {
+		return "", false
+	}
+	line, err := lr.r.ReadString('\n')
+	if err != nil {
+		lr.done = true
+		if err != io.EOF {
+			lr.err = err
+			return "", false
+		}
+		return line, true
+	}
+	return line[:len(line)-1], true
+}
@@ -0,0 +1,147 @@
+package marp
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestParseReaderMatchesParse(t *testing.T) {
+	inputs := map[string]string{
+		"sample":             sampleMarp,
+		"empty":              "",
+		"frontmatter only":   "---\nmarp: true\ntitle: Empty\n---\n",
+		"no frontmatter":     "---\n\n# Title\n\n---\n---\n\n# Next",
+		"unclosed":           "\n---\nmarp: true\n\n# Title\n",
+		"separator in fence": "# Code\n\n```yaml\n---\n```\n\n---\n\n# After\n",
+		"explicit ids":       "# Intro\n\n---\n\n<!-- _id: intro -->\n\n# Other\n",
+	}
+	files, _ := filepath.Glob(filepath.Join("testdata", "roundtrip", "*.md"))
+	for _, path := range files {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("reading %s: %v", path, err)
+		}
+		inputs[path] = string(data)
+	}
+
+	for name, input := range inputs {
+		want, err := NewReader().Parse(input)
+		if err != nil {
+			t.Fatalf("%s: Parse error: %v", name, err)
+		}
+		got, err := NewReader().ParseReader(context.Background(), strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("%s: ParseReader error: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: ParseReader = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestScanSlides(t *testing.T) {
+	var ids, sections []string
+	err := NewReader().ScanSlides(context.Background(), strings.NewReader(sampleMarp),
+		func(section model.Section, slide model.Slide) error {
+			if section.Slides != nil {
+				t.Errorf("expected section %q passed without slides", section.ID)
+			}
+			ids = append(ids, slide.ID)
+			sections = append(sections, section.ID)
+			return nil
+		})
+	if err != nil {
+		t.Fatalf("ScanSlides error: %v", err)
+	}
+
+	deck, _ := NewReader().Parse(sampleMarp)
+	var wantIDs, wantSections []string
+	for _, section := range deck.Sections {
+		for _, slide := range section.Slides {
+			wantIDs = append(wantIDs, slide.ID)
+			wantSections = append(wantSections, section.ID)
+		}
+	}
+	if !reflect.DeepEqual(ids, wantIDs) || !reflect.DeepEqual(sections, wantSections) {
+		t.Errorf("ScanSlides yielded %v in %v, want %v in %v", ids, sections, wantIDs, wantSections)
+	}
+}
+
+func TestScanSlidesExplicitIDs(t *testing.T) {
+	// The explicit IDs come after the slides whose titles would give the
+	// same IDs, and Parse still gives them priority
+	input := "# Intro\n\n---\n\n<!-- _class: lead -->\n\n# Part\n\n---\n\n" +
+		"<!-- _id: intro -->\n\n# Other\n\n---\n\n<!-- _class: lead -->\n<!-- _sectionId: part -->\n\n# Again\n\n---\n\n" +
+		"<!-- section: intro Later -->\n\n# Later\n\n---\n\n<!-- _sectionId: default -->\n\n# Last\n"
+	path := filepath.Join(t.TempDir(), "deck.md")
+	if err := os.WriteFile(path, []byte(input), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rules := range []model.SectionRules{{}, {Markers: true}, {PerFile: true}} {
+		opts := ReaderOptions{Sections: rules}
+		var got []string
+		err := NewReaderWithOptions(opts).StreamSlides(context.Background(), model.Ref{Path: path},
+			func(section model.Section, slide model.Slide) error {
+				got = append(got, section.ID+"/"+slide.ID)
+				return nil
+			})
+		if err != nil {
+			t.Fatalf("%+v: StreamSlides error: %v", rules, err)
+		}
+
+		deck, _ := NewReaderWithOptions(opts).Parse(input)
+		var want []string
+		for _, section := range deck.Sections {
+			for _, slide := range section.Slides {
+				want = append(want, section.ID+"/"+slide.ID)
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%+v: StreamSlides yielded %v, want %v", rules, got, want)
+		}
+		if rules.IsZero() && want[2] != "part-2/intro" {
+			t.Errorf("expected the explicit IDs to take priority, got %v", want)
+		}
+	}
+}
+
+func TestScanSlidesStops(t *testing.T) {
+	input := "# One\n\n---\n\n# Two\n\n---\n\n# Three\n"
+
+	// An error from the callback ends the scan
+	errEnough := errors.New("enough")
+	calls := 0
+	err := NewReader().ScanSlides(context.Background(), strings.NewReader(input),
+		func(model.Section, model.Slide) error {
+			calls++
+			return errEnough
+		})
+	if !errors.Is(err, errEnough) || calls != 1 {
+		t.Errorf("expected the scan to stop after one slide, got %d calls and %v", calls, err)
+	}
+
+	// So does cancelling the context
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = NewReader().ScanSlides(ctx, strings.NewReader(input),
+		func(model.Section, model.Slide) error {
+			calls++
+			cancel()
+			return nil
+		})
+	if !errors.Is(err, context.Canceled) || calls != 1 {
+		t.Errorf("expected the scan to stop on cancel, got %d calls and %v", calls, err)
+	}
+
+	if _, err := NewReader().ParseReader(ctx, strings.NewReader(input)); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected ParseReader to fail with a cancelled context, got %v", err)
+	}
+}
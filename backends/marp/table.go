@@ -0,0 +1,173 @@
+package marp
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// parseTable parses the lines of a pipe table: a header row, a delimiter
+// row such as | --- | :-: | and any number of body rows. It returns false
+// when the lines are not a well-formed table. Short rows are padded with
+// empty cells and long ones cut to the header width, as Markdown renders
+// them.
+func parseTable(lines []string) (*model.Table, bool) {
+	if len(lines) < 2 {
+		return nil, false
+	}
+	header := splitTableRow(lines[0])
+	align, ok := parseAlignRow(lines[1])
+	if !ok || len(align) != len(header) {
+		return nil, false
+	}
+
+	table := &model.Table{Header: tableCells(header, len(header))}
+	for _, a := range align {
+		if a != model.AlignDefault {
+			table.Align = align
+			break
+		}
+	}
+	for _, line := range lines[2:] {
+		table.Rows = append(table.Rows, tableCells(splitTableRow(line), len(header)))
+	}
+	return table, true
+}
+
+// splitTableRow splits a table row into its raw cell text. Pipes escaped
+// with a backslash or inside code spans do not end a cell.
+func splitTableRow(line string) []string {
+	var cells []string
+	for _, at := range tableCellSpans(line) {
+		cells = append(cells, strings.TrimSpace(line[at.start:at.end]))
+	}
+	return cells
+}
+
+// tableCellSpans returns where the raw text of each cell of a table row
+// stands in line, without the surrounding space. Blank cells span the
+// whole space between their pipes.
+func tableCellSpans(line string) []span {
+	start := len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+	end := len(strings.TrimRightFunc(line, unicode.IsSpace))
+	if start < end && line[start] == '|' {
+		start++
+	}
+	if end > start && line[end-1] == '|' && (end-2 < start || line[end-2] != '\\') {
+		end--
+	}
+
+	var cells []span
+	cell := func(from, to int) {
+		raw := line[from:to]
+		lead := len(raw) - len(strings.TrimLeftFunc(raw, unicode.IsSpace))
+		if lead == len(raw) {
+			cells = append(cells, span{start: from, end: to})
+			return
+		}
+		cells = append(cells, span{start: from + lead, end: from + len(strings.TrimRightFunc(raw, unicode.IsSpace))})
+	}
+	from := start
+	for i := start; i < end; i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '`':
+			if _, n, ok := parseCodeSpan(line[i:end]); ok {
+				i += n - 1
+			}
+		case '|':
+			cell(from, i)
+			from = i + 1
+		}
+	}
+	cell(from, end)
+	return cells
+}
+
+// parseAlignRow parses a delimiter row into column alignments.
+func parseAlignRow(line string) ([]model.Alignment, bool) {
+	if !strings.Contains(line, "-") {
+		return nil, false
+	}
+	cells := splitTableRow(line)
+	align := make([]model.Alignment, len(cells))
+	for i, cell := range cells {
+		dashes := strings.Trim(cell, ":")
+		if dashes == "" || strings.Trim(dashes, "-") != "" {
+			return nil, false
+		}
+		left, right := strings.HasPrefix(cell, ":"), strings.HasSuffix(cell, ":")
+		switch {
+		case left && right:
+			align[i] = model.AlignCenter
+		case left:
+			align[i] = model.AlignLeft
+		case right:
+			align[i] = model.AlignRight
+		}
+	}
+	return align, true
+}
+
+// tableCells parses raw cell text into cells, padded or cut to width.
+func tableCells(raw []string, width int) []model.TableCell {
+	cells := make([]model.TableCell, width)
+	for i := 0; i < width && i < len(raw); i++ {
+		text := strings.ReplaceAll(raw[i], `\|`, "|")
+		cell := model.TableCell{Text: text}
+		runs := parseInline(text)
+		if plain := model.RunsText(runs); plain != text {
+			cell = model.TableCell{Text: plain, Runs: runs}
+		}
+		cells[i] = cell
+	}
+	return cells
+}
+
+// renderTable renders a table as a pipe table with one space of padding
+// around each cell.
+func renderTable(table *model.Table) string {
+	var b strings.Builder
+	writeRow := func(cells []model.TableCell) {
+		b.WriteString("|")
+		for i := 0; i < table.Columns(); i++ {
+			text := ""
+			if i < len(cells) {
+				text = cellText(&cells[i])
+			}
+			b.WriteString(" " + text + " |")
+		}
+		b.WriteString("\n")
+	}
+
+	writeRow(table.Header)
+	b.WriteString("|")
+	for i := 0; i < table.Columns(); i++ {
+		switch table.ColumnAlign(i) {
+		case model.AlignLeft:
+			b.WriteString(" :--- |")
+		case model.AlignCenter:
+			b.WriteString(" :---: |")
+		case model.AlignRight:
+			b.WriteString(" ---: |")
+		default:
+			b.WriteString(" --- |")
+		}
+	}
+	b.WriteString("\n")
+	for _, row := range table.Rows {
+		writeRow(row)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// cellText returns the Markdown for a cell, with pipes escaped.
+func cellText(cell *model.TableCell) string {
+	text := cell.Text
+	if cell.HasRuns() {
+		text = renderInline(cell.Runs)
+	}
+	return strings.ReplaceAll(text, "|", `\|`)
+}
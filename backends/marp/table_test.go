@@ -0,0 +1,107 @@
+package marp
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestParseTable(t *testing.T) {
+	input := `# Data
+
+| Name | Role | Note |
+|:-----|:----:|-----:|
+| **Ada** | Dev | a \| b |
+| Bob |
+`
+	deck, err := NewReader().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	body := deck.Sections[0].Slides[0].Body
+	if len(body) != 1 || body[0].Kind != model.BlockTable {
+		t.Fatalf("expected one table block, got %+v", body)
+	}
+	table := body[0].Table
+	if got := []model.Alignment{model.AlignLeft, model.AlignCenter, model.AlignRight}; !reflect.DeepEqual(table.Align, got) {
+		t.Errorf("Align = %v, want %v", table.Align, got)
+	}
+	if table.Header[2].Text != "Note" {
+		t.Errorf("unexpected header: %+v", table.Header)
+	}
+	if len(table.Rows) != 2 {
+		t.Fatalf("expected 2 rows, got %+v", table.Rows)
+	}
+	ada := table.Rows[0]
+	if ada[0].Text != "Ada" || !ada[0].Runs[0].Bold || ada[2].Text != "a | b" {
+		t.Errorf("unexpected first row: %+v", ada)
+	}
+	if bob := table.Rows[1]; len(bob) != 3 || bob[0].Text != "Bob" || bob[2].Text != "" {
+		t.Errorf("expected short row padded to 3 cells, got %+v", bob)
+	}
+}
+
+func TestParseTableWithoutDelimiterRow(t *testing.T) {
+	input := "# Pipes\n\n| not | a table |\n| still | not |\n"
+	deck, _ := NewReader().Parse(input)
+
+	body := deck.Sections[0].Slides[0].Body
+	if len(body) != 1 || body[0].Kind != model.BlockParagraph ||
+		body[0].Text != "| not | a table |\n| still | not |" {
+		t.Errorf("expected pipe lines kept as a paragraph, got %+v", body)
+	}
+}
+
+func TestRenderTable(t *testing.T) {
+	block := model.NewTable([]string{"Key", "Value"}, [][]string{{"a|b", "1"}, {"c"}})
+	block.Table.Align = []model.Alignment{model.AlignDefault, model.AlignRight}
+
+	want := "| Key | Value |\n| --- | ---: |\n| a\\|b | 1 |\n| c |  |"
+	if got := renderTable(block.Table); got != want {
+		t.Errorf("renderTable = %q, want %q", got, want)
+	}
+}
+
+func TestPatchTableCell(t *testing.T) {
+	input := "# Data\n\nIntro text.\n\n|Name|Role|\n|----|----|\n|Ada|Dev|\n"
+	deck, _ := NewReader().Parse(input)
+
+	desired, _ := NewReader().Parse(input)
+	desired.Sections[0].Slides[0].Body[1].Table.Rows[0][1].Text = "Lead"
+	diff := model.ComputeDiff(deck, desired)
+	if diff.ChangeCount() != 1 || !strings.HasSuffix(diff.Changes[0].Path, "/body/1/table/rows/0/1") {
+		t.Fatalf("expected a single cell change, got %+v", diff.Changes)
+	}
+
+	// Only the cell changes; the table keeps its padding and delimiter row
+	want := strings.Replace(input, "|Ada|Dev|", "|Ada|Lead|", 1)
+	if got := applyPatch(t, input, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
+
+func TestPatchTableCellKeepsAlignment(t *testing.T) {
+	input := "# Data\n\n|  Name  | Count |\n|:--|--:|\n| Ada | |\n| Bob |\n"
+	desired, _ := NewReader().Parse(input)
+	table := desired.Sections[0].Slides[0].Body[0].Table
+	table.Rows[0][1].Text = "3"
+	table.Header[0].Text = "Who"
+	body := desired.Sections[0].Slides[0].Body
+
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/data/body", nil, body))
+	want := "# Data\n\n|  Who  | Count |\n|:--|--:|\n| Ada | 3 |\n| Bob |\n"
+	if got := applyPatch(t, input, diff); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+
+	// A cell missing from a short row cannot be edited in place
+	table.Rows[1][1].Text = "1"
+	got := applyPatch(t, input, diff)
+	if !strings.Contains(got, "| Bob | 1 |") {
+		t.Errorf("expected the table re-rendered, got:\n%s", got)
+	}
+}
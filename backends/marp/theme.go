@@ -0,0 +1,267 @@
+package marp
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// Theme fields map to CSS as follows, both when reading a style and when
+// generating one:
+//
+//	Background  section { background-color } (or a plain background color)
+//	Font        section { font-family }
+//	Primary     h1 { color }
+//	Secondary   h2 { color }
+//
+// Marp scopes theme CSS to slides, so :root in a theme file means section.
+
+// builtinThemes are the themes that ship with Marp and have no CSS file
+// next to the deck.
+var builtinThemes = []string{"default", "gaia", "uncover"}
+
+// themeNameRegex matches the @theme comment naming a Marp theme CSS file.
+var themeNameRegex = regexp.MustCompile(`@theme\s+([^\s*]+)`)
+
+// cssRule is a CSS rule: its selectors and its declarations in order.
+type cssRule struct {
+	selectors []string
+	decls     [][2]string // property, value
+}
+
+// parseCSS reads the plain rules from css. Comments are dropped, and
+// at-rules such as @import or @media are skipped along with their blocks.
+func parseCSS(css string) []cssRule {
+	css = stripCSSComments(css)
+	var rules []cssRule
+	for css != "" {
+		open := strings.IndexAny(css, "{;")
+		if open < 0 {
+			break
+		}
+		prelude := strings.TrimSpace(css[:open])
+		if css[open] == ';' {
+			// A statement at-rule such as @import
+			css = css[open+1:]
+			continue
+		}
+		end := matchingBrace(css, open)
+		block := css[open+1 : end]
+		css = css[min(end+1, len(css)):]
+		if strings.HasPrefix(prelude, "@") {
+			continue
+		}
+
+		rule := cssRule{}
+		for _, sel := range strings.Split(prelude, ",") {
+			if sel = strings.Join(strings.Fields(sel), " "); sel != "" {
+				rule.selectors = append(rule.selectors, sel)
+			}
+		}
+		for _, decl := range strings.Split(block, ";") {
+			prop, value, ok := strings.Cut(decl, ":")
+			if !ok {
+				continue
+			}
+			value = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(value), "!important"))
+			rule.decls = append(rule.decls, [2]string{strings.ToLower(strings.TrimSpace(prop)), value})
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// stripCSSComments removes /* ... */ comments from css.
+func stripCSSComments(css string) string {
+	var b strings.Builder
+	for {
+		start := strings.Index(css, "/*")
+		if start < 0 {
+			b.WriteString(css)
+			return b.String()
+		}
+		b.WriteString(css[:start])
+		end := strings.Index(css[start+2:], "*/")
+		if end < 0 {
+			return b.String()
+		}
+		css = css[start+2+end+2:]
+	}
+}
+
+// matchingBrace returns the index of the brace closing the one at open,
+// or len(css) when it is never closed.
+func matchingBrace(css string, open int) int {
+	depth := 0
+	for i := open; i < len(css); i++ {
+		switch css[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(css)
+}
+
+// themeFromCSS returns a Theme with the fields that css sets. Later rules
+// win, as they do in the browser.
+func themeFromCSS(css string) model.Theme {
+	var theme model.Theme
+	for _, rule := range parseCSS(css) {
+		root := slices.Contains(rule.selectors, "section") || slices.Contains(rule.selectors, ":root")
+		for _, decl := range rule.decls {
+			prop, value := decl[0], decl[1]
+			switch {
+			case root && prop == "background-color":
+				theme.Background = value
+			case root && prop == "background" && !strings.ContainsAny(value, " (/"):
+				theme.Background = value
+			case root && prop == "font-family":
+				theme.Font = value
+			case prop == "color" && slices.Contains(rule.selectors, "h1"):
+				theme.Primary = value
+			case prop == "color" && slices.Contains(rule.selectors, "h2"):
+				theme.Secondary = value
+			}
+		}
+	}
+	return theme
+}
+
+// mergeTheme returns the colors and font of base with the ones that over
+// sets replacing them.
+func mergeTheme(base, over model.Theme) model.Theme {
+	if over.Primary != "" {
+		base.Primary = over.Primary
+	}
+	if over.Secondary != "" {
+		base.Secondary = over.Secondary
+	}
+	if over.Background != "" {
+		base.Background = over.Background
+	}
+	if over.Font != "" {
+		base.Font = over.Font
+	}
+	return base
+}
+
+// themeCSS returns the CSS that sets the fields of want that differ from
+// have, or "" when none do.
+func themeCSS(want, have model.Theme) string {
+	var b strings.Builder
+	rule := func(selector string, decls ...string) {
+		var body []string
+		for i := 0; i < len(decls); i += 2 {
+			if decls[i+1] != "" {
+				body = append(body, "  "+decls[i]+": "+decls[i+1]+";\n")
+			}
+		}
+		if len(body) > 0 {
+			b.WriteString(selector + " {\n" + strings.Join(body, "") + "}\n")
+		}
+	}
+	changed := func(w, h string) string {
+		if w == h {
+			return ""
+		}
+		return w
+	}
+	rule("section",
+		"background-color", changed(want.Background, have.Background),
+		"font-family", changed(want.Font, have.Font))
+	rule("h1", "color", changed(want.Primary, have.Primary))
+	rule("h2", "color", changed(want.Secondary, have.Secondary))
+	return b.String()
+}
+
+// themeStyle returns the style to write for theme: its raw style, followed
+// by generated CSS for the fields that neither that style nor file, the
+// fields its theme CSS file sets, set to the same value.
+func themeStyle(theme *model.Theme, file model.Theme) string {
+	style := theme.GetCustom("style", "")
+	have := mergeTheme(file, themeFromCSS(style))
+	generated := themeCSS(*theme, have)
+	if generated == "" {
+		return style
+	}
+	if style == "" {
+		return generated
+	}
+	return strings.TrimRight(style, "\n") + "\n" + generated
+}
+
+// findThemeFile returns the CSS file defining the Marp theme name, looked
+// up in dir and its themes subdirectory, or "" if there is none.
+func findThemeFile(dir, name string) string {
+	if name == "" || slices.Contains(builtinThemes, name) {
+		return ""
+	}
+	for _, d := range []string{dir, filepath.Join(dir, "themes")} {
+		files, _ := filepath.Glob(filepath.Join(d, "*.css"))
+		for _, path := range files {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				continue
+			}
+			if m := themeNameRegex.FindStringSubmatch(string(data)); m != nil && m[1] == name {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// loadThemeFile returns the theme fields set by the CSS file defining the
+// Marp theme name next to the deck at path, and whether there is one.
+func loadThemeFile(path, name string) (model.Theme, bool) {
+	file := findThemeFile(filepath.Dir(path), name)
+	if file == "" {
+		return model.Theme{}, false
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return model.Theme{}, false
+	}
+	return themeFromCSS(string(data)), true
+}
+
+// applyThemeFile fills the theme fields of deck, read from the file at
+// path, that its style leaves unset from the CSS file defining its theme.
+func applyThemeFile(deck *model.Deck, path string) {
+	if deck.Theme == nil {
+		return
+	}
+	file, ok := loadThemeFile(path, deck.Theme.Name)
+	if !ok {
+		return
+	}
+	theme := mergeTheme(file, *deck.Theme)
+	theme.Name, theme.Custom = deck.Theme.Name, deck.Theme.Custom
+	*deck.Theme = theme
+}
+
+// withThemeFiles returns a copy of w that knows the theme CSS files next
+// to the deck at path for the themes of decks, so that it only writes CSS
+// for the theme fields they do not set.
+func (w *Writer) withThemeFiles(path string, decks ...*model.Deck) *Writer {
+	withFiles := *w
+	withFiles.themeFiles = make(map[string]model.Theme)
+	for _, deck := range decks {
+		if deck.Theme == nil {
+			continue
+		}
+		if file, ok := loadThemeFile(path, deck.Theme.Name); ok {
+			withFiles.themeFiles[deck.Theme.Name] = file
+		}
+	}
+	return &withFiles
+}
@@ -0,0 +1,139 @@
+package marp
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestThemeFromStyle(t *testing.T) {
+	input := `---
+marp: true
+theme: default
+style: |
+  @import url('https://fonts.example.com/inter.css');
+  /* h1 { color: #000; } */
+  section {
+    background: #fafafa;
+    font-family: 'Inter', sans-serif;
+  }
+  @media print {
+    h1 { color: #111; }
+  }
+  h1, h2 { color: #222 !important; }
+  h2 { color: #333; }
+---
+
+# Title
+`
+	deck, err := NewReader().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	theme := deck.Theme
+	if theme.Name != "default" || theme.Background != "#fafafa" || theme.Font != "'Inter', sans-serif" ||
+		theme.Primary != "#222" || theme.Secondary != "#333" {
+		t.Errorf("unexpected theme %+v", theme)
+	}
+	if !strings.Contains(theme.GetCustom("style", ""), "@media print") {
+		t.Errorf("expected the raw style to be kept, got %q", theme.GetCustom("style", ""))
+	}
+
+	// The fields match the style, so it is written back unchanged
+	head, _ := splitFrontmatter(input)
+	if out := NewWriter().renderFrontmatter(deck); out != head+"\n" {
+		t.Errorf("expected the style kept as is, got:\n%s", out)
+	}
+}
+
+func TestWriteTheme(t *testing.T) {
+	deck := &model.Deck{
+		Theme: model.DefaultTheme(),
+		Sections: []model.Section{{ID: "default", Slides: []model.Slide{
+			{ID: "title", Title: "Title"},
+		}}},
+	}
+	out := NewWriter().Encode(deck)
+	if !strings.Contains(out, "  section {\n    background-color: #FFFFFF;\n    font-family: sans-serif;\n  }\n") ||
+		!strings.Contains(out, "  h1 {\n    color: #2196F3;\n  }\n") {
+		t.Fatalf("expected a generated style, got:\n%s", out)
+	}
+	back, err := NewReader().Parse(out)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if got := back.Theme; got.Name != "default" || got.Primary != "#2196F3" || got.Secondary != "#FFC107" ||
+		got.Background != "#FFFFFF" || got.Font != "sans-serif" {
+		t.Errorf("theme did not round-trip: %+v", back.Theme)
+	}
+
+	// A changed color overrides the one in the raw style
+	back.Theme.Primary = "#E91E63"
+	out = NewWriter().Encode(back)
+	if !strings.Contains(out, "    color: #FFC107;\n  }\n  h1 {\n    color: #E91E63;\n  }\n") {
+		t.Errorf("expected an overriding rule after the style, got:\n%s", out)
+	}
+	again, _ := NewReader().Parse(out)
+	if again.Theme.Primary != "#E91E63" {
+		t.Errorf("expected the new primary color, got %q", again.Theme.Primary)
+	}
+}
+
+func TestThemeFile(t *testing.T) {
+	dir := t.TempDir()
+	source := "---\nmarp: true\ntheme: corporate\nstyle: |\n  h1 { color: navy; }\n---\n\n# Title\n"
+	css := "/* @theme corporate */\n\n:root {\n  background-color: #101010;\n  font-family: Georgia;\n}\nh1 { color: white; }\n"
+	path := filepath.Join(dir, "deck.md")
+	if err := os.WriteFile(path, []byte(source), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "themes"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "themes", "corporate.css"), []byte(css), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	deck, err := NewReader().ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile error: %v", err)
+	}
+	// The deck's style wins over its theme file
+	theme := deck.Theme
+	if theme.Background != "#101010" || theme.Font != "Georgia" || theme.Primary != "navy" {
+		t.Errorf("unexpected theme %+v", theme)
+	}
+	// The theme file's path stays out of the model
+	if len(theme.Custom) != 1 {
+		t.Errorf("expected only the style in the theme's custom fields, got %v", theme.Custom)
+	}
+
+	// Colors from the theme file are not copied into the deck written next
+	// to it, but are elsewhere
+	head, _ := splitFrontmatter(source)
+	if out := NewWriter().withThemeFiles(path, deck).renderFrontmatter(deck); out != head+"\n" {
+		t.Errorf("expected the deck written back unchanged, got:\n%s", out)
+	}
+	if out := NewWriter().renderFrontmatter(deck); !strings.Contains(out, "#101010") {
+		t.Errorf("expected the theme file's colors written away from it, got:\n%s", out)
+	}
+
+	// Editing the deck through the backend keeps the frontmatter as written
+	diff := model.NewDiff("test")
+	diff.AddChange(model.NewUpdateChange("slides/title/body", nil, []model.Block{model.NewParagraph("Text")}))
+	if err := NewBackend().Apply(context.Background(), model.Ref{Path: path}, diff); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.HasPrefix(string(data), head) {
+		t.Errorf("expected the frontmatter kept, got:\n%s", data)
+	}
+
+	// Built-in themes have no file to look for
+	if findThemeFile(dir, "gaia") != "" {
+		t.Error("expected no theme file for a built-in theme")
+	}
+}
@@ -3,13 +3,18 @@ package marp
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strings"
 
+	"gopkg.in/yaml.v3"
+
 	"github.com/grokify/slidekit/model"
 )
 
 // Writer converts a Deck back to Marp Markdown format.
-type Writer struct{}
+type Writer struct {
+	themeFiles map[string]model.Theme // Fields set by theme CSS files, by theme name
+}
 
 // NewWriter creates a new Marp writer.
 func NewWriter() *Writer {
@@ -30,14 +35,20 @@ func (w *Writer) Encode(deck *model.Deck) string {
 	w.writeFrontmatter(&b, deck)
 
 	// Write slides
+	ids := w.explicitIDs(deck, idDirectives{})
 	first := true
-	for _, section := range deck.Sections {
-		for _, slide := range section.Slides {
+	for i := range deck.Sections {
+		section := &deck.Sections[i]
+		for j := range section.Slides {
 			if !first {
 				b.WriteString("\n---\n\n")
 			}
 			first = false
-			w.writeSlide(&b, &slide)
+			opens := section
+			if j > 0 {
+				opens = nil
+			}
+			w.writeSlide(&b, &section.Slides[j], opens, ids)
 		}
 	}
 
@@ -45,49 +56,125 @@ func (w *Writer) Encode(deck *model.Deck) string {
 }
 
 func (w *Writer) writeFrontmatter(b *strings.Builder, deck *model.Deck) {
-	b.WriteString("---\n")
-	b.WriteString("marp: true\n")
+	if out, ok := encodeYAML(w.frontmatterFields(deck)); ok {
+		b.WriteString("---\n")
+		b.WriteString(out)
+		b.WriteString("---\n\n")
+	}
+}
 
-	if deck.Theme != nil && deck.Theme.Name != "" {
-		fmt.Fprintf(b, "theme: %s\n", deck.Theme.Name)
+// frontmatterFields returns the frontmatter fields written for deck, in
+// order, as a YAML mapping.
+func (w *Writer) frontmatterFields(deck *model.Deck) *yaml.Node {
+	fields := &yaml.Node{Kind: yaml.MappingNode}
+	add := func(key string, value any, style yaml.Style) {
+		var node yaml.Node
+		if err := node.Encode(value); err != nil {
+			return // Values decoded from YAML or JSON always encode
+		}
+		if style != 0 {
+			node.Style = style
+		}
+		fields.Content = append(fields.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Value: key}, &node)
+	}
+	addString := func(key, value string) {
+		if value != "" {
+			add(key, value, 0)
+		}
 	}
 
-	b.WriteString("paginate: true\n")
+	add("marp", true, 0)
+	if deck.Theme != nil {
+		addString("theme", deck.Theme.Name)
+	}
 
-	// Write custom style if present
+	// The reader falls back to the first slide's title, so only a title
+	// that differs from it needs a directive.
+	if slides := deck.AllSlides(); len(slides) == 0 || deck.Title != slides[0].Title {
+		addString("title", deck.Title)
+	}
+	addString("author", deck.Meta.Author)
+	addString("date", deck.Meta.Date)
+	addString("description", deck.Meta.Description)
+	addString("keywords", strings.Join(deck.Meta.Keywords, ", "))
+	addString("url", deck.Meta.URL)
+	addString("image", deck.Meta.Image)
+	addString("size", deck.Meta.Size)
+	addString("transition", deck.Transition)
+	if h := deck.HeaderFooter; h != nil {
+		if h.Header != nil {
+			add("header", *h.Header, 0)
+		}
+		if h.Footer != nil {
+			add("footer", *h.Footer, 0)
+		}
+		if h.PageNumber != nil {
+			add("paginate", *h.PageNumber, 0)
+		}
+	}
+
+	// Write remaining global directives (headingDivider, math, ...)
+	keys := make([]string, 0, len(deck.Meta.Custom))
+	for k := range deck.Meta.Custom {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(k, deck.Meta.Custom[k], 0)
+	}
+
+	// Write the custom style, extended with CSS for any theme colors or
+	// font it does not already set
 	if deck.Theme != nil {
-		style := deck.Theme.GetCustom("style", "")
-		if style != "" {
-			b.WriteString("style: |\n")
-			for _, line := range strings.Split(style, "\n") {
-				b.WriteString(line)
-				b.WriteString("\n")
-			}
+		if style := themeStyle(deck.Theme, w.themeFiles[deck.Theme.Name]); style != "" {
+			add("style", style, yaml.LiteralStyle)
 		}
 	}
+	return fields
+}
 
-	b.WriteString("---\n\n")
+// encodeYAML encodes node as YAML indented by two spaces.
+func encodeYAML(node *yaml.Node) (string, bool) {
+	var out strings.Builder
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil || enc.Close() != nil {
+		return "", false
+	}
+	return out.String(), true
 }
 
-func (w *Writer) writeSlide(b *strings.Builder, slide *model.Slide) {
-	// Write directives
-	switch slide.Layout {
-	case model.LayoutSection:
-		b.WriteString("<!-- _class: section-divider -->\n")
-		b.WriteString("<!-- _paginate: false -->\n\n")
-	case model.LayoutTitle:
-		b.WriteString("<!-- _class: lead -->\n")
-		b.WriteString("<!-- _paginate: false -->\n\n")
+// writeSlide writes a single slide. opens is the section the slide starts,
+// if any, whose ID is then recorded on the slide when ids lists it.
+func (w *Writer) writeSlide(b *strings.Builder, slide *model.Slide, opens *model.Section, ids idDirectives) {
+	// Write directives, starting with the layout's class unless the slide
+	// has a class of its own
+	class := layoutClass(slide.Layout)
+	if _, ok := slide.Directives["_class"]; ok {
+		class = ""
+	}
+	if class != "" {
+		b.WriteString(directiveComment("_class", class) + "\n")
+	}
+	directives := slideDirectives(slide, opens, ids)
+	keys := make([]string, 0, len(directives))
+	for k := range directives {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		b.WriteString(directiveComment(k, directives[k]))
+		b.WriteString("\n")
+	}
+	if class != "" || len(keys) > 0 {
+		b.WriteString("\n")
 	}
 
 	// Write speaker notes before content
 	if len(slide.Notes) > 0 {
-		b.WriteString("<!--\n")
-		for _, note := range slide.Notes {
-			b.WriteString(note.Text)
-			b.WriteString("\n")
-		}
-		b.WriteString("-->\n\n")
+		w.writeNotes(b, slide.Notes)
+		b.WriteString("\n")
 	}
 
 	// Write title
@@ -100,45 +187,195 @@ func (w *Writer) writeSlide(b *strings.Builder, slide *model.Slide) {
 		fmt.Fprintf(b, "## %s\n", slide.Subtitle)
 	}
 
-	// Write body blocks
-	if slide.Title != "" || slide.Subtitle != "" {
+	// Write background images, body blocks, then the content slots
+	if bg := slide.Background; bg != nil && len(bg.Images) > 0 {
+		if slide.Title != "" || slide.Subtitle != "" {
+			b.WriteString("\n")
+		}
+		b.WriteString(renderBackgrounds(bg.Images))
 		b.WriteString("\n")
 	}
-	for _, block := range slide.Body {
-		w.writeBlock(b, &block)
+	hasBackgrounds := slide.Background != nil && len(slide.Background.Images) > 0
+	if len(slide.Body) > 0 {
+		if slide.Title != "" || slide.Subtitle != "" || hasBackgrounds {
+			b.WriteString("\n")
+		}
+		w.writeBlocks(b, slide.Body)
+	}
+	if len(slide.Slots) > 0 {
+		if slide.Title != "" || slide.Subtitle != "" || hasBackgrounds || len(slide.Body) > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(w.renderSlots(slide.Slots))
+		b.WriteString("\n")
+	}
+}
+
+// writeBlocks writes blocks separated by blank lines, except within lists.
+func (w *Writer) writeBlocks(b *strings.Builder, blocks []model.Block) {
+	for i := range blocks {
+		if i > 0 && needsBlankLine(&blocks[i-1], &blocks[i]) {
+			b.WriteString("\n")
+		}
+		w.writeBlock(b, &blocks[i])
 	}
 }
 
+// slideDirectives returns the directives written for a slide: its own plus
+// the _id and, for the first slide of a section, _sectionId that persist
+// the IDs listed in ids, the _backgroundColor and _backgroundImage of its
+// background, and its header, footer and paginate settings.
+func slideDirectives(slide *model.Slide, opens *model.Section, ids idDirectives) map[string]string {
+	directives := make(map[string]string, len(slide.Directives)+2)
+	for k, v := range slide.Directives {
+		directives[k] = v
+	}
+	if ids.slides[slide.ID] {
+		directives["_id"] = slide.ID
+	}
+	if opens != nil && ids.sections[opens.ID] {
+		directives["_sectionId"] = opens.ID
+	}
+	if bg := slide.Background; bg != nil {
+		if bg.Color != "" {
+			directives["_backgroundColor"] = bg.Color
+		}
+		if bg.Image != "" {
+			directives["_backgroundImage"] = bg.Image
+		}
+	}
+	headerFooterDirectives(directives, slide.HeaderFooter, "_")
+	headerFooterDirectives(directives, slide.HeaderFooterFrom, "")
+	return directives
+}
+
+// idDirectives lists the slide and section IDs written out as, or read
+// from, _id and _sectionId directives.
+type idDirectives struct {
+	slides, sections map[string]bool
+}
+
+// explicitIDs returns the IDs of deck that need directives because the
+// reader would not derive them from the slide and section titles, given
+// that the IDs in keep are written out as well. Sections whose first slide
+// would not start a section when read always keep their ID.
+func (w *Writer) explicitIDs(deck *model.Deck, keep idDirectives) idDirectives {
+	ids := idDirectives{slides: make(map[string]bool), sections: make(map[string]bool)}
+	var slideIDs, slideTitles, slideKeep []string
+	var sectionIDs, sectionTitles, sectionKeep []string
+	for i := range deck.Sections {
+		section := &deck.Sections[i]
+		if len(section.Slides) == 0 {
+			continue
+		}
+		title, ok := w.readSectionTitle(&section.Slides[0], len(sectionIDs) == 0)
+		switch {
+		case !ok:
+			ids.sections[section.ID] = section.ID != ""
+		default:
+			sectionIDs = append(sectionIDs, section.ID)
+			sectionTitles = append(sectionTitles, title)
+			sectionKeep = append(sectionKeep, kept(keep.sections, section.ID))
+		}
+		for j := range section.Slides {
+			slide := &section.Slides[j]
+			slideIDs = append(slideIDs, slide.ID)
+			slideTitles = append(slideTitles, slide.Title)
+			slideKeep = append(slideKeep, kept(keep.slides, slide.ID))
+		}
+	}
+	for _, id := range model.ExplicitIDs(slideIDs, slideTitles, slideKeep, "slide") {
+		if id != "" {
+			ids.slides[id] = true
+		}
+	}
+	for _, id := range model.ExplicitIDs(sectionIDs, sectionTitles, sectionKeep, "section") {
+		if id != "" {
+			ids.sections[id] = true
+		}
+	}
+	return ids
+}
+
+// kept returns id if keep lists it, and "" otherwise.
+func kept(keep map[string]bool, id string) string {
+	if keep[id] {
+		return id
+	}
+	return ""
+}
+
+// readSectionTitle returns the title the reader gives the section that
+// slide starts when written first in it: the heading extractSectionTitle
+// picks for a slide with a class that starts a section, or "default" for
+// the first section of the deck otherwise. It reports false when the
+// reader would not start a section at the slide at all.
+func (w *Writer) readSectionTitle(slide *model.Slide, first bool) (string, bool) {
+	class := layoutClass(slide.Layout)
+	if c, ok := slide.Directives["_class"]; ok {
+		class = c
+	}
+	if !isSectionClass(class) {
+		return "default", first
+	}
+	ps := parseRawSlide(w.renderSlide(slide, nil, idDirectives{}), isDirective)
+	return extractSectionTitle(ps.content), true
+}
+
+func directiveComment(key, value string) string {
+	return fmt.Sprintf("<!-- %s: %s -->", key, value)
+}
+
+// writeNotes writes speaker notes as a single HTML comment.
+func (w *Writer) writeNotes(b *strings.Builder, notes []model.Block) {
+	b.WriteString("<!--\n")
+	w.writeBlocks(b, notes)
+	b.WriteString("-->\n")
+}
+
 func (w *Writer) writeBlock(b *strings.Builder, block *model.Block) {
 	switch block.Kind {
 	case model.BlockBullet:
 		indent := strings.Repeat("    ", block.Level)
-		fmt.Fprintf(b, "%s- %s\n", indent, block.Text)
+		marker := "-"
+		if block.Fragment {
+			marker = "*"
+		}
+		fmt.Fprintf(b, "%s%s %s\n", indent, marker, inlineText(block))
 	case model.BlockNumbered:
 		indent := strings.Repeat("    ", block.Level)
-		fmt.Fprintf(b, "%s1. %s\n", indent, block.Text)
-	case model.BlockParagraph:
-		if strings.HasPrefix(strings.TrimSpace(block.Text), "<") {
-			// HTML block - write as-is
-			b.WriteString(block.Text)
-			b.WriteString("\n")
-		} else if strings.HasPrefix(strings.TrimSpace(block.Text), "|") {
-			// Table - write as-is
-			b.WriteString(block.Text)
-			b.WriteString("\n")
-		} else {
-			b.WriteString("\n")
-			b.WriteString(block.Text)
-			b.WriteString("\n")
+		marker := "1."
+		if block.Fragment {
+			marker = "1)"
 		}
+		fmt.Fprintf(b, "%s%s %s\n", indent, marker, inlineText(block))
+	case model.BlockParagraph:
+		// Plain text and HTML blocks are written as-is
+		b.WriteString(inlineText(block))
+		b.WriteString("\n")
 	case model.BlockCode:
-		fmt.Fprintf(b, "\n```%s\n%s\n```\n", block.Lang, block.Text)
+		fmt.Fprintf(b, "```%s\n%s\n```\n", block.Lang, block.Text)
+	case model.BlockMath:
+		fmt.Fprintf(b, "$$\n%s\n$$\n", block.Text)
+	case model.BlockDiagram:
+		fmt.Fprintf(b, "```%s\n%s\n```\n", block.Dialect, block.Text)
 	case model.BlockImage:
-		fmt.Fprintf(b, "![%s](%s)\n", block.Alt, block.URL)
+		b.WriteString(renderImage(block))
+		b.WriteString("\n")
 	case model.BlockQuote:
-		fmt.Fprintf(b, "> %s\n", block.Text)
+		fmt.Fprintf(b, "> %s\n", inlineText(block))
 	case model.BlockHeading:
-		prefix := strings.Repeat("#", block.Level+1) // Level 1 = ##, Level 2 = ###
-		fmt.Fprintf(b, "%s %s\n", prefix, block.Text)
+		prefix := strings.Repeat("#", max(block.Level, 1)) // Level matches the reader
+		fmt.Fprintf(b, "%s %s\n", prefix, inlineText(block))
+	case model.BlockTable:
+		if block.Table != nil {
+			b.WriteString(renderTable(block.Table))
+			b.WriteString("\n")
+		}
+	case model.BlockPause:
+		if block.Pause != nil {
+			b.WriteString(renderPause(block))
+			b.WriteString("\n")
+		}
 	}
 }
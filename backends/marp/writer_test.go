@@ -139,6 +139,99 @@ func TestWriteSectionDivider(t *testing.T) {
 	}
 }
 
+func TestWriteIDDirectives(t *testing.T) {
+	deck := &model.Deck{
+		Title: "Intro",
+		Sections: []model.Section{
+			{
+				ID:    "default",
+				Title: "default",
+				Slides: []model.Slide{
+					{ID: "intro", Layout: model.LayoutTitleBody, Title: "Intro"},
+					{ID: "intro-2", Layout: model.LayoutTitleBody, Title: "Intro"},
+				},
+			},
+			{
+				ID:    "part",
+				Title: "Part One",
+				Slides: []model.Slide{
+					{ID: "custom", Layout: model.LayoutSection, Title: "Part One"},
+				},
+			},
+		},
+	}
+
+	output := NewWriter().Encode(deck)
+
+	// Only IDs the reader would not derive from the titles are written
+	for _, unwanted := range []string{"_id: intro", "_sectionId: default"} {
+		if strings.Contains(output, unwanted) {
+			t.Errorf("unexpected %q in output:\n%s", unwanted, output)
+		}
+	}
+	for _, wanted := range []string{"<!-- _id: custom -->", "<!-- _sectionId: part -->"} {
+		if !strings.Contains(output, wanted) {
+			t.Errorf("expected %q in output:\n%s", wanted, output)
+		}
+	}
+
+	reparsed, err := NewReader().Parse(output)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	var ids []string
+	for _, slide := range reparsed.AllSlides() {
+		ids = append(ids, slide.ID)
+	}
+	if got := strings.Join(ids, " "); got != "intro intro-2 custom" {
+		t.Errorf("slide IDs = %q", got)
+	}
+	if got := reparsed.Sections[1].ID; got != "part" {
+		t.Errorf("section ID = %q, want %q", got, "part")
+	}
+}
+
+func TestWriteSectionIDWithoutDivider(t *testing.T) {
+	deck := &model.Deck{
+		Title: "Guide",
+		Sections: []model.Section{
+			{
+				ID:     "default",
+				Title:  "default",
+				Slides: []model.Slide{{ID: "guide", Layout: model.LayoutTitleBody, Title: "Guide"}},
+			},
+			{
+				ID:    "setup",
+				Title: "Setup",
+				Slides: []model.Slide{
+					{ID: "install", Layout: model.LayoutTitleBody, Title: "Install"},
+					{ID: "configure", Layout: model.LayoutTitleBody, Title: "Configure"},
+				},
+			},
+		},
+	}
+
+	output := NewWriter().Encode(deck)
+	if !strings.Contains(output, "<!-- _sectionId: setup -->") {
+		t.Fatalf("expected the section ID on its opening slide:\n%s", output)
+	}
+
+	reparsed, err := NewReader().Parse(output)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if len(reparsed.Sections) != 2 {
+		t.Fatalf("expected 2 sections, got %+v", reparsed.Sections)
+	}
+	section := reparsed.Sections[1]
+	if section.ID != "setup" || section.Title != "Install" || len(section.Slides) != 2 {
+		t.Errorf("unexpected section after a round trip: %+v", section)
+	}
+	if section.Slides[0].Directives != nil {
+		t.Errorf("expected _sectionId left out of the slide directives, got %v", section.Slides[0].Directives)
+	}
+}
+
 func TestWriteImage(t *testing.T) {
 	deck := &model.Deck{
 		Title: "Images",
@@ -261,3 +354,32 @@ func TestWriteNumberedList(t *testing.T) {
 		t.Error("expected '1. Second step' in output")
 	}
 }
+
+func TestWriteFragments(t *testing.T) {
+	input := "# Build\n\n* Appears first\n    * Then this\n- Always shown\n1) Step one\n2) Step two\n"
+	deck, err := NewReader().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	body := deck.Sections[0].Slides[0].Body
+
+	fragments := []bool{true, true, false, true, true}
+	if len(body) != len(fragments) {
+		t.Fatalf("expected %d blocks, got %+v", len(fragments), body)
+	}
+	for i, want := range fragments {
+		if body[i].Fragment != want {
+			t.Errorf("block %d: expected fragment %v, got %+v", i, want, body[i])
+		}
+	}
+	if body[3].Kind != model.BlockNumbered || body[3].Text != "Step one" {
+		t.Errorf("expected numbered fragment, got %+v", body[3])
+	}
+
+	var b strings.Builder
+	NewWriter().writeBlocks(&b, body)
+	want := "* Appears first\n    * Then this\n- Always shown\n1) Step one\n1) Step two\n"
+	if got := b.String(); got != want {
+		t.Errorf("unexpected output:\n%s", got)
+	}
+}
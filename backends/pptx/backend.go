@@ -0,0 +1,118 @@
+// Package pptx implements the PowerPoint (Office Open XML) backend for
+// slidekit.
+package pptx
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grokify/slidekit/internal/deckfile"
+	"github.com/grokify/slidekit/model"
+)
+
+// Backend implements the model.Backend interface for .pptx files.
+type Backend struct {
+	reader *Reader
+	writer *Writer
+}
+
+// NewBackend creates a new PPTX backend.
+func NewBackend() *Backend {
+	return &Backend{
+		reader: NewReader(),
+		writer: NewWriter(),
+	}
+}
+
+// Info returns backend metadata.
+func (b *Backend) Info() model.BackendInfo {
+	return model.BackendInfo{
+		Name:    "pptx",
+		Version: "0.1.0",
+		Capabilities: []string{
+			model.CapabilityRead,
+			model.CapabilityWrite,
+			model.CapabilityCreate,
+			model.CapabilitySections,
+			model.CapabilityTransitions,
+		},
+	}
+}
+
+// Read loads a PowerPoint presentation from a file, exporting its
+// pictures beside it.
+func (b *Backend) Read(ctx context.Context, ref model.Ref) (*model.Deck, error) {
+	return b.reader.Read(ctx, ref)
+}
+
+// Plan computes changes needed to reach desired state. Pictures are
+// referred to where Read exports them, but nothing is written.
+func (b *Backend) Plan(ctx context.Context, ref model.Ref, desired *model.Deck) (*model.Diff, error) {
+	current, _, err := b.readCurrent(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.ComputeDiff(current, desired), nil
+}
+
+// Apply writes the diff to the file. The presentation is regenerated from
+// the updated deck with the writer's own masters and layouts, so formatting
+// the reader has no model for, such as custom themes and animations, is
+// lost. Pictures the deck refers to where Read exports them are read from
+// there, or copied over from the current presentation when they have not
+// been exported.
+func (b *Backend) Apply(ctx context.Context, ref model.Ref, diff *model.Diff) error {
+	if diff.IsEmpty() {
+		return nil
+	}
+
+	deck, src, err := b.readCurrent(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if err := deck.ApplyDiff(diff); err != nil {
+		return fmt.Errorf("applying diff: %w", err)
+	}
+
+	writer := *b.writer
+	writer.source = src
+	data, err := writer.encodeAt(deck, ref.Path)
+	if err != nil {
+		return fmt.Errorf("encoding deck: %w", err)
+	}
+	return deckfile.WriteApplied(ctx, ref.Path, data)
+}
+
+// readCurrent reads the presentation at ref like Read, without exporting
+// its pictures, and returns it with the package it was read from.
+func (b *Backend) readCurrent(ctx context.Context, ref model.Ref) (*model.Deck, *pptxFile, error) {
+	f, err := b.reader.open(ctx, ref)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading current deck: %w", err)
+	}
+	f.dryRun = true
+	deck, err := readPackage(f)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading current deck: %w", err)
+	}
+	return deck, f, nil
+}
+
+// Create creates a new .pptx file.
+func (b *Backend) Create(_ context.Context, deck *model.Deck) (model.Ref, error) {
+	// Default path if not set
+	path := "presentation.pptx"
+	if deck.ID != "" {
+		path = deck.ID + ".pptx"
+	}
+
+	if err := b.writer.WriteFile(deck, path); err != nil {
+		return model.Ref{}, fmt.Errorf("writing deck: %w", err)
+	}
+
+	return model.Ref{
+		Backend: "pptx",
+		Path:    path,
+	}, nil
+}
@@ -0,0 +1,104 @@
+package pptx
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/grokify/slidekit/internal/backendtest"
+	"github.com/grokify/slidekit/model"
+)
+
+func TestBackendCreate(t *testing.T) {
+	backend := NewBackend()
+	if info := backend.Info(); !info.HasCapability(model.CapabilitySections) || !info.HasCapability(model.CapabilityTransitions) {
+		t.Error("expected the sections and transitions capabilities")
+	}
+
+	deck := testDeck()
+	deck.ID = filepath.Join(t.TempDir(), "roadmap")
+	ref, err := backend.Create(context.Background(), deck)
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	if ref.Backend != "pptx" || ref.Path != deck.ID+".pptx" {
+		t.Errorf("unexpected ref %+v", ref)
+	}
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		t.Fatalf("reading created deck: %v", err)
+	}
+	containsAll(t, unzip(t, data), "ppt/_rels/presentation.xml.rels", `Target="slides/slide8.xml"`)
+}
+
+func TestBackendPlanApply(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "team.png"), 400, 300)
+	deck := testDeck()
+	deck.ID = filepath.Join(dir, "roadmap")
+	ref, err := NewBackend().Create(context.Background(), deck)
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+
+	// Edits that change the parts of the package: a list level, and notes
+	// for a slide that had none
+	updated := backendtest.PlanApply(t, NewBackend(), ref, func(deck *model.Deck) {
+		deck.FindSlide("goals").Body[1].Level = 2
+		deck.FindSlide("builds").Notes = []model.Block{model.NewParagraph("Ask about caching.")}
+	})
+
+	// Reading exports pictures beside the deck, and nothing else is
+	// written there
+	if url := updated.FindSlide("team").Background.Images[0].URL; url != "roadmap_assets/image1.png" {
+		t.Errorf("picture URL = %q, want the exported picture", url)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("expected the deck, its picture and the assets folder, got %v", entries)
+	}
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		t.Fatalf("reading applied deck: %v", err)
+	}
+	parts := unzip(t, data)
+	containsAll(t, parts, "ppt/slides/slide2.xml", `<a:p><a:pPr lvl="2"/><a:r><a:rPr lang="en-US" dirty="0"></a:rPr><a:t>Remote cache</a:t>`)
+	containsAll(t, parts, "ppt/slides/_rels/slide4.xml.rels", `Target="../notesSlides/notesSlide4.xml"`)
+	containsAll(t, parts, "ppt/notesSlides/notesSlide4.xml", `<a:t>Ask about caching.</a:t>`)
+	containsAll(t, parts, "docProps/app.xml", "<Notes>2</Notes>")
+	containsAll(t, parts, "ppt/slides/_rels/slide8.xml.rels", `Target="../media/image1.png"`)
+	if parts["ppt/media/image1.png"] == "" {
+		t.Error("expected the picture to stay embedded")
+	}
+
+	// Planning and applying do not export pictures, and copy the ones
+	// missing from the assets folder over from the current deck
+	ctx := context.Background()
+	backend := NewBackend()
+	desired, err := backend.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	assets := filepath.Join(dir, "roadmap_assets")
+	if err := os.RemoveAll(assets); err != nil {
+		t.Fatal(err)
+	}
+	desired.FindSlide("goals").Title = "Aims"
+	diff, err := backend.Plan(ctx, ref, desired)
+	if err != nil || len(diff.Changes) != 1 {
+		t.Fatalf("expected the title change alone, got %+v (%v)", diff, err)
+	}
+	if err := backend.Apply(ctx, ref, diff); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+	if _, err := os.Stat(assets); !os.IsNotExist(err) {
+		t.Errorf("expected no assets written by Plan and Apply, got %v", err)
+	}
+	data, err = os.ReadFile(ref.Path)
+	if err != nil {
+		t.Fatalf("reading applied deck: %v", err)
+	}
+	if parts := unzip(t, data); parts["ppt/media/image1.png"] == "" {
+		t.Error("expected the picture to stay embedded")
+	}
+}
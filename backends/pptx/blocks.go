@@ -0,0 +1,236 @@
+package pptx
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// paragraph is a paragraph of a text body, with the properties that decide
+// which block it becomes.
+type paragraph struct {
+	spans  []model.Span
+	level  int
+	bullet string // "char", "num" or "none"; "" inherits the placeholder's
+	marL   int64
+	algn   string
+	sz     int  // Font size of its first run, in hundredths of a point
+	bold   bool // Every run is bold
+	italic bool // Every run is italic
+}
+
+// readParagraphs reads the paragraphs of a text body, resolving links
+// through the relationships of its part.
+func readParagraphs(body *node, rels map[string]target) []paragraph {
+	var paras []paragraph
+	for p := range body.children("p") {
+		pPr := p.child("pPr")
+		para := paragraph{level: int(attrInt(pPr, "lvl")), marL: attrInt(pPr, "marL"), algn: pPr.attr("algn")}
+		switch {
+		case pPr.child("buAutoNum") != nil:
+			para.bullet = "num"
+		case pPr.child("buChar") != nil:
+			para.bullet = "char"
+		case pPr.child("buNone") != nil:
+			para.bullet = "none"
+		}
+		para.bold, para.italic = true, true
+		runs := 0
+		for _, c := range p.Nodes {
+			switch c.XMLName.Local {
+			case "r", "fld":
+				rPr := c.child("rPr")
+				span := runSpan(rPr, c.child("t").text(), rels)
+				if strings.TrimSpace(span.Text) != "" {
+					if runs == 0 {
+						para.sz = int(attrInt(rPr, "sz"))
+					}
+					runs++
+					para.bold = para.bold && span.Bold
+					para.italic = para.italic && span.Italic
+				}
+				para.spans = appendSpan(para.spans, span)
+			case "br":
+				// Line breaks take the style of the run before them, so
+				// that styled lines merge into one span
+				var span model.Span
+				if n := len(para.spans); n > 0 {
+					span = para.spans[n-1]
+				}
+				span.Text = "\n"
+				para.spans = appendSpan(para.spans, span)
+			}
+		}
+		if runs == 0 {
+			para.bold, para.italic = false, false
+		}
+		paras = append(paras, para)
+	}
+	return paras
+}
+
+// runSpan returns the span of a run with its character properties.
+// Monospace typefaces mark code, and the math font inline math.
+func runSpan(rPr *node, text string, rels map[string]target) model.Span {
+	on := func(name string) bool {
+		v := rPr.attr(name)
+		return v == "1" || v == "true"
+	}
+	span := model.Span{Text: text, Bold: on("b"), Italic: on("i")}
+	if strike := rPr.attr("strike"); strike != "" && strike != "noStrike" {
+		span.Strike = true
+	}
+	switch font := rPr.child("latin").attr("typeface"); {
+	case font == mathFont:
+		span.Math, span.Italic = true, false
+	case isMonospace(font):
+		span.Code = true
+	}
+	if t, ok := rels[rPr.child("hlinkClick").rattr("id")]; ok && t.external {
+		span.Href = t.name
+	}
+	return span
+}
+
+// monospaceFonts are common monospace typefaces, in lower case.
+var monospaceFonts = map[string]bool{
+	"courier": true, "courier new": true, "consolas": true, "menlo": true, "monaco": true,
+	"lucida console": true, "source code pro": true, "fira code": true, "jetbrains mono": true,
+	"cascadia code": true, "sf mono": true, "andale mono": true,
+}
+
+// isMonospace returns true if a typeface is a monospace one.
+func isMonospace(font string) bool {
+	font = strings.ToLower(font)
+	return monospaceFonts[font] || strings.HasSuffix(font, " mono")
+}
+
+// appendSpan appends a span, merging it into the last one when they have
+// the same style.
+func appendSpan(spans []model.Span, span model.Span) []model.Span {
+	if span.Text == "" {
+		return spans
+	}
+	if n := len(spans); n > 0 && spans[n-1].SameStyle(span) {
+		spans[n-1].Text += span.Text
+		return spans
+	}
+	return append(spans, span)
+}
+
+// restyle returns spans changed by fn, merged again where they now have
+// the same style.
+func restyle(spans []model.Span, fn func(*model.Span)) []model.Span {
+	var result []model.Span
+	for _, span := range spans {
+		fn(&span)
+		result = appendSpan(result, span)
+	}
+	return result
+}
+
+// allPlain returns true if no span carries styling or a link.
+func allPlain(spans []model.Span) bool {
+	for _, span := range spans {
+		if !span.IsPlain() {
+			return false
+		}
+	}
+	return true
+}
+
+// plainText returns the text of paragraphs, one line each.
+func plainText(paras []paragraph) string {
+	return strings.TrimSpace(lines(paras))
+}
+
+// lines returns the text of paragraphs, one line each, as is.
+func lines(paras []paragraph) string {
+	texts := make([]string, len(paras))
+	for i, para := range paras {
+		texts[i] = model.RunsText(para.spans)
+	}
+	return strings.Join(texts, "\n")
+}
+
+// allCode returns true if paragraphs have text, all of it set in a
+// monospace font, as in code pasted into a text box.
+func allCode(paras []paragraph) bool {
+	found := false
+	for _, para := range paras {
+		for _, span := range para.spans {
+			if strings.TrimSpace(span.Text) == "" {
+				continue
+			}
+			if !span.Code {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// pauseCue matches the pause cues the writer puts in notes.
+var pauseCue = regexp.MustCompile(`^\[(pause|break) ([0-9.]+[a-zµ]+(?:[0-9.]+[a-zµ]+)*)\]$`)
+
+// paragraphBlocks returns the blocks of paragraphs, leaving out empty
+// ones. With inherit, paragraphs that do not say otherwise are bullets, as
+// in body placeholders; in notes, pause cues become pause blocks.
+func paragraphBlocks(paras []paragraph, inherit, notes bool) []model.Block {
+	var blocks []model.Block
+	for i := range paras {
+		para := &paras[i]
+		text := model.RunsText(para.spans)
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		if notes {
+			if m := pauseCue.FindStringSubmatch(strings.TrimSpace(text)); m != nil {
+				if d, err := time.ParseDuration(m[2]); err == nil {
+					if m[1] == "break" {
+						blocks = append(blocks, model.NewBreak(d))
+					} else {
+						blocks = append(blocks, model.NewPause(d))
+					}
+					continue
+				}
+			}
+		}
+		blocks = append(blocks, para.block(inherit))
+	}
+	return blocks
+}
+
+// block returns the block for a paragraph with text: a list item for
+// bullets and numbering, a quote for indented italic text, a heading for
+// large bold text, and a paragraph otherwise.
+func (para *paragraph) block(inherit bool) model.Block {
+	spans := para.spans
+	block := model.Block{Kind: model.BlockParagraph}
+	switch {
+	case para.bullet == "num":
+		block.Kind, block.Level = model.BlockNumbered, para.level
+	case para.bullet == "char" || (para.bullet == "" && inherit):
+		block.Kind, block.Level = model.BlockBullet, para.level
+	case para.italic && para.marL > 0:
+		block.Kind = model.BlockQuote
+		spans = restyle(spans, func(s *model.Span) { s.Italic = false })
+	case para.bold && para.sz >= headingSizes[len(headingSizes)-1]:
+		block.Kind, block.Level = model.BlockHeading, len(headingSizes)
+		for i, sz := range headingSizes {
+			if para.sz >= sz {
+				block.Level = i + 1
+				break
+			}
+		}
+		spans = restyle(spans, func(s *model.Span) { s.Bold = false })
+	}
+	block.Text = model.RunsText(spans)
+	if !allPlain(spans) {
+		block.Runs = spans
+	}
+	return block
+}
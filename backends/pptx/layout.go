@@ -0,0 +1,320 @@
+package pptx
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// box is a rectangle on a slide, in EMUs.
+type box struct {
+	x, y, cx, cy int64
+}
+
+// scale stretches a box designed for a 16:9 slide horizontally to size.
+func (b box) scale(size slideSize) box {
+	if size.cx == size16x9.cx {
+		return b
+	}
+	f := func(v int64) int64 { return v * size.cx / size16x9.cx }
+	return box{f(b.x), b.y, f(b.cx), b.cy}
+}
+
+func (b box) xfrm() string {
+	return fmt.Sprintf(`<a:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></a:xfrm>`, b.x, b.y, b.cx, b.cy)
+}
+
+// Placeholder positions on a 16:9 slide, those of the Office theme.
+var (
+	titleBox       = box{838200, 365125, 10515600, 1325563}
+	bodyBox        = box{838200, 1825625, 10515600, 4351338}
+	ctrTitleBox    = box{1524000, 1122363, 9144000, 2387600}
+	subTitleBox    = box{1524000, 3602038, 9144000, 1655762}
+	secTitleBox    = box{831850, 1709738, 10515600, 2852737}
+	secBodyBox     = box{831850, 4589463, 10515600, 1500187}
+	leftBox        = box{838200, 1825625, 5181600, 4351338}
+	rightBox       = box{6172200, 1825625, 5181600, 4351338}
+	cmpLeftHead    = box{839788, 1681163, 5157787, 823912}
+	cmpLeftBody    = box{839788, 2505075, 5157787, 3684588}
+	cmpRightHead   = box{6172200, 1681163, 5183188, 823912}
+	cmpRightBody   = box{6172200, 2505075, 5183188, 3684588}
+	footerBox      = box{4038600, 6356350, 4114800, 365125}
+	slideNumberBox = box{8610600, 6356350, 2743200, 365125}
+	headerBox      = box{838200, 91440, 10515600, 274320}
+	blankBox       = box{838200, 365125, 10515600, 5811838}
+)
+
+// columnGap is the space between columns of content.
+const columnGap = 152400
+
+// placeholder is a placeholder of a slide layout.
+type placeholder struct {
+	typ  string // title, ctrTitle, subTitle or body
+	idx  int    // 0 for the title
+	name string
+	box  box
+
+	// lstStyle overrides the master's text styles, e.g. to center text
+	lstStyle string
+	anchor   string // Vertical anchor of the text, e.g. "b"
+}
+
+// ph returns the <p:ph> element that refers to the placeholder.
+func (ph *placeholder) ph() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<p:ph type="%s"`, ph.typ)
+	if ph.idx > 0 {
+		fmt.Fprintf(&b, ` idx="%d"`, ph.idx)
+	}
+	b.WriteString("/>")
+	return b.String()
+}
+
+// layoutDef is a slide layout generated for a model.Layout.
+type layoutDef struct {
+	layout model.Layout
+	name   string // Name shown in PowerPoint
+	typ    string // Layout type, which PowerPoint uses when switching layouts
+	phs    []placeholder
+	region box // Where content goes on slides without a placeholder for it
+}
+
+// find returns the placeholder of the given type and index, or nil.
+func (def *layoutDef) find(typ string, idx int) *placeholder {
+	for i := range def.phs {
+		if def.phs[i].typ == typ && def.phs[i].idx == idx {
+			return &def.phs[i]
+		}
+	}
+	return nil
+}
+
+// title returns the title placeholder, or nil.
+func (def *layoutDef) title() *placeholder {
+	for i := range def.phs {
+		if t := def.phs[i].typ; t == "title" || t == "ctrTitle" {
+			return &def.phs[i]
+		}
+	}
+	return nil
+}
+
+// Text styles for the placeholders that differ from the master's.
+const (
+	centered = `<a:lvl1pPr algn="ctr"><a:defRPr sz="6000"/></a:lvl1pPr>`
+	subtitle = `<a:lvl1pPr marL="0" indent="0" algn="ctr"><a:buNone/><a:defRPr sz="2400"/></a:lvl1pPr>`
+	bigTitle = `<a:lvl1pPr><a:defRPr sz="6000"/></a:lvl1pPr>`
+	plain    = `<a:lvl1pPr marL="0" indent="0"><a:buNone/><a:defRPr sz="2400"/></a:lvl1pPr>`
+	heading  = `<a:lvl1pPr marL="0" indent="0"><a:buNone/><a:defRPr sz="2400" b="1"/></a:lvl1pPr>`
+)
+
+// slideLayouts are the layouts of the generated slide master, one per
+// model.Layout, in the order of model.Layouts.
+var slideLayouts = []layoutDef{
+	{layout: model.LayoutTitle, name: "Title Slide", typ: "title", region: subTitleBox, phs: []placeholder{
+		{typ: "ctrTitle", name: "Title", box: ctrTitleBox, lstStyle: centered, anchor: "b"},
+		{typ: "subTitle", idx: 1, name: "Subtitle", box: subTitleBox, lstStyle: subtitle},
+	}},
+	{layout: model.LayoutTitleBody, name: "Title and Content", typ: "obj", region: bodyBox, phs: []placeholder{
+		{typ: "title", name: "Title", box: titleBox},
+		{typ: "body", idx: 1, name: "Content Placeholder", box: bodyBox},
+	}},
+	{layout: model.LayoutTitleTwoCol, name: "Two Content", typ: "twoObj", region: bodyBox, phs: []placeholder{
+		{typ: "title", name: "Title", box: titleBox},
+		{typ: "body", idx: 1, name: "Content Placeholder", box: leftBox},
+		{typ: "body", idx: 2, name: "Content Placeholder", box: rightBox},
+	}},
+	{layout: model.LayoutSection, name: "Section Header", typ: "secHead", region: secBodyBox, phs: []placeholder{
+		{typ: "title", name: "Title", box: secTitleBox, lstStyle: bigTitle, anchor: "b"},
+		{typ: "body", idx: 1, name: "Text Placeholder", box: secBodyBox, lstStyle: plain},
+	}},
+	{layout: model.LayoutBlank, name: "Blank", typ: "blank", region: blankBox},
+	{layout: model.LayoutImage, name: "Picture", typ: "picTx", region: blankBox},
+	{layout: model.LayoutComparison, name: "Comparison", typ: "twoTxTwoObj",
+		region: box{cmpLeftHead.x, cmpLeftHead.y, cmpRightHead.x + cmpRightHead.cx - cmpLeftHead.x,
+			cmpLeftBody.y + cmpLeftBody.cy - cmpLeftHead.y},
+		phs: []placeholder{
+			{typ: "title", name: "Title", box: titleBox},
+			{typ: "body", idx: 1, name: "Text Placeholder", box: cmpLeftHead, lstStyle: heading, anchor: "b"},
+			{typ: "body", idx: 2, name: "Content Placeholder", box: cmpLeftBody},
+			{typ: "body", idx: 3, name: "Text Placeholder", box: cmpRightHead, lstStyle: heading, anchor: "b"},
+			{typ: "body", idx: 4, name: "Content Placeholder", box: cmpRightBody},
+		}},
+}
+
+// layoutOf returns the layout definition for a slide's layout, or the
+// title and content layout if it has none.
+func layoutOf(layout model.Layout) (int, *layoutDef) {
+	for i := range slideLayouts {
+		if slideLayouts[i].layout == layout {
+			return i, &slideLayouts[i]
+		}
+	}
+	return 1, &slideLayouts[1]
+}
+
+// footerPhs are the footer and slide number placeholders of every layout.
+var footerPhs = []placeholder{
+	{typ: "ftr", idx: 11, name: "Footer Placeholder", box: footerBox},
+	{typ: "sldNum", idx: 12, name: "Slide Number Placeholder", box: slideNumberBox},
+}
+
+// footerPh returns the <p:ph> element for a footer placeholder of a slide.
+func footerPh(ph *placeholder) string {
+	return fmt.Sprintf(`<p:ph type="%s" sz="quarter" idx="%d"/>`, ph.typ, ph.idx)
+}
+
+// spTreeStart opens a shape tree with its required group properties.
+const spTreeStart = `<p:spTree><p:nvGrpSpPr><p:cNvPr id="1" name=""/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr>` +
+	`<p:grpSpPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="0" cy="0"/><a:chOff x="0" y="0"/><a:chExt cx="0" cy="0"/></a:xfrm></p:grpSpPr>`
+
+// layoutXML returns a slide layout part.
+func layoutXML(def *layoutDef, size slideSize) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<p:sldLayout xmlns:a="%s" xmlns:r="%s" xmlns:p="%s" type="%s" preserve="1">`, nsA, nsR, nsP, def.typ)
+	fmt.Fprintf(&b, `<p:cSld name="%s">`, def.name)
+	b.WriteString(spTreeStart)
+	id := 2
+	for _, ph := range def.phs {
+		writePlaceholderShape(&b, id, &ph, ph.ph(), ph.box.scale(size))
+		id++
+	}
+	for _, ph := range footerPhs {
+		writePlaceholderShape(&b, id, &ph, footerPh(&ph), ph.box.scale(size))
+		id++
+	}
+	b.WriteString(`</p:spTree></p:cSld><p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:sldLayout>`)
+	return b.String()
+}
+
+// writePlaceholderShape writes a placeholder of a layout or master with
+// the sample text PowerPoint shows for it.
+func writePlaceholderShape(b *strings.Builder, id int, ph *placeholder, phXML string, bx box) {
+	fmt.Fprintf(b, `<p:sp><p:nvSpPr><p:cNvPr id="%d" name="%s %d"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>`,
+		id, ph.name, id-1)
+	fmt.Fprintf(b, `<p:nvPr>%s</p:nvPr></p:nvSpPr><p:spPr>%s</p:spPr><p:txBody>`, phXML, bx.xfrm())
+	b.WriteString("<a:bodyPr")
+	if ph.anchor != "" {
+		fmt.Fprintf(b, ` anchor="%s"`, ph.anchor)
+	}
+	b.WriteString("/>")
+	if ph.lstStyle != "" {
+		fmt.Fprintf(b, "<a:lstStyle>%s</a:lstStyle>", ph.lstStyle)
+	} else {
+		b.WriteString("<a:lstStyle/>")
+	}
+	switch ph.typ {
+	case "title", "ctrTitle":
+		b.WriteString(`<a:p><a:r><a:rPr lang="en-US"/><a:t>Click to edit Master title style</a:t></a:r></a:p>`)
+	case "sldNum":
+		fmt.Fprintf(b, `<a:p><a:fld id="%s" type="slidenum"><a:rPr lang="en-US"/><a:t>‹#›</a:t></a:fld></a:p>`, slideNumberField)
+	case "ftr":
+		b.WriteString(`<a:p><a:endParaRPr lang="en-US"/></a:p>`)
+	default:
+		b.WriteString(`<a:p><a:r><a:rPr lang="en-US"/><a:t>Click to edit Master text styles</a:t></a:r></a:p>`)
+	}
+	b.WriteString("</p:txBody></p:sp>")
+}
+
+// slideNumberField identifies slide number fields.
+const slideNumberField = "{B6F15528-21DE-4FAA-801E-634DDDAF4B2B}"
+
+// masterXML returns the slide master: the shared title, body and footer
+// placeholders, the layouts and the text styles, in the theme's colors.
+func masterXML(theme *model.Theme, size slideSize) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<p:sldMaster xmlns:a="%s" xmlns:r="%s" xmlns:p="%s">`, nsA, nsR, nsP)
+	b.WriteString(`<p:cSld><p:bg><p:bgPr><a:solidFill><a:schemeClr val="bg1"/></a:solidFill><a:effectLst/></p:bgPr></p:bg>`)
+	b.WriteString(spTreeStart)
+	phs := []placeholder{
+		{typ: "title", name: "Title Placeholder", box: titleBox, anchor: "ctr"},
+		{typ: "body", idx: 1, name: "Text Placeholder", box: bodyBox},
+	}
+	for i, ph := range phs {
+		writePlaceholderShape(&b, i+2, &ph, ph.ph(), ph.box.scale(size))
+	}
+	footers := []placeholder{
+		{typ: "ftr", idx: 3, name: "Footer Placeholder", box: footerBox, anchor: "ctr",
+			lstStyle: `<a:lvl1pPr algn="ctr"><a:defRPr sz="1200"/></a:lvl1pPr>`},
+		{typ: "sldNum", idx: 4, name: "Slide Number Placeholder", box: slideNumberBox, anchor: "ctr",
+			lstStyle: `<a:lvl1pPr algn="r"><a:defRPr sz="1200"/></a:lvl1pPr>`},
+	}
+	for i, ph := range footers {
+		writePlaceholderShape(&b, i+4, &ph, footerPh(&ph), ph.box.scale(size))
+	}
+	b.WriteString("</p:spTree></p:cSld>")
+	b.WriteString(clrMap(theme))
+
+	b.WriteString("<p:sldLayoutIdLst>")
+	for i := range slideLayouts {
+		fmt.Fprintf(&b, `<p:sldLayoutId id="%d" r:id="rId%d"/>`, 2147483649+i, i+1)
+	}
+	b.WriteString("</p:sldLayoutIdLst>")
+
+	fonts := func(kind string) string {
+		return fmt.Sprintf(`<a:latin typeface="+%s-lt"/><a:ea typeface="+%s-ea"/><a:cs typeface="+%s-cs"/>`, kind, kind, kind)
+	}
+	b.WriteString("<p:txStyles><p:titleStyle>")
+	fmt.Fprintf(&b, `<a:lvl1pPr algn="l" defTabSz="914400" rtl="0" eaLnBrk="1" latinLnBrk="0" hangingPunct="1">`+
+		`<a:lnSpc><a:spcPct val="90000"/></a:lnSpc><a:spcBef><a:spcPct val="0"/></a:spcBef><a:buNone/>`+
+		`<a:defRPr sz="4400" kern="1200"><a:solidFill><a:schemeClr val="%s"/></a:solidFill>%s</a:defRPr></a:lvl1pPr>`,
+		titleColor(theme), fonts("mj"))
+	b.WriteString("</p:titleStyle><p:bodyStyle>")
+	for i, sz := range bulletSizes {
+		spc := 500
+		if i == 0 {
+			spc = 1000
+		}
+		fmt.Fprintf(&b, `<a:lvl%dpPr marL="%d" indent="-228600" algn="l" defTabSz="914400" rtl="0" eaLnBrk="1" latinLnBrk="0" hangingPunct="1">`+
+			`<a:lnSpc><a:spcPct val="90000"/></a:lnSpc><a:spcBef><a:spcPts val="%d"/></a:spcBef>`+
+			`<a:buFont typeface="Arial"/><a:buChar char="•"/>`+
+			`<a:defRPr sz="%d" kern="1200"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill>%s</a:defRPr></a:lvl%dpPr>`,
+			i+1, bulletMargin(i), spc, sz, fonts("mn"), i+1)
+	}
+	b.WriteString("</p:bodyStyle><p:otherStyle>")
+	fmt.Fprintf(&b, `<a:defPPr><a:defRPr lang="en-US"/></a:defPPr>`+
+		`<a:lvl1pPr marL="0" algn="l" defTabSz="914400" rtl="0" eaLnBrk="1" latinLnBrk="0" hangingPunct="1">`+
+		`<a:defRPr sz="1800" kern="1200"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill>%s</a:defRPr></a:lvl1pPr>`,
+		fonts("mn"))
+	b.WriteString("</p:otherStyle></p:txStyles></p:sldMaster>")
+	return b.String()
+}
+
+// bulletSizes are the font sizes, in hundredths of a point, of the body
+// text levels; levels below the last use its size.
+var bulletSizes = []int{2800, 2400, 2000, 1800, 1800}
+
+// bulletMargin returns the left margin of body text at a bullet level.
+func bulletMargin(level int) int {
+	return 228600 + 457200*level
+}
+
+// notesMasterXML returns the notes master, which lays out the notes pages
+// with the slide image above the notes.
+func notesMasterXML() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<p:notesMaster xmlns:a="%s" xmlns:r="%s" xmlns:p="%s">`, nsA, nsR, nsP)
+	b.WriteString(`<p:cSld><p:bg><p:bgRef idx="1001"><a:schemeClr val="bg1"/></p:bgRef></p:bg>`)
+	b.WriteString(spTreeStart)
+	b.WriteString(`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Slide Image Placeholder 1"/>` +
+		`<p:cNvSpPr><a:spLocks noGrp="1" noRot="1" noChangeAspect="1"/></p:cNvSpPr><p:nvPr><p:ph type="sldImg" idx="2"/></p:nvPr></p:nvSpPr>` +
+		`<p:spPr><a:xfrm><a:off x="685800" y="1143000"/><a:ext cx="5486400" cy="3086100"/></a:xfrm>` +
+		`<a:prstGeom prst="rect"><a:avLst/></a:prstGeom><a:noFill/><a:ln w="12700"><a:solidFill><a:prstClr val="black"/></a:solidFill></a:ln></p:spPr></p:sp>`)
+	b.WriteString(`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Notes Placeholder 2"/>` +
+		`<p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr><p:nvPr><p:ph type="body" sz="quarter" idx="1"/></p:nvPr></p:nvSpPr>` +
+		`<p:spPr><a:xfrm><a:off x="685800" y="4400550"/><a:ext cx="5486400" cy="3600450"/></a:xfrm>` +
+		`<a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr>` +
+		`<p:txBody><a:bodyPr vert="horz" lIns="91440" tIns="45720" rIns="91440" bIns="45720" rtlCol="0"/><a:lstStyle/>` +
+		`<a:p><a:r><a:rPr lang="en-US"/><a:t>Click to edit Master text styles</a:t></a:r></a:p></p:txBody></p:sp>`)
+	b.WriteString("</p:spTree></p:cSld>")
+	b.WriteString(clrMap(nil))
+	b.WriteString(`<p:notesStyle><a:lvl1pPr marL="0" algn="l" defTabSz="914400" rtl="0" eaLnBrk="1" latinLnBrk="0" hangingPunct="1">` +
+		`<a:defRPr sz="1200" kern="1200"><a:solidFill><a:schemeClr val="tx1"/></a:solidFill>` +
+		`<a:latin typeface="+mn-lt"/><a:ea typeface="+mn-ea"/><a:cs typeface="+mn-cs"/></a:defRPr></a:lvl1pPr></p:notesStyle>`)
+	b.WriteString("</p:notesMaster>")
+	return b.String()
+}
@@ -0,0 +1,143 @@
+package pptx
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/gif" // Registers GIF for image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// media is an image used on slides: a part in the package, or a link to
+// an image that could not be read.
+type media struct {
+	target   string // Part name, or the URL of a linked image
+	external bool
+
+	// Size in pixels, or zero when unknown, as for linked images
+	width, height int
+}
+
+// imageTypes maps image file extensions to content types.
+var imageTypes = map[string]string{
+	"png":  "image/png",
+	"jpg":  "image/jpeg",
+	"jpeg": "image/jpeg",
+	"gif":  "image/gif",
+	"bmp":  "image/bmp",
+	"tif":  "image/tiff",
+	"tiff": "image/tiff",
+	"svg":  "image/svg+xml",
+	"emf":  "image/x-emf",
+	"wmf":  "image/x-wmf",
+}
+
+// image returns the image at src, adding it to the package the first time
+// it is used. Local files and data: URLs are embedded; web images and
+// files that cannot be read are linked, so that the source is kept.
+func (p *pkg) image(src string) media {
+	if m, ok := p.media[src]; ok {
+		return m
+	}
+	m := media{target: src, external: true}
+	if data, ext, ok := p.loadImage(src); ok {
+		p.images++
+		m = media{target: fmt.Sprintf("ppt/media/image%d.%s", p.images, ext)}
+		p.add(m.target, "", data)
+		p.defaults[ext] = imageTypes[ext]
+		if cfg, _, err := image.DecodeConfig(bytes.NewReader(data)); err == nil {
+			m.width, m.height = cfg.Width, cfg.Height
+		}
+	}
+	p.media[src] = m
+	return m
+}
+
+// loadImage reads the image at src and returns it with its file extension.
+// A part of the source package, such as "ppt/media/image1.png", is read
+// from there, as is a picture of it that was never exported.
+func (p *pkg) loadImage(src string) (data []byte, ext string, ok bool) {
+	if p.source != nil && p.source.files[src] != nil {
+		return p.sourceImage(src)
+	}
+	if rest, found := strings.CutPrefix(src, "data:"); found {
+		meta, payload, found := strings.Cut(rest, ",")
+		if !found || !strings.HasSuffix(meta, ";base64") {
+			return nil, "", false
+		}
+		data, err := base64.StdEncoding.DecodeString(payload)
+		if err != nil {
+			return nil, "", false
+		}
+		return data, imageExt(data, ""), true
+	}
+
+	u, err := url.Parse(src)
+	if err != nil || (u.Scheme != "" && u.Scheme != "file") || src == "" {
+		return nil, "", false
+	}
+	file := filepath.FromSlash(u.Path)
+	if u.Scheme == "" && !filepath.IsAbs(file) {
+		file = filepath.Join(p.base, file)
+	}
+	data, err = os.ReadFile(file)
+	if err != nil {
+		if p.source != nil {
+			if name := p.source.exportedPart(src); name != "" {
+				return p.sourceImage(name)
+			}
+		}
+		return nil, "", false
+	}
+	ext = imageExt(data, strings.TrimPrefix(strings.ToLower(path.Ext(u.Path)), "."))
+	return data, ext, ext != ""
+}
+
+// sourceImage reads the image part name of the source package.
+func (p *pkg) sourceImage(name string) (data []byte, ext string, ok bool) {
+	data, err := p.source.read(name)
+	if err != nil {
+		return nil, "", false
+	}
+	ext = imageExt(data, strings.TrimPrefix(strings.ToLower(path.Ext(name)), "."))
+	return data, ext, ext != ""
+}
+
+// imageExt returns the extension for an image: name if it is one of the
+// known image types, or else one detected from the data.
+func imageExt(data []byte, name string) string {
+	if _, ok := imageTypes[name]; ok {
+		return name
+	}
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return "png"
+	case "image/jpeg":
+		return "jpeg"
+	case "image/gif":
+		return "gif"
+	case "image/bmp":
+		return "bmp"
+	}
+	if bytes.Contains(data[:min(len(data), 512)], []byte("<svg")) {
+		return "svg"
+	}
+	return ""
+}
+
+// rel adds the relationship from a slide to the image and returns the
+// attribute that refers to it from a blip.
+func (m media) rel(r *rels) string {
+	if m.external {
+		return fmt.Sprintf(`r:link="%s"`, r.addExternal(relImage, m.target))
+	}
+	return fmt.Sprintf(`r:embed="%s"`, r.add(relImage, "../media/"+path.Base(m.target)))
+}
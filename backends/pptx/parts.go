@@ -0,0 +1,286 @@
+package pptx
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// node is an XML element with its attributes and children in document
+// order. Elements are matched by local name, which is unambiguous in the
+// parts the reader looks at.
+type node struct {
+	XMLName xml.Name
+	Attrs   []xml.Attr `xml:",any,attr"`
+	Nodes   []*node    `xml:",any"`
+	Text    string     `xml:",chardata"`
+}
+
+// attr returns the value of an attribute without a namespace, or "".
+func (n *node) attr(name string) string {
+	return n.attrNS("", name)
+}
+
+// rattr returns the value of a relationship attribute such as r:id.
+func (n *node) rattr(name string) string {
+	return n.attrNS(nsR, name)
+}
+
+func (n *node) attrNS(space, name string) string {
+	if n == nil {
+		return ""
+	}
+	for _, a := range n.Attrs {
+		if a.Name.Space == space && a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// text returns the character data of n, or "".
+func (n *node) text() string {
+	if n == nil {
+		return ""
+	}
+	return n.Text
+}
+
+// child returns the first child with the given local name, or nil. The
+// names of a path descend through the children.
+func (n *node) child(names ...string) *node {
+	for _, name := range names {
+		if n == nil {
+			return nil
+		}
+		var found *node
+		for _, c := range n.Nodes {
+			if c.XMLName.Local == name {
+				found = c
+				break
+			}
+		}
+		n = found
+	}
+	return n
+}
+
+// children yields the children with the given local name.
+func (n *node) children(name string) iter.Seq[*node] {
+	return func(yield func(*node) bool) {
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			if c.XMLName.Local == name && !yield(c) {
+				return
+			}
+		}
+	}
+}
+
+// descendants yields the elements below n with the given local name, in
+// document order.
+func (n *node) descendants(name string) iter.Seq[*node] {
+	return func(yield func(*node) bool) {
+		var walk func(*node) bool
+		walk = func(n *node) bool {
+			for _, c := range n.Nodes {
+				if c.XMLName.Local == name && !yield(c) {
+					return false
+				}
+				if !walk(c) {
+					return false
+				}
+			}
+			return true
+		}
+		if n != nil {
+			walk(n)
+		}
+	}
+}
+
+// alternate returns the content PowerPoint wraps in mc:AlternateContent
+// for older readers: the fallback, or the first choice without one.
+func (n *node) alternate() *node {
+	if fallback := n.child("Fallback"); fallback != nil {
+		return fallback
+	}
+	return n.child("Choice")
+}
+
+// target is the target of a relationship: a part name in the package, or
+// an external URL.
+type target struct {
+	typ, name string
+	external  bool
+}
+
+// pptxFile gives access to the parts of a presentation package and
+// exports its images.
+type pptxFile struct {
+	files map[string]*zip.File
+
+	// Pictures are exported to assets, and referred to relative to base.
+	// With dryRun they are referred to where they would be exported, but
+	// nothing is written.
+	assets, base string
+	dryRun       bool
+	exported     map[string]string // URLs of exported images by part name
+	err          error             // First error exporting images
+}
+
+func openPackage(r io.ReaderAt, size int64) (*pptxFile, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("opening package: %w", err)
+	}
+	f := &pptxFile{files: make(map[string]*zip.File), exported: make(map[string]string)}
+	for _, file := range zr.File {
+		f.files[strings.TrimPrefix(file.Name, "/")] = file
+	}
+	return f, nil
+}
+
+// read returns the content of a part.
+func (f *pptxFile) read(name string) ([]byte, error) {
+	file, ok := f.files[name]
+	if !ok {
+		return nil, fmt.Errorf("missing part %s", name)
+	}
+	rc, err := file.Open()
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", name, err)
+	}
+	defer func() { _ = rc.Close() }()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+	return data, nil
+}
+
+// xml parses an XML part.
+func (f *pptxFile) xml(name string) (*node, error) {
+	data, err := f.read(name)
+	if err != nil {
+		return nil, err
+	}
+	var n node
+	if err := xml.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return &n, nil
+}
+
+// rels returns the relationships of a part by ID, with targets resolved
+// to part names. A part without relationships has none.
+func (f *pptxFile) rels(name string) (map[string]target, error) {
+	result := make(map[string]target)
+	if _, ok := f.files[relsName(name)]; !ok {
+		return result, nil
+	}
+	n, err := f.xml(relsName(name))
+	if err != nil {
+		return nil, err
+	}
+	for r := range n.children("Relationship") {
+		t := target{typ: r.attr("Type"), name: r.attr("Target"), external: r.attr("TargetMode") == "External"}
+		if !t.external {
+			if abs, ok := strings.CutPrefix(t.name, "/"); ok {
+				t.name = abs
+			} else {
+				t.name = path.Join(path.Dir(name), t.name)
+			}
+		}
+		result[r.attr("Id")] = t
+	}
+	return result, nil
+}
+
+// relOfType returns the first relationship of a type, by ID order.
+func relOfType(rels map[string]target, typ string) (target, bool) {
+	var found target
+	var foundID string
+	for id, t := range rels {
+		if t.typ == typ && (foundID == "" || relIDLess(id, foundID)) {
+			found, foundID = t, id
+		}
+	}
+	return found, foundID != ""
+}
+
+// relIDLess orders relationship IDs such as rId2 and rId10 numerically.
+func relIDLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
+
+// imageURL returns the URL of an image a picture refers to. Embedded
+// images are exported to the assets directory the first time they are
+// used; without one they keep their part name. Linked images keep their
+// URL.
+func (f *pptxFile) imageURL(t target) string {
+	if t.external {
+		return t.name
+	}
+	if url, ok := f.exported[t.name]; ok {
+		return url
+	}
+	url := t.name
+	if f.assets != "" {
+		file := filepath.Join(f.assets, path.Base(t.name))
+		if err := f.export(t.name, file); err != nil {
+			if f.err == nil {
+				f.err = err
+			}
+		} else {
+			url = filepath.ToSlash(file)
+			if f.base != "" {
+				if rel, err := filepath.Rel(f.base, file); err == nil {
+					url = filepath.ToSlash(rel)
+				}
+			}
+		}
+	}
+	f.exported[t.name] = url
+	return url
+}
+
+// export writes the part name to file, unless f is a dry run.
+func (f *pptxFile) export(name, file string) error {
+	if f.dryRun {
+		return nil
+	}
+	data, err := f.read(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0750); err != nil {
+		return fmt.Errorf("creating assets directory: %w", err)
+	}
+	if err := os.WriteFile(file, data, 0600); err != nil {
+		return fmt.Errorf("exporting %s: %w", name, err)
+	}
+	return nil
+}
+
+// exportedPart returns the part name of the image imageURL referred to as
+// url, or "" if there is none.
+func (f *pptxFile) exportedPart(url string) string {
+	for name, u := range f.exported {
+		if u == url {
+			return name
+		}
+	}
+	return ""
+}
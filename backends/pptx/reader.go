@@ -0,0 +1,280 @@
+package pptx
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// Reader reads PowerPoint presentations into the canonical slide model.
+type Reader struct {
+	// AssetsDir is the directory pictures are exported to. Read defaults
+	// it to "<name>_assets" beside the presentation, and refers to the
+	// pictures relative to the presentation. Without one, as for Parse by
+	// default, pictures are not exported and keep their part names, such
+	// as "ppt/media/image1.png".
+	AssetsDir string
+}
+
+// NewReader creates a new PPTX reader.
+func NewReader() *Reader {
+	return &Reader{}
+}
+
+// ReadFile reads a .pptx file and returns a Deck.
+func (r *Reader) ReadFile(path string) (*model.Deck, error) {
+	return r.Read(context.Background(), model.Ref{Backend: "pptx", Path: path})
+}
+
+// Read implements the Backend interface for reading from a Ref.
+func (r *Reader) Read(ctx context.Context, ref model.Ref) (*model.Deck, error) {
+	f, err := r.open(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return readPackage(f)
+}
+
+// open opens the presentation at ref.Path, with its pictures to be
+// exported to AssetsDir or its default.
+func (r *Reader) open(ctx context.Context, ref model.Ref) (*pptxFile, error) {
+	if ref.Path == "" {
+		return nil, fmt.Errorf("pptx backend requires a file path")
+	}
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %w", ref.Path, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f, err := openPackage(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	f.assets, f.base = r.AssetsDir, filepath.Dir(ref.Path)
+	if f.assets == "" {
+		name := strings.TrimSuffix(filepath.Base(ref.Path), filepath.Ext(ref.Path))
+		f.assets = filepath.Join(f.base, name+"_assets")
+	}
+	return f, nil
+}
+
+// Parse parses the bytes of a .pptx file into a Deck, exporting pictures
+// to AssetsDir if set.
+func (r *Reader) Parse(data []byte) (*model.Deck, error) {
+	return parse(data, r.AssetsDir, "")
+}
+
+// parse reads the bytes of a presentation: the slides in the order of the
+// presentation's slide list, with their layouts, content and notes, the
+// sections of PowerPoint 2010 and later, the document properties and the
+// theme.
+func parse(data []byte, assets, base string) (*model.Deck, error) {
+	f, err := openPackage(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	f.assets, f.base = assets, base
+	return readPackage(f)
+}
+
+// readPackage reads the presentation in the opened package f.
+func readPackage(f *pptxFile) (*model.Deck, error) {
+	rootRels, err := f.rels("")
+	if err != nil {
+		return nil, err
+	}
+	presName := "ppt/presentation.xml"
+	if t, ok := relOfType(rootRels, relOfficeDocument); ok {
+		presName = t.name
+	}
+	pres, err := f.xml(presName)
+	if err != nil {
+		return nil, err
+	}
+	presRels, err := f.rels(presName)
+	if err != nil {
+		return nil, err
+	}
+
+	deck := &model.Deck{Meta: model.Meta{Custom: make(map[string]any)}}
+	if t, ok := relOfType(rootRels, relCoreProps); ok {
+		if core, err := f.xml(t.name); err == nil {
+			readCore(deck, core)
+		}
+	}
+	if sz := pres.child("sldSz"); sz != nil {
+		cx, _ := strconv.ParseInt(sz.attr("cx"), 10, 64)
+		cy, _ := strconv.ParseInt(sz.attr("cy"), 10, 64)
+		switch {
+		case cx*9 == cy*16:
+			deck.Meta.Size = "16:9"
+		case cx*3 == cy*4:
+			deck.Meta.Size = "4:3"
+		}
+	}
+	if t, ok := relOfType(presRels, relSlideMaster); ok {
+		deck.Theme = f.readTheme(t.name)
+	}
+
+	sr := newSlideReader(f, pres)
+	b := model.NewDeckBuilder(deck)
+	bySlideID := make(map[string]int)
+	var slides []slideData
+	for sldID := range pres.child("sldIdLst").children("sldId") {
+		t, ok := presRels[sldID.rattr("id")]
+		if !ok {
+			continue
+		}
+		data, err := sr.read(t.name)
+		if err != nil {
+			return nil, err
+		}
+		bySlideID[sldID.attr("id")] = len(slides)
+		slides = append(slides, data)
+	}
+	if f.err != nil {
+		return nil, fmt.Errorf("exporting images: %w", f.err)
+	}
+
+	// Sections group the slides in the order of the slide list, each from
+	// its first slide on. Slides outside every section, as in a file
+	// without sections, stay in the section before them, or in a default
+	// one at the start. Sections without slides keep their place.
+	sections := readSections(pres)
+	sectionOf := make([]int, len(slides))
+	for k := range sectionOf {
+		sectionOf[k] = -1
+	}
+	counts := make([]int, len(sections))
+	for i, section := range sections {
+		for _, id := range section.slides {
+			if k, ok := bySlideID[id]; ok && sectionOf[k] < 0 {
+				sectionOf[k] = i
+				counts[i]++
+			}
+		}
+	}
+	current, next := -1, 0 // Section of the last slide, and the first one not started
+	startEmpty := func(until int) {
+		for ; next < until; next++ {
+			if counts[next] == 0 {
+				b.StartSection("", sections[next].name)
+			}
+		}
+	}
+	for k := range slides {
+		if i := sectionOf[k]; i >= 0 && i != current {
+			startEmpty(i)
+			next = max(next, i+1)
+			b.StartSection("", sections[i].name)
+			current = i
+		}
+		b.Add(slides[k].slide, slides[k].name, slides[k].settings)
+	}
+	startEmpty(len(sections))
+	deck = b.Finish()
+	deck.SettleTransitions()
+	return deck, nil
+}
+
+// readCore reads the title, author, description and keywords from the
+// core document properties.
+func readCore(deck *model.Deck, core *node) {
+	text := func(name string) string {
+		return strings.TrimSpace(core.child(name).text())
+	}
+	deck.Title = text("title")
+	deck.Meta.Author = text("creator")
+	deck.Meta.Description = text("description")
+	for _, k := range strings.FieldsFunc(text("keywords"), func(r rune) bool { return r == ',' || r == ';' }) {
+		if k = strings.TrimSpace(k); k != "" {
+			deck.Meta.Keywords = append(deck.Meta.Keywords, k)
+		}
+	}
+}
+
+// readTheme reads the theme of a slide master: its name, the first two
+// accents as the primary and secondary colors, the background color the
+// master maps onto, and the heading font. Values the writer uses by
+// default are left out, so that decks without a theme read back without
+// one.
+func (f *pptxFile) readTheme(masterName string) *model.Theme {
+	master, err := f.xml(masterName)
+	if err != nil {
+		return nil
+	}
+	rels, err := f.rels(masterName)
+	if err != nil {
+		return nil
+	}
+	t, ok := relOfType(rels, relTheme)
+	if !ok {
+		return nil
+	}
+	doc, err := f.xml(t.name)
+	if err != nil {
+		return nil
+	}
+
+	var theme model.Theme
+	if name := doc.attr("name"); name != "Office Theme" {
+		theme.Name = name
+	}
+	scheme := doc.child("themeElements", "clrScheme")
+	color := func(name, def string) string {
+		c := scheme.child(name)
+		hex := c.child("srgbClr").attr("val")
+		if hex == "" {
+			hex = c.child("sysClr").attr("lastClr")
+		}
+		if hex == "" || strings.EqualFold(hex, def) {
+			return ""
+		}
+		return "#" + strings.ToLower(hex)
+	}
+	theme.Primary = color("accent1", defaultAccent)
+	theme.Secondary = color("accent2", "ED7D31")
+	if master.child("clrMap").attr("bg1") == "dk1" {
+		theme.Background = color("dk1", "")
+	} else {
+		theme.Background = color("lt1", defaultLight)
+	}
+	if font := doc.child("themeElements", "fontScheme", "majorFont", "latin").attr("typeface"); font != defaultFont {
+		theme.Font = font
+	}
+	if theme.Name == "" && theme.Primary == "" && theme.Secondary == "" && theme.Background == "" && theme.Font == "" {
+		return nil
+	}
+	return &theme
+}
+
+// section is a PowerPoint section: its name and the IDs of its slides.
+type section struct {
+	name   string
+	slides []string
+}
+
+// readSections reads the sections PowerPoint 2010 and later keep in an
+// extension of the presentation.
+func readSections(pres *node) []section {
+	var sections []section
+	for list := range pres.descendants("sectionLst") {
+		for s := range list.children("section") {
+			sec := section{name: s.attr("name")}
+			for id := range s.child("sldIdLst").children("sldId") {
+				sec.slides = append(sec.slides, id.attr("id"))
+			}
+			sections = append(sections, sec)
+		}
+		break
+	}
+	return sections
+}
@@ -0,0 +1,243 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestReadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "team.png"), 400, 300)
+	w := &Writer{BaseDir: dir}
+	written, err := w.Encode(testDeck())
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	r := &Reader{AssetsDir: filepath.Join(dir, "assets")}
+	deck, err := r.Parse(written)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if again, err := w.Encode(deck); err != nil || !bytes.Equal(again, written) {
+		t.Errorf("round trip changed the presentation (error %v)", err)
+	}
+
+	if deck.Title != "Platform Roadmap" || deck.Meta.Author != "Platform Team" || deck.Meta.Size != "16:9" ||
+		deck.Transition != "slide" {
+		t.Errorf("unexpected metadata %q %+v", deck.Title, deck.Meta)
+	}
+	if th := deck.Theme; th == nil || th.Name != "harbor" || th.Primary != "#0f6cbd" || th.Background != "#f5f5f5" ||
+		th.Font != "Segoe UI" {
+		t.Errorf("unexpected theme %+v", deck.Theme)
+	}
+	if hf := deck.HeaderFooter; hf == nil || hf.FooterText() != "Draft & internal" || !hf.ShowPageNumber() {
+		t.Errorf("unexpected header and footer %+v", deck.HeaderFooter)
+	}
+	if got := sectionIDs(deck); !reflect.DeepEqual(got, []string{"overview", "engineering"}) {
+		t.Errorf("sections = %v", got)
+	}
+
+	// Layouts come back from the layout each slide uses
+	want := map[string]model.Layout{
+		"cover": model.LayoutTitle, "goals": model.LayoutTitleBody, "engineering-2": model.LayoutSection,
+		"builds": model.LayoutTitleBody, "options": model.LayoutComparison, "tradeoffs": model.LayoutTitleTwoCol,
+		"questions": model.LayoutBlank, "team": model.LayoutImage,
+	}
+	for _, slide := range deck.AllSlides() {
+		if slide.Layout != want[slide.ID] {
+			t.Errorf("slide %q: layout = %q, want %q", slide.ID, slide.Layout, want[slide.ID])
+		}
+	}
+	if divider := deck.FindSlide("engineering-2"); divider.Title != "Engineering" || divider.Subtitle != "Q1 to Q4" {
+		t.Errorf("unexpected section slide %+v", divider)
+	}
+
+	// List levels, from placeholders and text boxes alike, and notes
+	goals := deck.FindSlide("goals")
+	if goals.Transition == nil || *goals.Transition != "wipe" || goals.Background == nil || goals.Background.Color != "#008080" {
+		t.Errorf("unexpected goals slide %+v", goals)
+	}
+	if want := testDeck().Sections[0].Slides[1].Body; !reflect.DeepEqual(goals.Body, want) {
+		t.Errorf("body = %+v, want %+v", goals.Body, want)
+	}
+	wantNotes := []model.Block{model.NewParagraph("Start with the numbers."), model.NewPause(time.Second)}
+	if !reflect.DeepEqual(goals.Notes, wantNotes) {
+		t.Errorf("notes = %+v, want %+v", goals.Notes, wantNotes)
+	}
+	wantBox := []model.Block{model.NewBullet("Roadmap", 0), model.NewBullet("Slides", 1)}
+	if questions := deck.FindSlide("questions"); questions.Title != "Questions?" || !reflect.DeepEqual(questions.Body, wantBox) {
+		t.Errorf("unexpected text box slide %+v", questions)
+	}
+
+	body := deck.FindSlide("builds").Body
+	for i, want := range []model.BlockKind{model.BlockParagraph, model.BlockCode, model.BlockTable} {
+		if body[i].Kind != want {
+			t.Errorf("block %d kind = %q, want %q", i, body[i].Kind, want)
+		}
+	}
+	wantRuns := []model.Span{{Text: "Down ", Italic: true}, {Text: "by half", Href: "https://example.com/ci"}}
+	if !reflect.DeepEqual(body[0].Runs, wantRuns) {
+		t.Errorf("runs = %+v, want %+v", body[0].Runs, wantRuns)
+	}
+	if body[1].Text != "go vet ./...\ngo test ./..." || body[1].Lang != "sh" {
+		t.Errorf("unexpected code %+v", body[1])
+	}
+	if table := body[2].Table; table == nil || table.Header[1].Text != "Minutes" || table.Rows[0][0].Text != "Test" {
+		t.Errorf("unexpected table %+v", body[2].Table)
+	}
+
+	options := deck.FindSlide("options")
+	wantRight := []model.Block{model.NewHeading("Build", 3), model.NewBullet("Cheaper", 0)}
+	if !reflect.DeepEqual(options.Slots[model.SlotRight], wantRight) {
+		t.Errorf("unexpected comparison slide %+v", options)
+	}
+
+	// Pictures are exported to the assets directory
+	team := deck.FindSlide("team")
+	if team.Background == nil || len(team.Background.Images) != 1 {
+		t.Fatalf("unexpected team background %+v", team.Background)
+	}
+	if _, err := os.Stat(team.Background.Images[0].URL); err != nil {
+		t.Errorf("expected the picture exported: %v", err)
+	}
+}
+
+func sectionIDs(deck *model.Deck) []string {
+	var ids []string
+	for _, section := range deck.Sections {
+		ids = append(ids, section.ID)
+	}
+	return ids
+}
+
+// handWritten is a presentation in the style of other tools: no layouts,
+// no sections, text boxes in a scaled group and a transition wrapped in
+// markup compatibility elements.
+var handWritten = map[string]string{
+	"_rels/.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + relOfficeDocument + `" Target="ppt/presentation.xml"/></Relationships>`,
+	"ppt/presentation.xml": `<p:presentation xmlns:p="` + nsP + `" xmlns:r="` + nsR + `">` +
+		`<p:sldIdLst><p:sldId id="256" r:id="rId1"/></p:sldIdLst><p:sldSz cx="9144000" cy="6858000"/></p:presentation>`,
+	"ppt/_rels/presentation.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="` + relSlide + `" Target="slides/slide1.xml"/></Relationships>`,
+	"ppt/slides/slide1.xml": `<p:sld xmlns:a="` + nsA + `" xmlns:p="` + nsP + `" ` +
+		`xmlns:mc="http://schemas.openxmlformats.org/markup-compatibility/2006"><p:cSld><p:spTree>` +
+		`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title"/><p:cNvSpPr/><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr>` +
+		`<p:spPr/><p:txBody><a:p><a:r><a:t>Hello</a:t></a:r></a:p></p:txBody></p:sp>` +
+		`<p:grpSp><p:nvGrpSpPr><p:cNvPr id="3" name="Group"/><p:cNvGrpSpPr/><p:nvPr/></p:nvGrpSpPr>` +
+		`<p:grpSpPr><a:xfrm><a:off x="457200" y="1600200"/><a:ext cx="8229600" cy="4000000"/>` +
+		`<a:chOff x="0" y="0"/><a:chExt cx="4114800" cy="2000000"/></a:xfrm></p:grpSpPr>` +
+		`<p:sp><p:nvSpPr><p:cNvPr id="4" name="Left"/><p:cNvSpPr txBox="1"/><p:nvPr/></p:nvSpPr>` +
+		`<p:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="2000000" cy="2000000"/></a:xfrm></p:spPr><p:txBody>` +
+		`<a:p><a:pPr><a:buChar char="•"/></a:pPr><a:r><a:t>One</a:t></a:r></a:p>` +
+		`<a:p><a:pPr lvl="1"><a:buChar char="•"/></a:pPr><a:r><a:t>Two</a:t></a:r></a:p></p:txBody></p:sp>` +
+		`<p:sp><p:nvSpPr><p:cNvPr id="5" name="Right"/><p:cNvSpPr txBox="1"/><p:nvPr/></p:nvSpPr>` +
+		`<p:spPr><a:xfrm><a:off x="2114800" y="0"/><a:ext cx="2000000" cy="2000000"/></a:xfrm></p:spPr><p:txBody>` +
+		`<a:p><a:r><a:rPr><a:latin typeface="Consolas"/></a:rPr><a:t>x := 1</a:t></a:r></a:p>` +
+		`<a:p><a:r><a:rPr><a:latin typeface="Consolas"/></a:rPr><a:t>y := 2</a:t></a:r></a:p></p:txBody></p:sp>` +
+		`</p:grpSp></p:spTree></p:cSld>` +
+		`<mc:AlternateContent><mc:Choice Requires="p14"><p:transition><p:vortex/></p:transition></mc:Choice>` +
+		`<mc:Fallback><p:transition><p:fade/></p:transition></mc:Fallback></mc:AlternateContent></p:sld>`,
+}
+
+// zipParts returns a package of the given parts.
+func zipParts(t *testing.T, parts map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range parts {
+		fw, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestReadHandWritten(t *testing.T) {
+	deck, err := NewReader().Parse(zipParts(t, handWritten))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if deck.Meta.Size != "4:3" || deck.Transition != "fade" || deck.Theme != nil {
+		t.Errorf("unexpected meta %+v or theme %+v", deck.Meta, deck.Theme)
+	}
+	if got := sectionIDs(deck); !reflect.DeepEqual(got, []string{"default"}) {
+		t.Errorf("sections = %v", got)
+	}
+
+	slide := deck.Sections[0].Slides[0]
+	if slide.ID != "hello" || slide.Title != "Hello" || slide.Layout != model.LayoutTitleBody {
+		t.Errorf("unexpected slide %+v", slide)
+	}
+	// Text boxes side by side become columns, and monospace text code
+	wantLeft := []model.Block{model.NewBullet("One", 0), model.NewBullet("Two", 1)}
+	if !reflect.DeepEqual(slide.Slots[model.SlotLeft], wantLeft) {
+		t.Errorf("left = %+v, want %+v", slide.Slots[model.SlotLeft], wantLeft)
+	}
+	wantRight := []model.Block{model.NewCode("x := 1\ny := 2", "")}
+	if !reflect.DeepEqual(slide.Slots[model.SlotRight], wantRight) {
+		t.Errorf("right = %+v, want %+v", slide.Slots[model.SlotRight], wantRight)
+	}
+}
+
+func TestReadSectionOrder(t *testing.T) {
+	// Four slides, the first outside every section and the third listed
+	// in no section either, with an empty section between the others
+	parts := map[string]string{
+		"_rels/.rels": handWritten["_rels/.rels"],
+		"ppt/presentation.xml": `<p:presentation xmlns:p="` + nsP + `" xmlns:r="` + nsR + `" xmlns:p14="` + nsP14 + `">` +
+			`<p:sldIdLst><p:sldId id="256" r:id="rId1"/><p:sldId id="257" r:id="rId2"/>` +
+			`<p:sldId id="258" r:id="rId3"/><p:sldId id="259" r:id="rId4"/></p:sldIdLst>` +
+			`<p:extLst><p:ext><p14:sectionLst>` +
+			`<p14:section name="Intro"><p14:sldIdLst><p14:sldId id="257"/></p14:sldIdLst></p14:section>` +
+			`<p14:section name="Empty"><p14:sldIdLst/></p14:section>` +
+			`<p14:section name="Later"><p14:sldIdLst><p14:sldId id="259"/></p14:sldIdLst></p14:section>` +
+			`</p14:sectionLst></p:ext></p:extLst></p:presentation>`,
+	}
+	var rels strings.Builder
+	for i, title := range []string{"Cover", "Welcome", "Aside", "Finale"} {
+		name := fmt.Sprintf("ppt/slides/slide%d.xml", i+1)
+		parts[name] = `<p:sld xmlns:a="` + nsA + `" xmlns:p="` + nsP + `"><p:cSld><p:spTree>` +
+			`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Title"/><p:cNvSpPr/><p:nvPr><p:ph type="title"/></p:nvPr></p:nvSpPr>` +
+			`<p:spPr/><p:txBody><a:p><a:r><a:t>` + title + `</a:t></a:r></a:p></p:txBody></p:sp>` +
+			`</p:spTree></p:cSld></p:sld>`
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="%s" Target="slides/slide%d.xml"/>`, i+1, relSlide, i+1)
+	}
+	parts["ppt/_rels/presentation.xml.rels"] = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		rels.String() + `</Relationships>`
+
+	deck, err := NewReader().Parse(zipParts(t, parts))
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+
+	// The slides keep their order, and the sections follow it
+	var got []string
+	for _, section := range deck.Sections {
+		var titles []string
+		for _, slide := range section.Slides {
+			titles = append(titles, slide.Title)
+		}
+		got = append(got, section.Title+": "+strings.Join(titles, ", "))
+	}
+	want := []string{"default: Cover", "Intro: Welcome, Aside", "Empty: ", "Later: Finale"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,773 @@
+package pptx
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// slideData is a slide as read, with what the deck builder settles over
+// the whole deck.
+type slideData struct {
+	slide    model.Slide
+	name     string // Name of the slide, which the writer sets to its ID
+	settings model.HeaderFooter
+}
+
+// slideReader reads slides, caching the layouts and masters they use.
+type slideReader struct {
+	f       *pptxFile
+	size    slideSize
+	layouts map[string]*layoutInfo
+	masters map[string][]phInfo
+}
+
+// newSlideReader creates a slide reader for the slides of a presentation.
+func newSlideReader(f *pptxFile, pres *node) *slideReader {
+	size := size16x9
+	if sz := pres.child("sldSz"); sz != nil {
+		cx, cy := attrInt(sz, "cx"), attrInt(sz, "cy")
+		if cx > 0 && cy > 0 {
+			size = slideSize{cx, cy}
+		}
+	}
+	return &slideReader{f: f, size: size, layouts: make(map[string]*layoutInfo), masters: make(map[string][]phInfo)}
+}
+
+// layoutInfo is what the reader needs of a slide layout: the model layout
+// it stands for, and its placeholders and those of its master, which give
+// the position of placeholders that slides do not move.
+type layoutInfo struct {
+	layout model.Layout
+	phs    []phInfo
+	master []phInfo
+}
+
+// phInfo is a placeholder of a slide, layout or master.
+type phInfo struct {
+	typ       string // "obj" for placeholders without a type
+	idx       int
+	box       box
+	hasBox    bool
+	noBullets bool // Its first text level has no bullets
+}
+
+// layoutTypes maps PowerPoint layout types onto model layouts.
+var layoutTypes = map[string]model.Layout{
+	"title":       model.LayoutTitle,
+	"secHead":     model.LayoutSection,
+	"twoObj":      model.LayoutTitleTwoCol,
+	"twoColTx":    model.LayoutTitleTwoCol,
+	"txAndObj":    model.LayoutTitleTwoCol,
+	"objAndTx":    model.LayoutTitleTwoCol,
+	"twoTxTwoObj": model.LayoutComparison,
+	"blank":       model.LayoutBlank,
+	"picTx":       model.LayoutImage,
+	"obj":         model.LayoutTitleBody,
+	"tx":          model.LayoutTitleBody,
+	"titleOnly":   model.LayoutTitleBody,
+}
+
+// layoutFor returns the model layout of a slide layout, by its type, or by
+// its name for custom layouts; title and content is the default.
+func layoutFor(typ, name string) model.Layout {
+	if layout, ok := layoutTypes[typ]; ok {
+		return layout
+	}
+	name = strings.ToLower(name)
+	for _, def := range slideLayouts {
+		if strings.ToLower(def.name) == name {
+			return def.layout
+		}
+	}
+	for _, kw := range []struct {
+		word   string
+		layout model.Layout
+	}{
+		{"comparison", model.LayoutComparison},
+		{"section", model.LayoutSection},
+		{"two", model.LayoutTitleTwoCol},
+		{"picture", model.LayoutImage},
+		{"blank", model.LayoutBlank},
+		{"title slide", model.LayoutTitle},
+	} {
+		if strings.Contains(name, kw.word) {
+			return kw.layout
+		}
+	}
+	return model.LayoutTitleBody
+}
+
+// layout returns what the reader needs of the slide layout part name,
+// reading it and its master the first time.
+func (sr *slideReader) layout(name string) *layoutInfo {
+	if info, ok := sr.layouts[name]; ok {
+		return info
+	}
+	info := &layoutInfo{layout: model.LayoutTitleBody}
+	sr.layouts[name] = info
+	n, err := sr.f.xml(name)
+	if err != nil {
+		return info
+	}
+	info.layout = layoutFor(n.attr("type"), n.child("cSld").attr("name"))
+	info.phs = placeholders(n)
+	if rels, err := sr.f.rels(name); err == nil {
+		if t, ok := relOfType(rels, relSlideMaster); ok {
+			if _, ok := sr.masters[t.name]; !ok {
+				if master, err := sr.f.xml(t.name); err == nil {
+					sr.masters[t.name] = placeholders(master)
+				}
+			}
+			info.master = sr.masters[t.name]
+		}
+	}
+	return info
+}
+
+// placeholders returns the placeholders of a layout or master.
+func placeholders(part *node) []phInfo {
+	var phs []phInfo
+	for _, s := range flatten(part.child("cSld", "spTree")) {
+		if ph, ok := placeholderOf(s.n); ok {
+			ph.box, ph.hasBox = s.box, s.hasBox
+			ph.noBullets = s.n.child("txBody", "lstStyle", "lvl1pPr", "buNone") != nil
+			phs = append(phs, ph)
+		}
+	}
+	return phs
+}
+
+// placeholderOf returns the placeholder a shape fills, if any.
+func placeholderOf(shape *node) (phInfo, bool) {
+	ph := nonVisual(shape).child("nvPr", "ph")
+	if ph == nil {
+		return phInfo{}, false
+	}
+	typ := ph.attr("type")
+	if typ == "" {
+		typ = "obj"
+	}
+	idx, _ := strconv.Atoi(ph.attr("idx"))
+	return phInfo{typ: typ, idx: idx}, true
+}
+
+// match returns the layout's placeholder that a slide's placeholder
+// inherits from: the one with the same index, or else the same type, in
+// the layout, or the one of the same kind in the master.
+func (info *layoutInfo) match(ph phInfo) (phInfo, bool) {
+	for _, p := range info.phs {
+		if (ph.idx > 0 && p.idx == ph.idx) || (ph.idx == 0 && p.typ == ph.typ) {
+			return p, true
+		}
+	}
+	kind := func(typ string) string {
+		switch typ {
+		case "title", "ctrTitle":
+			return "title"
+		case "obj", "body", "subTitle":
+			return "body"
+		}
+		return typ
+	}
+	for _, p := range info.master {
+		if kind(p.typ) == kind(ph.typ) {
+			return p, true
+		}
+	}
+	return phInfo{}, false
+}
+
+// nonVisual returns the non-visual properties of a shape, which hold its
+// name, description and placeholder.
+func nonVisual(shape *node) *node {
+	for _, name := range []string{"nvSpPr", "nvPicPr", "nvGraphicFramePr", "nvCxnSpPr", "nvGrpSpPr"} {
+		if nv := shape.child(name); nv != nil {
+			return nv
+		}
+	}
+	return nil
+}
+
+// shapeNode is a shape of a shape tree, with its position on the slide.
+type shapeNode struct {
+	n      *node
+	box    box
+	hasBox bool
+}
+
+// flatten returns the shapes of a shape tree in document order, with the
+// shapes of groups moved into slide coordinates.
+func flatten(tree *node) []shapeNode {
+	var shapes []shapeNode
+	var walk func(tree *node, tf func(box) box)
+	walk = func(tree *node, tf func(box) box) {
+		if tree == nil {
+			return
+		}
+		for _, n := range tree.Nodes {
+			switch n.XMLName.Local {
+			case "AlternateContent":
+				walk(n.alternate(), tf)
+			case "grpSp":
+				xfrm := n.child("grpSpPr", "xfrm")
+				outer, ok := readXfrm(xfrm)
+				off, ext := xfrm.child("chOff"), xfrm.child("chExt")
+				chX, chY, chCX, chCY := attrInt(off, "x"), attrInt(off, "y"), attrInt(ext, "cx"), attrInt(ext, "cy")
+				inner := tf
+				if ok && chCX > 0 && chCY > 0 {
+					inner = func(b box) box {
+						b = box{
+							outer.x + (b.x-chX)*outer.cx/chCX, outer.y + (b.y-chY)*outer.cy/chCY,
+							b.cx * outer.cx / chCX, b.cy * outer.cy / chCY,
+						}
+						return tf(b)
+					}
+				}
+				walk(n, inner)
+			case "sp", "pic", "graphicFrame", "cxnSp":
+				xfrm := n.child("spPr", "xfrm")
+				if n.XMLName.Local == "graphicFrame" {
+					xfrm = n.child("xfrm")
+				}
+				b, ok := readXfrm(xfrm)
+				if ok {
+					b = tf(b)
+				}
+				shapes = append(shapes, shapeNode{n: n, box: b, hasBox: ok})
+			}
+		}
+	}
+	walk(tree, func(b box) box { return b })
+	return shapes
+}
+
+// readXfrm reads the position and size of a transform.
+func readXfrm(xfrm *node) (box, bool) {
+	off, ext := xfrm.child("off"), xfrm.child("ext")
+	if off == nil || ext == nil {
+		return box{}, false
+	}
+	return box{attrInt(off, "x"), attrInt(off, "y"), attrInt(ext, "cx"), attrInt(ext, "cy")}, true
+}
+
+// attrInt returns an integer attribute, or 0.
+func attrInt(n *node, name string) int64 {
+	v, _ := strconv.ParseInt(n.attr(name), 10, 64)
+	return v
+}
+
+// slideParser reads the shapes of one slide.
+type slideParser struct {
+	*slideReader
+	rels   map[string]target
+	layout *layoutInfo
+	data   slideData
+
+	items      []content
+	background []placed // Background pictures
+}
+
+// content is what a shape holds, with its position on the slide.
+type content struct {
+	blocks  []model.Block
+	box     box
+	hasBox  bool
+	caption bool
+}
+
+// placed is a picture with its position on the slide.
+type placed struct {
+	block model.Block
+	box   box
+}
+
+// read reads the slide part name: its layout, title, content, background,
+// header and footer, transition and notes.
+func (sr *slideReader) read(name string) (slideData, error) {
+	n, err := sr.f.xml(name)
+	if err != nil {
+		return slideData{}, err
+	}
+	rels, err := sr.f.rels(name)
+	if err != nil {
+		return slideData{}, err
+	}
+	p := &slideParser{slideReader: sr, rels: rels, layout: &layoutInfo{layout: model.LayoutTitleBody}}
+	if t, ok := relOfType(rels, relSlideLayout); ok {
+		p.layout = sr.layout(t.name)
+	}
+	cSld := n.child("cSld")
+	p.data.name = cSld.attr("name")
+	p.data.slide.Layout = p.layout.layout
+
+	p.readBackground(cSld.child("bg", "bgPr"))
+	for _, s := range flatten(cSld.child("spTree")) {
+		p.shape(s)
+	}
+	p.placeBackground()
+	p.arrange()
+	if t := readTransition(n); t != "" {
+		p.data.slide.Transition = &t
+	}
+	if err := p.readNotes(); err != nil {
+		return slideData{}, err
+	}
+	return p.data, nil
+}
+
+// shapeKind returns the kind of shape a name such as "Picture 2" names.
+func shapeKind(name string) string {
+	kind, n, ok := strings.Cut(name, " ")
+	if _, err := strconv.Atoi(n); !ok || err != nil {
+		return ""
+	}
+	return kind
+}
+
+// shape reads a shape of the slide.
+func (p *slideParser) shape(s shapeNode) {
+	cNvPr := nonVisual(s.n).child("cNvPr")
+	kind, descr := shapeKind(cNvPr.attr("name")), cNvPr.attr("descr")
+	var ph *phInfo
+	if info, ok := placeholderOf(s.n); ok {
+		if m, ok := p.layout.match(info); ok {
+			if !s.hasBox {
+				s.box, s.hasBox = m.box, m.hasBox
+			}
+			info.noBullets = m.noBullets
+		}
+		ph = &info
+	}
+	switch s.n.XMLName.Local {
+	case "pic":
+		p.picture(s, kind, descr)
+	case "graphicFrame":
+		for tbl := range s.n.descendants("tbl") {
+			p.table(s, tbl)
+			break
+		}
+	case "sp":
+		p.text(s, ph, kind, descr)
+	}
+}
+
+// text reads a shape holding text: a title, footer or slide number, a
+// header, a code, math or diagram box, or body text.
+func (p *slideParser) text(s shapeNode, ph *phInfo, kind, descr string) {
+	paras := readParagraphs(s.n.child("txBody"), p.rels)
+	slide := &p.data.slide
+	if ph != nil {
+		switch ph.typ {
+		case "title", "ctrTitle":
+			slide.Title = plainText(paras)
+			return
+		case "ftr":
+			if text := plainText(paras); text != "" {
+				p.data.settings.Footer = &text
+			}
+			return
+		case "sldNum":
+			on := true
+			p.data.settings.PageNumber = &on
+			return
+		case "dt", "hdr", "sldImg":
+			return
+		}
+	} else {
+		switch kind {
+		case "Header":
+			if text := plainText(paras); text != "" {
+				p.data.settings.Header = &text
+			}
+			return
+		case "Title":
+			if slide.Title == "" {
+				slide.Title = plainText(paras)
+				return
+			}
+		}
+	}
+
+	var blocks []model.Block
+	switch {
+	case kind == "Code" && ph == nil:
+		blocks = []model.Block{model.NewCode(lines(paras), descr)}
+	case kind == "Math" && ph == nil:
+		blocks = []model.Block{model.NewMath(lines(paras))}
+	case kind == "Diagram" && ph == nil:
+		blocks = []model.Block{model.NewDiagram(lines(paras), descr)}
+	case ph == nil && allCode(paras):
+		blocks = []model.Block{model.NewCode(lines(paras), "")}
+	default:
+		inherit := ph != nil && !ph.noBullets && (ph.typ == "obj" || ph.typ == "body")
+		blocks = paragraphBlocks(paras, inherit, false)
+	}
+
+	if ph != nil {
+		// The comparison layout has headings above its columns, and the
+		// title and section layouts a subtitle below the title
+		switch {
+		case p.layout.layout == model.LayoutComparison && (ph.idx == 1 || ph.idx == 3):
+			for i := range blocks {
+				blocks[i] = model.Block{Kind: model.BlockHeading, Text: blocks[i].Text, Runs: blocks[i].Runs, Level: 3}
+			}
+		case ph.typ == "subTitle" || p.layout.layout == model.LayoutSection && ph.idx == 1:
+			if len(blocks) > 0 && slide.Subtitle == "" && blocks[0].Kind == model.BlockParagraph && len(blocks[0].Runs) == 0 {
+				slide.Subtitle = blocks[0].Text
+				blocks = blocks[1:]
+			}
+		}
+	}
+	if len(blocks) > 0 {
+		p.items = append(p.items, content{blocks: blocks, box: s.box, hasBox: s.hasBox, caption: kind == "Caption" && ph == nil})
+	}
+}
+
+// picture reads a picture: an image block, or a background image for the
+// pictures the writer names "Background".
+func (p *slideParser) picture(s shapeNode, kind, descr string) {
+	blip := s.n.child("blipFill", "blip")
+	t, ok := p.blipTarget(blip)
+	if !ok {
+		return
+	}
+	block := model.NewImage(p.f.imageURL(t), descr)
+	if filters := blipFilters(blip); len(filters) > 0 {
+		block.Image = &model.ImageAttrs{Filters: filters}
+	}
+	if kind == "Background" {
+		p.background = append(p.background, placed{block: block, box: s.box})
+		return
+	}
+	// Pictures shown at other than their own size keep their width
+	if w, _ := p.imageSize(t); w > 0 && s.hasBox {
+		if px := (s.box.cx + emuPerPixel/2) / emuPerPixel; px != int64(w) {
+			if block.Image == nil {
+				block.Image = &model.ImageAttrs{}
+			}
+			block.Image.Width = fmt.Sprintf("%dpx", px)
+		}
+	}
+	p.items = append(p.items, content{blocks: []model.Block{block}, box: s.box, hasBox: s.hasBox})
+}
+
+// blipTarget returns the image a blip embeds or links to.
+func (p *slideParser) blipTarget(blip *node) (target, bool) {
+	for _, attr := range []string{"embed", "link"} {
+		if id := blip.rattr(attr); id != "" {
+			t, ok := p.rels[id]
+			return t, ok
+		}
+	}
+	return target{}, false
+}
+
+// imageSize returns the size in pixels of an embedded image, or zero.
+func (p *slideParser) imageSize(t target) (int, int) {
+	if t.external {
+		return 0, 0
+	}
+	data, err := p.f.read(t.name)
+	if err != nil {
+		return 0, 0
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0
+	}
+	return cfg.Width, cfg.Height
+}
+
+// blipFilters returns the image filters for the effects of a blip.
+func blipFilters(blip *node) []model.ImageFilter {
+	var filters []model.ImageFilter
+	for _, effect := range blip.Nodes {
+		switch effect.XMLName.Local {
+		case "alphaModFix":
+			amt := float64(attrInt(effect, "amt")) / 100000
+			filters = append(filters, model.ImageFilter{Name: "opacity", Value: strconv.FormatFloat(amt, 'f', -1, 64)})
+		case "grayscl":
+			filters = append(filters, model.ImageFilter{Name: "grayscale"})
+		}
+	}
+	return filters
+}
+
+// readBackground reads the slide's own background fill: a color or an
+// image.
+func (p *slideParser) readBackground(bgPr *node) {
+	if bgPr == nil {
+		return
+	}
+	bg := &model.Background{}
+	if hex := bgPr.child("solidFill", "srgbClr").attr("val"); hex != "" {
+		bg.Color = "#" + strings.ToLower(hex)
+	}
+	if blip := bgPr.child("blipFill", "blip"); blip != nil {
+		if t, ok := p.blipTarget(blip); ok {
+			image := model.NewBackgroundImage(p.f.imageURL(t))
+			image.Image.Filters = blipFilters(blip)
+			bg.Images = append(bg.Images, image)
+		}
+	}
+	if !bg.IsEmpty() {
+		p.data.slide.Background = bg
+	}
+}
+
+// placeBackground turns the background pictures into background images:
+// side by side or stacked, and split to one side of the slide when they
+// leave the rest of it to the content.
+func (p *slideParser) placeBackground() {
+	if len(p.background) == 0 {
+		return
+	}
+	area := p.background[0].box
+	vertical := len(p.background) > 1
+	for _, pic := range p.background[1:] {
+		right, bottom := max(area.x+area.cx, pic.box.x+pic.box.cx), max(area.y+area.cy, pic.box.y+pic.box.cy)
+		area.x, area.y = min(area.x, pic.box.x), min(area.y, pic.box.y)
+		area.cx, area.cy = right-area.x, bottom-area.y
+		vertical = vertical && pic.box.x == p.background[0].box.x
+	}
+	slide := &p.data.slide
+	if slide.Background == nil {
+		slide.Background = &model.Background{}
+	}
+	for i, pic := range p.background {
+		block := pic.block
+		attrs := &model.ImageAttrs{Bg: true}
+		if block.Image != nil {
+			attrs.Filters = block.Image.Filters
+		}
+		block.Image = attrs
+		if i == 0 {
+			attrs.Vertical = vertical
+			if area.cx*20 < p.size.cx*19 {
+				attrs.Split = model.SplitLeft
+				if area.x > 0 {
+					attrs.Split = model.SplitRight
+				}
+				if pct := (area.cx*100 + p.size.cx/2) / p.size.cx; pct != 50 {
+					attrs.SplitSize = fmt.Sprintf("%d%%", pct)
+				}
+			}
+		}
+		slide.Background.Images = append(slide.Background.Images, block)
+	}
+}
+
+// table reads a table, with its first row as the header and the
+// alignment of its columns taken from that row.
+func (p *slideParser) table(s shapeNode, tbl *node) {
+	var rows [][]model.TableCell
+	var align []model.Alignment
+	for tr := range tbl.children("tr") {
+		var row []model.TableCell
+		for tc := range tr.children("tc") {
+			paras := readParagraphs(tc.child("txBody"), p.rels)
+			var spans []model.Span
+			for i, para := range paras {
+				if i > 0 {
+					spans = appendSpan(spans, model.Span{Text: "\n"})
+				}
+				for _, span := range para.spans {
+					spans = appendSpan(spans, span)
+				}
+			}
+			cell := model.TableCell{Text: model.RunsText(spans)}
+			if !allPlain(spans) {
+				cell.Runs = spans
+			}
+			if len(rows) == 0 {
+				a := model.AlignDefault
+				if len(paras) > 0 {
+					for k, v := range alignments {
+						if v == paras[0].algn {
+							a = k
+						}
+					}
+				}
+				align = append(align, a)
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+	if len(rows) == 0 {
+		return
+	}
+	for len(align) > 0 && align[len(align)-1] == model.AlignDefault {
+		align = align[:len(align)-1]
+	}
+	table := &model.Table{Header: rows[0], Rows: rows[1:], Align: align}
+	if len(table.Rows) == 0 {
+		table.Rows = nil
+	}
+	block := model.Block{Kind: model.BlockTable, Table: table}
+	p.items = append(p.items, content{blocks: []model.Block{block}, box: s.box, hasBox: s.hasBox})
+}
+
+// arrange places the content of the slide in reading order. Narrow shapes
+// side by side become columns, with wide shapes above them going into the
+// body and those below into the caption; without columns, everything but
+// the caption goes into the body, top to bottom.
+func (p *slideParser) arrange() {
+	var items, caption []content
+	for _, it := range p.items {
+		if it.caption {
+			caption = append(caption, it)
+		} else {
+			items = append(items, it)
+		}
+	}
+
+	type column struct {
+		right int64
+		items []content
+	}
+	var columns []column
+	var wide []content
+	narrow := slices.Clone(items)
+	narrow = slices.DeleteFunc(narrow, func(it content) bool {
+		if !it.hasBox || it.box.cx*5 > p.size.cx*3 {
+			wide = append(wide, it)
+			return true
+		}
+		return false
+	})
+	slices.SortStableFunc(narrow, func(a, b content) int { return int(a.box.x - b.box.x) })
+	for _, it := range narrow {
+		if n := len(columns); n > 0 && it.box.x < columns[n-1].right {
+			columns[n-1].right = max(columns[n-1].right, it.box.x+it.box.cx)
+			columns[n-1].items = append(columns[n-1].items, it)
+		} else {
+			columns = append(columns, column{right: it.box.x + it.box.cx, items: []content{it}})
+		}
+	}
+
+	slide := &p.data.slide
+	if len(columns) < 2 {
+		slide.Body = blocksOf(items)
+	} else {
+		top := narrow[0].box.y
+		for _, it := range narrow {
+			top = min(top, it.box.y)
+		}
+		var above, below []content
+		for _, it := range wide {
+			if it.hasBox && it.box.y >= top {
+				below = append(below, it)
+			} else {
+				above = append(above, it)
+			}
+		}
+		slide.Body = blocksOf(above)
+		caption = append(below, caption...)
+		slide.Slots = make(model.Slots)
+		for i, col := range columns {
+			slide.Slots[columnName(i, len(columns))] = blocksOf(col.items)
+		}
+	}
+	if blocks := blocksOf(caption); len(blocks) > 0 {
+		if slide.Slots == nil {
+			slide.Slots = make(model.Slots)
+		}
+		slide.Slots[model.SlotCaption] = blocks
+	}
+}
+
+// blocksOf returns the blocks of content items, top to bottom and then
+// left to right.
+func blocksOf(items []content) []model.Block {
+	items = slices.Clone(items)
+	slices.SortStableFunc(items, func(a, b content) int {
+		if !a.hasBox || !b.hasBox {
+			return 0
+		}
+		if a.box.y != b.box.y {
+			return int(a.box.y - b.box.y)
+		}
+		return int(a.box.x - b.box.x)
+	})
+	var blocks []model.Block
+	for _, it := range items {
+		blocks = append(blocks, it.blocks...)
+	}
+	return blocks
+}
+
+// columnName returns the slot name of the i-th of n columns.
+func columnName(i, n int) string {
+	switch {
+	case i == 0:
+		return model.SlotLeft
+	case i == n-1 && n <= 3:
+		return model.SlotRight
+	case i == 1:
+		return model.SlotCenter
+	case i == 2:
+		return model.SlotRight
+	}
+	return fmt.Sprintf("column%d", i+1)
+}
+
+// transitionNames maps PowerPoint transitions onto the names the writer
+// takes.
+var transitionNames = map[string]string{
+	"fade":     "fade",
+	"push":     "slide",
+	"cover":    "cover",
+	"pull":     "reveal",
+	"wipe":     "wipe",
+	"zoom":     "zoom",
+	"dissolve": "dissolve",
+}
+
+// readTransition returns the name of a slide's transition, or "".
+func readTransition(sld *node) string {
+	t := sld.child("transition")
+	for alt := range sld.children("AlternateContent") {
+		if t == nil {
+			t = alt.alternate().child("transition")
+		}
+	}
+	if t == nil {
+		return ""
+	}
+	for _, effect := range t.Nodes {
+		if name, ok := transitionNames[effect.XMLName.Local]; ok {
+			return name
+		}
+	}
+	return ""
+}
+
+// readNotes reads the speaker notes from the body of the notes page.
+func (p *slideParser) readNotes() error {
+	t, ok := relOfType(p.rels, relNotesSlide)
+	if !ok {
+		return nil
+	}
+	n, err := p.f.xml(t.name)
+	if err != nil {
+		return err
+	}
+	rels, err := p.f.rels(t.name)
+	if err != nil {
+		return err
+	}
+	for _, s := range flatten(n.child("cSld", "spTree")) {
+		if ph, ok := placeholderOf(s.n); ok && ph.typ == "body" {
+			paras := readParagraphs(s.n.child("txBody"), rels)
+			p.data.slide.Notes = append(p.data.slide.Notes, paragraphBlocks(paras, false, true)...)
+		}
+	}
+	return nil
+}
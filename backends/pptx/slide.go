@@ -0,0 +1,682 @@
+package pptx
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// slideWriter generates the shapes of a slide, or of its notes page, and
+// collects the relationships they need.
+type slideWriter struct {
+	p     *pkg
+	slide *model.Slide
+	index int // Index of the slide's layout in slideLayouts
+	def   *layoutDef
+	rels  rels
+
+	shapes strings.Builder
+	id     int            // ID of the last shape written
+	count  map[string]int // Shapes written by kind, for their names
+}
+
+func newSlideWriter(p *pkg, slide *model.Slide) *slideWriter {
+	index, def := layoutOf(slide.Layout)
+	return &slideWriter{p: p, slide: slide, index: index, def: def, id: 1, count: make(map[string]int)}
+}
+
+// Sizes and styles of the shapes the writer places itself.
+const (
+	emuPerPixel = 9525                                     // At 96 dpi
+	rowHeight   = 370840                                   // Of table rows
+	sideMargin  = 457200                                   // Between a split background and the content
+	tableStyle  = "{5C22544A-7EE6-4342-B048-85BDC9FD1C3A}" // Medium Style 2 - Accent 1
+	codeFill    = "F2F2F2"
+	codeColor   = "262626"
+	codeSize    = 1600
+)
+
+// write returns the slide part. settings are the header and footer shown
+// on the slide and number is its page number.
+func (sw *slideWriter) write(settings model.HeaderFooter, number int) string {
+	sw.rels.add(relSlideLayout, fmt.Sprintf("../slideLayouts/slideLayout%d.xml", sw.index+1))
+	region := sw.def.region.scale(sw.p.size)
+	bg := sw.background(&region)
+	sw.title(&region)
+	sw.content(region)
+	sw.headerFooter(settings, number)
+
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<p:sld xmlns:a="%s" xmlns:r="%s" xmlns:p="%s">`, nsA, nsR, nsP)
+	if sw.slide.ID != "" {
+		fmt.Fprintf(&b, `<p:cSld name="%s">`, esc(sw.slide.ID))
+	} else {
+		b.WriteString("<p:cSld>")
+	}
+	b.WriteString(bg)
+	b.WriteString(spTreeStart)
+	b.WriteString(sw.shapes.String())
+	b.WriteString("</p:spTree></p:cSld>")
+	b.WriteString("<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr>")
+	b.WriteString(sw.transition())
+	b.WriteString("</p:sld>")
+	return b.String()
+}
+
+// writeNotes returns the notes slide part, with the slide image above the
+// speaker notes.
+func (sw *slideWriter) writeNotes() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<p:notes xmlns:a="%s" xmlns:r="%s" xmlns:p="%s">`, nsA, nsR, nsP)
+	b.WriteString("<p:cSld>")
+	b.WriteString(spTreeStart)
+	b.WriteString(`<p:sp><p:nvSpPr><p:cNvPr id="2" name="Slide Image Placeholder 1"/>` +
+		`<p:cNvSpPr><a:spLocks noGrp="1" noRot="1" noChangeAspect="1"/></p:cNvSpPr>` +
+		`<p:nvPr><p:ph type="sldImg"/></p:nvPr></p:nvSpPr><p:spPr/></p:sp>`)
+	b.WriteString(`<p:sp><p:nvSpPr><p:cNvPr id="3" name="Notes Placeholder 2"/>` +
+		`<p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr><p:nvPr><p:ph type="body" idx="1"/></p:nvPr></p:nvSpPr>` +
+		`<p:spPr/><p:txBody><a:bodyPr/><a:lstStyle/>`)
+	b.WriteString(sw.paragraphs(sw.slide.Notes, inNotes))
+	b.WriteString("</p:txBody></p:sp></p:spTree></p:cSld>")
+	b.WriteString("<p:clrMapOvr><a:masterClrMapping/></p:clrMapOvr></p:notes>")
+	return b.String()
+}
+
+// nextID returns the ID of a new shape.
+func (sw *slideWriter) nextID() int {
+	sw.id++
+	return sw.id
+}
+
+// name returns the name of a new shape of a kind, such as "Picture 2".
+// The reader recognizes shapes the writer placed itself by these names.
+func (sw *slideWriter) name(kind string) string {
+	sw.count[kind]++
+	return fmt.Sprintf("%s %d", kind, sw.count[kind])
+}
+
+// background writes the slide's background and returns its <p:bg>, or ""
+// to keep the master's. A color, or a single image covering the slide,
+// fills the background itself. Other images become pictures behind the
+// content, side by side; a split puts them on one side of the slide and
+// narrows region to the other.
+func (sw *slideWriter) background(region *box) string {
+	var images []model.Block
+	bg := sw.slide.Background
+	if bg != nil {
+		images = append(images, bg.Images...)
+	}
+	for _, block := range sw.slide.Body {
+		if block.IsBackground() {
+			images = append(images, block)
+		}
+	}
+	if len(images) == 0 && bg != nil {
+		if url := cssURL(bg.Image); url != "" {
+			images = append(images, model.NewBackgroundImage(url))
+		}
+	}
+	for i := range images {
+		if images[i].Image == nil {
+			images[i].Image = &model.ImageAttrs{Bg: true}
+		}
+	}
+	fill := ""
+	if bg != nil {
+		if color, ok := cssColor(bg.Color); ok {
+			fill = fmt.Sprintf(`<a:solidFill><a:srgbClr val="%s"/></a:solidFill>`, color)
+		}
+	}
+
+	size := sw.p.size
+	area := box{0, 0, size.cx, size.cy}
+	if len(images) > 0 {
+		attrs := images[0].Image
+		if attrs.Split == model.SplitLeft || attrs.Split == model.SplitRight {
+			area.cx = int64(float64(size.cx) * fraction(attrs.SplitSize, 0.5))
+			if attrs.Split == model.SplitRight {
+				area.x = size.cx - area.cx
+				region.cx = min(region.x+region.cx, area.x-sideMargin) - region.x
+			} else if left := area.cx + sideMargin; region.x < left {
+				region.cx -= left - region.x
+				region.x = left
+			}
+		} else if len(images) == 1 && (attrs.BgSize == "" || attrs.BgSize == "cover") {
+			m := sw.p.image(images[0].URL)
+			_, crop := sw.fitImage(&images[0], area)
+			return fmt.Sprintf(`<p:bg><p:bgPr><a:blipFill dpi="0" rotWithShape="1"><a:blip %s>%s</a:blip>%s`+
+				`<a:stretch><a:fillRect/></a:stretch></a:blipFill><a:effectLst/></p:bgPr></p:bg>`,
+				m.rel(&sw.rels), imageEffects(&images[0]), crop)
+		}
+	}
+
+	vertical := false
+	for _, image := range images {
+		vertical = vertical || image.Image.Vertical
+	}
+	n := int64(len(images))
+	for i := range images {
+		tile := area
+		if vertical {
+			tile.cy = area.cy / n
+			tile.y = area.y + int64(i)*tile.cy
+		} else {
+			tile.cx = area.cx / n
+			tile.x = area.x + int64(i)*tile.cx
+		}
+		bx, crop := sw.fitImage(&images[i], tile)
+		sw.picture(sw.name("Background"), &images[i], bx, crop)
+	}
+	if fill == "" {
+		return ""
+	}
+	return "<p:bg><p:bgPr>" + fill + "<a:effectLst/></p:bgPr></p:bg>"
+}
+
+// fitImage places a background image in tile: cropped to cover it, or
+// shrunk to fit inside it for the contain and fit sizes. It returns the
+// picture's box and its <a:srcRect>, if cropped.
+func (sw *slideWriter) fitImage(block *model.Block, tile box) (box, string) {
+	m := sw.p.image(block.URL)
+	if m.width == 0 || m.height == 0 {
+		return tile, ""
+	}
+	w, h := int64(m.width), int64(m.height)
+	switch block.Image.BgSize {
+	case "", "cover":
+		// Crops are in thousandths of a percent of the image
+		if w*tile.cy > h*tile.cx {
+			crop := (100000 - tile.cx*h*100000/(tile.cy*w)) / 2
+			if crop > 0 {
+				return tile, fmt.Sprintf(`<a:srcRect l="%d" r="%d"/>`, crop, crop)
+			}
+		} else if crop := (100000 - tile.cy*w*100000/(tile.cx*h)) / 2; crop > 0 {
+			return tile, fmt.Sprintf(`<a:srcRect t="%d" b="%d"/>`, crop, crop)
+		}
+		return tile, ""
+	}
+	cx, cy := tile.cx, tile.cx*h/w
+	if cy > tile.cy {
+		cx, cy = tile.cy*w/h, tile.cy
+	}
+	return box{tile.x + (tile.cx-cx)/2, tile.y + (tile.cy-cy)/2, cx, cy}, ""
+}
+
+// title writes the slide title into the layout's title placeholder, or
+// into a text box at the top of region for layouts without one.
+func (sw *slideWriter) title(region *box) {
+	if sw.slide.Title == "" {
+		return
+	}
+	spans := []model.Span{{Text: sw.slide.Title}}
+	ph := sw.def.title()
+	bx := titleBox.scale(sw.p.size)
+	if ph != nil {
+		bx = ph.box.scale(sw.p.size)
+	}
+	if *region != sw.def.region.scale(sw.p.size) {
+		// Keep the title over content narrowed by a split background
+		bx.x, bx.cx = region.x, region.cx
+	}
+	if ph == nil {
+		style := runStyle{sz: 4000, font: "+mj-lt"}
+		sw.textBox(sw.name("Title"), "", bx, "", "<a:p>"+sw.runs(spans, style)+"</a:p>")
+		if bottom := bx.y + bx.cy; region.y < bottom {
+			region.cy -= bottom - region.y
+			region.y = bottom
+		}
+		return
+	}
+	sw.fill(ph, bx, "<a:p>"+sw.runs(spans, runStyle{})+"</a:p>")
+}
+
+// content lays out the subtitle, body and slots in region: the body above
+// the columns, side by side, and the caption below them.
+func (sw *slideWriter) content(region box) {
+	slide := sw.slide
+	var body []model.Block
+	if slide.Subtitle != "" {
+		body = append(body, model.NewParagraph(slide.Subtitle))
+	}
+	for _, block := range slide.Body {
+		if !block.IsBackground() {
+			body = append(body, block)
+		}
+	}
+	columns := slide.Slots.Columns()
+	caption := slide.Slots[model.SlotCaption]
+	if len(columns) == 0 && len(caption) == 0 {
+		sw.flow(body, region, sw.bodyPlaceholder(region), "TextBox")
+		return
+	}
+
+	var captionBox box
+	if len(caption) > 0 {
+		h := min(sw.height(caption, region.cx), region.cy/3)
+		captionBox = box{region.x, region.y + region.cy - h, region.cx, h}
+		region.cy -= h + columnGap
+	}
+	if len(body) > 0 {
+		h := min(sw.height(body, region.cx), region.cy/2)
+		sw.flow(body, box{region.x, region.y, region.cx, h}, nil, "TextBox")
+		region.y += h + columnGap
+		region.cy -= h + columnGap
+	}
+	if n := int64(len(columns)); n > 0 {
+		width := (region.cx - columnGap*(n-1)) / n
+		for i, name := range columns {
+			col := box{region.x + int64(i)*(width+columnGap), region.y, width, region.cy}
+			sw.column(i, len(columns), slide.Slots[name], col)
+		}
+	}
+	if len(caption) > 0 {
+		sw.flow(caption, captionBox, nil, "Caption")
+	}
+}
+
+// bodyPlaceholder returns the placeholder the body of the slide goes into:
+// the layout's first body or subtitle placeholder, if it spans region.
+func (sw *slideWriter) bodyPlaceholder(region box) *placeholder {
+	for _, typ := range []string{"body", "subTitle"} {
+		if ph := sw.def.find(typ, 1); ph != nil && ph.box.scale(sw.p.size) == region {
+			return ph
+		}
+	}
+	return nil
+}
+
+// column lays out the i-th of n columns. The two content layout takes two
+// columns into its placeholders, and the comparison layout also puts a
+// heading opening a column into the heading placeholder above it.
+func (sw *slideWriter) column(i, n int, blocks []model.Block, col box) {
+	var ph *placeholder
+	switch {
+	case n != 2:
+	case sw.def.layout == model.LayoutTitleTwoCol:
+		ph = sw.def.find("body", i+1)
+	case sw.def.layout == model.LayoutComparison:
+		if len(blocks) > 0 && blocks[0].Kind == model.BlockHeading {
+			head := sw.def.find("body", 2*i+1)
+			hb := box{col.x, col.y, col.cx, min(head.box.cy, col.cy)}
+			sw.fill(head, hb, "<a:p>"+sw.runs(blocks[0].Spans(), runStyle{})+"</a:p>")
+			blocks = blocks[1:]
+			col.y += hb.cy
+			col.cy -= hb.cy
+		}
+		ph = sw.def.find("body", 2*i+2)
+	}
+	sw.flow(blocks, col, ph, "TextBox")
+}
+
+// item is a unit of content laid out down a region: a run of text blocks,
+// or a single picture, table, or code, math or diagram box.
+type item struct {
+	text   []model.Block
+	block  *model.Block
+	height int64
+}
+
+// items groups blocks into the items they are laid out as.
+func items(blocks []model.Block) []item {
+	var result []item
+	for i := range blocks {
+		block := &blocks[i]
+		switch {
+		case block.IsBackground():
+		case block.Kind == model.BlockTable && block.Table == nil:
+		case block.Kind == model.BlockImage, block.Kind == model.BlockTable,
+			block.Kind == model.BlockCode, block.Kind == model.BlockMath, block.Kind == model.BlockDiagram:
+			result = append(result, item{block: block})
+		default:
+			if n := len(result); n > 0 && result[n-1].block == nil {
+				result[n-1].text = append(result[n-1].text, *block)
+			} else {
+				result = append(result, item{text: []model.Block{*block}})
+			}
+		}
+	}
+	return result
+}
+
+// measure returns the height an item takes at width.
+func (sw *slideWriter) measure(it *item, width int64) int64 {
+	switch {
+	case it.block == nil:
+		return textHeight(it.text, width)
+	case it.block.Kind == model.BlockImage:
+		_, h := sw.imageSize(it.block, width)
+		return h
+	case it.block.Kind == model.BlockTable:
+		return int64(1+len(it.block.Table.Rows)) * rowHeight
+	}
+	return linesHeight(it.block.Text, width, codeSize) + 2*textInsets
+}
+
+// height returns the height blocks take at width.
+func (sw *slideWriter) height(blocks []model.Block, width int64) int64 {
+	items := items(blocks)
+	total := columnGap * int64(max(len(items)-1, 0))
+	for i := range items {
+		total += sw.measure(&items[i], width)
+	}
+	return total
+}
+
+// flow lays out blocks down region, shrinking them to fit. The first run
+// of text fills the placeholder ph, if given, and later ones go into text
+// boxes named after kind. The last run of text takes the rest of the
+// region.
+func (sw *slideWriter) flow(blocks []model.Block, region box, ph *placeholder, kind string) {
+	items := items(blocks)
+	if len(items) == 0 {
+		return
+	}
+	var total int64
+	for i := range items {
+		items[i].height = sw.measure(&items[i], region.cx)
+		total += items[i].height
+	}
+	if avail := max(region.cy-columnGap*int64(len(items)-1), 0); total > avail {
+		for i := range items {
+			items[i].height = items[i].height * avail / total
+		}
+	}
+
+	y := region.y
+	for i := range items {
+		it := &items[i]
+		bx := box{region.x, y, region.cx, it.height}
+		switch {
+		case it.block == nil:
+			if i == len(items)-1 {
+				bx.cy = max(region.y+region.cy-y, it.height)
+			}
+			if ph != nil {
+				sw.fill(ph, bx, sw.paragraphs(it.text, inPlaceholder))
+				ph = nil
+			} else {
+				sw.textBox(sw.name(kind), "", bx, "", sw.paragraphs(it.text, inTextBox))
+			}
+		case it.block.Kind == model.BlockImage:
+			w, h := sw.imageSize(it.block, bx.cx)
+			if h > bx.cy && h > 0 {
+				w, h = w*bx.cy/h, bx.cy
+			}
+			sw.picture(sw.name("Picture"), it.block, box{bx.x + (bx.cx-w)/2, bx.y, w, h}, "")
+		case it.block.Kind == model.BlockTable:
+			sw.table(bx, it.block.Table)
+		default:
+			sw.code(bx, it.block)
+		}
+		y += bx.cy + columnGap
+	}
+}
+
+// fill writes paragraphs into a layout placeholder at bx, keeping the
+// layout's position when bx is where the layout puts it.
+func (sw *slideWriter) fill(ph *placeholder, bx box, paras string) {
+	xfrm := ""
+	if bx != ph.box.scale(sw.p.size) {
+		xfrm = bx.xfrm()
+	}
+	bodyPr := "<a:bodyPr><a:normAutofit/></a:bodyPr>"
+	if ph.typ == "title" || ph.typ == "ctrTitle" {
+		bodyPr = "<a:bodyPr/>"
+	}
+	sw.placeholderShape(ph.name, ph.ph(), xfrm, bodyPr, paras)
+}
+
+// placeholderShape writes a shape that fills a placeholder; an empty xfrm
+// inherits the placeholder's position from the layout.
+func (sw *slideWriter) placeholderShape(name, phXML, xfrm, bodyPr, paras string) {
+	id := sw.nextID()
+	fmt.Fprintf(&sw.shapes, `<p:sp><p:nvSpPr><p:cNvPr id="%d" name="%s %d"/><p:cNvSpPr><a:spLocks noGrp="1"/></p:cNvSpPr>`+
+		`<p:nvPr>%s</p:nvPr></p:nvSpPr><p:spPr>%s</p:spPr><p:txBody>%s<a:lstStyle/>%s</p:txBody></p:sp>`,
+		id, name, id-1, phXML, xfrm, bodyPr, paras)
+}
+
+// textBox writes a text box, filled with an RRGGBB color or not at all.
+func (sw *slideWriter) textBox(name, descr string, bx box, fill, paras string) {
+	fillXML := "<a:noFill/>"
+	if fill != "" {
+		fillXML = fmt.Sprintf(`<a:solidFill><a:srgbClr val="%s"/></a:solidFill>`, fill)
+	}
+	if descr != "" {
+		descr = fmt.Sprintf(` descr="%s"`, esc(descr))
+	}
+	fmt.Fprintf(&sw.shapes, `<p:sp><p:nvSpPr><p:cNvPr id="%d" name="%s"%s/><p:cNvSpPr txBox="1"/><p:nvPr/></p:nvSpPr>`+
+		`<p:spPr>%s<a:prstGeom prst="rect"><a:avLst/></a:prstGeom>%s</p:spPr>`+
+		`<p:txBody><a:bodyPr wrap="square" rtlCol="0"><a:normAutofit/></a:bodyPr><a:lstStyle/>%s</p:txBody></p:sp>`,
+		sw.nextID(), esc(name), descr, bx.xfrm(), fillXML, paras)
+}
+
+// code writes a code, math or diagram block as its source in a shaded
+// monospace box, one paragraph per line, with the font shrunk to fit.
+// The box's description holds the code language or diagram dialect.
+func (sw *slideWriter) code(bx box, block *model.Block) {
+	kind, descr, font := "Code", block.Lang, codeFont
+	switch block.Kind {
+	case model.BlockMath:
+		kind, descr, font = "Math", "", mathFont
+	case model.BlockDiagram:
+		kind, descr = "Diagram", block.Dialect
+	}
+	lines := strings.Split(block.Text, "\n")
+	perLine := float64(len(lines)) * lineSpacing * emuPerPoint
+	sz := int(min(float64(codeSize), max(800, float64(bx.cy-2*textInsets)*100/perLine)))
+	style := runStyle{sz: sz, font: font, color: codeColor}
+	var paras strings.Builder
+	for _, line := range lines {
+		fmt.Fprintf(&paras, `<a:p><a:pPr marL="0" indent="0"><a:buNone/></a:pPr>%s<a:endParaRPr lang="en-US" sz="%d" dirty="0"/></a:p>`,
+			sw.runs([]model.Span{{Text: line}}, style), sz)
+	}
+	sw.textBox(sw.name(kind), descr, bx, codeFill, paras.String())
+}
+
+// imageSize returns the size of an inline picture: the size its attributes
+// give, or its own at 96 dpi, shrunk to width.
+func (sw *slideWriter) imageSize(block *model.Block, width int64) (int64, int64) {
+	m := sw.p.image(block.URL)
+	w, h := int64(m.width)*emuPerPixel, int64(m.height)*emuPerPixel
+	if attrs := block.Image; attrs != nil {
+		aw, wok := length(attrs.Width, width)
+		ah, hok := length(attrs.Height, sw.p.size.cy)
+		switch {
+		case wok && hok:
+			w, h = aw, ah
+		case wok && w > 0:
+			w, h = aw, h*aw/w
+		case hok && h > 0:
+			w, h = w*ah/h, ah
+		case wok:
+			w, h = aw, aw*3/4
+		case hok:
+			w, h = ah*4/3, ah
+		}
+	}
+	if w == 0 || h == 0 {
+		w, h = width/2, width*3/8
+	}
+	if w > width {
+		w, h = width, h*width/w
+	}
+	return w, h
+}
+
+// picture writes a picture, cropped by an optional <a:srcRect>. Its
+// description holds the alt text.
+func (sw *slideWriter) picture(name string, block *model.Block, bx box, crop string) {
+	m := sw.p.image(block.URL)
+	fmt.Fprintf(&sw.shapes, `<p:pic><p:nvPicPr><p:cNvPr id="%d" name="%s" descr="%s"/>`+
+		`<p:cNvPicPr><a:picLocks noChangeAspect="1"/></p:cNvPicPr><p:nvPr/></p:nvPicPr>`,
+		sw.nextID(), esc(name), esc(block.Alt))
+	fmt.Fprintf(&sw.shapes, `<p:blipFill><a:blip %s>%s</a:blip>%s<a:stretch><a:fillRect/></a:stretch></p:blipFill>`,
+		m.rel(&sw.rels), imageEffects(block), crop)
+	fmt.Fprintf(&sw.shapes, `<p:spPr>%s<a:prstGeom prst="rect"><a:avLst/></a:prstGeom></p:spPr></p:pic>`, bx.xfrm())
+}
+
+// imageEffects returns the blip effects for the image filters DrawingML
+// has: opacity and grayscale.
+func imageEffects(block *model.Block) string {
+	if block.Image == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, filter := range block.Image.Filters {
+		switch filter.Name {
+		case "opacity":
+			fmt.Fprintf(&b, `<a:alphaModFix amt="%d"/>`, int(fraction(filter.Value, 0.2)*100000))
+		case "grayscale":
+			b.WriteString("<a:grayscl/>")
+		}
+	}
+	return b.String()
+}
+
+// table writes a table at the top of bx in the default table style, with
+// the header as its first row.
+func (sw *slideWriter) table(bx box, table *model.Table) {
+	rows := append([][]model.TableCell{table.Header}, table.Rows...)
+	cols := 0
+	for _, row := range rows {
+		cols = max(cols, len(row))
+	}
+	if cols == 0 {
+		return
+	}
+	width := bx.cx / int64(cols)
+	fmt.Fprintf(&sw.shapes, `<p:graphicFrame><p:nvGraphicFramePr><p:cNvPr id="%d" name="%s"/>`+
+		`<p:cNvGraphicFramePr><a:graphicFrameLocks noGrp="1"/></p:cNvGraphicFramePr><p:nvPr/></p:nvGraphicFramePr>`+
+		`<p:xfrm><a:off x="%d" y="%d"/><a:ext cx="%d" cy="%d"/></p:xfrm>`,
+		sw.nextID(), sw.name("Table"), bx.x, bx.y, width*int64(cols), rowHeight*int64(len(rows)))
+	fmt.Fprintf(&sw.shapes, `<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/table">`+
+		`<a:tbl><a:tblPr firstRow="1" bandRow="1"><a:tableStyleId>%s</a:tableStyleId></a:tblPr><a:tblGrid>`, tableStyle)
+	for range cols {
+		fmt.Fprintf(&sw.shapes, `<a:gridCol w="%d"/>`, width)
+	}
+	sw.shapes.WriteString("</a:tblGrid>")
+	for _, row := range rows {
+		fmt.Fprintf(&sw.shapes, `<a:tr h="%d">`, rowHeight)
+		for c := range cols {
+			var cell model.TableCell
+			if c < len(row) {
+				cell = row[c]
+			}
+			spans := []model.Span{{Text: cell.Text}}
+			if cell.HasRuns() {
+				spans = cell.Runs
+			}
+			sw.shapes.WriteString("<a:tc><a:txBody><a:bodyPr/><a:lstStyle/><a:p>")
+			if algn := alignments[table.ColumnAlign(c)]; algn != "" {
+				fmt.Fprintf(&sw.shapes, `<a:pPr algn="%s"/>`, algn)
+			}
+			sw.shapes.WriteString(sw.runs(spans, runStyle{}))
+			sw.shapes.WriteString(`<a:endParaRPr lang="en-US" dirty="0"/></a:p></a:txBody><a:tcPr/></a:tc>`)
+		}
+		sw.shapes.WriteString("</a:tr>")
+	}
+	sw.shapes.WriteString("</a:tbl></a:graphicData></a:graphic></p:graphicFrame>")
+}
+
+// alignments maps column alignments to DrawingML paragraph alignments.
+var alignments = map[model.Alignment]string{
+	model.AlignLeft:   "l",
+	model.AlignCenter: "ctr",
+	model.AlignRight:  "r",
+}
+
+// headerFooter writes the header as a text box at the top of the slide,
+// and the footer and page number into the layout's footer placeholders.
+func (sw *slideWriter) headerFooter(settings model.HeaderFooter, number int) {
+	if text := settings.HeaderText(); text != "" {
+		paras := "<a:p>" + sw.runs([]model.Span{{Text: text}}, runStyle{sz: 1200}) + "</a:p>"
+		sw.textBox(sw.name("Header"), "", headerBox.scale(sw.p.size), "", paras)
+	}
+	if text := settings.FooterText(); text != "" {
+		ph := &footerPhs[0]
+		paras := "<a:p>" + sw.runs([]model.Span{{Text: text}}, runStyle{}) + "</a:p>"
+		sw.placeholderShape(ph.name, footerPh(ph), "", "<a:bodyPr/>", paras)
+	}
+	if settings.ShowPageNumber() {
+		ph := &footerPhs[1]
+		paras := fmt.Sprintf(`<a:p><a:fld id="%s" type="slidenum"><a:rPr lang="en-US"/><a:t>%d</a:t></a:fld></a:p>`,
+			slideNumberField, number)
+		sw.placeholderShape(ph.name, footerPh(ph), "", "<a:bodyPr/>", paras)
+	}
+}
+
+// transitions maps Reveal.js and Marp transition names to PowerPoint
+// transitions.
+var transitions = map[string]string{
+	"fade":     "<p:fade/>",
+	"slide":    "<p:push/>",
+	"push":     "<p:push/>",
+	"cover":    "<p:cover/>",
+	"reveal":   "<p:pull/>",
+	"pull":     "<p:pull/>",
+	"wipe":     "<p:wipe/>",
+	"zoom":     "<p:zoom/>",
+	"dissolve": "<p:dissolve/>",
+}
+
+// transition returns the slide's <p:transition>: its own, or the deck's
+// default. Reveal.js variants such as "fade-in" use their base transition.
+func (sw *slideWriter) transition() string {
+	name := sw.p.deck.Transition
+	if sw.slide.Transition != nil {
+		name = *sw.slide.Transition
+	}
+	name, _, _ = strings.Cut(strings.TrimSpace(name), " ")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "-in"), "-out")
+	if el, ok := transitions[name]; ok {
+		return `<p:transition spd="med">` + el + "</p:transition>"
+	}
+	return ""
+}
+
+// cssURL returns the URL of a CSS url() value, or "".
+func cssURL(css string) string {
+	_, rest, ok := strings.Cut(css, "url(")
+	if !ok {
+		return ""
+	}
+	url, _, _ := strings.Cut(rest, ")")
+	return strings.Trim(strings.TrimSpace(url), `"'`)
+}
+
+// length converts a CSS length in pixels, or a percentage of total, to
+// EMUs.
+func length(css string, total int64) (int64, bool) {
+	css = strings.TrimSpace(css)
+	if pct, ok := strings.CutSuffix(css, "%"); ok {
+		v, err := strconv.ParseFloat(pct, 64)
+		if err != nil || v <= 0 {
+			return 0, false
+		}
+		return int64(float64(total) * v / 100), true
+	}
+	v, err := strconv.ParseFloat(strings.TrimSuffix(css, "px"), 64)
+	if err != nil || v <= 0 {
+		return 0, false
+	}
+	return int64(v * emuPerPixel), true
+}
+
+// fraction parses a CSS number or percentage as a fraction, or returns def.
+func fraction(css string, def float64) float64 {
+	css = strings.TrimSpace(css)
+	if pct, ok := strings.CutSuffix(css, "%"); ok {
+		if v, err := strconv.ParseFloat(pct, 64); err == nil {
+			return v / 100
+		}
+		return def
+	}
+	if v, err := strconv.ParseFloat(css, 64); err == nil {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,199 @@
+package pptx
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// runStyle is the character formatting a block gives all of its runs.
+type runStyle struct {
+	sz     int // Font size in hundredths of a point; 0 inherits it
+	bold   bool
+	italic bool
+	font   string // Typeface; "" inherits it
+	color  string // RRGGBB; "" inherits it
+}
+
+// textFrame is what paragraphs are set in, which decides what they
+// inherit.
+type textFrame int
+
+const (
+	inPlaceholder textFrame = iota // Bullets, indents and sizes come from the master
+	inTextBox                      // Everything is spelled out
+	inNotes                        // Bullets are spelled out; sizes come from the notes master
+)
+
+// headingSizes are the font sizes of heading blocks by level, from 1.
+var headingSizes = []int{3200, 2800, 2400, 2000}
+
+// paragraphs returns the <a:p> elements for text blocks set in frame.
+func (sw *slideWriter) paragraphs(blocks []model.Block, frame textFrame) string {
+	explicit := frame != inPlaceholder
+	var b strings.Builder
+	for i := range blocks {
+		block := &blocks[i]
+		var style runStyle
+		if frame == inTextBox {
+			style.sz = textSize(block)
+		}
+		b.WriteString("<a:p>")
+		switch block.Kind {
+		case model.BlockBullet:
+			b.WriteString(listPPr(block.Level, false, explicit))
+		case model.BlockNumbered:
+			b.WriteString(listPPr(block.Level, true, explicit))
+		case model.BlockQuote:
+			b.WriteString(`<a:pPr marL="457200" indent="0"><a:buNone/></a:pPr>`)
+			style.italic = true
+		case model.BlockHeading:
+			b.WriteString(`<a:pPr marL="0" indent="0"><a:buNone/></a:pPr>`)
+			style.bold = true
+			style.sz = textSize(block)
+		default:
+			b.WriteString(`<a:pPr marL="0" indent="0"><a:buNone/></a:pPr>`)
+		}
+		b.WriteString(sw.runs(blockSpans(block), style))
+		b.WriteString(`<a:endParaRPr lang="en-US" dirty="0"/></a:p>`)
+	}
+	return b.String()
+}
+
+// listPPr returns the paragraph properties of a list item at level.
+func listPPr(level int, numbered, explicit bool) string {
+	bullet := ""
+	if numbered {
+		bullet = `<a:buFont typeface="+mj-lt"/><a:buAutoNum type="arabicPeriod"/>`
+	}
+	if explicit {
+		if !numbered {
+			bullet = `<a:buFont typeface="Arial"/><a:buChar char="•"/>`
+		}
+		return fmt.Sprintf(`<a:pPr marL="%d" lvl="%d" indent="-228600">%s</a:pPr>`, bulletMargin(level), level, bullet)
+	}
+	lvl := ""
+	if level > 0 {
+		lvl = fmt.Sprintf(` lvl="%d"`, level)
+	}
+	switch {
+	case bullet != "":
+		return fmt.Sprintf("<a:pPr%s>%s</a:pPr>", lvl, bullet)
+	case lvl != "":
+		return fmt.Sprintf("<a:pPr%s/>", lvl)
+	}
+	return "" // The master's first level
+}
+
+// blockSpans returns the runs of a text block. Pause cues are written the
+// way TOON output shows them, "[pause 500ms]" or "[break 500ms]".
+func blockSpans(block *model.Block) []model.Span {
+	if block.Kind == model.BlockPause && block.Pause != nil {
+		word := "pause"
+		if block.Pause.SSML {
+			word = "break"
+		}
+		return []model.Span{{Text: fmt.Sprintf("[%s %s]", word, block.Pause.Duration)}}
+	}
+	return block.Spans()
+}
+
+// runs returns the <a:r> elements for inline spans, with line breaks
+// between their lines.
+func (sw *slideWriter) runs(spans []model.Span, style runStyle) string {
+	var b strings.Builder
+	for _, span := range spans {
+		for i, line := range strings.Split(span.Text, "\n") {
+			if i > 0 {
+				b.WriteString("<a:br/>")
+			}
+			if line != "" {
+				fmt.Fprintf(&b, "<a:r>%s<a:t>%s</a:t></a:r>", sw.rPr(span, style), esc(line))
+			}
+		}
+	}
+	return b.String()
+}
+
+// rPr returns the run properties for a span in a block's style.
+func (sw *slideWriter) rPr(span model.Span, style runStyle) string {
+	var b strings.Builder
+	b.WriteString(`<a:rPr lang="en-US"`)
+	if style.sz > 0 {
+		fmt.Fprintf(&b, ` sz="%d"`, style.sz)
+	}
+	if style.bold || span.Bold {
+		b.WriteString(` b="1"`)
+	}
+	if style.italic || span.Italic || span.Math {
+		b.WriteString(` i="1"`)
+	}
+	if span.Strike {
+		b.WriteString(` strike="sngStrike"`)
+	}
+	b.WriteString(` dirty="0">`)
+	if style.color != "" {
+		fmt.Fprintf(&b, `<a:solidFill><a:srgbClr val="%s"/></a:solidFill>`, style.color)
+	}
+	font := style.font
+	switch {
+	case span.Code:
+		font = codeFont
+	case span.Math:
+		font = mathFont
+	}
+	if font != "" {
+		fmt.Fprintf(&b, `<a:latin typeface="%s"/><a:cs typeface="%s"/>`, esc(font), esc(font))
+	}
+	if span.Href != "" {
+		fmt.Fprintf(&b, `<a:hlinkClick r:id="%s"/>`, sw.rels.addExternal(relHyperlink, span.Href))
+	}
+	b.WriteString("</a:rPr>")
+	return b.String()
+}
+
+// Text metrics used to estimate how much room blocks take.
+const (
+	emuPerPoint = 12700
+	lineSpacing = 1.2 // Line height as a multiple of the font size
+	charWidth   = 0.5 // Average character width as a multiple of the font size
+	textInsets  = 91440
+)
+
+// textHeight estimates the height of text blocks set in a box of the given
+// width.
+func textHeight(blocks []model.Block, width int64) int64 {
+	height := int64(2 * textInsets)
+	for i := range blocks {
+		block := &blocks[i]
+		height += linesHeight(model.RunsText(blockSpans(block)), width, textSize(block)) + 10*emuPerPoint
+	}
+	return height
+}
+
+// textSize returns the font size of a text block, in hundredths of a point:
+// that of its bullet level in the master's body style, or of its heading
+// level.
+func textSize(block *model.Block) int {
+	switch block.Kind {
+	case model.BlockBullet, model.BlockNumbered:
+		return bulletSizes[min(block.Level, len(bulletSizes)-1)]
+	case model.BlockHeading:
+		return headingSizes[min(max(block.Level, 1), len(headingSizes))-1]
+	}
+	return bulletSizes[0]
+}
+
+// linesHeight estimates the height of text at a font size, in hundredths
+// of a point, wrapped to width.
+func linesHeight(text string, width int64, sz int) int64 {
+	points := float64(sz) / 100
+	perLine := max(int(float64(width)/(points*charWidth*emuPerPoint)), 1)
+	lines := 0
+	for _, line := range strings.Split(text, "\n") {
+		lines += max((utf8.RuneCountInString(line)+perLine-1)/perLine, 1)
+	}
+	return int64(float64(lines) * points * lineSpacing * emuPerPoint)
+}
@@ -0,0 +1,211 @@
+package pptx
+
+import (
+	"cmp"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// slideSize is the size of a slide in EMUs (English Metric Units; there are
+// 914400 to the inch).
+type slideSize struct {
+	cx, cy int64
+}
+
+// Slide sizes for the model's size presets. The layouts are designed for
+// 16:9 and stretched horizontally to other sizes.
+var (
+	size16x9 = slideSize{12192000, 6858000}
+	size4x3  = slideSize{9144000, 6858000}
+)
+
+func sizeOf(preset string) slideSize {
+	if preset == "4:3" {
+		return size4x3
+	}
+	return size16x9
+}
+
+// Default theme colors and fonts, those of the Office theme.
+const (
+	defaultDark   = "000000"
+	defaultLight  = "FFFFFF"
+	defaultFont   = "Calibri"
+	codeFont      = "Courier New"
+	mathFont      = "Cambria Math"
+	defaultAccent = "4472C4"
+)
+
+// themeXML returns the theme part for theme, whose primary and secondary
+// colors become the first two accents and whose font is used for both
+// headings and body text. A nil theme gives the Office defaults.
+func themeXML(theme *model.Theme) string {
+	if theme == nil {
+		theme = &model.Theme{}
+	}
+	dk1, lt1 := defaultDark, defaultLight
+	if bg, ok := cssColor(theme.Background); ok {
+		// A dark background is the scheme's dark color, with the master
+		// mapping light text onto it
+		if isDark(bg) {
+			dk1 = bg
+		} else {
+			lt1 = bg
+		}
+	}
+	accent1, ok := cssColor(theme.Primary)
+	if !ok {
+		accent1 = defaultAccent
+	}
+	accent2, ok := cssColor(theme.Secondary)
+	if !ok {
+		accent2 = "ED7D31"
+	}
+	font := fontFamily(theme.Font)
+
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<a:theme xmlns:a="%s" name="%s">`, nsA, esc(cmp.Or(theme.Name, "Office Theme")))
+	b.WriteString(`<a:themeElements><a:clrScheme name="slidekit">`)
+	for _, c := range [][2]string{
+		{"dk1", dk1}, {"lt1", lt1}, {"dk2", "44546A"}, {"lt2", "E7E6E6"},
+		{"accent1", accent1}, {"accent2", accent2}, {"accent3", "A5A5A5"},
+		{"accent4", "FFC000"}, {"accent5", "5B9BD5"}, {"accent6", "70AD47"},
+		{"hlink", "0563C1"}, {"folHlink", "954F72"},
+	} {
+		fmt.Fprintf(&b, `<a:%s><a:srgbClr val="%s"/></a:%s>`, c[0], c[1], c[0])
+	}
+	b.WriteString(`</a:clrScheme><a:fontScheme name="slidekit">`)
+	fmt.Fprintf(&b, `<a:majorFont><a:latin typeface="%s"/><a:ea typeface=""/><a:cs typeface=""/></a:majorFont>`, esc(font))
+	fmt.Fprintf(&b, `<a:minorFont><a:latin typeface="%s"/><a:ea typeface=""/><a:cs typeface=""/></a:minorFont>`, esc(font))
+	b.WriteString(`</a:fontScheme>`)
+	b.WriteString(fmtScheme)
+	b.WriteString(`</a:themeElements><a:objectDefaults/><a:extraClrSchemeLst/></a:theme>`)
+	return b.String()
+}
+
+// fmtScheme is the theme's fill, line and effect styles: plain ones, which
+// the generated shapes do not rely on.
+const fmtScheme = `<a:fmtScheme name="slidekit"><a:fillStyleLst>` +
+	`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>` +
+	`<a:solidFill><a:schemeClr val="phClr"><a:tint val="50000"/></a:schemeClr></a:solidFill>` +
+	`<a:solidFill><a:schemeClr val="phClr"><a:shade val="80000"/></a:schemeClr></a:solidFill>` +
+	`</a:fillStyleLst><a:lnStyleLst>` +
+	`<a:ln w="6350"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln>` +
+	`<a:ln w="12700"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln>` +
+	`<a:ln w="19050"><a:solidFill><a:schemeClr val="phClr"/></a:solidFill></a:ln>` +
+	`</a:lnStyleLst><a:effectStyleLst>` +
+	`<a:effectStyle><a:effectLst/></a:effectStyle>` +
+	`<a:effectStyle><a:effectLst/></a:effectStyle>` +
+	`<a:effectStyle><a:effectLst/></a:effectStyle>` +
+	`</a:effectStyleLst><a:bgFillStyleLst>` +
+	`<a:solidFill><a:schemeClr val="phClr"/></a:solidFill>` +
+	`<a:solidFill><a:schemeClr val="phClr"><a:tint val="95000"/></a:schemeClr></a:solidFill>` +
+	`<a:solidFill><a:schemeClr val="phClr"><a:shade val="90000"/></a:schemeClr></a:solidFill>` +
+	`</a:bgFillStyleLst></a:fmtScheme>`
+
+// clrMap returns the master's color mapping: dark text on a light
+// background, or the reverse for a theme with a dark background.
+func clrMap(theme *model.Theme) string {
+	bg1, tx1, bg2, tx2 := "lt1", "dk1", "lt2", "dk2"
+	if theme != nil {
+		if bg, ok := cssColor(theme.Background); ok && isDark(bg) {
+			bg1, tx1, bg2, tx2 = "dk1", "lt1", "dk2", "lt2"
+		}
+	}
+	return fmt.Sprintf(`<p:clrMap bg1="%s" tx1="%s" bg2="%s" tx2="%s" accent1="accent1" accent2="accent2"`+
+		` accent3="accent3" accent4="accent4" accent5="accent5" accent6="accent6" hlink="hlink" folHlink="folHlink"/>`,
+		bg1, tx1, bg2, tx2)
+}
+
+// titleColor returns the scheme color of slide titles: the primary color if
+// the theme sets one, or the text color.
+func titleColor(theme *model.Theme) string {
+	if theme != nil {
+		if _, ok := cssColor(theme.Primary); ok {
+			return "accent1"
+		}
+	}
+	return "tx1"
+}
+
+// fontFamily returns the first family of a CSS font-family list, with
+// generic families mapped onto fonts found in Office.
+func fontFamily(css string) string {
+	first, _, _ := strings.Cut(css, ",")
+	first = strings.Trim(strings.TrimSpace(first), `"'`)
+	switch strings.ToLower(first) {
+	case "":
+		return defaultFont
+	case "sans-serif", "system-ui", "ui-sans-serif":
+		return defaultFont
+	case "serif", "ui-serif":
+		return "Cambria"
+	case "monospace", "ui-monospace":
+		return codeFont
+	}
+	return first
+}
+
+// cssNamedColors holds the basic CSS color keywords.
+var cssNamedColors = map[string]string{
+	"black": "000000", "white": "FFFFFF", "red": "FF0000", "lime": "00FF00",
+	"green": "008000", "blue": "0000FF", "yellow": "FFFF00", "cyan": "00FFFF",
+	"aqua": "00FFFF", "magenta": "FF00FF", "fuchsia": "FF00FF", "silver": "C0C0C0",
+	"gray": "808080", "grey": "808080", "maroon": "800000", "olive": "808000",
+	"purple": "800080", "teal": "008080", "navy": "000080", "orange": "FFA500",
+}
+
+// cssColor converts a CSS color in hex, rgb() or keyword form to the
+// RRGGBB hex used by DrawingML. Alpha is dropped.
+func cssColor(css string) (string, bool) {
+	css = strings.ToLower(strings.TrimSpace(css))
+	if hex, ok := cssNamedColors[css]; ok {
+		return hex, true
+	}
+	if hex, ok := strings.CutPrefix(css, "#"); ok {
+		switch len(hex) {
+		case 3, 4:
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		case 6, 8:
+			hex = hex[:6]
+		default:
+			return "", false
+		}
+		if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+			return "", false
+		}
+		return strings.ToUpper(hex), true
+	}
+	if args, ok := strings.CutPrefix(css, "rgb"); ok {
+		args = strings.TrimPrefix(args, "a")
+		args = strings.TrimSuffix(strings.TrimPrefix(args, "("), ")")
+		fields := strings.FieldsFunc(args, func(r rune) bool { return r == ',' || r == ' ' || r == '/' })
+		if len(fields) < 3 {
+			return "", false
+		}
+		var hex string
+		for _, f := range fields[:3] {
+			v, err := strconv.Atoi(f)
+			if err != nil || v < 0 || v > 255 {
+				return "", false
+			}
+			hex += fmt.Sprintf("%02X", v)
+		}
+		return hex, true
+	}
+	return "", false
+}
+
+// isDark reports whether an RRGGBB color is dark enough to need light text.
+func isDark(hex string) bool {
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return false
+	}
+	r, g, b := float64(v>>16&0xFF), float64(v>>8&0xFF), float64(v&0xFF)
+	return 0.299*r+0.587*g+0.114*b < 128
+}
@@ -0,0 +1,390 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// Namespaces and relationship types used by the parts of a presentation.
+const (
+	nsA   = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	nsR   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsP   = "http://schemas.openxmlformats.org/presentationml/2006/main"
+	nsP14 = "http://schemas.microsoft.com/office/powerpoint/2010/main"
+
+	relOfficeDocument = nsR + "/officeDocument"
+	relCoreProps      = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+	relExtendedProps  = nsR + "/extended-properties"
+	relSlideMaster    = nsR + "/slideMaster"
+	relSlideLayout    = nsR + "/slideLayout"
+	relSlide          = nsR + "/slide"
+	relNotesMaster    = nsR + "/notesMaster"
+	relNotesSlide     = nsR + "/notesSlide"
+	relTheme          = nsR + "/theme"
+	relImage          = nsR + "/image"
+	relHyperlink      = nsR + "/hyperlink"
+	relPresProps      = nsR + "/presProps"
+	relViewProps      = nsR + "/viewProps"
+	relTableStyles    = nsR + "/tableStyles"
+
+	ctPresentation = "application/vnd.openxmlformats-officedocument.presentationml.presentation.main+xml"
+	ctSlideMaster  = "application/vnd.openxmlformats-officedocument.presentationml.slideMaster+xml"
+	ctSlideLayout  = "application/vnd.openxmlformats-officedocument.presentationml.slideLayout+xml"
+	ctSlide        = "application/vnd.openxmlformats-officedocument.presentationml.slide+xml"
+	ctNotesMaster  = "application/vnd.openxmlformats-officedocument.presentationml.notesMaster+xml"
+	ctNotesSlide   = "application/vnd.openxmlformats-officedocument.presentationml.notesSlide+xml"
+	ctTheme        = "application/vnd.openxmlformats-officedocument.theme+xml"
+	ctPresProps    = "application/vnd.openxmlformats-officedocument.presentationml.presProps+xml"
+	ctViewProps    = "application/vnd.openxmlformats-officedocument.presentationml.viewProps+xml"
+	ctTableStyles  = "application/vnd.openxmlformats-officedocument.presentationml.tableStyles+xml"
+	ctCoreProps    = "application/vnd.openxmlformats-package.core-properties+xml"
+	ctExtended     = "application/vnd.openxmlformats-officedocument.extended-properties+xml"
+)
+
+// xmlHeader starts every XML part.
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+// Writer converts a Deck to a PowerPoint presentation in the Office Open
+// XML format, with native placeholders, text, pictures and tables that
+// stay editable in PowerPoint and LibreOffice.
+type Writer struct {
+	// BaseDir is the directory relative image paths are resolved against.
+	// WriteFile defaults it to the directory of the file written.
+	BaseDir string
+
+	// source is the presentation a deck was read from, whose pictures the
+	// deck may refer to by part name
+	source *pptxFile
+}
+
+// NewWriter creates a new PPTX writer.
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+// WriteFile writes a deck to a .pptx file.
+func (w *Writer) WriteFile(deck *model.Deck, path string) error {
+	data, err := w.encodeAt(deck, path)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+// encodeAt encodes deck for writing to path, resolving images against the
+// directory of path unless BaseDir is set.
+func (w *Writer) encodeAt(deck *model.Deck, path string) ([]byte, error) {
+	writer := *w
+	if writer.BaseDir == "" {
+		writer.BaseDir = filepath.Dir(path)
+	}
+	return writer.Encode(deck)
+}
+
+// Encode converts a Deck to the bytes of a .pptx file. Images are embedded
+// when they can be read from BaseDir, and linked otherwise.
+func (w *Writer) Encode(deck *model.Deck) ([]byte, error) {
+	p := newPackage(deck, w.BaseDir)
+	p.source = w.source
+	p.build()
+	return p.zip()
+}
+
+// pkg collects the parts of a presentation package as they are generated.
+type pkg struct {
+	deck   *model.Deck
+	base   string
+	source *pptxFile // Package whose parts images may name, or nil
+	size   slideSize
+
+	parts     []part
+	overrides map[string]string // Content types by part name
+	defaults  map[string]string // Content types by extension
+	media     map[string]media  // Images by source URL
+	images    int               // Images embedded so far
+}
+
+type part struct {
+	name string
+	data []byte
+}
+
+func newPackage(deck *model.Deck, base string) *pkg {
+	return &pkg{
+		deck:      deck,
+		base:      base,
+		size:      sizeOf(deck.Meta.Size),
+		overrides: make(map[string]string),
+		defaults: map[string]string{
+			"rels": "application/vnd.openxmlformats-package.relationships+xml",
+			"xml":  "application/xml",
+		},
+		media: make(map[string]media),
+	}
+}
+
+// add adds a part with its content type; an empty content type leaves it
+// to the default for its extension.
+func (p *pkg) add(name, contentType string, data []byte) {
+	p.parts = append(p.parts, part{name: name, data: data})
+	if contentType != "" {
+		p.overrides["/"+name] = contentType
+	}
+}
+
+// build generates every part of the presentation.
+func (p *pkg) build() {
+	theme := p.deck.Theme
+	p.add("ppt/theme/theme1.xml", ctTheme, []byte(themeXML(theme)))
+	p.add("ppt/theme/theme2.xml", ctTheme, []byte(themeXML(nil)))
+
+	var masterRels rels
+	for i := range slideLayouts {
+		def := &slideLayouts[i]
+		name := fmt.Sprintf("ppt/slideLayouts/slideLayout%d.xml", i+1)
+		p.add(name, ctSlideLayout, []byte(layoutXML(def, p.size)))
+		var lr rels
+		lr.add(relSlideMaster, "../slideMasters/slideMaster1.xml")
+		p.add(relsName(name), "", lr.xml())
+		masterRels.add(relSlideLayout, fmt.Sprintf("../slideLayouts/slideLayout%d.xml", i+1))
+	}
+	masterRels.add(relTheme, "../theme/theme1.xml")
+	p.add("ppt/slideMasters/slideMaster1.xml", ctSlideMaster, []byte(masterXML(theme, p.size)))
+	p.add("ppt/slideMasters/_rels/slideMaster1.xml.rels", "", masterRels.xml())
+
+	var nmRels rels
+	nmRels.add(relTheme, "../theme/theme2.xml")
+	p.add("ppt/notesMasters/notesMaster1.xml", ctNotesMaster, []byte(notesMasterXML()))
+	p.add("ppt/notesMasters/_rels/notesMaster1.xml.rels", "", nmRels.xml())
+
+	var presRels rels
+	presRels.add(relSlideMaster, "slideMasters/slideMaster1.xml")
+	presRels.add(relNotesMaster, "notesMasters/notesMaster1.xml")
+	var slideRefs []string
+	settings := p.deck.HeaderFooters()
+	notes := 0
+	for k, slide := range p.deck.AllSlides() {
+		n := k + 1
+		name := fmt.Sprintf("ppt/slides/slide%d.xml", n)
+		sw := newSlideWriter(p, &slide)
+		p.add(name, ctSlide, []byte(sw.write(settings[k], n)))
+		if slide.HasNotes() {
+			notes++
+			notesName := fmt.Sprintf("ppt/notesSlides/notesSlide%d.xml", n)
+			nw := newSlideWriter(p, &slide)
+			p.add(notesName, ctNotesSlide, []byte(nw.writeNotes()))
+			nw.rels.add(relNotesMaster, "../notesMasters/notesMaster1.xml")
+			nw.rels.add(relSlide, fmt.Sprintf("../slides/slide%d.xml", n))
+			p.add(relsName(notesName), "", nw.rels.xml())
+			sw.rels.add(relNotesSlide, fmt.Sprintf("../notesSlides/notesSlide%d.xml", n))
+		}
+		p.add(relsName(name), "", sw.rels.xml())
+		slideRefs = append(slideRefs, presRels.add(relSlide, fmt.Sprintf("slides/slide%d.xml", n)))
+	}
+	presRels.add(relTheme, "theme/theme1.xml")
+	presRels.add(relPresProps, "presProps.xml")
+	presRels.add(relViewProps, "viewProps.xml")
+	presRels.add(relTableStyles, "tableStyles.xml")
+
+	p.add("ppt/presentation.xml", ctPresentation, []byte(p.presentationXML(slideRefs)))
+	p.add("ppt/_rels/presentation.xml.rels", "", presRels.xml())
+	p.add("ppt/presProps.xml", ctPresProps, []byte(xmlHeader+
+		`<p:presentationPr xmlns:a="`+nsA+`" xmlns:r="`+nsR+`" xmlns:p="`+nsP+`"/>`))
+	p.add("ppt/viewProps.xml", ctViewProps, []byte(xmlHeader+
+		`<p:viewPr xmlns:a="`+nsA+`" xmlns:r="`+nsR+`" xmlns:p="`+nsP+`">`+
+		`<p:normalViewPr><p:restoredLeft sz="15620"/><p:restoredTop sz="94660"/></p:normalViewPr>`+
+		`<p:gridSpacing cx="76200" cy="76200"/></p:viewPr>`))
+	p.add("ppt/tableStyles.xml", ctTableStyles, []byte(xmlHeader+
+		`<a:tblStyleLst xmlns:a="`+nsA+`" def="`+tableStyle+`"/>`))
+
+	p.add("docProps/core.xml", ctCoreProps, []byte(p.coreXML()))
+	p.add("docProps/app.xml", ctExtended, []byte(xmlHeader+
+		`<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties">`+
+		`<Application>slidekit</Application>`+
+		fmt.Sprintf("<Slides>%d</Slides><Notes>%d</Notes>", len(slideRefs), notes)+
+		`</Properties>`))
+
+	var rootRels rels
+	rootRels.add(relOfficeDocument, "ppt/presentation.xml")
+	rootRels.add(relCoreProps, "docProps/core.xml")
+	rootRels.add(relExtendedProps, "docProps/app.xml")
+	p.add("_rels/.rels", "", rootRels.xml())
+}
+
+// presentationXML returns the presentation part, listing the slides by
+// their relationship IDs and grouping them into the deck's sections.
+func (p *pkg) presentationXML(slideRefs []string) string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	fmt.Fprintf(&b, `<p:presentation xmlns:a="%s" xmlns:r="%s" xmlns:p="%s" saveSubsetFonts="1">`, nsA, nsR, nsP)
+	b.WriteString(`<p:sldMasterIdLst><p:sldMasterId id="2147483648" r:id="rId1"/></p:sldMasterIdLst>`)
+	b.WriteString(`<p:notesMasterIdLst><p:notesMasterId r:id="rId2"/></p:notesMasterIdLst>`)
+	if len(slideRefs) > 0 {
+		b.WriteString("<p:sldIdLst>")
+		for i, ref := range slideRefs {
+			fmt.Fprintf(&b, `<p:sldId id="%d" r:id="%s"/>`, firstSlideID+i, ref)
+		}
+		b.WriteString("</p:sldIdLst>")
+	}
+	fmt.Fprintf(&b, `<p:sldSz cx="%d" cy="%d"/>`, p.size.cx, p.size.cy)
+	b.WriteString(`<p:notesSz cx="6858000" cy="9144000"/>`)
+
+	if len(p.deck.Sections) > 0 {
+		b.WriteString(`<p:extLst><p:ext uri="{521415D9-36F7-43E2-AB2F-B90AF26B5E84}">`)
+		fmt.Fprintf(&b, `<p14:sectionLst xmlns:p14="%s">`, nsP14)
+		id := firstSlideID
+		for i, section := range p.deck.Sections {
+			fmt.Fprintf(&b, `<p14:section name="%s" id="%s"><p14:sldIdLst>`,
+				esc(cmp.Or(section.Title, section.ID)), sectionGUID(i, section.ID))
+			for range section.Slides {
+				fmt.Fprintf(&b, `<p14:sldId id="%d"/>`, id)
+				id++
+			}
+			b.WriteString("</p14:sldIdLst></p14:section>")
+		}
+		b.WriteString("</p14:sectionLst></p:ext></p:extLst>")
+	}
+	b.WriteString("</p:presentation>")
+	return b.String()
+}
+
+// firstSlideID is the ID of the first slide; PowerPoint numbers slides
+// from 256.
+const firstSlideID = 256
+
+// sectionGUID returns a stable GUID for the i-th section, so that writing
+// the same deck twice gives the same file.
+func sectionGUID(i int, id string) string {
+	return fmt.Sprintf("{%08X-%04X-4000-8000-%012X}", crc32.ChecksumIEEE([]byte(id)), i&0xFFFF, i)
+}
+
+// coreXML returns the document properties: title, author, description and
+// keywords.
+func (p *pkg) coreXML() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties"` +
+		` xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/"` +
+		` xmlns:dcmitype="http://purl.org/dc/dcmitype/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">`)
+	element := func(name, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			fmt.Fprintf(&b, "<%s>%s</%s>", name, esc(value), name)
+		}
+	}
+	element("dc:title", p.deck.Title)
+	element("dc:creator", p.deck.Meta.Author)
+	element("cp:keywords", strings.Join(p.deck.Meta.Keywords, ", "))
+	element("dc:description", p.deck.Meta.Description)
+	b.WriteString("</cp:coreProperties>")
+	return b.String()
+}
+
+// contentTypesXML returns the [Content_Types].xml part.
+func (p *pkg) contentTypesXML() []byte {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	for _, ext := range slices.Sorted(maps.Keys(p.defaults)) {
+		fmt.Fprintf(&b, `<Default Extension="%s" ContentType="%s"/>`, ext, p.defaults[ext])
+	}
+	for _, name := range slices.Sorted(maps.Keys(p.overrides)) {
+		fmt.Fprintf(&b, `<Override PartName="%s" ContentType="%s"/>`, name, p.overrides[name])
+	}
+	b.WriteString("</Types>")
+	return []byte(b.String())
+}
+
+// zip packs the parts into a zip archive. Timestamps are fixed so that the
+// same deck always gives the same bytes.
+func (p *pkg) zip() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	modified := time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	parts := append([]part{{name: "[Content_Types].xml", data: p.contentTypesXML()}}, p.parts...)
+	for _, part := range parts {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: part.name, Method: zip.Deflate, Modified: modified})
+		if err != nil {
+			return nil, fmt.Errorf("writing %s: %w", part.name, err)
+		}
+		if _, err := w.Write(part.data); err != nil {
+			return nil, fmt.Errorf("writing %s: %w", part.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("writing package: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// relsName returns the name of the relationships part of the part name.
+func relsName(name string) string {
+	dir, file := filepath.Split(name)
+	return dir + "_rels/" + file + ".rels"
+}
+
+// rels collects the relationships of a part, numbering them rId1, rId2
+// and so on.
+type rels struct {
+	items []rel
+}
+
+type rel struct {
+	id, typ, target string
+	external        bool
+}
+
+// add adds a relationship to a part in the package and returns its ID.
+func (r *rels) add(typ, target string) string {
+	return r.addRel(typ, target, false)
+}
+
+// addExternal adds a relationship to an external target, such as a web
+// page, and returns its ID.
+func (r *rels) addExternal(typ, target string) string {
+	return r.addRel(typ, target, true)
+}
+
+func (r *rels) addRel(typ, target string, external bool) string {
+	for _, item := range r.items {
+		if item.typ == typ && item.target == target && item.external == external {
+			return item.id
+		}
+	}
+	id := fmt.Sprintf("rId%d", len(r.items)+1)
+	r.items = append(r.items, rel{id: id, typ: typ, target: target, external: external})
+	return id
+}
+
+func (r *rels) xml() []byte {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for _, item := range r.items {
+		mode := ""
+		if item.external {
+			mode = ` TargetMode="External"`
+		}
+		fmt.Fprintf(&b, `<Relationship Id="%s" Type="%s" Target="%s"%s/>`, item.id, item.typ, esc(item.target), mode)
+	}
+	b.WriteString("</Relationships>")
+	return []byte(b.String())
+}
+
+// esc escapes s for XML text and attribute values.
+func esc(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,426 @@
+package pptx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// testDeck returns a deck with a slide in most layouts, nested and
+// numbered lists, code, a table, speaker notes and two sections.
+func testDeck() *model.Deck {
+	wipe := "wipe"
+	footer := "Draft & internal"
+	on := true
+	return &model.Deck{
+		Title: "Platform Roadmap",
+		Meta: model.Meta{
+			Author:   "Platform Team",
+			Keywords: []string{"roadmap", "2026"},
+			Size:     "16:9",
+		},
+		Transition:   "push",
+		Theme:        &model.Theme{Name: "harbor", Primary: "#0f6cbd", Background: "#f5f5f5", Font: "Segoe UI, sans-serif"},
+		HeaderFooter: &model.HeaderFooter{Footer: &footer, PageNumber: &on},
+		Sections: []model.Section{
+			{ID: "overview", Title: "Overview", Slides: []model.Slide{
+				{ID: "cover", Layout: model.LayoutTitle, Title: "Platform Roadmap", Subtitle: "2026"},
+				{
+					ID: "goals", Layout: model.LayoutTitleBody, Title: "Goals", Transition: &wipe,
+					Background: &model.Background{Color: "teal"},
+					Body: []model.Block{
+						model.NewBullet("Faster builds", 0),
+						model.NewBullet("Remote cache", 1),
+						model.NewBullet("Shared runners", 2),
+						model.NewNumbered("Measure", 0),
+						model.NewNumbered("Fix", 0),
+					},
+					Notes: []model.Block{model.NewParagraph("Start with the numbers."), model.NewPause(time.Second)},
+				},
+			}},
+			{ID: "engineering", Title: "Engineering", Slides: []model.Slide{
+				{ID: "engineering-2", Layout: model.LayoutSection, Title: "Engineering", Subtitle: "Q1 to Q4"},
+				{
+					ID: "builds", Layout: model.LayoutTitleBody, Title: "Build times",
+					Body: []model.Block{
+						{Kind: model.BlockParagraph, Text: "Down by half", Runs: []model.Span{
+							{Text: "Down ", Italic: true}, {Text: "by half", Href: "https://example.com/ci"},
+						}},
+						model.NewCode("go vet ./...\ngo test ./...", "sh"),
+						model.NewTable([]string{"Step", "Minutes"}, [][]string{{"Test", "12"}}),
+					},
+				},
+				{
+					ID: "options", Layout: model.LayoutComparison, Title: "Options",
+					Slots: model.Slots{
+						model.SlotLeft:  {model.NewHeading("Buy", 3), model.NewBullet("Sooner", 0)},
+						model.SlotRight: {model.NewHeading("Build", 3), model.NewBullet("Cheaper", 0)},
+					},
+				},
+				{
+					ID: "tradeoffs", Layout: model.LayoutTitleTwoCol, Title: "Trade-offs",
+					Slots: model.Slots{
+						model.SlotLeft:  {model.NewBullet("Speed", 0)},
+						model.SlotRight: {model.NewBullet("Cost", 0)},
+					},
+				},
+				{
+					ID: "questions", Layout: model.LayoutBlank, Title: "Questions?",
+					Body: []model.Block{model.NewBullet("Roadmap", 0), model.NewBullet("Slides", 1)},
+				},
+				{
+					ID: "team", Layout: model.LayoutImage,
+					Background: &model.Background{Images: []model.Block{model.NewBackgroundImage("team.png")}},
+				},
+			}},
+		},
+	}
+}
+
+// writePNG writes a w×h PNG image to path.
+func writePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// unzip returns the parts of a package by name, checking that every XML
+// part is well-formed.
+func unzip(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("reading package: %v", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("opening %s: %v", f.Name, err)
+		}
+		content, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			t.Fatalf("reading %s: %v", f.Name, err)
+		}
+		parts[f.Name] = string(content)
+		if strings.HasSuffix(f.Name, ".xml") || strings.HasSuffix(f.Name, ".rels") {
+			dec := xml.NewDecoder(bytes.NewReader(content))
+			for {
+				if _, err := dec.Token(); errors.Is(err, io.EOF) {
+					break
+				} else if err != nil {
+					t.Errorf("%s is not well-formed: %v", f.Name, err)
+					break
+				}
+			}
+		}
+	}
+	if len(zr.File) == 0 || zr.File[0].Name != "[Content_Types].xml" {
+		t.Error("expected [Content_Types].xml to be the first part")
+	}
+	return parts
+}
+
+// encodeTestDeck encodes testDeck, with its background picture, and
+// returns the parts of the presentation.
+func encodeTestDeck(t *testing.T) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "team.png"), 400, 300)
+	data, err := (&Writer{BaseDir: dir}).Encode(testDeck())
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	return unzip(t, data)
+}
+
+// containsAll reports the strings of wants that part, named name, lacks.
+func containsAll(t *testing.T, parts map[string]string, name string, wants ...string) {
+	t.Helper()
+	part, ok := parts[name]
+	if !ok {
+		t.Errorf("missing part %s", name)
+		return
+	}
+	for _, want := range wants {
+		if !strings.Contains(part, want) {
+			t.Errorf("expected %s to contain %q, got:\n%s", name, want, part)
+		}
+	}
+}
+
+func TestEncode(t *testing.T) {
+	parts := encodeTestDeck(t)
+	containsAll(t, parts, "[Content_Types].xml",
+		`<Default Extension="png" ContentType="image/png"/>`,
+		`<Override PartName="/ppt/slides/slide8.xml" ContentType="`+ctSlide+`"/>`)
+	containsAll(t, parts, "docProps/core.xml",
+		"<dc:title>Platform Roadmap</dc:title>", "<dc:creator>Platform Team</dc:creator>", "<cp:keywords>roadmap, 2026</cp:keywords>")
+	containsAll(t, parts, "docProps/app.xml", "<Slides>8</Slides><Notes>1</Notes>")
+	containsAll(t, parts, "ppt/presentation.xml", `<p:sldSz cx="12192000" cy="6858000"/>`)
+	// The theme's colors and font
+	containsAll(t, parts, "ppt/theme/theme1.xml",
+		`<a:lt1><a:srgbClr val="F5F5F5"/></a:lt1>`,
+		`<a:accent1><a:srgbClr val="0F6CBD"/></a:accent1>`,
+		`<a:majorFont><a:latin typeface="Segoe UI"/>`)
+	containsAll(t, parts, "ppt/slideMasters/slideMaster1.xml", `<a:schemeClr val="accent1"/>`, `<p:sldLayoutId id="2147483655" r:id="rId7"/>`)
+	// The deck's footer and transition show on every slide
+	containsAll(t, parts, "ppt/slides/slide1.xml",
+		`<a:t>Draft &amp; internal</a:t>`,
+		`<a:fld id="`+slideNumberField+`" type="slidenum"><a:rPr lang="en-US"/><a:t>1</a:t></a:fld>`,
+		`<p:transition spd="med"><p:push/></p:transition>`)
+	containsAll(t, parts, "ppt/slides/slide2.xml",
+		`<p:bg><p:bgPr><a:solidFill><a:srgbClr val="008080"/></a:solidFill><a:effectLst/></p:bgPr></p:bg>`,
+		`<p:transition spd="med"><p:wipe/></p:transition>`)
+	containsAll(t, parts, "ppt/slides/slide4.xml",
+		`<a:rPr lang="en-US" i="1" dirty="0"></a:rPr><a:t>Down </a:t>`,
+		`<a:hlinkClick r:id="rId2"/>`,
+		`<a:tableStyleId>`+tableStyle+`</a:tableStyleId>`, `<a:t>Minutes</a:t>`)
+	containsAll(t, parts, "ppt/slides/_rels/slide4.xml.rels", `Target="https://example.com/ci" TargetMode="External"`)
+	// A single background image fills the slide background
+	containsAll(t, parts, "ppt/slides/slide8.xml", `<p:bg><p:bgPr><a:blipFill dpi="0" rotWithShape="1"><a:blip r:embed="rId2">`, `<a:srcRect t="`)
+	containsAll(t, parts, "ppt/slides/_rels/slide8.xml.rels", `Target="../media/image1.png"`)
+	if _, ok := parts["ppt/media/image1.png"]; !ok {
+		t.Error("expected the background image to be embedded")
+	}
+}
+
+// placeholderRe matches the placeholder a shape fills.
+var placeholderRe = regexp.MustCompile(`<p:ph [^>]*/>`)
+
+func TestEncodePlaceholders(t *testing.T) {
+	parts := encodeTestDeck(t)
+	const title = `<p:ph type="title"/>`
+	body := func(idx int) string { return fmt.Sprintf(`<p:ph type="body" idx="%d"/>`, idx) }
+	footers := []string{`<p:ph type="ftr" sz="quarter" idx="11"/>`, `<p:ph type="sldNum" sz="quarter" idx="12"/>`}
+
+	// Each layout has its own layout part, whose type and placeholders
+	// PowerPoint uses when switching layouts, and the slides fill them
+	for _, tt := range []struct {
+		slide, layout int
+		typ           string
+		phs           []string
+	}{
+		{1, 1, "title", []string{`<p:ph type="ctrTitle"/>`, `<p:ph type="subTitle" idx="1"/>`}},
+		{2, 2, "obj", []string{title, body(1)}},
+		{6, 3, "twoObj", []string{title, body(1), body(2)}},
+		{3, 4, "secHead", []string{title, body(1)}},
+		{7, 5, "blank", nil},
+		{8, 6, "picTx", nil},
+		{5, 7, "twoTxTwoObj", []string{title, body(1), body(2), body(3), body(4)}},
+	} {
+		want := append(tt.phs, footers...)
+		layout := fmt.Sprintf("ppt/slideLayouts/slideLayout%d.xml", tt.layout)
+		containsAll(t, parts, layout, ` type="`+tt.typ+`" preserve="1">`)
+		if got := placeholderRe.FindAllString(parts[layout], -1); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s placeholders = %v, want %v", layout, got, want)
+		}
+		slide := fmt.Sprintf("ppt/slides/slide%d.xml", tt.slide)
+		containsAll(t, parts, relsName(slide), fmt.Sprintf(`Target="../slideLayouts/slideLayout%d.xml"`, tt.layout))
+		if got := placeholderRe.FindAllString(parts[slide], -1); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s placeholders = %v, want %v", slide, got, want)
+		}
+	}
+
+	// Without a title placeholder, the title goes in a text box
+	containsAll(t, parts, "ppt/slides/slide7.xml", `name="Title 1"`, `<a:t>Questions?</a:t>`)
+}
+
+func TestEncodeBulletLevels(t *testing.T) {
+	parts := encodeTestDeck(t)
+
+	// In a placeholder, list items only set their level and take their
+	// bullet and indent from the master's text style for it
+	containsAll(t, parts, "ppt/slides/slide2.xml",
+		`<a:p><a:r><a:rPr lang="en-US" dirty="0"></a:rPr><a:t>Faster builds</a:t>`,
+		`<a:p><a:pPr lvl="1"/><a:r><a:rPr lang="en-US" dirty="0"></a:rPr><a:t>Remote cache</a:t>`,
+		`<a:p><a:pPr lvl="2"/><a:r><a:rPr lang="en-US" dirty="0"></a:rPr><a:t>Shared runners</a:t>`,
+		`<a:p><a:pPr><a:buFont typeface="+mj-lt"/><a:buAutoNum type="arabicPeriod"/></a:pPr><a:r><a:rPr lang="en-US" dirty="0"></a:rPr><a:t>Measure</a:t>`)
+	containsAll(t, parts, "ppt/slideMasters/slideMaster1.xml",
+		`<a:lvl1pPr marL="228600" indent="-228600"`,
+		`<a:lvl2pPr marL="685800" indent="-228600"`,
+		`<a:lvl3pPr marL="1143000" indent="-228600"`)
+	// A text box has no master style to inherit, so its items spell out
+	// their bullet and indent
+	containsAll(t, parts, "ppt/slides/slide7.xml",
+		`<a:pPr marL="228600" lvl="0" indent="-228600"><a:buFont typeface="Arial"/><a:buChar char="•"/></a:pPr>`,
+		`<a:pPr marL="685800" lvl="1" indent="-228600"><a:buFont typeface="Arial"/><a:buChar char="•"/></a:pPr>`)
+}
+
+func TestEncodeCode(t *testing.T) {
+	parts := encodeTestDeck(t)
+	slide := parts["ppt/slides/slide4.xml"]
+
+	// Code goes in a shaded text box, described by its language, with a
+	// monospace paragraph per line and no bullets
+	i := strings.Index(slide, `name="Code 1" descr="sh"`)
+	if i < 0 {
+		t.Fatalf("expected a code box, got:\n%s", slide)
+	}
+	box := slide[i : i+strings.Index(slide[i:], "</p:sp>")]
+	for _, want := range []string{
+		`<p:cNvSpPr txBox="1"/>`,
+		`<a:solidFill><a:srgbClr val="F2F2F2"/></a:solidFill>`,
+		`<a:pPr marL="0" indent="0"><a:buNone/></a:pPr>`,
+		`<a:latin typeface="Courier New"/>`,
+		`<a:t>go vet ./...</a:t>`,
+	} {
+		if !strings.Contains(box, want) {
+			t.Errorf("expected the code box to contain %q, got:\n%s", want, box)
+		}
+	}
+	if n := strings.Count(box, "<a:p>"); n != 2 {
+		t.Errorf("expected a paragraph per line of code, got %d", n)
+	}
+	if strings.Contains(box, "<p:ph ") {
+		t.Error("expected the code box outside the body placeholder")
+	}
+}
+
+func TestEncodeNotes(t *testing.T) {
+	parts := encodeTestDeck(t)
+
+	// Only slides with notes get a notes slide, linked both ways and to
+	// the notes master
+	var notes []string
+	for name := range parts {
+		if strings.HasPrefix(name, "ppt/notesSlides/notesSlide") {
+			notes = append(notes, name)
+		}
+	}
+	if !reflect.DeepEqual(notes, []string{"ppt/notesSlides/notesSlide2.xml"}) {
+		t.Errorf("notes slides = %v, want only the second slide's", notes)
+	}
+	containsAll(t, parts, "[Content_Types].xml",
+		`<Override PartName="/ppt/notesSlides/notesSlide2.xml" ContentType="`+ctNotesSlide+`"/>`,
+		`<Override PartName="/ppt/notesMasters/notesMaster1.xml" ContentType="`+ctNotesMaster+`"/>`)
+	containsAll(t, parts, "ppt/slides/_rels/slide2.xml.rels", `Target="../notesSlides/notesSlide2.xml"`)
+	containsAll(t, parts, "ppt/notesSlides/_rels/notesSlide2.xml.rels",
+		`Target="../notesMasters/notesMaster1.xml"`, `Target="../slides/slide2.xml"`)
+	containsAll(t, parts, "ppt/presentation.xml", `<p:notesMasterIdLst><p:notesMasterId r:id="rId2"/></p:notesMasterIdLst>`)
+
+	// The notes go in the body placeholder below the slide image, pause
+	// cues as text
+	containsAll(t, parts, "ppt/notesSlides/notesSlide2.xml",
+		`<p:ph type="sldImg"/>`, `<p:ph type="body" idx="1"/>`,
+		`<a:t>Start with the numbers.</a:t>`, `<a:t>[pause 1s]</a:t>`)
+}
+
+func TestEncodeSections(t *testing.T) {
+	parts := encodeTestDeck(t)
+
+	// Sections list their slides by the IDs of the slide list, with IDs of
+	// their own that stay the same between writes
+	containsAll(t, parts, "ppt/presentation.xml",
+		`<p:sldIdLst><p:sldId id="256" r:id="rId3"/>`,
+		`<p:sldId id="263" r:id="rId10"/></p:sldIdLst>`,
+		`<p:ext uri="{521415D9-36F7-43E2-AB2F-B90AF26B5E84}"><p14:sectionLst xmlns:p14="`+nsP14+`">`,
+		`<p14:section name="Overview" id="`+sectionGUID(0, "overview")+`">`+
+			`<p14:sldIdLst><p14:sldId id="256"/><p14:sldId id="257"/></p14:sldIdLst></p14:section>`,
+		`<p14:section name="Engineering" id="`+sectionGUID(1, "engineering")+`">`+
+			`<p14:sldIdLst><p14:sldId id="258"/><p14:sldId id="259"/><p14:sldId id="260"/>`+
+			`<p14:sldId id="261"/><p14:sldId id="262"/><p14:sldId id="263"/></p14:sldIdLst></p14:section>`)
+
+	// A section without a title is named after its ID
+	deck := &model.Deck{Sections: []model.Section{{ID: "appendix", Slides: []model.Slide{{ID: "extra", Title: "Extra"}}}}}
+	data, err := NewWriter().Encode(deck)
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	containsAll(t, unzip(t, data), "ppt/presentation.xml", `<p14:section name="appendix" id="`+sectionGUID(0, "appendix")+`">`)
+}
+
+func TestEncodeDeterministic(t *testing.T) {
+	first, err := NewWriter().Encode(testDeck())
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	second, err := NewWriter().Encode(testDeck())
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("expected the same deck to encode to the same bytes")
+	}
+}
+
+func TestEncodeLayout(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "chart.png"), 800, 400)
+	split := model.NewBackgroundImage("chart.png")
+	split.Image.Split = model.SplitLeft
+	deck := &model.Deck{Meta: model.Meta{Size: "4:3"}, Sections: []model.Section{{ID: "main", Slides: []model.Slide{
+		{ID: "chart", Layout: model.LayoutBlank, Title: "Chart", Body: []model.Block{
+			{Kind: model.BlockImage, URL: "chart.png", Alt: "Sales chart", Image: &model.ImageAttrs{Width: "200px"}},
+			model.NewParagraph("Up and to the right"),
+		}},
+		{ID: "split", Layout: model.LayoutTitleBody, Title: "Split", Body: []model.Block{split, model.NewBullet("Right side", 0)}},
+		{ID: "cols", Layout: model.LayoutTitleTwoCol, Title: "Columns", Slots: model.Slots{
+			model.SlotLeft:    {model.NewBullet("Left", 0)},
+			model.SlotRight:   {model.NewImage("https://example.com/logo.png", "Logo")},
+			model.SlotCaption: {model.NewParagraph("Caption")},
+		}},
+	}}}}
+	data, err := (&Writer{BaseDir: dir}).Encode(deck)
+	if err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	parts := unzip(t, data)
+
+	if want := `<p:sldSz cx="9144000" cy="6858000"/>`; !strings.Contains(parts["ppt/presentation.xml"], want) {
+		t.Errorf("expected a 4:3 slide size %s", want)
+	}
+	// The blank layout has no placeholders: the title is a text box, and
+	// the picture is sized from its width attribute
+	chart := parts["ppt/slides/slide1.xml"]
+	for _, want := range []string{
+		`name="Title 1"`, `<a:t>Chart</a:t>`,
+		`name="Picture 1" descr="Sales chart"`, `<a:ext cx="1905000" cy="952500"/>`,
+		`name="TextBox 1"`, `<a:buNone/>`,
+	} {
+		if !strings.Contains(chart, want) {
+			t.Errorf("expected the chart slide to contain %q, got:\n%s", want, chart)
+		}
+	}
+	// A split background takes the left half, moving the title and body
+	// to the right
+	splitXML := parts["ppt/slides/slide2.xml"]
+	for _, want := range []string{
+		`name="Background 1"`, `<a:off x="0" y="0"/><a:ext cx="4572000" cy="6858000"/>`,
+		`<p:ph type="title"/></p:nvPr></p:nvSpPr><p:spPr><a:xfrm><a:off x="5029200"`,
+	} {
+		if !strings.Contains(splitXML, want) {
+			t.Errorf("expected the split slide to contain %q, got:\n%s", want, splitXML)
+		}
+	}
+	// Web images are linked rather than embedded
+	cols := parts["ppt/slides/slide3.xml"]
+	for _, want := range []string{`<p:ph type="body" idx="1"/>`, `<a:blip r:link="rId2">`, `name="Caption 1"`} {
+		if !strings.Contains(cols, want) {
+			t.Errorf("expected the columns slide to contain %q, got:\n%s", want, cols)
+		}
+	}
+	if rels := parts["ppt/slides/_rels/slide3.xml.rels"]; !strings.Contains(rels, `Target="https://example.com/logo.png" TargetMode="External"`) {
+		t.Errorf("expected a link to the web image, got:\n%s", rels)
+	}
+}
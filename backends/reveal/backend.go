@@ -0,0 +1,91 @@
+// Package reveal implements the Reveal.js HTML backend for slidekit.
+package reveal
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/grokify/slidekit/internal/deckfile"
+	"github.com/grokify/slidekit/model"
+)
+
+// Backend implements the model.Backend interface for Reveal.js HTML files.
+type Backend struct {
+	reader *Reader
+	writer *Writer
+}
+
+// NewBackend creates a new Reveal.js backend.
+func NewBackend() *Backend {
+	return &Backend{
+		reader: NewReader(),
+		writer: NewWriter(),
+	}
+}
+
+// Info returns backend metadata.
+func (b *Backend) Info() model.BackendInfo {
+	return model.BackendInfo{
+		Name:    "reveal",
+		Version: "0.1.0",
+		Capabilities: []string{
+			model.CapabilityRead,
+			model.CapabilityWrite,
+			model.CapabilityCreate,
+			model.CapabilitySections,
+			model.CapabilityTransitions,
+		},
+	}
+}
+
+// Read loads a Reveal.js presentation from a file.
+func (b *Backend) Read(ctx context.Context, ref model.Ref) (*model.Deck, error) {
+	return b.reader.Read(ctx, ref)
+}
+
+// Plan computes changes needed to reach desired state.
+func (b *Backend) Plan(ctx context.Context, ref model.Ref, desired *model.Deck) (*model.Diff, error) {
+	current, err := b.reader.Read(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("reading current deck: %w", err)
+	}
+
+	return model.ComputeDiff(current, desired), nil
+}
+
+// Apply writes the diff to the file. Unlike Marp files, which are patched
+// in place, the page is regenerated from the updated deck, so markup the
+// reader has no model for is kept only where it was read as raw HTML.
+func (b *Backend) Apply(ctx context.Context, ref model.Ref, diff *model.Diff) error {
+	if diff.IsEmpty() {
+		return nil
+	}
+
+	deck, err := b.reader.Read(ctx, ref)
+	if err != nil {
+		return fmt.Errorf("reading current deck: %w", err)
+	}
+	if err := deck.ApplyDiff(diff); err != nil {
+		return fmt.Errorf("applying diff: %w", err)
+	}
+
+	return deckfile.WriteApplied(ctx, ref.Path, []byte(b.writer.Encode(deck)))
+}
+
+// Create creates a new Reveal.js HTML file.
+func (b *Backend) Create(_ context.Context, deck *model.Deck) (model.Ref, error) {
+	// Default path if not set
+	path := "presentation.html"
+	if deck.ID != "" {
+		path = deck.ID + ".html"
+	}
+
+	if err := b.writer.WriteFile(deck, path); err != nil {
+		return model.Ref{}, fmt.Errorf("writing deck: %w", err)
+	}
+
+	return model.Ref{
+		Backend: "reveal",
+		Path:    path,
+	}, nil
+}
@@ -0,0 +1,48 @@
+package reveal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grokify/slidekit/internal/backendtest"
+	"github.com/grokify/slidekit/model"
+)
+
+func TestBackendCreate(t *testing.T) {
+	backend := NewBackend()
+	if !backend.Info().HasCapability(model.CapabilityTransitions) {
+		t.Error("expected the transitions capability")
+	}
+
+	deck := testDeck()
+	deck.ID = filepath.Join(t.TempDir(), "review")
+	ref, err := backend.Create(context.Background(), deck)
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	if ref.Backend != "reveal" || ref.Path != deck.ID+".html" {
+		t.Errorf("unexpected ref %+v", ref)
+	}
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		t.Fatalf("reading created deck: %v", err)
+	}
+	if !strings.HasPrefix(string(data), "<!DOCTYPE html>") {
+		t.Errorf("expected an HTML page, got:\n%s", data)
+	}
+}
+
+func TestBackendPlanApply(t *testing.T) {
+	deck := testDeck()
+	deck.ID = filepath.Join(t.TempDir(), "review")
+	ref, err := NewBackend().Create(context.Background(), deck)
+	if err != nil {
+		t.Fatalf("Create error: %v", err)
+	}
+	backendtest.PlanApply(t, NewBackend(), ref, func(deck *model.Deck) {
+		deck.FindSlide("agenda").Title = "Plan"
+	})
+}
@@ -0,0 +1,487 @@
+package reveal
+
+import (
+	"cmp"
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// readBlocks reads the children of n as blocks. fragment marks them all as
+// fragments, as inside an element with the fragment class.
+func readBlocks(n *html.Node, fragment bool) []model.Block {
+	var blocks []model.Block
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		blocks = append(blocks, readBlock(child, fragment)...)
+	}
+	return blocks
+}
+
+// readBlock reads the node n as blocks. Elements with no block of their own
+// are kept as raw HTML paragraphs, which the Writer writes back as-is.
+func readBlock(n *html.Node, fragment bool) []model.Block {
+	switch n.Type {
+	case html.TextNode:
+		// Loose text, as in notes written without paragraphs, gives a
+		// paragraph per line
+		var blocks []model.Block
+		for _, line := range strings.Split(n.Data, "\n") {
+			if line = collapseSpace(line); strings.TrimSpace(line) != "" {
+				blocks = append(blocks, withFragment(model.NewParagraph(strings.TrimSpace(line)), fragment))
+			}
+		}
+		return blocks
+	case html.ElementNode:
+	default:
+		return nil
+	}
+
+	fragment = fragment || hasClass(n, "fragment")
+	block := model.Block{Kind: model.BlockParagraph}
+	switch n.DataAtom {
+	case atom.P:
+		if hasClass(n, "pause") {
+			block = readPause(n)
+			break
+		}
+		if images := onlyImages(n); len(images) > 0 {
+			var blocks []model.Block
+			for _, img := range images {
+				blocks = append(blocks, withFragment(readImage(img), fragment))
+			}
+			return blocks
+		}
+		block = textBlock(model.BlockParagraph, readSpans(n))
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		block = textBlock(model.BlockHeading, readSpans(n))
+		block.Level = headingLevel(n)
+	case atom.Ul, atom.Ol:
+		return readList(n, 0, fragment)
+	case atom.Pre:
+		block = readPre(n)
+	case atom.Div, atom.Figure, atom.Article:
+		switch {
+		case hasClass(n, "math"):
+			block = model.NewMath(stripMathDelims(textContent(n)))
+		case hasClass(n, "mermaid"):
+			block = model.NewDiagram(strings.TrimSpace(textContent(n)), model.DiagramMermaid)
+		default:
+			return readBlocks(n, fragment)
+		}
+	case atom.Img:
+		block = readImage(n)
+	case atom.Blockquote:
+		block = textBlock(model.BlockQuote, readSpans(n))
+	case atom.Table:
+		block = readTable(n)
+	default:
+		var b strings.Builder
+		if err := html.Render(&b, n); err != nil {
+			return nil
+		}
+		block = model.NewParagraph(b.String())
+	}
+	if block.Kind == model.BlockParagraph && block.Text == "" {
+		return nil
+	}
+	return []model.Block{withFragment(block, fragment)}
+}
+
+func withFragment(block model.Block, fragment bool) model.Block {
+	block.Fragment = block.Fragment || fragment
+	return block
+}
+
+// textBlock returns a block of kind with the text of spans. Runs are only
+// kept for styled text.
+func textBlock(kind model.BlockKind, spans []model.Span) model.Block {
+	block := model.Block{Kind: kind, Text: model.RunsText(spans)}
+	if slices.ContainsFunc(spans, func(s model.Span) bool { return !s.IsPlain() }) {
+		block.Runs = spans
+	}
+	return block
+}
+
+// readList reads the items of a <ul> or <ol> list at level, and the lists
+// nested in them one level deeper.
+func readList(n *html.Node, level int, fragment bool) []model.Block {
+	kind := model.BlockBullet
+	if n.DataAtom == atom.Ol {
+		kind = model.BlockNumbered
+	}
+	fragment = fragment || hasClass(n, "fragment")
+
+	var blocks []model.Block
+	for item := range elements(n, atom.Li) {
+		block := textBlock(kind, readSpans(item))
+		block.Level = level
+		block.Fragment = fragment || hasClass(item, "fragment")
+		blocks = append(blocks, block)
+		for child := item.FirstChild; child != nil; child = child.NextSibling {
+			if isList(child) {
+				blocks = append(blocks, readList(child, level+1, fragment)...)
+			}
+		}
+	}
+	return blocks
+}
+
+func isList(n *html.Node) bool {
+	return n.Type == html.ElementNode && (n.DataAtom == atom.Ul || n.DataAtom == atom.Ol)
+}
+
+// readPre reads a <pre> element: a diagram if it names a diagram dialect,
+// or else code, in the language named by the class of its <code>.
+func readPre(n *html.Node) model.Block {
+	dialect := attrOf(n, "data-dialect")
+	if dialect == "" && hasClass(n, "mermaid") {
+		dialect = model.DiagramMermaid
+	}
+	if dialect != "" || hasClass(n, "diagram") {
+		return model.NewDiagram(strings.Trim(textContent(n), "\n"), dialect)
+	}
+
+	code := findChild(n, atom.Code)
+	if code == nil {
+		code = n
+	}
+	lang := ""
+	for _, class := range strings.Fields(attrOf(code, "class")) {
+		if after, ok := strings.CutPrefix(class, "language-"); ok {
+			lang = after
+		} else if after, ok := strings.CutPrefix(class, "lang-"); ok {
+			lang = after
+		}
+	}
+	text := strings.TrimRight(strings.TrimLeft(textContent(code), "\n"), " \t\n")
+	return model.NewCode(text, lang)
+}
+
+// stripMathDelims returns the TeX inside display math delimiters.
+func stripMathDelims(tex string) string {
+	tex = strings.TrimSpace(tex)
+	for _, delims := range [][2]string{{`\[`, `\]`}, {"$$", "$$"}} {
+		if inner, ok := strings.CutPrefix(tex, delims[0]); ok {
+			if inner, ok := strings.CutSuffix(inner, delims[1]); ok {
+				return strings.TrimSpace(inner)
+			}
+		}
+	}
+	return tex
+}
+
+// onlyImages returns the images of a paragraph that holds nothing else,
+// or nil.
+func onlyImages(n *html.Node) []*html.Node {
+	var images []*html.Node
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case child.Type == html.ElementNode && child.DataAtom == atom.Img:
+			images = append(images, child)
+		case child.Type == html.TextNode && strings.TrimSpace(child.Data) == "":
+		default:
+			return nil
+		}
+	}
+	return images
+}
+
+var reCSSFilter = regexp.MustCompile(`([a-z-]+)\(([^)]*)\)`)
+
+// readImage reads an <img> element, with its size and filters from its
+// width and height attributes and inline style.
+func readImage(n *html.Node) model.Block {
+	block := model.NewImage(attrOf(n, "src"), attrOf(n, "alt"))
+	attrs := model.ImageAttrs{Width: attrOf(n, "width"), Height: attrOf(n, "height")}
+	for decl := range strings.SplitSeq(attrOf(n, "style"), ";") {
+		prop, value, ok := strings.Cut(decl, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(prop) {
+		case "width":
+			attrs.Width = value
+		case "height":
+			attrs.Height = value
+		case "filter":
+			for _, m := range reCSSFilter.FindAllStringSubmatch(value, -1) {
+				attrs.Filters = append(attrs.Filters, model.ImageFilter{Name: m[1], Value: m[2]})
+			}
+		}
+	}
+	if attrs.Width != "" || attrs.Height != "" || len(attrs.Filters) > 0 {
+		block.Image = &attrs
+	}
+	return block
+}
+
+// readTable reads a table. Its header is the <thead> row, or the first
+// row without one; column alignments come from the header cells.
+func readTable(n *html.Node) model.Block {
+	var rows [][]*html.Node
+	header := -1
+	for tr := range descendants(n, atom.Tr) {
+		if header < 0 && tr.Parent.DataAtom == atom.Thead {
+			header = len(rows)
+		}
+		var cells []*html.Node
+		for cell := tr.FirstChild; cell != nil; cell = cell.NextSibling {
+			if cell.Type == html.ElementNode && (cell.DataAtom == atom.Th || cell.DataAtom == atom.Td) {
+				cells = append(cells, cell)
+			}
+		}
+		rows = append(rows, cells)
+	}
+	header = max(header, 0)
+
+	table := &model.Table{}
+	if len(rows) == 0 {
+		return model.Block{Kind: model.BlockTable, Table: table}
+	}
+	for i, row := range rows {
+		var cells []model.TableCell
+		for _, cell := range row {
+			b := textBlock(model.BlockParagraph, readSpans(cell))
+			cells = append(cells, model.TableCell{Text: b.Text, Runs: b.Runs})
+		}
+		if i == header {
+			table.Header = cells
+		} else {
+			table.Rows = append(table.Rows, cells)
+		}
+	}
+	for _, cell := range rows[header] {
+		table.Align = append(table.Align, cellAlign(cell))
+	}
+	if !slices.ContainsFunc(table.Align, func(a model.Alignment) bool { return a != model.AlignDefault }) {
+		table.Align = nil
+	}
+	return model.Block{Kind: model.BlockTable, Table: table}
+}
+
+var reTextAlign = regexp.MustCompile(`text-align\s*:\s*(left|center|right)`)
+
+// cellAlign returns the alignment of a table cell from its style or its
+// align attribute.
+func cellAlign(n *html.Node) model.Alignment {
+	if m := reTextAlign.FindStringSubmatch(attrOf(n, "style")); m != nil {
+		return model.Alignment(m[1])
+	}
+	if align := model.Alignment(attrOf(n, "align")); align.IsValid() {
+		return align
+	}
+	return model.AlignDefault
+}
+
+// reInlineMath matches inline math written between \( and \).
+var reInlineMath = regexp.MustCompile(`\\\((.+?)\\\)`)
+
+// readSpans reads the inline content of n as styled runs, with whitespace
+// collapsed as a browser shows it. Nested lists are left out, as they are
+// blocks of their own.
+func readSpans(n *html.Node) []model.Span {
+	var p spanReader
+	p.read(n, model.Span{})
+	if k := len(p.runs); k > 0 {
+		p.runs[0].Text = strings.TrimLeft(p.runs[0].Text, " ")
+		p.runs[k-1].Text = strings.TrimRight(p.runs[k-1].Text, " ")
+	}
+	return slices.DeleteFunc(p.runs, func(s model.Span) bool { return s.Text == "" })
+}
+
+type spanReader struct {
+	runs []model.Span
+}
+
+// add appends text with the given style, merging it into the previous run
+// when the styles match. A space is dropped after another.
+func (p *spanReader) add(text string, style model.Span) {
+	k := len(p.runs)
+	if k > 0 && strings.HasSuffix(p.runs[k-1].Text, " ") {
+		text = strings.TrimLeft(text, " ")
+	}
+	if text == "" {
+		return
+	}
+	if k > 0 && p.runs[k-1].SameStyle(style) {
+		p.runs[k-1].Text += text
+		return
+	}
+	style.Text = text
+	p.runs = append(p.runs, style)
+}
+
+func (p *spanReader) read(n *html.Node, style model.Span) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.TextNode:
+			p.readText(collapseSpace(child.Data), style)
+			continue
+		case html.ElementNode:
+		default:
+			continue
+		}
+
+		inner := style
+		switch child.DataAtom {
+		case atom.Ul, atom.Ol:
+			continue
+		case atom.Br:
+			p.add(" ", style)
+			continue
+		case atom.Strong, atom.B:
+			inner.Bold = true
+		case atom.Em, atom.I:
+			inner.Italic = true
+		case atom.Code:
+			inner.Code = true
+		case atom.Del, atom.S, atom.Strike:
+			inner.Strike = true
+		case atom.A:
+			inner.Href = cmp.Or(attrOf(child, "href"), style.Href)
+		}
+		p.read(child, inner)
+	}
+}
+
+// readText adds text, splitting out the inline math in it.
+func (p *spanReader) readText(text string, style model.Span) {
+	if style.Code {
+		p.add(text, style)
+		return
+	}
+	last := 0
+	for _, m := range reInlineMath.FindAllStringSubmatchIndex(text, -1) {
+		p.add(text[last:m[0]], style)
+		p.add(text[m[2]:m[3]], model.Span{Math: true})
+		last = m[1]
+	}
+	p.add(text[last:], style)
+}
+
+// collapseSpace replaces each run of whitespace in s with a single space.
+func collapseSpace(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '\f' {
+			space = true
+			continue
+		}
+		if space {
+			b.WriteByte(' ')
+			space = false
+		}
+		b.WriteRune(r)
+	}
+	if space {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
+
+// headingLevel returns the level of a heading element, or 0 for other
+// nodes.
+func headingLevel(n *html.Node) int {
+	if n.Type != html.ElementNode {
+		return 0
+	}
+	switch n.DataAtom {
+	case atom.H1:
+		return 1
+	case atom.H2:
+		return 2
+	case atom.H3:
+		return 3
+	case atom.H4:
+		return 4
+	case atom.H5:
+		return 5
+	case atom.H6:
+		return 6
+	}
+	return 0
+}
+
+// elements yields the child elements of n of type a.
+func elements(n *html.Node, a atom.Atom) iter.Seq[*html.Node] {
+	return func(yield func(*html.Node) bool) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && child.DataAtom == a && !yield(child) {
+				return
+			}
+		}
+	}
+}
+
+// descendants yields the elements of type a below n, in document order.
+func descendants(n *html.Node, a atom.Atom) iter.Seq[*html.Node] {
+	return func(yield func(*html.Node) bool) {
+		for d := range n.Descendants() {
+			if d.Type == html.ElementNode && d.DataAtom == a && !yield(d) {
+				return
+			}
+		}
+	}
+}
+
+// findChild returns the first child element of n of type a, or nil.
+func findChild(n *html.Node, a atom.Atom) *html.Node {
+	for child := range elements(n, a) {
+		return child
+	}
+	return nil
+}
+
+// findElement returns the first element below n that match reports, in
+// document order, or nil.
+func findElement(n *html.Node, match func(*html.Node) bool) *html.Node {
+	for d := range n.Descendants() {
+		if d.Type == html.ElementNode && match(d) {
+			return d
+		}
+	}
+	return nil
+}
+
+func isElement(a atom.Atom) func(*html.Node) bool {
+	return func(n *html.Node) bool { return n.DataAtom == a }
+}
+
+func attrOf(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	return slices.ContainsFunc(n.Attr, func(a html.Attribute) bool { return a.Key == key })
+}
+
+func hasClass(n *html.Node, class string) bool {
+	return slices.Contains(strings.Fields(attrOf(n, "class")), class)
+}
+
+// textContent returns the text of n and everything below it.
+func textContent(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for d := range n.Descendants() {
+		if d.Type == html.TextNode {
+			b.WriteString(d.Data)
+		}
+	}
+	return b.String()
+}
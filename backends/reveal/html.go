@@ -0,0 +1,267 @@
+package reveal
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// writeBlocks writes blocks as HTML. Runs of bullet and numbered items
+// become nested lists following their levels.
+func writeBlocks(b *strings.Builder, blocks []model.Block) {
+	for i := 0; i < len(blocks); i++ {
+		if !isListItem(&blocks[i]) {
+			writeBlock(b, &blocks[i])
+			continue
+		}
+		end := i
+		for end < len(blocks) && isListItem(&blocks[end]) {
+			end++
+		}
+		writeList(b, blocks[i:end])
+		i = end - 1
+	}
+}
+
+func isListItem(block *model.Block) bool {
+	return block.Kind == model.BlockBullet || block.Kind == model.BlockNumbered
+}
+
+// writeList writes consecutive list items as nested <ul> and <ol> lists.
+// An item nested deeper than the one before it opens a list inside that
+// item, however many levels it skips.
+func writeList(b *strings.Builder, items []model.Block) {
+	var open []string // Tags of the open lists, outermost first
+
+	// closeItem ends the open item of the innermost list, on a line of
+	// its own after a nested list
+	closeItem := func() {
+		if strings.HasSuffix(b.String(), "\n") {
+			b.WriteString(indent(len(open)))
+		}
+		b.WriteString("</li>\n")
+	}
+	closeList := func() {
+		closeItem()
+		fmt.Fprintf(b, "%s</%s>\n", indent(len(open)-1), open[len(open)-1])
+		open = open[:len(open)-1]
+	}
+
+	for i := range items {
+		item := &items[i]
+		tag := "ul"
+		if item.Kind == model.BlockNumbered {
+			tag = "ol"
+		}
+		depth := min(item.Level+1, len(open)+1)
+
+		for len(open) > depth {
+			closeList()
+		}
+		switch {
+		case len(open) == depth && open[depth-1] != tag:
+			closeList()
+		case len(open) == depth:
+			closeItem()
+		case len(open) > 0:
+			b.WriteString("\n") // The list nests inside the open item
+		}
+		if len(open) < depth {
+			fmt.Fprintf(b, "%s<%s>\n", indent(len(open)), tag)
+			open = append(open, tag)
+		}
+		fmt.Fprintf(b, "%s<li%s>%s", indent(depth), fragment(item), renderSpans(item.Spans()))
+	}
+	for len(open) > 0 {
+		closeList()
+	}
+}
+
+func indent(depth int) string {
+	return strings.Repeat("  ", depth)
+}
+
+// fragment returns the class attribute that reveals a block as a fragment,
+// or "" for blocks shown with the slide.
+func fragment(block *model.Block) string {
+	if block.Fragment {
+		return ` class="fragment"`
+	}
+	return ""
+}
+
+// writeBlock writes a block other than a list item.
+func writeBlock(b *strings.Builder, block *model.Block) {
+	frag := fragment(block)
+	switch block.Kind {
+	case model.BlockParagraph:
+		// HTML read from other formats is kept as written
+		if !block.HasRuns() && strings.HasPrefix(strings.TrimSpace(block.Text), "<") {
+			b.WriteString(block.Text)
+			b.WriteString("\n")
+			return
+		}
+		fmt.Fprintf(b, "<p%s>%s</p>\n", frag, renderSpans(block.Spans()))
+	case model.BlockCode:
+		fmt.Fprintf(b, "<pre%s><code%s>%s</code></pre>\n",
+			frag, attr("class", languageClass(block.Lang)), html.EscapeString(block.Text))
+	case model.BlockMath:
+		fmt.Fprintf(b, "<div class=\"%s\">\\[\n%s\n\\]</div>\n",
+			classes("math", block.Fragment), html.EscapeString(block.Text))
+	case model.BlockDiagram:
+		fmt.Fprintf(b, "<pre class=\"%s\"%s>\n%s\n</pre>\n",
+			classes("diagram "+block.Dialect, block.Fragment), attr("data-dialect", block.Dialect),
+			html.EscapeString(block.Text))
+	case model.BlockImage:
+		fmt.Fprintf(b, "<img%s%s%s%s>\n", frag, attr("src", block.URL), attr("alt", block.Alt), attr("style", imageStyle(block.Image)))
+	case model.BlockQuote:
+		fmt.Fprintf(b, "<blockquote%s>%s</blockquote>\n", frag, renderSpans(block.Spans()))
+	case model.BlockHeading:
+		level := min(max(block.Level, 1), 6)
+		fmt.Fprintf(b, "<h%d%s>%s</h%d>\n", level, frag, renderSpans(block.Spans()), level)
+	case model.BlockTable:
+		if block.Table != nil {
+			writeTable(b, block.Table, frag)
+		}
+	case model.BlockPause:
+		if block.Pause != nil {
+			d := block.Pause.Duration.String()
+			ssml := ""
+			if block.Pause.SSML {
+				ssml = " data-ssml"
+			}
+			fmt.Fprintf(b, "<p class=\"pause\"%s%s>[pause %s]</p>\n", attr("data-duration", d), ssml, d)
+		}
+	case model.BlockBullet, model.BlockNumbered:
+		writeList(b, []model.Block{*block})
+	}
+}
+
+// classes returns the class attribute value for base, with the fragment
+// class added when fragment is set.
+func classes(base string, fragment bool) string {
+	if fragment {
+		return base + " fragment"
+	}
+	return base
+}
+
+// languageClass returns the class that tells the highlight plugin the
+// language of a code block, or "" for none.
+func languageClass(lang string) string {
+	if lang == "" {
+		return ""
+	}
+	return "language-" + lang
+}
+
+// imageStyle returns the inline style for an image's size and filters.
+func imageStyle(attrs *model.ImageAttrs) string {
+	if attrs == nil {
+		return ""
+	}
+	var styles []string
+	size := func(prop, value string) {
+		if value == "" {
+			return
+		}
+		if strings.Trim(value, "0123456789.") == "" {
+			value += "px" // A bare number means pixels
+		}
+		styles = append(styles, prop+": "+value)
+	}
+	size("width", attrs.Width)
+	size("height", attrs.Height)
+	var filters []string
+	for _, f := range attrs.Filters {
+		filters = append(filters, f.Name+"("+f.Value+")")
+	}
+	if len(filters) > 0 {
+		styles = append(styles, "filter: "+strings.Join(filters, " "))
+	}
+	return strings.Join(styles, "; ")
+}
+
+// writeTable writes a table with its header row and column alignments.
+func writeTable(b *strings.Builder, table *model.Table, frag string) {
+	cell := func(tag string, i int, c model.TableCell) {
+		style := ""
+		if align := table.ColumnAlign(i); align != model.AlignDefault {
+			style = attr("style", "text-align: "+string(align))
+		}
+		runs := []model.Span{{Text: c.Text}}
+		if c.HasRuns() {
+			runs = c.Runs
+		}
+		fmt.Fprintf(b, "<%s%s>%s</%s>", tag, style, renderSpans(runs), tag)
+	}
+
+	fmt.Fprintf(b, "<table%s>\n<thead>\n<tr>", frag)
+	for i, c := range table.Header {
+		cell("th", i, c)
+	}
+	b.WriteString("</tr>\n</thead>\n")
+	if len(table.Rows) > 0 {
+		b.WriteString("<tbody>\n")
+		for _, row := range table.Rows {
+			b.WriteString("<tr>")
+			for i, c := range row {
+				cell("td", i, c)
+			}
+			b.WriteString("</tr>\n")
+		}
+		b.WriteString("</tbody>\n")
+	}
+	b.WriteString("</table>\n")
+}
+
+// writeSlots writes column slots side by side, in the same markup the
+// Marp writer uses, followed by the caption.
+func writeSlots(b *strings.Builder, slots model.Slots) {
+	if columns := slots.Columns(); len(columns) > 0 {
+		b.WriteString("<div class=\"columns\">\n")
+		for _, name := range columns {
+			fmt.Fprintf(b, "<div%s>\n", attr("class", "column-"+name))
+			writeBlocks(b, slots[name])
+			b.WriteString("</div>\n")
+		}
+		b.WriteString("</div>\n")
+	}
+	if caption, ok := slots[model.SlotCaption]; ok {
+		b.WriteString("<div class=\"caption\">\n")
+		writeBlocks(b, caption)
+		b.WriteString("</div>\n")
+	}
+}
+
+// renderSpans renders inline runs as HTML. Math is written between \( and
+// \) for the math plugin.
+func renderSpans(spans []model.Span) string {
+	var b strings.Builder
+	for _, s := range spans {
+		text := html.EscapeString(s.Text)
+		if s.Math {
+			b.WriteString(`\(` + text + `\)`)
+			continue
+		}
+		if s.Code {
+			text = "<code>" + text + "</code>"
+		}
+		if s.Strike {
+			text = "<del>" + text + "</del>"
+		}
+		if s.Italic {
+			text = "<em>" + text + "</em>"
+		}
+		if s.Bold {
+			text = "<strong>" + text + "</strong>"
+		}
+		if s.Href != "" {
+			text = "<a" + attr("href", s.Href) + ">" + text + "</a>"
+		}
+		b.WriteString(text)
+	}
+	return b.String()
+}
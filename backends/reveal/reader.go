@@ -0,0 +1,373 @@
+package reveal
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// Reader parses Reveal.js HTML files into the canonical slide model.
+type Reader struct{}
+
+// NewReader creates a new Reveal.js reader.
+func NewReader() *Reader {
+	return &Reader{}
+}
+
+// ReadFile reads a Reveal.js HTML file and returns a Deck.
+func (r *Reader) ReadFile(path string) (*model.Deck, error) {
+	return r.Read(context.Background(), model.Ref{Backend: "reveal", Path: path})
+}
+
+// Read implements the Backend interface for reading from a Ref.
+func (r *Reader) Read(ctx context.Context, ref model.Ref) (*model.Deck, error) {
+	if ref.Path == "" {
+		return nil, fmt.Errorf("reveal backend requires a file path")
+	}
+	data, err := os.ReadFile(ref.Path)
+	if err != nil {
+		return nil, fmt.Errorf("reading file %s: %w", ref.Path, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.Parse(string(data))
+}
+
+// Parse parses a Reveal.js HTML page into a Deck. Top-level <section>
+// elements that hold slides of their own are vertical stacks and become
+// sections; runs of other top-level slides are grouped into a "default"
+// section. The attributes the Writer adds, such as data-section-id and
+// data-layout, are read back so that written decks round-trip.
+func (r *Reader) Parse(content string) (*model.Deck, error) {
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing HTML: %w", err)
+	}
+	slides := findElement(doc, func(n *html.Node) bool {
+		return n.DataAtom == atom.Div && hasClass(n, "slides")
+	})
+	if slides == nil {
+		return nil, fmt.Errorf("no Reveal.js slides found: missing <div class=\"slides\">")
+	}
+
+	deck := &model.Deck{Meta: model.Meta{Custom: make(map[string]any)}}
+	readHead(deck, doc)
+	b := model.NewDeckBuilder(deck)
+	loose := false // Whether the last section holds top-level slides
+	for n := range elements(slides, atom.Section) {
+		if findChild(n, atom.Section) == nil {
+			if !loose {
+				b.StartSection("", "default")
+				loose = true
+			}
+			b.Add(readSlide(n, deck.SlideCount() == 0, false))
+			continue
+		}
+		b.StartSection(attrOf(n, "data-section-id"), attrOf(n, "data-section-title"))
+		loose = false
+		start := true
+		for child := range elements(n, atom.Section) {
+			b.Add(readSlide(child, deck.SlideCount() == 0, start))
+			start = false
+		}
+	}
+	return b.Finish(), nil
+}
+
+var (
+	// reRootVars matches the custom properties set on :root
+	reRootVars = regexp.MustCompile(`:root\s*\{([^}]*)\}`)
+	reCSSVar   = regexp.MustCompile(`(--r-[a-z-]+)\s*:\s*([^;]+);?`)
+
+	// reInitOption matches a string or number option passed to
+	// Reveal.initialize
+	reInitOption = regexp.MustCompile(`\b(transition|width|height)\s*:\s*(?:["']([^"']*)["']|(\d+))`)
+)
+
+// readHead reads the deck's title, metadata and theme from the document
+// head, and the transition and slide size it starts Reveal.js with.
+func readHead(deck *model.Deck, doc *html.Node) {
+	if n := findElement(doc, isElement(atom.Html)); n != nil {
+		if lang := attrOf(n, "lang"); lang != "" && lang != "en" {
+			deck.Meta.Custom["lang"] = lang
+		}
+	}
+	if n := findElement(doc, isElement(atom.Title)); n != nil {
+		deck.Title = strings.TrimSpace(textContent(n))
+	}
+	for n := range descendants(doc, atom.Meta) {
+		content := strings.TrimSpace(attrOf(n, "content"))
+		switch attrOf(n, "name") {
+		case "author":
+			deck.Meta.Author = content
+		case "date":
+			deck.Meta.Date = content
+		case "description":
+			deck.Meta.Description = content
+		case "keywords":
+			for _, k := range strings.Split(content, ",") {
+				if k = strings.TrimSpace(k); k != "" {
+					deck.Meta.Keywords = append(deck.Meta.Keywords, k)
+				}
+			}
+		}
+	}
+
+	var theme model.Theme
+	for n := range descendants(doc, atom.Link) {
+		href := attrOf(n, "href")
+		if attrOf(n, "id") == "theme" || strings.Contains(href, "/theme/") {
+			file := strings.TrimSuffix(path.Base(href), ".css")
+			theme.Name = cmp.Or(attrOf(n, "data-theme"), file)
+		}
+	}
+	for n := range descendants(doc, atom.Style) {
+		for _, root := range reRootVars.FindAllStringSubmatch(textContent(n), -1) {
+			for _, m := range reCSSVar.FindAllStringSubmatch(root[1], -1) {
+				value := strings.TrimSpace(m[2])
+				switch m[1] {
+				case "--r-background-color":
+					theme.Background = value
+				case "--r-main-font":
+					theme.Font = value
+				case "--r-heading-color":
+					theme.Primary = value
+				case "--r-link-color":
+					theme.Secondary = value
+				}
+			}
+		}
+	}
+	if theme.Name != "" || theme.Primary != "" || theme.Secondary != "" ||
+		theme.Background != "" || theme.Font != "" {
+		deck.Theme = &theme
+	}
+
+	var width, height int
+	for n := range descendants(doc, atom.Script) {
+		script := textContent(n)
+		i := strings.Index(script, "Reveal.initialize(")
+		if i < 0 {
+			continue
+		}
+		for _, m := range reInitOption.FindAllStringSubmatch(script[i:], -1) {
+			switch m[1] {
+			case "transition":
+				deck.Transition = m[2]
+			case "width":
+				width, _ = strconv.Atoi(m[3])
+			case "height":
+				height, _ = strconv.Atoi(m[3])
+			}
+		}
+	}
+	for name, size := range slideSizes {
+		if size == [2]int{width, height} {
+			deck.Meta.Size = name
+		}
+	}
+}
+
+// readSlide reads the slide element n. It also returns the slide's id
+// attribute and the header, footer and page number shown on it, which the
+// deck builder settles over the whole deck. first is set for the first
+// slide of the deck, and start for the first slide of a vertical stack.
+func readSlide(n *html.Node, first, start bool) (model.Slide, string, model.HeaderFooter) {
+	var slide model.Slide
+	var settings model.HeaderFooter
+	readSlideAttrs(&slide, n)
+
+	titleLevel := 0 // Level of the title heading, while a subtitle may follow it
+	h1 := false     // Whether the title is an <h1>
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch {
+		case child.Type == html.ElementNode && child.DataAtom == atom.Header && hasClass(child, "slide-header"):
+			text := strings.TrimSpace(textContent(child))
+			settings.Header = &text
+			continue
+		case child.Type == html.ElementNode && child.DataAtom == atom.Footer && hasClass(child, "slide-footer"):
+			readFooter(&settings, child)
+			continue
+		case child.Type == html.ElementNode && child.DataAtom == atom.Aside && hasClass(child, "notes"):
+			slide.Notes = append(slide.Notes, readBlocks(child, false)...)
+			continue
+		case child.Type == html.ElementNode && child.DataAtom == atom.Div && hasClass(child, "columns"):
+			readColumns(&slide, child)
+			titleLevel = 0
+			continue
+		case child.Type == html.ElementNode && child.DataAtom == atom.Div && hasClass(child, "caption"):
+			if slide.Slots == nil {
+				slide.Slots = make(model.Slots)
+			}
+			slide.Slots[model.SlotCaption] = readBlocks(child, false)
+			continue
+		}
+
+		level := headingLevel(child)
+		switch {
+		case level > 0 && slide.Title == "" && len(slide.Body) == 0 && len(slide.Slots) == 0:
+			slide.Title = model.RunsText(readSpans(child))
+			titleLevel = level
+			h1 = level == 1
+		case level > titleLevel && titleLevel > 0 && slide.Subtitle == "":
+			slide.Subtitle = model.RunsText(readSpans(child))
+			titleLevel = 0
+		default:
+			blocks := readBlock(child, false)
+			if len(blocks) > 0 {
+				slide.Body = append(slide.Body, blocks...)
+				titleLevel = 0
+			}
+		}
+	}
+
+	if !slide.Layout.IsValid() {
+		slide.Layout = inferLayout(&slide, first, start || h1)
+	}
+	return slide, attrOf(n, "id"), settings
+}
+
+// readSlideAttrs reads the transition, background and layout of a slide
+// from the attributes of its element. Other data attributes are kept as
+// directives, which the Writer writes back.
+func readSlideAttrs(slide *model.Slide, n *html.Node) {
+	var bg model.Background
+	var image *model.Block
+	imageAttrs := func() *model.ImageAttrs {
+		if image == nil {
+			image = &model.Block{Kind: model.BlockImage, Image: &model.ImageAttrs{Bg: true}}
+		}
+		return image.Image
+	}
+
+	for _, a := range n.Attr {
+		switch a.Key {
+		case "id":
+		case "data-layout":
+			slide.Layout = model.Layout(a.Val)
+		case "data-transition":
+			t := a.Val
+			slide.Transition = &t
+		case "data-notes":
+			slide.Notes = append(slide.Notes, model.NewParagraph(a.Val))
+		case "data-background-color":
+			bg.Color = a.Val
+		case "data-background-gradient":
+			bg.Image = a.Val
+		case "data-background-image":
+			imageAttrs()
+			image.URL = a.Val
+		case "data-background-size":
+			imageAttrs().BgSize = a.Val
+		case "data-background-opacity":
+			attrs := imageAttrs()
+			attrs.Filters = append(attrs.Filters, model.ImageFilter{Name: "opacity", Value: a.Val})
+		case "data-background-position":
+			if a.Val == model.SplitLeft || a.Val == model.SplitRight {
+				imageAttrs().Split = a.Val
+				continue
+			}
+			fallthrough
+		default:
+			if strings.HasPrefix(a.Key, "data-") {
+				if slide.Directives == nil {
+					slide.Directives = make(map[string]string)
+				}
+				slide.Directives[a.Key] = a.Val
+			}
+		}
+	}
+
+	if image != nil && image.URL != "" {
+		bg.Images = append(bg.Images, *image)
+	}
+	if !bg.IsEmpty() {
+		slide.Background = &bg
+	}
+}
+
+// readFooter reads the footer text and page number of a slide footer.
+func readFooter(settings *model.HeaderFooter, n *html.Node) {
+	var text strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.ElementNode && hasClass(child, "page-number") {
+			on := true
+			settings.PageNumber = &on
+			continue
+		}
+		text.WriteString(textContent(child))
+	}
+	if footer := strings.TrimSpace(text.String()); footer != "" || settings.PageNumber == nil {
+		settings.Footer = &footer
+	}
+}
+
+// readColumns reads the column slots of a slide from the columns element
+// the Writer generates, whose children are named by a column-NAME class.
+// Unnamed columns are named left, center and right in order.
+func readColumns(slide *model.Slide, n *html.Node) {
+	if slide.Slots == nil {
+		slide.Slots = make(model.Slots)
+	}
+	names := []string{model.SlotLeft, model.SlotCenter, model.SlotRight}
+	i := 0
+	for column := range elements(n, atom.Div) {
+		name := ""
+		for _, class := range strings.Fields(attrOf(column, "class")) {
+			if after, ok := strings.CutPrefix(class, "column-"); ok {
+				name = after
+			}
+		}
+		if name == "" && i < len(names) {
+			name = names[i]
+		}
+		slide.Slots[cmp.Or(name, "column-"+strconv.Itoa(i+1))] = readBlocks(column, false)
+		i++
+	}
+}
+
+// inferLayout guesses the layout of a slide written without a data-layout
+// attribute from its content. Past the first slide, a slide with only a
+// heading is a section divider if divider is set, as for one that starts
+// a vertical stack or is titled with an <h1>.
+func inferLayout(slide *model.Slide, first, divider bool) model.Layout {
+	hasImage := slide.Background != nil && len(slide.Background.Images) > 0
+	switch {
+	case len(slide.Slots.Columns()) > 0:
+		return model.LayoutTitleTwoCol
+	case slide.Title == "" && len(slide.Body) == 0 && hasImage:
+		return model.LayoutImage
+	case slide.Title == "" && len(slide.Body) == 0:
+		return model.LayoutBlank
+	case len(slide.Body) == 0 && first:
+		return model.LayoutTitle
+	case len(slide.Body) == 0 && divider:
+		return model.LayoutSection
+	}
+	return model.LayoutTitleBody
+}
+
+// readPause reads a pause cue written by the Writer, defaulting to one
+// second if its duration is missing or malformed.
+func readPause(n *html.Node) model.Block {
+	d, err := time.ParseDuration(attrOf(n, "data-duration"))
+	if err != nil {
+		d = time.Second
+	}
+	if hasAttr(n, "data-ssml") {
+		return model.NewBreak(d)
+	}
+	return model.NewPause(d)
+}
@@ -0,0 +1,189 @@
+package reveal
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func TestReadRoundTrip(t *testing.T) {
+	written := NewWriter().Encode(testDeck())
+	deck, err := NewReader().Parse(written)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if again := NewWriter().Encode(deck); again != written {
+		t.Errorf("round trip changed the page:\n%s\nwant:\n%s", again, written)
+	}
+
+	if deck.Title != "Quarterly Review" || deck.Meta.Author != "Ada" || deck.Meta.Size != "16:9" ||
+		deck.Transition != "slide" {
+		t.Errorf("unexpected metadata %q %+v", deck.Title, deck.Meta)
+	}
+	if th := deck.Theme; th == nil || th.Name != "gaia" || th.Primary != "#123456" || th.Font != "Georgia" {
+		t.Errorf("unexpected theme %+v", deck.Theme)
+	}
+	if hf := deck.HeaderFooter; hf == nil || hf.FooterText() != "ACME <Confidential>" || !hf.ShowPageNumber() {
+		t.Errorf("unexpected header and footer %+v", deck.HeaderFooter)
+	}
+	if got := sectionIDs(deck); !reflect.DeepEqual(got, []string{"intro", "details"}) {
+		t.Errorf("sections = %v", got)
+	}
+
+	agenda := deck.FindSlide("agenda")
+	if agenda == nil {
+		t.Fatal("missing agenda slide")
+	}
+	if agenda.Layout != model.LayoutTitleBody || agenda.Transition == nil || *agenda.Transition != "fade" {
+		t.Errorf("unexpected agenda slide %+v", agenda)
+	}
+	if bg := agenda.Background; bg == nil || bg.Color != "#000" || len(bg.Images) != 1 || bg.Images[0].URL != "bg.png" {
+		t.Errorf("unexpected background %+v", agenda.Background)
+	}
+	wantBody := []model.Block{
+		model.NewBullet("First", 0),
+		model.NewBullet("Nested", 1),
+		model.NewNumbered("Step", 0),
+		{Kind: model.BlockBullet, Text: "Revealed", Fragment: true},
+	}
+	if !reflect.DeepEqual(agenda.Body, wantBody) {
+		t.Errorf("body = %+v, want %+v", agenda.Body, wantBody)
+	}
+	wantNotes := []model.Block{model.NewParagraph("Say hi."), model.NewPause(500 * time.Millisecond)}
+	if !reflect.DeepEqual(agenda.Notes, wantNotes) {
+		t.Errorf("notes = %+v, want %+v", agenda.Notes, wantNotes)
+	}
+
+	body := deck.FindSlide("math").Body
+	for i, want := range []model.BlockKind{model.BlockMath, model.BlockDiagram, model.BlockCode, model.BlockParagraph, model.BlockTable} {
+		if body[i].Kind != want {
+			t.Errorf("block %d kind = %q, want %q", i, body[i].Kind, want)
+		}
+	}
+	if body[1].Text != "graph TD\n  A --> B" || body[2].Text != `fmt.Println("<hi>")` || body[2].Lang != "go" {
+		t.Errorf("unexpected diagram or code %+v %+v", body[1], body[2])
+	}
+}
+
+func sectionIDs(deck *model.Deck) []string {
+	var ids []string
+	for _, section := range deck.Sections {
+		ids = append(ids, section.ID)
+	}
+	return ids
+}
+
+// handWritten is a deck in the style of the Reveal.js demo, written
+// without any of the attributes the Writer adds.
+const handWritten = `<html>
+<head><link rel="stylesheet" href="dist/theme/moon.css"></head>
+<body>
+<div class="reveal"><div class="slides">
+	<section data-background-video="intro.mp4" data-auto-animate>
+		<h2>Hello <em>Reveal</em></h2>
+		<p><img src="logo.png" alt="Logo" width="200"></p>
+		<aside class="notes">
+			Welcome everyone.
+			Introduce the team.
+		</aside>
+	</section>
+	<section>
+		<section><h2>Stacked</h2></section>
+		<section>
+			<h2>Code</h2>
+			<pre><code data-trim class="lang-js">
+console.log(1)
+			</code></pre>
+			<div class="fragment"><p>Later, with \(x^2\)</p></div>
+			<table><tr><th style="text-align: right">N</th></tr><tr><td>1</td></tr></table>
+		</section>
+	</section>
+	<section><video src="clip.mp4"></video></section>
+</div></div>
+<script>Reveal.initialize({ transition: 'zoom' });</script>
+</body>
+</html>`
+
+func TestReadHandWritten(t *testing.T) {
+	deck, err := NewReader().Parse(handWritten)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if deck.Theme == nil || deck.Theme.Name != "moon" || deck.Transition != "zoom" {
+		t.Errorf("unexpected theme %+v or meta %+v", deck.Theme, deck.Meta)
+	}
+	if got := sectionIDs(deck); !reflect.DeepEqual(got, []string{"default", "stacked", "default-2"}) {
+		t.Errorf("sections = %v", got)
+	}
+
+	hello := deck.Sections[0].Slides[0]
+	if hello.ID != "hello-reveal" || hello.Title != "Hello Reveal" || hello.Layout != model.LayoutTitleBody {
+		t.Errorf("unexpected first slide %+v", hello)
+	}
+	if hello.Directives["data-background-video"] != "intro.mp4" || hello.Directives["data-auto-animate"] != "" {
+		t.Errorf("expected data attributes kept as directives, got %v", hello.Directives)
+	}
+	img := hello.Body[0]
+	if img.Kind != model.BlockImage || img.URL != "logo.png" || img.Alt != "Logo" || img.Image.Width != "200" {
+		t.Errorf("unexpected image %+v", img)
+	}
+	if hello.NotesText() != "Welcome everyone.\nIntroduce the team." {
+		t.Errorf("notes = %q", hello.NotesText())
+	}
+
+	stack := deck.Sections[1].Slides
+	if stack[0].Layout != model.LayoutSection {
+		t.Errorf("expected a title-only slide to be a section divider, got %q", stack[0].Layout)
+	}
+	code := stack[1].Body
+	if code[0].Kind != model.BlockCode || code[0].Text != "console.log(1)" || code[0].Lang != "js" {
+		t.Errorf("unexpected code %+v", code[0])
+	}
+	wantRuns := []model.Span{{Text: "Later, with "}, {Text: "x^2", Math: true}}
+	if !code[1].Fragment || !reflect.DeepEqual(code[1].Runs, wantRuns) {
+		t.Errorf("unexpected fragment paragraph %+v", code[1])
+	}
+	if table := code[2].Table; table == nil || table.Header[0].Text != "N" || table.Rows[0][0].Text != "1" ||
+		table.ColumnAlign(0) != model.AlignRight {
+		t.Errorf("unexpected table %+v", code[2].Table)
+	}
+
+	// Markup without a block of its own is kept as HTML
+	video := deck.Sections[2].Slides[0]
+	if video.Layout != model.LayoutTitleBody || video.Body[0].Text != `<video src="clip.mp4"></video>` {
+		t.Errorf("unexpected video slide %+v", video)
+	}
+	if out := NewWriter().Encode(deck); !strings.Contains(out, ` data-auto-animate data-background-video="intro.mp4">`) {
+		t.Errorf("expected the data attributes written back, got:\n%s", out)
+	}
+}
+
+func TestReadHeadingOnlyLayouts(t *testing.T) {
+	input := `<div class="reveal"><div class="slides">
+	<section><h1>Deck</h1></section>
+	<section><h2>Big statement</h2></section>
+	<section><h1>Part two</h1></section>
+	<section>
+		<section><h2>Stack</h2></section>
+		<section><h2>Question?</h2></section>
+	</section>
+</div></div>`
+	deck, err := NewReader().Parse(input)
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	want := []model.Layout{model.LayoutTitle, model.LayoutTitleBody, model.LayoutSection,
+		model.LayoutSection, model.LayoutTitleBody}
+	slides := deck.AllSlides()
+	if len(slides) != len(want) {
+		t.Fatalf("expected %d slides, got %d", len(want), len(slides))
+	}
+	for i, slide := range slides {
+		if slide.Layout != want[i] {
+			t.Errorf("slide %q: layout = %q, want %q", slide.Title, slide.Layout, want[i])
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package reveal
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"html"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// DefaultRevealURL is where generated decks load Reveal.js and its plugins
+// from unless the Writer says otherwise: a CDN, so that a deck is a single
+// HTML file with nothing to install, but one that needs network access to
+// be shown.
+const DefaultRevealURL = "https://cdn.jsdelivr.net/npm/reveal.js@5.1.0"
+
+// DefaultMermaidURL is where decks with Mermaid diagrams load Mermaid from
+// unless the Writer says otherwise.
+const DefaultMermaidURL = "https://cdn.jsdelivr.net/npm/mermaid@11/dist/mermaid.min.js"
+
+// revealThemes are the themes that ship with Reveal.js.
+var revealThemes = []string{
+	"beige", "black", "black-contrast", "blood", "dracula", "league", "moon",
+	"night", "serif", "simple", "sky", "solarized", "white", "white-contrast",
+}
+
+// themeAliases maps theme names from other backends, such as Marp's
+// built-in themes, onto the closest Reveal.js theme.
+var themeAliases = map[string]string{
+	"default": "white",
+	"gaia":    "league",
+	"uncover": "simple",
+	"dark":    "black",
+}
+
+// slideSizes maps slide size presets onto Reveal.js slide dimensions.
+var slideSizes = map[string][2]int{
+	"16:9": {1280, 720},
+	"4:3":  {960, 720},
+}
+
+// baseStyle lays out the markup the writer generates for slots, headers,
+// footers and page numbers.
+const baseStyle = `.reveal .columns { display: grid; grid-auto-flow: column; grid-auto-columns: 1fr; gap: 1em; text-align: left; }
+.reveal .caption { font-size: 0.7em; }
+.reveal .slide-header, .reveal .slide-footer { position: absolute; left: 0; right: 0; font-size: 0.5em; opacity: 0.7; }
+.reveal .slide-header { top: 0; }
+.reveal .slide-footer { bottom: 0; }
+.reveal .page-number { float: right; }
+.reveal .pause { font-style: italic; }
+`
+
+// Writer converts a Deck to a single-file Reveal.js HTML page.
+type Writer struct {
+	// RevealURL is the base URL of the Reveal.js distribution the page
+	// loads its scripts, styles and themes from, such as "reveal.js" for
+	// a copy beside the page, so that it can be shown offline. It defaults
+	// to DefaultRevealURL.
+	RevealURL string
+
+	// MermaidURL is the URL of the Mermaid script loaded by decks with
+	// Mermaid diagrams, such as "mermaid.min.js" for a copy beside the
+	// page. It defaults to DefaultMermaidURL.
+	MermaidURL string
+}
+
+// NewWriter creates a new Reveal.js writer.
+func NewWriter() *Writer {
+	return &Writer{}
+}
+
+// WriteFile writes a deck to a Reveal.js HTML file.
+func (w *Writer) WriteFile(deck *model.Deck, path string) error {
+	content := w.Encode(deck)
+	return os.WriteFile(path, []byte(content), 0600)
+}
+
+// Encode converts a Deck to a Reveal.js HTML page. Each section becomes a
+// vertical stack of its slides.
+func (w *Writer) Encode(deck *model.Deck) string {
+	var b strings.Builder
+	w.writeHead(&b, deck)
+
+	b.WriteString("<body>\n<div class=\"reveal\">\n<div class=\"slides\">\n")
+	settings := deck.HeaderFooters()
+	k := 0
+	for i := range deck.Sections {
+		section := &deck.Sections[i]
+		fmt.Fprintf(&b, "<section%s%s>\n",
+			attr("data-section-id", section.ID), attr("data-section-title", section.Title))
+		for j := range section.Slides {
+			w.writeSlide(&b, &section.Slides[j], settings[k], k+1)
+			k++
+		}
+		b.WriteString("</section>\n")
+	}
+	b.WriteString("</div>\n</div>\n")
+
+	w.writeScripts(&b, deck)
+	b.WriteString("</body>\n</html>\n")
+	return b.String()
+}
+
+// writeHead writes the document up to the body: metadata, the Reveal.js
+// styles and the deck's theme.
+func (w *Writer) writeHead(b *strings.Builder, deck *model.Deck) {
+	lang := "en"
+	if l, ok := deck.Meta.Custom["lang"].(string); ok && l != "" {
+		lang = l
+	}
+	fmt.Fprintf(b, "<!DOCTYPE html>\n<html lang=\"%s\">\n<head>\n", html.EscapeString(lang))
+	b.WriteString("<meta charset=\"utf-8\">\n")
+	b.WriteString("<meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\">\n")
+	fmt.Fprintf(b, "<title>%s</title>\n", html.EscapeString(deck.Title))
+	meta := func(name, content string) {
+		if content = strings.TrimSpace(content); content != "" {
+			fmt.Fprintf(b, "<meta name=\"%s\"%s>\n", name, attr("content", content))
+		}
+	}
+	meta("author", deck.Meta.Author)
+	meta("date", deck.Meta.Date)
+	meta("description", deck.Meta.Description)
+	meta("keywords", strings.Join(deck.Meta.Keywords, ", "))
+
+	revealURL := w.revealURL()
+	name, file := themeFile(deck.Theme)
+	fmt.Fprintf(b, "<link rel=\"stylesheet\" href=\"%s/dist/reset.css\">\n", revealURL)
+	fmt.Fprintf(b, "<link rel=\"stylesheet\" href=\"%s/dist/reveal.css\">\n", revealURL)
+	themeAttr := ""
+	if name != file {
+		themeAttr = attr("data-theme", name)
+	}
+	fmt.Fprintf(b, "<link rel=\"stylesheet\" href=\"%s/dist/theme/%s.css\" id=\"theme\"%s>\n", revealURL, file, themeAttr)
+	fmt.Fprintf(b, "<link rel=\"stylesheet\" href=\"%s/plugin/highlight/monokai.css\">\n", revealURL)
+	b.WriteString("<style>\n")
+	b.WriteString(baseStyle)
+	b.WriteString(themeStyle(deck.Theme))
+	b.WriteString("</style>\n</head>\n")
+}
+
+// themeFile returns the name of the deck's theme and the Reveal.js theme
+// file used for it.
+func themeFile(theme *model.Theme) (name, file string) {
+	if theme == nil || theme.Name == "" {
+		return "", "white"
+	}
+	switch {
+	case slices.Contains(revealThemes, theme.Name):
+		return theme.Name, theme.Name
+	case themeAliases[theme.Name] != "":
+		return theme.Name, themeAliases[theme.Name]
+	}
+	return theme.Name, "white"
+}
+
+// themeStyle returns the Reveal.js theme variables for the colors and font
+// the theme sets, or "" if it sets none.
+func themeStyle(theme *model.Theme) string {
+	if theme == nil {
+		return ""
+	}
+	var vars []string
+	add := func(name, value string) {
+		if value != "" {
+			vars = append(vars, fmt.Sprintf("  %s: %s;\n", name, value))
+		}
+	}
+	add("--r-background-color", theme.Background)
+	add("--r-main-font", theme.Font)
+	add("--r-heading-font", theme.Font)
+	add("--r-heading-color", theme.Primary)
+	add("--r-link-color", theme.Secondary)
+	if len(vars) == 0 {
+		return ""
+	}
+	return ":root {\n" + strings.Join(vars, "") + "}\n"
+}
+
+// writeSlide writes a single slide with settings, the header, footer and
+// page number in effect on it. number is the slide's position in the deck,
+// counting from 1.
+func (w *Writer) writeSlide(b *strings.Builder, slide *model.Slide, settings model.HeaderFooter, number int) {
+	b.WriteString("<section")
+	b.WriteString(attr("id", slide.ID))
+	b.WriteString(attr("data-layout", string(slide.Layout)))
+	if slide.Transition != nil {
+		b.WriteString(attr("data-transition", *slide.Transition))
+	}
+	extra := backgroundAttrs(b, slide.Background)
+	// Data attributes read from other Reveal.js decks
+	for _, key := range slices.Sorted(maps.Keys(slide.Directives)) {
+		switch value := slide.Directives[key]; {
+		case !strings.HasPrefix(key, "data-"):
+		case value == "":
+			b.WriteString(" " + key) // A boolean attribute, such as data-auto-animate
+		default:
+			b.WriteString(attr(key, value))
+		}
+	}
+	b.WriteString(">\n")
+
+	if text := settings.HeaderText(); text != "" {
+		fmt.Fprintf(b, "<header class=\"slide-header\">%s</header>\n", html.EscapeString(text))
+	}
+	if slide.Title != "" {
+		fmt.Fprintf(b, "<h1>%s</h1>\n", html.EscapeString(slide.Title))
+	}
+	if slide.Subtitle != "" {
+		fmt.Fprintf(b, "<h2>%s</h2>\n", html.EscapeString(slide.Subtitle))
+	}
+	writeBlocks(b, extra)
+	writeBlocks(b, slide.Body)
+	writeSlots(b, slide.Slots)
+
+	if text, page := settings.FooterText(), settings.ShowPageNumber(); text != "" || page {
+		b.WriteString("<footer class=\"slide-footer\">")
+		b.WriteString(html.EscapeString(text))
+		if page {
+			fmt.Fprintf(b, "<span class=\"page-number\">%d</span>", number)
+		}
+		b.WriteString("</footer>\n")
+	}
+	if len(slide.Notes) > 0 {
+		b.WriteString("<aside class=\"notes\">\n")
+		writeBlocks(b, slide.Notes)
+		b.WriteString("</aside>\n")
+	}
+	b.WriteString("</section>\n")
+}
+
+// reCSSURL matches the URL in a CSS url() value.
+var reCSSURL = regexp.MustCompile(`^url\(\s*['"]?([^'")]*)['"]?\s*\)$`)
+
+// backgroundAttrs writes the data-background attributes for bg. Reveal.js
+// shows one background image per slide: the first background image block,
+// or else the CSS background-image, which becomes a gradient unless it is
+// a url(). It returns the other background images, which are written as
+// images on the slide instead.
+func backgroundAttrs(b *strings.Builder, bg *model.Background) []model.Block {
+	if bg == nil {
+		return nil
+	}
+	b.WriteString(attr("data-background-color", bg.Color))
+	if len(bg.Images) == 0 {
+		if m := reCSSURL.FindStringSubmatch(bg.Image); m != nil {
+			b.WriteString(attr("data-background-image", m[1]))
+		} else {
+			b.WriteString(attr("data-background-gradient", bg.Image))
+		}
+		return nil
+	}
+
+	image := bg.Images[0]
+	b.WriteString(attr("data-background-image", image.URL))
+	if attrs := image.Image; attrs != nil {
+		size := attrs.BgSize
+		if size == "fit" {
+			size = "contain"
+		}
+		b.WriteString(attr("data-background-size", size))
+		b.WriteString(attr("data-background-position", attrs.Split))
+		for _, f := range attrs.Filters {
+			if f.Name == "opacity" {
+				b.WriteString(attr("data-background-opacity", f.Value))
+			}
+		}
+	}
+
+	var extra []model.Block
+	for _, img := range bg.Images[1:] {
+		img.Image = nil
+		extra = append(extra, img)
+	}
+	return extra
+}
+
+// revealURL returns the base URL Reveal.js is loaded from, escaped for use
+// in an attribute.
+func (w *Writer) revealURL() string {
+	return html.EscapeString(strings.TrimSuffix(cmp.Or(w.RevealURL, DefaultRevealURL), "/"))
+}
+
+// writeScripts loads Reveal.js with the notes, highlight and math plugins,
+// and Mermaid if the deck has Mermaid diagrams, and starts the deck.
+func (w *Writer) writeScripts(b *strings.Builder, deck *model.Deck) {
+	revealURL := w.revealURL()
+	for _, src := range []string{
+		"dist/reveal.js",
+		"plugin/notes/notes.js",
+		"plugin/highlight/highlight.js",
+		"plugin/math/math.js",
+	} {
+		fmt.Fprintf(b, "<script src=\"%s/%s\"></script>\n", revealURL, src)
+	}
+	mermaid := hasDiagram(deck, model.DiagramMermaid)
+	if mermaid {
+		fmt.Fprintf(b, "<script src=\"%s\"></script>\n", html.EscapeString(cmp.Or(w.MermaidURL, DefaultMermaidURL)))
+	}
+
+	b.WriteString("<script>\n")
+	if mermaid {
+		b.WriteString("mermaid.initialize({ startOnLoad: true });\n")
+	}
+	b.WriteString("Reveal.initialize({\n  hash: true,\n")
+	if deck.Transition != "" {
+		fmt.Fprintf(b, "  transition: %s,\n", jsString(deck.Transition))
+	}
+	if size, ok := slideSizes[deck.Meta.Size]; ok {
+		fmt.Fprintf(b, "  width: %d,\n  height: %d,\n", size[0], size[1])
+	}
+	b.WriteString("  plugins: [RevealNotes, RevealHighlight, RevealMath.KaTeX]\n});\n</script>\n")
+}
+
+// hasDiagram reports whether any slide of deck has a diagram in dialect.
+func hasDiagram(deck *model.Deck, dialect string) bool {
+	for _, slide := range deck.AllSlides() {
+		blocks := slices.Clone(slide.Body)
+		for _, name := range slide.Slots.Names() {
+			blocks = append(blocks, slide.Slots[name]...)
+		}
+		for _, block := range blocks {
+			if block.Kind == model.BlockDiagram && block.Dialect == dialect {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// attr returns an HTML attribute with its value escaped, or "" when value
+// is empty.
+func attr(name, value string) string {
+	if value == "" {
+		return ""
+	}
+	return fmt.Sprintf(" %s=\"%s\"", name, html.EscapeString(value))
+}
+
+// jsString returns s as a JavaScript string literal.
+func jsString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -0,0 +1,126 @@
+package reveal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grokify/slidekit/model"
+)
+
+func testDeck() *model.Deck {
+	fade := "fade"
+	footer := "ACME <Confidential>"
+	on := true
+	bullet := model.NewBullet("Revealed", 0)
+	bullet.Fragment = true
+	return &model.Deck{
+		Title: "Quarterly Review",
+		Meta: model.Meta{
+			Author: "Ada",
+			Size:   "16:9",
+		},
+		Transition:   "slide",
+		Theme:        &model.Theme{Name: "gaia", Primary: "#123456", Font: "Georgia"},
+		HeaderFooter: &model.HeaderFooter{Footer: &footer, PageNumber: &on},
+		Sections: []model.Section{
+			{ID: "intro", Title: "Intro", Slides: []model.Slide{
+				{ID: "welcome", Layout: model.LayoutTitle, Title: "Welcome", Subtitle: "Q3"},
+				{
+					ID: "agenda", Layout: model.LayoutTitleBody, Title: "Agenda", Transition: &fade,
+					Background: &model.Background{Color: "#000", Image: "url('bg.png')"},
+					Body: []model.Block{
+						model.NewBullet("First", 0),
+						model.NewBullet("Nested", 1),
+						model.NewNumbered("Step", 0),
+						bullet,
+					},
+					Notes: []model.Block{model.NewParagraph("Say hi."), model.NewPause(500 * time.Millisecond)},
+				},
+			}},
+			{ID: "details", Title: "Details", Slides: []model.Slide{
+				{
+					ID: "math", Layout: model.LayoutTitleBody, Title: "Math & diagrams",
+					Body: []model.Block{
+						model.NewMath(`E = mc^2`),
+						model.NewDiagram("graph TD\n  A --> B", model.DiagramMermaid),
+						model.NewCode("fmt.Println(\"<hi>\")", "go"),
+						{Kind: model.BlockParagraph, Text: "Bold move", Runs: []model.Span{
+							{Text: "Bold", Bold: true}, {Text: " "}, {Text: "move", Href: "https://example.com"},
+						}},
+						model.NewTable([]string{"Q", "Revenue"}, [][]string{{"Q3", "10"}}),
+					},
+				},
+				{
+					ID: "photo", Layout: model.LayoutImage,
+					Background: &model.Background{Images: []model.Block{model.NewBackgroundImage("photo.jpg")}},
+				},
+			}},
+		},
+	}
+}
+
+func TestEncode(t *testing.T) {
+	out := NewWriter().Encode(testDeck())
+
+	for _, want := range []string{
+		"<title>Quarterly Review</title>",
+		`<meta name="author" content="Ada">`,
+		// The Marp theme maps onto a Reveal.js one, and its colors and
+		// font onto the theme variables
+		`/dist/theme/league.css" id="theme" data-theme="gaia">`,
+		"  --r-main-font: Georgia;\n  --r-heading-font: Georgia;\n  --r-heading-color: #123456;\n",
+		// Sections become vertical stacks
+		"<section data-section-id=\"intro\" data-section-title=\"Intro\">\n<section id=\"welcome\" data-layout=\"title\">\n",
+		"</section>\n</section>\n<section data-section-id=\"details\"",
+		"<h1>Welcome</h1>\n<h2>Q3</h2>\n",
+		`<section id="agenda" data-layout="title_body" data-transition="fade" data-background-color="#000" data-background-image="bg.png">`,
+		"<ul>\n  <li>First\n  <ul>\n    <li>Nested</li>\n  </ul>\n  </li>\n</ul>\n<ol>\n  <li>Step</li>\n</ol>\n<ul>\n  <li class=\"fragment\">Revealed</li>\n</ul>\n",
+		"<aside class=\"notes\">\n<p>Say hi.</p>\n<p class=\"pause\" data-duration=\"500ms\">[pause 500ms]</p>\n</aside>\n",
+		"<footer class=\"slide-footer\">ACME &lt;Confidential&gt;<span class=\"page-number\">2</span></footer>",
+		"<div class=\"math\">\\[\nE = mc^2\n\\]</div>",
+		"<pre class=\"diagram mermaid\" data-dialect=\"mermaid\">\ngraph TD\n  A --&gt; B\n</pre>",
+		`<pre><code class="language-go">fmt.Println(&#34;&lt;hi&gt;&#34;)</code></pre>`,
+		`<p><strong>Bold</strong> <a href="https://example.com">move</a></p>`,
+		"<tr><th>Q</th><th>Revenue</th></tr>",
+		`<section id="photo" data-layout="image" data-background-image="photo.jpg">`,
+		"<script src=\"" + DefaultMermaidURL + "\"></script>",
+		"<script src=\"" + DefaultRevealURL + "/dist/reveal.js\"></script>",
+		"  transition: \"slide\",\n  width: 1280,\n  height: 720,\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestEncodeLocalAssets(t *testing.T) {
+	writer := &Writer{RevealURL: "vendor/reveal.js/", MermaidURL: "vendor/mermaid.min.js"}
+	out := writer.Encode(testDeck())
+	for _, want := range []string{
+		`<link rel="stylesheet" href="vendor/reveal.js/dist/reveal.css">`,
+		`<script src="vendor/reveal.js/plugin/notes/notes.js"></script>`,
+		`<script src="vendor/mermaid.min.js"></script>`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "cdn.jsdelivr.net") {
+		t.Errorf("expected nothing loaded from the CDN:\n%s", out)
+	}
+}
+
+func TestEncodeMinimal(t *testing.T) {
+	deck := &model.Deck{Title: "Plain", Sections: []model.Section{{ID: "default", Title: "default",
+		Slides: []model.Slide{{ID: "one", Layout: model.LayoutTitleBody, Title: "One"}}}}}
+	out := NewWriter().Encode(deck)
+	if !strings.Contains(out, `/dist/theme/white.css" id="theme">`) {
+		t.Errorf("expected the default theme, got:\n%s", out)
+	}
+	for _, unwanted := range []string{":root", "mermaid", "slide-footer\">", "transition:", "<aside"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("expected no %q in output:\n%s", unwanted, out)
+		}
+	}
+}
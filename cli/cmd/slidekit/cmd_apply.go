@@ -14,8 +14,9 @@ import (
 )
 
 var (
-	applyDiff    string
-	applyConfirm bool
+	applyDiff     string
+	applyConfirm  bool
+	applySections model.SectionRules
 )
 
 var applyCmd = &cobra.Command{
@@ -24,7 +25,9 @@ var applyCmd = &cobra.Command{
 	Long: `Apply changes from a diff file to a presentation.
 
 The diff must be provided as a JSON file using the --diff flag.
-The --confirm flag is required to actually apply changes.`,
+The --confirm flag is required to actually apply changes. Pass the
+--section-* flags the diff was planned with, so that the sections it
+names exist.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := args[0]
@@ -45,7 +48,8 @@ The --confirm flag is required to actually apply changes.`,
 		}
 
 		result, err := ops.ApplyChangesFromPath(context.Background(), path, &diff, ops.ApplyOptions{
-			Confirm: applyConfirm,
+			Confirm:  applyConfirm,
+			Sections: applySections,
 		})
 		if err != nil {
 			if errors.Is(err, ops.ErrConfirmRequired) {
@@ -64,4 +68,5 @@ The --confirm flag is required to actually apply changes.`,
 func init() {
 	applyCmd.Flags().StringVarP(&applyDiff, "diff", "d", "", "Path to diff file (JSON)")
 	applyCmd.Flags().BoolVar(&applyConfirm, "confirm", false, "Confirm application of changes")
+	addSectionFlags(applyCmd, &applySections)
 }
@@ -14,8 +14,9 @@ import (
 )
 
 var (
-	planDesired string
-	planFormat  string
+	planDesired  string
+	planFormat   string
+	planSections model.SectionRules
 )
 
 var planCmd = &cobra.Command{
@@ -23,7 +24,9 @@ var planCmd = &cobra.Command{
 	Short: "Show changes between current and desired state",
 	Long: `Plan computes the diff between the current presentation and a desired state.
 
-The desired state can be provided as a JSON file using the --desired flag.`,
+The desired state can be provided as a JSON file using the --desired flag.
+Pass the --section-* flags the deck was read with, so that its sections
+are grouped the same way.`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := args[0]
@@ -50,7 +53,8 @@ The desired state can be provided as a JSON file using the --desired flag.`,
 		}
 
 		result, err := ops.PlanChangesFromPath(context.Background(), path, &desired, ops.PlanOptions{
-			Format: f,
+			Format:   f,
+			Sections: planSections,
 		})
 		if err != nil {
 			return fmt.Errorf("computing plan: %w", err)
@@ -64,4 +68,5 @@ The desired state can be provided as a JSON file using the --desired flag.`,
 func init() {
 	planCmd.Flags().StringVarP(&planDesired, "desired", "d", "", "Path to desired state file (JSON)")
 	planCmd.Flags().StringVarP(&planFormat, "format", "f", "toon", "Output format: toon or json")
+	addSectionFlags(planCmd, &planSections)
 }
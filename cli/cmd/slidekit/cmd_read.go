@@ -8,17 +8,29 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/grokify/slidekit/format"
+	"github.com/grokify/slidekit/model"
 	"github.com/grokify/slidekit/ops"
 )
 
-var readFormat string
+var (
+	readFormat      string
+	readDiagnostics bool
+	readSections    model.SectionRules
+)
 
 var readCmd = &cobra.Command{
 	Use:   "read <file>",
 	Short: "Read a presentation file",
 	Long: `Read a presentation file and output its content in TOON (default) or JSON format.
 
-TOON format is optimized for AI agents, being ~8x more token-efficient than JSON.`,
+TOON format is optimized for AI agents, being ~8x more token-efficient than JSON.
+
+With --diagnostics, problems found in the source (such as unclosed code
+fences or HTML blocks) are printed to stderr as file:line:column messages.
+
+By default Marp slides with the section-divider or lead class start a new
+section. The --section-* flags add further rules, such as a custom class
+(--section-class chapter) or slides holding only an H1 (--section-heading 1).`,
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
 		path := args[0]
@@ -30,17 +42,32 @@ TOON format is optimized for AI agents, being ~8x more token-efficient than JSON
 		}
 
 		result, err := ops.ReadDeckFromPath(context.Background(), path, ops.ReadOptions{
-			Format: f,
+			Format:      f,
+			Diagnostics: readDiagnostics,
+			Sections:    readSections,
 		})
 		if err != nil {
 			return fmt.Errorf("reading deck: %w", err)
 		}
 
 		fmt.Fprint(os.Stdout, result.Output)
+		for _, d := range result.Diagnostics {
+			fmt.Fprintf(os.Stderr, "%s:%s\n", path, d)
+		}
 		return nil
 	},
 }
 
 func init() {
 	readCmd.Flags().StringVarP(&readFormat, "format", "f", "toon", "Output format: toon or json")
+	readCmd.Flags().BoolVar(&readDiagnostics, "diagnostics", false, "Report malformed source on stderr")
+	addSectionFlags(readCmd, &readSections)
+}
+
+// addSectionFlags adds the --section-* flags, which set rules, to cmd.
+func addSectionFlags(cmd *cobra.Command, rules *model.SectionRules) {
+	cmd.Flags().StringSliceVar(&rules.Classes, "section-class", nil, "Slide class that starts a section (repeatable)")
+	cmd.Flags().IntVar(&rules.HeadingLevel, "section-heading", 0, "Start a section at slides holding only a heading of this level")
+	cmd.Flags().BoolVar(&rules.Markers, "section-markers", false, "Start a section at <!-- section: id Title --> markers")
+	cmd.Flags().BoolVar(&rules.PerFile, "section-per-file", false, "Put all slides of the file in one section")
 }
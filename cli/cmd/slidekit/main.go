@@ -8,6 +8,8 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/grokify/slidekit/backends/marp"
+	"github.com/grokify/slidekit/backends/pptx"
+	"github.com/grokify/slidekit/backends/reveal"
 	"github.com/grokify/slidekit/ops"
 )
 
@@ -19,6 +21,8 @@ var (
 func main() {
 	// Register backends
 	ops.DefaultRegistry.Register("marp", marp.NewBackend())
+	ops.DefaultRegistry.Register("reveal", reveal.NewBackend())
+	ops.DefaultRegistry.Register("pptx", pptx.NewBackend())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -31,7 +35,8 @@ var rootCmd = &cobra.Command{
 	Short: "A toolkit for managing presentations",
 	Long: `slidekit is a CLI for reading, planning, and modifying presentations.
 
-Supports multiple backends including Marp Markdown.`,
+Supports multiple backends including Marp Markdown, Reveal.js HTML and
+PowerPoint.`,
 	Version: Version,
 }
 
@@ -5,6 +5,7 @@ package format
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/grokify/slidekit/model"
@@ -45,6 +46,15 @@ func (e *TOONEncoder) EncodeDeck(d *model.Deck) string {
 		b.WriteString(d.Meta.Description)
 		b.WriteString("\n")
 	}
+	if !d.HeaderFooter.IsEmpty() {
+		encodeHeaderFooter(&b, "header_footer", d.HeaderFooter)
+		b.WriteString("\n")
+	}
+	if d.Transition != "" {
+		b.WriteString("transition ")
+		b.WriteString(d.Transition)
+		b.WriteString("\n")
+	}
 
 	// Sections
 	for _, section := range d.Sections {
@@ -110,11 +120,48 @@ func (e *TOONEncoder) encodeSlide(b *strings.Builder, s *model.Slide) {
 		b.WriteString("\n")
 	}
 
+	// Background, followed by its images
+	if bg := s.Background; !bg.IsEmpty() {
+		b.WriteString(indent2)
+		encodeBackground(b, bg)
+		b.WriteString("\n")
+		for _, block := range bg.Images {
+			b.WriteString(indent2 + e.indent)
+			e.encodeBlock(b, &block)
+			b.WriteString("\n")
+		}
+	}
+
+	// Header, footer and page number settings
+	if !s.HeaderFooter.IsEmpty() {
+		b.WriteString(indent2)
+		encodeHeaderFooter(b, "header_footer", s.HeaderFooter)
+		b.WriteString("\n")
+	}
+	if !s.HeaderFooterFrom.IsEmpty() {
+		b.WriteString(indent2)
+		encodeHeaderFooter(b, "header_footer_from", s.HeaderFooterFrom)
+		b.WriteString("\n")
+	}
+
+	// Slots, each followed by its blocks
+	for _, name := range s.Slots.Names() {
+		b.WriteString(indent2)
+		b.WriteString("slot ")
+		b.WriteString(name)
+		b.WriteString("\n")
+		for _, block := range s.Slots[name] {
+			b.WriteString(indent2 + e.indent)
+			e.encodeBlock(b, &block)
+			b.WriteString("\n")
+		}
+	}
+
 	// Notes
 	for _, block := range s.Notes {
 		b.WriteString(indent2)
 		b.WriteString("note ")
-		b.WriteString(block.Text)
+		e.encodeNote(b, &block)
 		b.WriteString("\n")
 	}
 
@@ -140,41 +187,261 @@ func (e *TOONEncoder) encodeBlock(b *strings.Builder, block *model.Block) {
 		for i := 0; i < block.Level; i++ {
 			b.WriteString("  ")
 		}
-		b.WriteString("bullet ")
-		b.WriteString(block.Text)
+		b.WriteString(keyword(block, "bullet") + " ")
+		b.WriteString(blockText(block))
 	case model.BlockNumbered:
 		for i := 0; i < block.Level; i++ {
 			b.WriteString("  ")
 		}
-		b.WriteString("numbered ")
-		b.WriteString(block.Text)
+		b.WriteString(keyword(block, "numbered") + " ")
+		b.WriteString(blockText(block))
 	case model.BlockParagraph:
-		b.WriteString("para ")
-		b.WriteString(block.Text)
+		b.WriteString(keyword(block, "para") + " ")
+		b.WriteString(blockText(block))
 	case model.BlockCode:
-		b.WriteString("code ")
+		b.WriteString(keyword(block, "code") + " ")
 		if block.Lang != "" {
 			b.WriteString(block.Lang)
 			b.WriteString(" ")
 		}
 		b.WriteString(block.Text)
+	case model.BlockMath:
+		b.WriteString(keyword(block, "math") + " ")
+		b.WriteString(block.Text)
+	case model.BlockDiagram:
+		b.WriteString(keyword(block, "diagram") + " ")
+		if block.Dialect != "" {
+			b.WriteString(block.Dialect)
+			b.WriteString(" ")
+		}
+		b.WriteString(block.Text)
 	case model.BlockImage:
-		b.WriteString("image ")
+		b.WriteString(keyword(block, "image") + " ")
 		b.WriteString(block.URL)
+		if block.Image != nil {
+			encodeImageAttrs(b, block.Image)
+		}
 		if block.Alt != "" {
 			b.WriteString(" ")
 			b.WriteString(block.Alt)
 		}
 	case model.BlockQuote:
-		b.WriteString("quote ")
-		b.WriteString(block.Text)
+		b.WriteString(keyword(block, "quote") + " ")
+		b.WriteString(blockText(block))
 	case model.BlockHeading:
-		b.WriteString("heading ")
+		b.WriteString(keyword(block, "heading") + " ")
 		if block.Level > 0 {
 			fmt.Fprintf(b, "%d ", block.Level)
 		}
-		b.WriteString(block.Text)
+		b.WriteString(blockText(block))
+	case model.BlockTable:
+		b.WriteString(keyword(block, "table"))
+		if block.Table != nil {
+			encodeTable(b, block.Table)
+		}
+	case model.BlockPause:
+		b.WriteString(keyword(block, "pause") + " ")
+		b.WriteString(block.PauseDuration().String())
+	}
+}
+
+// keyword returns the TOON keyword for a block, with a "*" appended for
+// fragments, e.g. "bullet*".
+func keyword(block *model.Block, name string) string {
+	if block.Fragment {
+		return name + "*"
+	}
+	return name
+}
+
+// encodeNote writes a speaker note block compactly: paragraphs as plain
+// text on one line, list items as "- text" or "1. text" indented by level,
+// pause cues as "[pause 500ms]" or, for SSML breaks, "[break 500ms]", and
+// other blocks as in the body.
+func (e *TOONEncoder) encodeNote(b *strings.Builder, block *model.Block) {
+	switch block.Kind {
+	case model.BlockBullet, model.BlockNumbered:
+		b.WriteString(strings.Repeat("  ", block.Level))
+		switch {
+		case block.Kind == model.BlockNumbered:
+			b.WriteString("1. ")
+		case block.Fragment:
+			b.WriteString("* ")
+		default:
+			b.WriteString("- ")
+		}
+		b.WriteString(blockText(block))
+	case model.BlockPause:
+		if block.Pause != nil && block.Pause.SSML {
+			b.WriteString("[break ")
+		} else {
+			b.WriteString("[pause ")
+		}
+		b.WriteString(block.PauseDuration().String())
+		b.WriteString("]")
+	case model.BlockCode, model.BlockImage, model.BlockQuote, model.BlockHeading, model.BlockTable,
+		model.BlockMath, model.BlockDiagram:
+		e.encodeBlock(b, block)
+	default:
+		b.WriteString(strings.ReplaceAll(blockText(block), "\n", " "))
+	}
+}
+
+// encodeImageAttrs writes image attributes as key=value pairs, e.g.
+// " bg=contain split=right:40% w=300px filters=blur:10px,sepia".
+func encodeImageAttrs(b *strings.Builder, a *model.ImageAttrs) {
+	if a.Bg {
+		b.WriteString(" bg")
+		if a.BgSize != "" {
+			b.WriteString("=")
+			b.WriteString(a.BgSize)
+		}
+		if a.Split != "" {
+			b.WriteString(" split=")
+			b.WriteString(a.Split)
+			if a.SplitSize != "" {
+				b.WriteString(":")
+				b.WriteString(a.SplitSize)
+			}
+		}
+		if a.Vertical {
+			b.WriteString(" vertical")
+		}
+	}
+	if a.Width != "" {
+		b.WriteString(" w=")
+		b.WriteString(a.Width)
+	}
+	if a.Height != "" {
+		b.WriteString(" h=")
+		b.WriteString(a.Height)
+	}
+	if len(a.Filters) > 0 {
+		filters := make([]string, len(a.Filters))
+		for i, f := range a.Filters {
+			filters[i] = f.Name
+			if f.Value != "" {
+				filters[i] += ":" + f.Value
+			}
+		}
+		b.WriteString(" filters=")
+		b.WriteString(strings.Join(filters, ","))
+	}
+}
+
+// encodeBackground writes the color and image of a slide background; its
+// image blocks are written separately.
+func encodeBackground(b *strings.Builder, bg *model.Background) {
+	b.WriteString("background")
+	if bg.Color != "" {
+		b.WriteString(" color=")
+		b.WriteString(bg.Color)
 	}
+	if bg.Image != "" {
+		b.WriteString(" image=")
+		b.WriteString(bg.Image)
+	}
+}
+
+// encodeHeaderFooter writes the settings h makes after keyword, with
+// header and footer text quoted since it may be empty or hold spaces.
+func encodeHeaderFooter(b *strings.Builder, keyword string, h *model.HeaderFooter) {
+	b.WriteString(keyword)
+	if h.Header != nil {
+		b.WriteString(" header=")
+		b.WriteString(strconv.Quote(*h.Header))
+	}
+	if h.Footer != nil {
+		b.WriteString(" footer=")
+		b.WriteString(strconv.Quote(*h.Footer))
+	}
+	if h.PageNumber != nil {
+		b.WriteString(" page_number=")
+		b.WriteString(strconv.FormatBool(*h.PageNumber))
+	}
+}
+
+// encodeTable writes a table on one line: an optional align= list with one
+// of l, c, r or - per column, then the header and rows separated by " / "
+// with cells separated by " | ".
+func encodeTable(b *strings.Builder, t *model.Table) {
+	if len(t.Align) > 0 {
+		codes := make([]string, t.Columns())
+		for i := range codes {
+			switch t.ColumnAlign(i) {
+			case model.AlignLeft:
+				codes[i] = "l"
+			case model.AlignCenter:
+				codes[i] = "c"
+			case model.AlignRight:
+				codes[i] = "r"
+			default:
+				codes[i] = "-"
+			}
+		}
+		b.WriteString(" align=")
+		b.WriteString(strings.Join(codes, ","))
+	}
+	b.WriteString(" ")
+	b.WriteString(rowText(t.Header))
+	for _, row := range t.Rows {
+		b.WriteString(" / ")
+		b.WriteString(rowText(row))
+	}
+}
+
+// rowText returns the cells of a table row separated by " | ".
+func rowText(row []model.TableCell) string {
+	texts := make([]string, len(row))
+	for i := range row {
+		texts[i] = cellText(&row[i])
+	}
+	return strings.Join(texts, " | ")
+}
+
+// blockText returns a block's text with its inline runs marked up.
+func blockText(block *model.Block) string {
+	if !block.HasRuns() {
+		return block.Text
+	}
+	return markupRuns(block.Runs)
+}
+
+// cellText returns a table cell's text with its inline runs marked up.
+func cellText(cell *model.TableCell) string {
+	if !cell.HasRuns() {
+		return cell.Text
+	}
+	return markupRuns(cell.Runs)
+}
+
+// markupRuns marks up inline runs compactly: **bold**, _italic_, `code`,
+// $math$, ~~strike~~ and [text](href).
+func markupRuns(runs []model.Span) string {
+	var b strings.Builder
+	for _, run := range runs {
+		text := run.Text
+		if run.Code {
+			text = "`" + text + "`"
+		}
+		if run.Math {
+			text = "$" + text + "$"
+		}
+		if run.Strike {
+			text = "~~" + text + "~~"
+		}
+		if run.Italic {
+			text = "_" + text + "_"
+		}
+		if run.Bold {
+			text = "**" + text + "**"
+		}
+		if run.Href != "" {
+			text = "[" + text + "](" + run.Href + ")"
+		}
+		b.WriteString(text)
+	}
+	return b.String()
 }
 
 func (e *TOONEncoder) encodeAudio(b *strings.Builder, a *model.Audio) {
@@ -240,18 +507,96 @@ func (e *TOONEncoder) encodeChange(b *strings.Builder, c *model.Change) {
 	b.WriteString(c.Path)
 	b.WriteString("\n")
 
+	if c.Op == model.ChangeMove && c.To != nil {
+		e.encodeMove(b, c)
+		return
+	}
+
 	if c.OldValue != nil {
 		b.WriteString(e.indent)
 		b.WriteString("- ")
-		fmt.Fprintf(b, "%v", c.OldValue)
+		e.encodeValue(b, c.OldValue)
 		b.WriteString("\n")
 	}
 	if c.NewValue != nil {
 		b.WriteString(e.indent)
 		b.WriteString("+ ")
-		fmt.Fprintf(b, "%v", c.NewValue)
+		e.encodeValue(b, c.NewValue)
+		b.WriteString("\n")
+	}
+}
+
+// encodeMove writes the source and destination positions of a move.
+func (e *TOONEncoder) encodeMove(b *strings.Builder, c *model.Change) {
+	if c.From != nil {
+		b.WriteString(e.indent)
+		b.WriteString("- ")
+		encodePosition(b, c.From)
 		b.WriteString("\n")
 	}
+	b.WriteString(e.indent)
+	b.WriteString("+ ")
+	encodePosition(b, c.To)
+	b.WriteString("\n")
+}
+
+// encodePosition writes a position as section[index], or [index] for sections.
+func encodePosition(b *strings.Builder, p *model.Position) {
+	fmt.Fprintf(b, "%s[%d]", p.SectionID, p.Index)
+}
+
+// encodeValue writes a change value, using TOON notation for model types.
+func (e *TOONEncoder) encodeValue(b *strings.Builder, v any) {
+	switch val := v.(type) {
+	case model.Block:
+		e.encodeBlock(b, &val)
+	case []model.Block:
+		for i := range val {
+			if i > 0 {
+				b.WriteString("; ")
+			}
+			e.encodeBlock(b, &val[i])
+		}
+	case model.Slide:
+		b.WriteString("slide ")
+		b.WriteString(val.ID)
+		b.WriteString(" ")
+		b.WriteString(string(val.Layout))
+		if val.Title != "" {
+			b.WriteString(" ")
+			b.WriteString(val.Title)
+		}
+	case model.TableCell:
+		b.WriteString(cellText(&val))
+	case []model.TableCell:
+		b.WriteString(rowText(val))
+	case model.Section:
+		b.WriteString("section ")
+		b.WriteString(val.ID)
+		if val.Title != "" {
+			b.WriteString(" ")
+			b.WriteString(val.Title)
+		}
+		fmt.Fprintf(b, " (%d slides)", len(val.Slides))
+	case *model.Audio:
+		if val != nil {
+			e.encodeAudio(b, val)
+		}
+	case *model.HeaderFooter:
+		if val != nil {
+			encodeHeaderFooter(b, "header_footer", val)
+		}
+	case *model.Background:
+		if val != nil {
+			encodeBackground(b, val)
+			for i := range val.Images {
+				b.WriteString("; ")
+				e.encodeBlock(b, &val.Images[i])
+			}
+		}
+	default:
+		fmt.Fprintf(b, "%v", v)
+	}
 }
 
 // Format is an output format type.
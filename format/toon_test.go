@@ -111,6 +111,128 @@ func TestTOONEncoderEncodeBlocks(t *testing.T) {
 	}
 }
 
+func TestTOONEncoderEncodeRuns(t *testing.T) {
+	block := model.NewBullet("Read the docs now", 0)
+	block.Runs = []model.Span{
+		{Text: "Read "},
+		{Text: "the docs", Href: "https://go.dev", Bold: true},
+		{Text: " "},
+		{Text: "now", Italic: true},
+	}
+
+	var b strings.Builder
+	NewTOONEncoder().encodeBlock(&b, &block)
+	if got, want := b.String(), "bullet Read [**the docs**](https://go.dev) _now_"; got != want {
+		t.Errorf("encodeBlock = %q, want %q", got, want)
+	}
+
+	// Runs that no longer match the text are ignored
+	block.Text = "Edited"
+	b.Reset()
+	NewTOONEncoder().encodeBlock(&b, &block)
+	if got, want := b.String(), "bullet Edited"; got != want {
+		t.Errorf("encodeBlock = %q, want %q", got, want)
+	}
+}
+
+func TestTOONEncoderEncodeTable(t *testing.T) {
+	block := model.NewTable([]string{"Name", "Role"}, [][]string{{"Ada", "Dev"}, {"Bob", "Ops"}})
+
+	var b strings.Builder
+	NewTOONEncoder().encodeBlock(&b, &block)
+	if got, want := b.String(), "table Name | Role / Ada | Dev / Bob | Ops"; got != want {
+		t.Errorf("encodeBlock = %q, want %q", got, want)
+	}
+
+	block.Table.Align = []model.Alignment{model.AlignLeft, model.AlignRight}
+	b.Reset()
+	NewTOONEncoder().encodeBlock(&b, &block)
+	if got, want := b.String(), "table align=l,r Name | Role / Ada | Dev / Bob | Ops"; got != want {
+		t.Errorf("encodeBlock = %q, want %q", got, want)
+	}
+}
+
+func TestTOONEncoderEncodeSlots(t *testing.T) {
+	slide := &model.Slide{
+		ID:     "s1",
+		Layout: model.LayoutTitleTwoCol,
+		Slots: model.Slots{
+			model.SlotRight: {model.NewBullet("After", 0)},
+			model.SlotLeft:  {model.NewBullet("Before", 0)},
+		},
+	}
+
+	output := NewTOONEncoder().EncodeSlide(slide)
+	want := "    slot left\n      bullet Before\n    slot right\n      bullet After\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("expected slots in output, got:\n%s", output)
+	}
+}
+
+func TestTOONEncoderEncodeBackground(t *testing.T) {
+	split := model.NewBackgroundImage("photo.jpg")
+	split.Image.Split, split.Image.SplitSize = model.SplitRight, "40%"
+	split.Image.Filters = []model.ImageFilter{{Name: "blur", Value: "4px"}, {Name: "sepia"}}
+	logo := model.NewImage("logo.png", "Logo")
+	logo.Image = &model.ImageAttrs{Width: "200"}
+
+	slide := &model.Slide{
+		ID:         "s1",
+		Layout:     model.LayoutTitleBody,
+		Body:       []model.Block{logo},
+		Background: &model.Background{Color: "#000", Images: []model.Block{split}},
+	}
+
+	output := NewTOONEncoder().EncodeSlide(slide)
+	for _, want := range []string{
+		"    image logo.png w=200 Logo\n",
+		"    background color=#000\n      image photo.jpg bg split=right:40% filters=blur:4px,sepia\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestTOONEncoderEncodeFragments(t *testing.T) {
+	first := model.NewBullet("First", 0)
+	first.Fragment = true
+	table := model.NewTable([]string{"A"}, [][]string{{"1"}})
+	table.Fragment = true
+	slide := &model.Slide{
+		ID:     "s1",
+		Layout: model.LayoutTitleBody,
+		Body:   []model.Block{first, model.NewBullet("Always", 0), table},
+	}
+
+	output := NewTOONEncoder().EncodeSlide(slide)
+	want := "    bullet* First\n    bullet Always\n    table* A / 1\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("expected fragment markers in output, got:\n%s", output)
+	}
+}
+
+func TestTOONEncoderEncodeNotes(t *testing.T) {
+	slide := &model.Slide{
+		ID:     "s1",
+		Layout: model.LayoutTitleBody,
+		Notes: []model.Block{
+			model.NewParagraph("Welcome,\neveryone."),
+			model.NewPause(1500 * time.Millisecond),
+			model.NewBullet("Goals", 0),
+			model.NewNumbered("Ship it", 1),
+			model.NewBreak(time.Second),
+		},
+	}
+
+	output := NewTOONEncoder().EncodeSlide(slide)
+	want := "    note Welcome, everyone.\n    note [pause 1.5s]\n    note - Goals\n" +
+		"    note   1. Ship it\n    note [break 1s]\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("expected compact notes in output, got:\n%s", output)
+	}
+}
+
 func TestTOONEncoderEncodeAudio(t *testing.T) {
 	transition := "fade"
 	deck := &model.Deck{
@@ -377,3 +499,95 @@ func TestTOONEncoderCodeWithoutLang(t *testing.T) {
 		t.Errorf("code without lang should work, got:\n%s", output)
 	}
 }
+
+func TestTOONEncoderEncodeDiffModelValues(t *testing.T) {
+	diff := model.NewDiff("deck123")
+	diff.AddChange(model.NewAddChange("sections/intro/slides/s1/body/1", model.NewBullet("Inserted", 0)))
+	diff.AddChange(model.NewAddChange("sections/intro/slides/s2",
+		model.Slide{ID: "s2", Layout: model.LayoutTitleBody, Title: "New Slide"}))
+	diff.AddChange(model.NewRemoveChange("sections/outro",
+		model.Section{ID: "outro", Title: "Outro", Slides: []model.Slide{{ID: "s9"}}}))
+
+	encoder := NewTOONEncoder()
+	output := encoder.EncodeDiff(diff)
+
+	expectations := []string{
+		"  + bullet Inserted\n",
+		"  + slide s2 title_body New Slide\n",
+		"  - section outro Outro (1 slides)\n",
+	}
+	for _, exp := range expectations {
+		if !strings.Contains(output, exp) {
+			t.Errorf("output should contain %q, got:\n%s", exp, output)
+		}
+	}
+}
+
+func TestTOONEncoderEncodeDiffMove(t *testing.T) {
+	diff := model.NewDiff("deck123")
+	move := model.NewMoveChange("sections/intro/slides/s2", "sections/main/slides/s2").
+		At(model.Position{SectionID: "main", Index: 0})
+	move.From = &model.Position{SectionID: "intro", Index: 1}
+	diff.AddChange(move)
+	diff.AddChange(model.NewMoveChange("sections/main", "sections/main").
+		At(model.Position{Index: 0}))
+
+	encoder := NewTOONEncoder()
+	output := encoder.EncodeDiff(diff)
+
+	want := "plan deck deck123\n" +
+		"> sections/intro/slides/s2\n" +
+		"  - intro[1]\n" +
+		"  + main[0]\n" +
+		"> sections/main\n" +
+		"  + [0]\n"
+	if output != want {
+		t.Errorf("EncodeDiff() =\n%s\nwant:\n%s", output, want)
+	}
+}
+
+func TestTOONEncoderEncodeHeaderFooter(t *testing.T) {
+	footer, empty, on := "Acme Corp", "", true
+	deck := &model.Deck{
+		Title:        "Deck",
+		HeaderFooter: &model.HeaderFooter{Footer: &footer, PageNumber: &on},
+		Transition:   "fade",
+		Sections: []model.Section{{ID: "s", Slides: []model.Slide{{
+			ID:               "s1",
+			Layout:           model.LayoutTitleBody,
+			HeaderFooter:     &model.HeaderFooter{Header: &empty},
+			HeaderFooterFrom: &model.HeaderFooter{Footer: &empty},
+		}}}},
+	}
+
+	output := NewTOONEncoder().EncodeDeck(deck)
+	for _, want := range []string{
+		"header_footer footer=\"Acme Corp\" page_number=true\ntransition fade\n",
+		"    header_footer header=\"\"\n",
+		"    header_footer_from footer=\"\"\n",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("expected %q in output, got:\n%s", want, output)
+		}
+	}
+}
+
+func TestTOONEncoderEncodeMathAndDiagrams(t *testing.T) {
+	para := model.NewParagraph("Euler: e^{i\\pi}")
+	para.Runs = []model.Span{{Text: "Euler: "}, {Text: "e^{i\\pi}", Math: true}}
+	slide := &model.Slide{
+		ID:     "s1",
+		Layout: model.LayoutTitleBody,
+		Body: []model.Block{
+			para,
+			model.NewMath("x^2"),
+			model.NewDiagram("graph TD", model.DiagramMermaid),
+		},
+	}
+
+	output := NewTOONEncoder().EncodeSlide(slide)
+	want := "    para Euler: $e^{i\\pi}$\n    math x^2\n    diagram mermaid graph TD\n"
+	if !strings.Contains(output, want) {
+		t.Errorf("expected %q in output, got:\n%s", want, output)
+	}
+}
@@ -0,0 +1,42 @@
+// Package backendtest provides checks shared by the tests of the
+// backends.
+package backendtest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/grokify/slidekit/model"
+)
+
+// PlanApply checks that backend plans and applies the edits edit makes to
+// the deck at ref: the plan has changes, and the deck reads back as edited
+// once they are applied. It returns the deck read back.
+func PlanApply(t *testing.T, backend model.Backend, ref model.Ref, edit func(deck *model.Deck)) *model.Deck {
+	t.Helper()
+	ctx := context.Background()
+	desired, err := backend.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	edit(desired)
+	diff, err := backend.Plan(ctx, ref, desired)
+	if err != nil {
+		t.Fatalf("Plan error: %v", err)
+	}
+	if diff.IsEmpty() {
+		t.Fatal("expected the edits to be planned")
+	}
+	if err := backend.Apply(ctx, ref, diff); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+
+	updated, err := backend.Read(ctx, ref)
+	if err != nil {
+		t.Fatalf("Read error: %v", err)
+	}
+	if diff := model.ComputeDiff(updated, desired); !diff.IsEmpty() {
+		t.Errorf("expected the edits applied, got changes left %+v", diff.Changes)
+	}
+	return updated
+}
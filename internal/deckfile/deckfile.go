@@ -0,0 +1,22 @@
+// Package deckfile provides the file handling shared by the backends that
+// keep a deck in a single file.
+package deckfile
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// WriteApplied writes content, the result of applying a diff, to path as
+// the last step of a backend's Apply. The file is left untouched if ctx is
+// done by then, so a caller that gave up meanwhile finds it unchanged.
+func WriteApplied(ctx context.Context, path string, content []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, content, 0600); err != nil {
+		return fmt.Errorf("writing deck: %w", err)
+	}
+	return nil
+}
@@ -15,6 +15,7 @@ type ApplyChangesInput struct {
 	Path    string     `json:"path" jsonschema:"description=path to the presentation file"`
 	Diff    model.Diff `json:"diff" jsonschema:"description=the diff to apply"`
 	Confirm bool       `json:"confirm" jsonschema:"description=must be true to actually apply changes"`
+	SectionInput
 }
 
 // ApplyChangesOutput is the output for the apply_changes tool.
@@ -25,12 +26,13 @@ type ApplyChangesOutput struct {
 
 var applyChangesTool = &mcp.Tool{
 	Name:        "apply_changes",
-	Description: "Apply a diff to a presentation. Requires confirm=true to make changes.",
+	Description: "Apply a diff to a presentation. Requires confirm=true to make changes. Pass the section_* options the diff was planned with.",
 }
 
 func handleApplyChanges(ctx context.Context, req *mcp.CallToolRequest, input ApplyChangesInput) (*mcp.CallToolResult, ApplyChangesOutput, error) {
 	result, err := ops.ApplyChangesFromPath(ctx, input.Path, &input.Diff, ops.ApplyOptions{
-		Confirm: input.Confirm,
+		Confirm:  input.Confirm,
+		Sections: input.rules(),
 	})
 	if err != nil {
 		if errors.Is(err, ops.ErrConfirmRequired) {
@@ -6,6 +6,7 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
 	"github.com/grokify/slidekit/format"
+	"github.com/grokify/slidekit/model"
 	"github.com/grokify/slidekit/ops"
 )
 
@@ -18,7 +19,8 @@ type GetSlideInput struct {
 
 // GetSlideOutput is the output for the get_slide tool.
 type GetSlideOutput struct {
-	Content string `json:"content" jsonschema:"description=the slide content in the requested format"`
+	Content string           `json:"content" jsonschema:"description=the slide content in the requested format"`
+	Source  *model.SourceMap `json:"source,omitempty" jsonschema:"description=line and column ranges of the slide and its title, body blocks and notes in the source file"`
 }
 
 var getSlideTool = &mcp.Tool{
@@ -37,5 +39,5 @@ func handleGetSlide(ctx context.Context, req *mcp.CallToolRequest, input GetSlid
 		return nil, GetSlideOutput{}, err
 	}
 
-	return nil, GetSlideOutput{Content: result.Output}, nil
+	return nil, GetSlideOutput{Content: result.Output, Source: result.Source}, nil
 }
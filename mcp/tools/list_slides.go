@@ -13,6 +13,7 @@ import (
 type ListSlidesInput struct {
 	Path   string `json:"path" jsonschema:"description=path to the presentation file"`
 	Format string `json:"format,omitempty" jsonschema:"description=output format: toon (default) or json"`
+	SectionInput
 }
 
 // ListSlidesOutput is the output for the list_slides tool.
@@ -22,7 +23,7 @@ type ListSlidesOutput struct {
 
 var listSlidesTool = &mcp.Tool{
 	Name:        "list_slides",
-	Description: "List all slides in a presentation with their IDs and titles",
+	Description: "List all slides in a presentation with their IDs and titles. The section_* options change which slides start a section.",
 }
 
 func handleListSlides(ctx context.Context, req *mcp.CallToolRequest, input ListSlidesInput) (*mcp.CallToolResult, ListSlidesOutput, error) {
@@ -31,7 +32,10 @@ func handleListSlides(ctx context.Context, req *mcp.CallToolRequest, input ListS
 		f = format.FormatTOON
 	}
 
-	result, err := ops.ListSlidesFromPath(ctx, input.Path, f)
+	result, err := ops.ListSlidesFromPath(ctx, input.Path, ops.ListSlidesOptions{
+		Format:   f,
+		Sections: input.rules(),
+	})
 	if err != nil {
 		return nil, ListSlidesOutput{}, err
 	}
@@ -16,6 +16,7 @@ type PlanChangesInput struct {
 	Path    string     `json:"path" jsonschema:"description=path to the presentation file"`
 	Desired model.Deck `json:"desired" jsonschema:"description=the desired state of the deck"`
 	Format  string     `json:"format,omitempty" jsonschema:"description=output format: toon (default) or json"`
+	SectionInput
 }
 
 // PlanChangesOutput is the output for the plan_changes tool.
@@ -26,7 +27,7 @@ type PlanChangesOutput struct {
 
 var planChangesTool = &mcp.Tool{
 	Name:        "plan_changes",
-	Description: "Compute the diff between the current presentation and a desired state",
+	Description: "Compute the diff between the current presentation and a desired state. Pass the section_* options the deck was read with.",
 }
 
 func handlePlanChanges(ctx context.Context, req *mcp.CallToolRequest, input PlanChangesInput) (*mcp.CallToolResult, PlanChangesOutput, error) {
@@ -36,7 +37,8 @@ func handlePlanChanges(ctx context.Context, req *mcp.CallToolRequest, input Plan
 	}
 
 	result, err := ops.PlanChangesFromPath(ctx, input.Path, &input.Desired, ops.PlanOptions{
-		Format: f,
+		Format:   f,
+		Sections: input.rules(),
 	})
 	if err != nil {
 		return nil, PlanChangesOutput{}, err
@@ -6,6 +6,7 @@ import (
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 
 	"github.com/grokify/slidekit/format"
+	"github.com/grokify/slidekit/model"
 	"github.com/grokify/slidekit/ops"
 )
 
@@ -13,16 +14,37 @@ import (
 type ReadDeckInput struct {
 	Path   string `json:"path" jsonschema:"description=path to the presentation file"`
 	Format string `json:"format,omitempty" jsonschema:"description=output format: toon (default) or json"`
+	SectionInput
+}
+
+// SectionInput holds the section_* options of the tools that read a deck,
+// which change which slides start a section.
+type SectionInput struct {
+	SectionClasses      []string `json:"section_classes,omitempty" jsonschema:"description=slide classes that start a section besides section-divider and lead"`
+	SectionHeadingLevel int      `json:"section_heading_level,omitempty" jsonschema:"description=start a section at slides holding only a heading of this level"`
+	SectionMarkers      bool     `json:"section_markers,omitempty" jsonschema:"description=start a section at <!-- section: id Title --> markers"`
+	SectionPerFile      bool     `json:"section_per_file,omitempty" jsonschema:"description=put all slides of the file in one section"`
+}
+
+// rules returns the section rules the options ask for.
+func (s SectionInput) rules() model.SectionRules {
+	return model.SectionRules{
+		Classes:      s.SectionClasses,
+		HeadingLevel: s.SectionHeadingLevel,
+		Markers:      s.SectionMarkers,
+		PerFile:      s.SectionPerFile,
+	}
 }
 
 // ReadDeckOutput is the output for the read_deck tool.
 type ReadDeckOutput struct {
-	Content string `json:"content" jsonschema:"description=the presentation content in the requested format"`
+	Content     string             `json:"content" jsonschema:"description=the presentation content in the requested format"`
+	Diagnostics []model.Diagnostic `json:"diagnostics,omitempty" jsonschema:"description=problems found in the source, with line, column and slide ID"`
 }
 
 var readDeckTool = &mcp.Tool{
 	Name:        "read_deck",
-	Description: "Read a presentation file and return its content in TOON (default) or JSON format. TOON is ~8x more token-efficient than JSON.",
+	Description: "Read a presentation file and return its content in TOON (default) or JSON format. TOON is ~8x more token-efficient than JSON. Problems found in the source, such as unclosed code fences, are listed under diagnostics. The section_* options change which slides start a section.",
 }
 
 func handleReadDeck(ctx context.Context, req *mcp.CallToolRequest, input ReadDeckInput) (*mcp.CallToolResult, ReadDeckOutput, error) {
@@ -32,11 +54,13 @@ func handleReadDeck(ctx context.Context, req *mcp.CallToolRequest, input ReadDec
 	}
 
 	result, err := ops.ReadDeckFromPath(ctx, input.Path, ops.ReadOptions{
-		Format: f,
+		Format:      f,
+		Diagnostics: true,
+		Sections:    input.rules(),
 	})
 	if err != nil {
 		return nil, ReadDeckOutput{}, err
 	}
 
-	return nil, ReadDeckOutput{Content: result.Output}, nil
+	return nil, ReadDeckOutput{Content: result.Output, Diagnostics: result.Diagnostics}, nil
 }
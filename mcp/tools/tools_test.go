@@ -63,6 +63,37 @@ Content here.
 	}
 }
 
+func TestHandleReadDeckDiagnostics(t *testing.T) {
+	path := createTestPresentation(t, "# Broken\n\n```go\nfmt.Println()\n")
+	ctx := context.Background()
+
+	_, output, err := handleReadDeck(ctx, nil, ReadDeckInput{Path: path})
+	if err != nil {
+		t.Fatalf("handleReadDeck failed: %v", err)
+	}
+	if len(output.Diagnostics) != 1 || output.Diagnostics[0].Line != 3 ||
+		output.Diagnostics[0].SlideID != "broken" {
+		t.Errorf("expected unclosed fence diagnostic on line 3, got %+v", output.Diagnostics)
+	}
+}
+
+func TestHandleReadDeckSections(t *testing.T) {
+	path := createTestPresentation(t, "# Guide\n\n---\n\n# Basics\n\n---\n\n# Setup\n\nSteps.\n")
+	ctx := context.Background()
+
+	_, output, err := handleReadDeck(ctx, nil, ReadDeckInput{
+		Path:         path,
+		Format:       "json",
+		SectionInput: SectionInput{SectionHeadingLevel: 1},
+	})
+	if err != nil {
+		t.Fatalf("handleReadDeck failed: %v", err)
+	}
+	if !strings.Contains(output.Content, `"id": "basics"`) || strings.Contains(output.Content, `"id": "default"`) {
+		t.Errorf("expected sections at the heading-only slides: %s", output.Content)
+	}
+}
+
 func TestHandleReadDeckNotFound(t *testing.T) {
 	ctx := context.Background()
 
@@ -129,7 +160,7 @@ marp: true
 	}
 
 	// Get list result to find ID
-	listResult, err := ops.ListSlidesFromPath(ctx, path, "toon")
+	listResult, err := ops.ListSlidesFromPath(ctx, path, ops.ListSlidesOptions{Format: "toon"})
 	if err != nil {
 		t.Fatalf("ListSlidesFromPath failed: %v", err)
 	}
@@ -318,7 +349,7 @@ Original content.
 	ctx := context.Background()
 
 	// Get slide ID
-	listResult, err := ops.ListSlidesFromPath(ctx, path, "toon")
+	listResult, err := ops.ListSlidesFromPath(ctx, path, ops.ListSlidesOptions{Format: "toon"})
 	if err != nil {
 		t.Fatalf("ListSlidesFromPath failed: %v", err)
 	}
@@ -353,7 +384,7 @@ Some content.
 	ctx := context.Background()
 
 	// Get slide ID
-	listResult, err := ops.ListSlidesFromPath(ctx, path, "toon")
+	listResult, err := ops.ListSlidesFromPath(ctx, path, ops.ListSlidesOptions{Format: "toon"})
 	if err != nil {
 		t.Fatalf("ListSlidesFromPath failed: %v", err)
 	}
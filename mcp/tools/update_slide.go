@@ -14,8 +14,9 @@ import (
 type UpdateSlideInput struct {
 	Path    string      `json:"path" jsonschema:"description=path to the presentation file"`
 	SlideID string      `json:"slide_id" jsonschema:"description=ID of the slide to update"`
-	Updates model.Slide `json:"updates" jsonschema:"description=fields to update (title, subtitle, body, notes)"`
+	Updates model.Slide `json:"updates" jsonschema:"description=fields to update (title, subtitle, body, slots, notes)"`
 	Confirm bool        `json:"confirm" jsonschema:"description=must be true to actually apply changes"`
+	SectionInput
 }
 
 // UpdateSlideOutput is the output for the update_slide tool.
@@ -26,7 +27,7 @@ type UpdateSlideOutput struct {
 
 var updateSlideTool = &mcp.Tool{
 	Name:        "update_slide",
-	Description: "Update a single slide. Requires confirm=true to make changes.",
+	Description: "Update a single slide. Requires confirm=true to make changes. The section_* options change which slides start a section.",
 }
 
 func handleUpdateSlide(ctx context.Context, req *mcp.CallToolRequest, input UpdateSlideInput) (*mcp.CallToolResult, UpdateSlideOutput, error) {
@@ -37,7 +38,8 @@ func handleUpdateSlide(ctx context.Context, req *mcp.CallToolRequest, input Upda
 	}
 
 	result, err := ops.UpdateSlide(ctx, ref, input.SlideID, &input.Updates, ops.UpdateSlideOptions{
-		Confirm: input.Confirm,
+		Confirm:  input.Confirm,
+		Sections: input.rules(),
 	})
 	if err != nil {
 		if errors.Is(err, ops.ErrConfirmRequired) {
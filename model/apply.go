@@ -0,0 +1,599 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Errors returned when a change cannot be applied to a deck.
+var (
+	ErrInvalidPath     = errors.New("invalid change path")
+	ErrTargetNotFound  = errors.New("change target not found")
+	ErrTargetExists    = errors.New("change target already exists")
+	ErrUnsupportedOp   = errors.New("unsupported change operation")
+	ErrInvalidPosition = errors.New("invalid change position")
+)
+
+// ApplyDiff applies changes from a diff to the deck (in-place).
+//
+// Changes are applied in order, so positions and block indices refer to the
+// deck as left by the preceding changes. Supported paths are:
+//
+//	title
+//	header_footer
+//	transition
+//	meta/{field}
+//	theme
+//	theme/{field}
+//	sections/{sectionID}
+//	sections/{sectionID}/{title|audio}
+//	sections/{sectionID}/slides/{slideID}
+//	sections/{sectionID}/slides/{slideID}/{field}
+//	sections/{sectionID}/slides/{slideID}/{body|notes}/{index}
+//	slides/{slideID}
+//	slides/{slideID}/{field}
+//	slides/{slideID}/{body|notes}/{index}
+//	slides/{slideID}/{body|notes}/{index}/table/align
+//	slides/{slideID}/{body|notes}/{index}/table/header/{column}
+//	slides/{slideID}/{body|notes}/{index}/table/rows/{row}
+//	slides/{slideID}/{body|notes}/{index}/table/rows/{row}/{column}
+//	slides/{slideID}/slots/{name}
+//	slides/{slideID}/slots/{name}/{index}
+//
+// where a slide field is one of title, subtitle, body, slots, notes, layout,
+// audio, transition, background, header_footer, header_footer_from or
+// directives. Blocks within a slot accept
+// the same table paths as body blocks, and every slide path is also
+// accepted under sections/{sectionID}/slides/{slideID}. An error is returned
+// for the first change that cannot be applied; the deck may have been
+// partially modified by then.
+func (d *Deck) ApplyDiff(diff *Diff) error {
+	for i, change := range diff.Changes {
+		if err := applyChange(d, change); err != nil {
+			return fmt.Errorf("change %d (%s %s): %w", i, change.Op, change.Path, err)
+		}
+	}
+	return nil
+}
+
+// applyChange applies a single change to the deck.
+func applyChange(deck *Deck, c Change) error {
+	if !c.Op.IsValid() {
+		return fmt.Errorf("%w: %q", ErrUnsupportedOp, c.Op)
+	}
+
+	parts := splitPath(c.Path)
+	switch {
+	case len(parts) == 1 && parts[0] == "title":
+		return applyField(c, &deck.Title)
+	case len(parts) == 1 && parts[0] == "header_footer":
+		return applyField(c, &deck.HeaderFooter)
+	case len(parts) == 1 && parts[0] == "transition":
+		return applyField(c, &deck.Transition)
+	case len(parts) == 2 && parts[0] == "meta":
+		return applyMetaField(&deck.Meta, c, parts[1])
+	case len(parts) == 1 && parts[0] == "theme":
+		return applyField(c, &deck.Theme)
+	case len(parts) == 2 && parts[0] == "theme":
+		if deck.Theme == nil {
+			deck.Theme = &Theme{}
+		}
+		return applyThemeField(deck.Theme, c, parts[1])
+	case len(parts) >= 2 && parts[0] == "sections":
+		return applySectionChange(deck, c, parts[1], parts[2:])
+	case len(parts) >= 2 && parts[0] == "slides":
+		return applySlideChange(deck, c, "", parts[1], parts[2:])
+	}
+	return ErrInvalidPath
+}
+
+// applyMetaField applies a change to a single metadata field.
+func applyMetaField(meta *Meta, c Change, field string) error {
+	switch field {
+	case "author":
+		return applyField(c, &meta.Author)
+	case "date":
+		return applyField(c, &meta.Date)
+	case "description":
+		return applyField(c, &meta.Description)
+	case "keywords":
+		return applyField(c, &meta.Keywords)
+	case "url":
+		return applyField(c, &meta.URL)
+	case "image":
+		return applyField(c, &meta.Image)
+	case "size":
+		return applyField(c, &meta.Size)
+	case "custom":
+		return applyField(c, &meta.Custom)
+	}
+	return fmt.Errorf("%w: unknown meta field %q", ErrInvalidPath, field)
+}
+
+// applyThemeField applies a change to a single theme field.
+func applyThemeField(theme *Theme, c Change, field string) error {
+	switch field {
+	case "name":
+		return applyField(c, &theme.Name)
+	case "primary":
+		return applyField(c, &theme.Primary)
+	case "secondary":
+		return applyField(c, &theme.Secondary)
+	case "background":
+		return applyField(c, &theme.Background)
+	case "font":
+		return applyField(c, &theme.Font)
+	case "custom":
+		return applyField(c, &theme.Custom)
+	}
+	return fmt.Errorf("%w: unknown theme field %q", ErrInvalidPath, field)
+}
+
+// applySectionChange applies a change rooted at sections/{sectionID}.
+func applySectionChange(deck *Deck, c Change, sectionID string, rest []string) error {
+	if len(rest) >= 2 && rest[0] == "slides" {
+		return applySlideChange(deck, c, sectionID, rest[1], rest[2:])
+	}
+	if len(rest) > 1 {
+		return ErrInvalidPath
+	}
+
+	idx := sectionIndex(deck, sectionID)
+
+	if len(rest) == 0 {
+		switch c.Op {
+		case ChangeAdd:
+			if idx >= 0 {
+				return fmt.Errorf("%w: section %s", ErrTargetExists, sectionID)
+			}
+			var section Section
+			if err := decodeValue(c.NewValue, &section); err != nil {
+				return err
+			}
+			if section.ID == "" {
+				section.ID = sectionID
+			}
+			for _, slide := range section.Slides {
+				if deck.FindSlide(slide.ID) != nil {
+					return fmt.Errorf("%w: slide %s", ErrTargetExists, slide.ID)
+				}
+			}
+			at, err := insertIndex(c.To, len(deck.Sections))
+			if err != nil {
+				return err
+			}
+			deck.Sections = insertAt(deck.Sections, at, section)
+			return nil
+		case ChangeRemove:
+			if idx < 0 {
+				return fmt.Errorf("%w: section %s", ErrTargetNotFound, sectionID)
+			}
+			deck.Sections = removeAt(deck.Sections, idx)
+			return nil
+		case ChangeMove:
+			if idx < 0 {
+				return fmt.Errorf("%w: section %s", ErrTargetNotFound, sectionID)
+			}
+			if c.To == nil {
+				return fmt.Errorf("%w: section move requires a destination", ErrInvalidPosition)
+			}
+			section := deck.Sections[idx]
+			deck.Sections = removeAt(deck.Sections, idx)
+			at, err := insertIndex(c.To, len(deck.Sections))
+			if err != nil {
+				return err
+			}
+			deck.Sections = insertAt(deck.Sections, at, section)
+			return nil
+		}
+		return fmt.Errorf("%w: %s on section", ErrUnsupportedOp, c.Op)
+	}
+
+	if idx < 0 {
+		return fmt.Errorf("%w: section %s", ErrTargetNotFound, sectionID)
+	}
+	section := &deck.Sections[idx]
+	switch rest[0] {
+	case "title":
+		return applyField(c, &section.Title)
+	case "audio":
+		return applyField(c, &section.Audio)
+	}
+	return fmt.Errorf("%w: unknown section field %q", ErrInvalidPath, rest[0])
+}
+
+// applySlideChange applies a change rooted at a slide. If sectionID is
+// empty, the slide is looked up across all sections.
+func applySlideChange(deck *Deck, c Change, sectionID, slideID string, rest []string) error {
+	if len(rest) >= 2 && rest[0] != "body" && rest[0] != "notes" && rest[0] != "slots" {
+		return ErrInvalidPath
+	}
+
+	if len(rest) == 0 && c.Op == ChangeAdd {
+		return addSlide(deck, c, sectionID, slideID)
+	}
+
+	secIdx, slideIdx := slideIndex(deck, sectionID, slideID)
+	if secIdx < 0 {
+		if sectionID != "" && sectionIndex(deck, sectionID) < 0 {
+			return fmt.Errorf("%w: section %s", ErrTargetNotFound, sectionID)
+		}
+		return fmt.Errorf("%w: slide %s", ErrTargetNotFound, slideID)
+	}
+
+	if len(rest) == 0 {
+		switch c.Op {
+		case ChangeRemove:
+			deck.Sections[secIdx].Slides = removeAt(deck.Sections[secIdx].Slides, slideIdx)
+			return nil
+		case ChangeMove:
+			return moveSlide(deck, c, secIdx, slideIdx)
+		}
+		return fmt.Errorf("%w: %s on slide", ErrUnsupportedOp, c.Op)
+	}
+
+	slide := &deck.Sections[secIdx].Slides[slideIdx]
+	if len(rest) >= 2 && rest[0] == "slots" {
+		return applySlotChange(c, slide, rest[1], rest[2:])
+	}
+	if len(rest) >= 2 {
+		blocks := &slide.Body
+		if rest[0] == "notes" {
+			blocks = &slide.Notes
+		}
+		if len(rest) > 2 {
+			return applyInBlock(c, *blocks, rest[1], rest[2:])
+		}
+		return applyBlockChange(c, blocks, rest[1])
+	}
+	switch rest[0] {
+	case "title":
+		return applyField(c, &slide.Title)
+	case "subtitle":
+		return applyField(c, &slide.Subtitle)
+	case "body":
+		return applyField(c, &slide.Body)
+	case "notes":
+		return applyField(c, &slide.Notes)
+	case "slots":
+		return applyField(c, &slide.Slots)
+	case "layout":
+		return applyField(c, &slide.Layout)
+	case "audio":
+		return applyField(c, &slide.Audio)
+	case "transition":
+		return applyField(c, &slide.Transition)
+	case "background":
+		return applyField(c, &slide.Background)
+	case "header_footer":
+		return applyField(c, &slide.HeaderFooter)
+	case "header_footer_from":
+		return applyField(c, &slide.HeaderFooterFrom)
+	case "directives":
+		return applyField(c, &slide.Directives)
+	}
+	return fmt.Errorf("%w: unknown slide field %q", ErrInvalidPath, rest[0])
+}
+
+// addSlide inserts a new slide into the section named by the path or the
+// change's destination position.
+func addSlide(deck *Deck, c Change, sectionID, slideID string) error {
+	if c.To != nil && c.To.SectionID != "" {
+		sectionID = c.To.SectionID
+	}
+	if sectionID == "" {
+		return fmt.Errorf("%w: slide add requires a section", ErrInvalidPath)
+	}
+	secIdx := sectionIndex(deck, sectionID)
+	if secIdx < 0 {
+		return fmt.Errorf("%w: section %s", ErrTargetNotFound, sectionID)
+	}
+
+	var slide Slide
+	if err := decodeValue(c.NewValue, &slide); err != nil {
+		return err
+	}
+	if slide.ID == "" {
+		slide.ID = slideID
+	}
+	if deck.FindSlide(slide.ID) != nil {
+		return fmt.Errorf("%w: slide %s", ErrTargetExists, slide.ID)
+	}
+
+	section := &deck.Sections[secIdx]
+	at, err := insertIndex(c.To, len(section.Slides))
+	if err != nil {
+		return err
+	}
+	section.Slides = insertAt(section.Slides, at, slide)
+	return nil
+}
+
+// moveSlide relocates the slide at the given indices to the change's
+// destination. The destination section is taken from c.To, or from the
+// target path in NewValue when no position is given.
+func moveSlide(deck *Deck, c Change, secIdx, slideIdx int) error {
+	destID := deck.Sections[secIdx].ID
+	if c.To != nil && c.To.SectionID != "" {
+		destID = c.To.SectionID
+	} else if toPath, ok := c.NewValue.(string); ok {
+		parts := splitPath(toPath)
+		if len(parts) >= 2 && parts[0] == "sections" {
+			destID = parts[1]
+		}
+	}
+	destIdx := sectionIndex(deck, destID)
+	if destIdx < 0 {
+		return fmt.Errorf("%w: section %s", ErrTargetNotFound, destID)
+	}
+
+	slide := deck.Sections[secIdx].Slides[slideIdx]
+	deck.Sections[secIdx].Slides = removeAt(deck.Sections[secIdx].Slides, slideIdx)
+
+	dest := &deck.Sections[destIdx]
+	at, err := insertIndex(c.To, len(dest.Slides))
+	if err != nil {
+		return err
+	}
+	dest.Slides = insertAt(dest.Slides, at, slide)
+	return nil
+}
+
+// applyBlockChange inserts, removes or replaces the block at the given index.
+func applyBlockChange(c Change, blocks *[]Block, index string) error {
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return fmt.Errorf("%w: block index %q", ErrInvalidPath, index)
+	}
+	limit := len(*blocks)
+	if c.Op == ChangeAdd {
+		limit++
+	}
+	if i < 0 || i >= limit {
+		return fmt.Errorf("%w: block index %d out of range", ErrInvalidPosition, i)
+	}
+
+	switch c.Op {
+	case ChangeAdd, ChangeUpdate:
+		var block Block
+		if err := decodeValue(c.NewValue, &block); err != nil {
+			return err
+		}
+		if c.Op == ChangeAdd {
+			*blocks = insertAt(*blocks, i, block)
+		} else {
+			(*blocks)[i] = block
+		}
+		return nil
+	case ChangeRemove:
+		*blocks = removeAt(*blocks, i)
+		return nil
+	}
+	return fmt.Errorf("%w: %s on block", ErrUnsupportedOp, c.Op)
+}
+
+// applySlotChange applies a change to a whole content slot or, when rest
+// is not empty, to a block within it.
+func applySlotChange(c Change, slide *Slide, name string, rest []string) error {
+	if len(rest) == 0 {
+		switch c.Op {
+		case ChangeAdd, ChangeUpdate:
+			var blocks []Block
+			if err := decodeValue(c.NewValue, &blocks); err != nil {
+				return err
+			}
+			if slide.Slots == nil {
+				slide.Slots = make(Slots)
+			}
+			slide.Slots[name] = blocks
+			return nil
+		case ChangeRemove:
+			delete(slide.Slots, name)
+			return nil
+		}
+		return fmt.Errorf("%w: %s on slot", ErrUnsupportedOp, c.Op)
+	}
+
+	blocks, ok := slide.Slots[name]
+	if !ok {
+		return fmt.Errorf("%w: slot %s", ErrTargetNotFound, name)
+	}
+	if len(rest) > 1 {
+		return applyInBlock(c, blocks, rest[0], rest[1:])
+	}
+	if err := applyBlockChange(c, &blocks, rest[0]); err != nil {
+		return err
+	}
+	slide.Slots[name] = blocks
+	return nil
+}
+
+// applyInBlock applies a change to part of the block at the given index.
+// Only table content can be addressed this way.
+func applyInBlock(c Change, blocks []Block, index string, rest []string) error {
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return fmt.Errorf("%w: block index %q", ErrInvalidPath, index)
+	}
+	if i < 0 || i >= len(blocks) {
+		return fmt.Errorf("%w: block index %d out of range", ErrInvalidPosition, i)
+	}
+	if rest[0] != "table" || len(rest) < 2 {
+		return ErrInvalidPath
+	}
+	if blocks[i].Table == nil {
+		return fmt.Errorf("%w: block %d is not a table", ErrTargetNotFound, i)
+	}
+	// The table may be shared with another deck, so edit a copy
+	table := blocks[i].Table.clone()
+	if err := applyTableChange(c, table, rest[1:]); err != nil {
+		return err
+	}
+	blocks[i].Table = table
+	return nil
+}
+
+// applyTableChange applies a change to a table's alignment, a header cell,
+// a row or a single row cell.
+func applyTableChange(c Change, table *Table, rest []string) error {
+	switch {
+	case len(rest) == 1 && rest[0] == "align":
+		return applyField(c, &table.Align)
+	case len(rest) == 2 && rest[0] == "header":
+		col, err := cellIndex(rest[1], len(table.Header))
+		if err != nil {
+			return err
+		}
+		return applyField(c, &table.Header[col])
+	case len(rest) == 2 && rest[0] == "rows":
+		return applyRowChange(c, table, rest[1])
+	case len(rest) == 3 && rest[0] == "rows":
+		row, err := cellIndex(rest[1], len(table.Rows))
+		if err != nil {
+			return err
+		}
+		col, err := cellIndex(rest[2], len(table.Rows[row]))
+		if err != nil {
+			return err
+		}
+		return applyField(c, &table.Rows[row][col])
+	}
+	return ErrInvalidPath
+}
+
+// applyRowChange inserts, removes or replaces the table row at the given
+// index.
+func applyRowChange(c Change, table *Table, index string) error {
+	limit := len(table.Rows)
+	if c.Op == ChangeAdd {
+		limit++
+	}
+	i, err := cellIndex(index, limit)
+	if err != nil {
+		return err
+	}
+
+	switch c.Op {
+	case ChangeAdd, ChangeUpdate:
+		var row []TableCell
+		if err := decodeValue(c.NewValue, &row); err != nil {
+			return err
+		}
+		if c.Op == ChangeAdd {
+			table.Rows = insertAt(table.Rows, i, row)
+		} else {
+			table.Rows[i] = row
+		}
+		return nil
+	case ChangeRemove:
+		table.Rows = removeAt(table.Rows, i)
+		return nil
+	}
+	return fmt.Errorf("%w: %s on table row", ErrUnsupportedOp, c.Op)
+}
+
+// cellIndex parses a row or column index and checks it against limit.
+func cellIndex(index string, limit int) (int, error) {
+	i, err := strconv.Atoi(index)
+	if err != nil {
+		return 0, fmt.Errorf("%w: table index %q", ErrInvalidPath, index)
+	}
+	if i < 0 || i >= limit {
+		return 0, fmt.Errorf("%w: table index %d out of range", ErrInvalidPosition, i)
+	}
+	return i, nil
+}
+
+// applyField sets or clears a single field. Add and update set the field to
+// the change's new value; remove resets it to its zero value.
+func applyField[T any](c Change, field *T) error {
+	switch c.Op {
+	case ChangeAdd, ChangeUpdate:
+		var v T
+		if err := decodeValue(c.NewValue, &v); err != nil {
+			return err
+		}
+		*field = v
+		return nil
+	case ChangeRemove:
+		var zero T
+		*field = zero
+		return nil
+	}
+	return fmt.Errorf("%w: %s on field", ErrUnsupportedOp, c.Op)
+}
+
+// decodeValue converts a change value into dst. Values may be typed model
+// values (when the diff was built in-process) or the generic maps and slices
+// produced by decoding a diff from JSON, so both go through a JSON round trip.
+func decodeValue(v, dst any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("encoding change value: %w", err)
+	}
+	if err := json.Unmarshal(data, dst); err != nil {
+		return fmt.Errorf("decoding change value: %w", err)
+	}
+	return nil
+}
+
+// splitPath splits a change path into its segments.
+func splitPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// sectionIndex returns the index of the section with the given ID, or -1.
+func sectionIndex(deck *Deck, id string) int {
+	for i := range deck.Sections {
+		if deck.Sections[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// slideIndex returns the section and slide indices of the slide with the
+// given ID, or -1, -1. If sectionID is non-empty only that section is searched.
+func slideIndex(deck *Deck, sectionID, slideID string) (int, int) {
+	for i := range deck.Sections {
+		if sectionID != "" && deck.Sections[i].ID != sectionID {
+			continue
+		}
+		for j := range deck.Sections[i].Slides {
+			if deck.Sections[i].Slides[j].ID == slideID {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// insertIndex resolves the insertion index for a destination position,
+// defaulting to appending when no position is given.
+func insertIndex(to *Position, length int) (int, error) {
+	if to == nil {
+		return length, nil
+	}
+	if to.Index < 0 || to.Index > length {
+		return 0, fmt.Errorf("%w: index %d out of range [0,%d]", ErrInvalidPosition, to.Index, length)
+	}
+	return to.Index, nil
+}
+
+func insertAt[T any](s []T, i int, v T) []T {
+	s = append(s, v)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+func removeAt[T any](s []T, i int) []T {
+	return append(s[:i], s[i+1:]...)
+}
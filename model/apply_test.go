@@ -0,0 +1,222 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func newApplyTestDeck() *Deck {
+	return &Deck{
+		Title: "Apply Test",
+		Sections: []Section{
+			{
+				ID:    "intro",
+				Title: "Introduction",
+				Slides: []Slide{
+					{ID: "a", Layout: LayoutTitle, Title: "Slide A"},
+					{ID: "b", Layout: LayoutTitleBody, Title: "Slide B",
+						Body: []Block{NewBullet("Point", 0)}},
+				},
+			},
+			{
+				ID:    "main",
+				Title: "Main",
+				Slides: []Slide{
+					{ID: "c", Layout: LayoutTitleBody, Title: "Slide C"},
+				},
+			},
+		},
+	}
+}
+
+func slideIDs(deck *Deck) []string {
+	var ids []string
+	for _, s := range deck.Sections {
+		for _, slide := range s.Slides {
+			ids = append(ids, s.ID+"/"+slide.ID)
+		}
+	}
+	return ids
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDeckApplyDiffSlideFields(t *testing.T) {
+	deck := newApplyTestDeck()
+	diff := NewDiff("test")
+	diff.AddChange(NewUpdateChange("sections/intro/slides/b/title", "Slide B", "Renamed"))
+	diff.AddChange(NewUpdateChange("slides/b/subtitle", "", "Sub"))
+	diff.AddChange(NewUpdateChange("slides/b/body", nil,
+		[]Block{NewBullet("One", 0), NewBullet("Two", 1)}))
+	diff.AddChange(NewUpdateChange("slides/b/notes", nil,
+		[]Block{NewParagraph("Say this.")}))
+	diff.AddChange(NewUpdateChange("slides/c/layout", LayoutTitleBody, LayoutImage))
+	diff.AddChange(NewAddChange("slides/c/audio", NewTTSAudio("Hello", "alloy")))
+
+	if err := deck.ApplyDiff(diff); err != nil {
+		t.Fatalf("ApplyDiff error: %v", err)
+	}
+
+	b := deck.FindSlide("b")
+	if b.Title != "Renamed" || b.Subtitle != "Sub" {
+		t.Errorf("unexpected title/subtitle: %q/%q", b.Title, b.Subtitle)
+	}
+	if len(b.Body) != 2 || b.Body[1].Text != "Two" || b.Body[1].Level != 1 {
+		t.Errorf("unexpected body: %+v", b.Body)
+	}
+	if b.NotesText() != "Say this." {
+		t.Errorf("unexpected notes: %q", b.NotesText())
+	}
+	c := deck.FindSlide("c")
+	if c.Layout != LayoutImage {
+		t.Errorf("expected layout image, got %q", c.Layout)
+	}
+	if c.Audio == nil || c.Audio.Script != "Hello" {
+		t.Errorf("expected TTS audio, got %+v", c.Audio)
+	}
+}
+
+func TestDeckApplyDiffFromJSON(t *testing.T) {
+	// Diffs arriving over CLI/MCP are decoded into generic values.
+	data := `{"deck_id":"test","changes":[
+		{"op":"update","path":"slides/b/body","new_value":[{"kind":"bullet","text":"From JSON"}]},
+		{"op":"add","path":"sections/main/slides/d","new_value":{"id":"d","layout":"title_body","title":"Slide D"},"to":{"section_id":"main","index":0}}
+	]}`
+	var diff Diff
+	if err := json.Unmarshal([]byte(data), &diff); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+
+	deck := newApplyTestDeck()
+	if err := deck.ApplyDiff(&diff); err != nil {
+		t.Fatalf("ApplyDiff error: %v", err)
+	}
+
+	if b := deck.FindSlide("b"); len(b.Body) != 1 || b.Body[0].Text != "From JSON" {
+		t.Errorf("unexpected body: %+v", b.Body)
+	}
+	want := []string{"intro/a", "intro/b", "main/d", "main/c"}
+	if got := slideIDs(deck); !equalStrings(got, want) {
+		t.Errorf("slide order = %v, want %v", got, want)
+	}
+}
+
+func TestDeckApplyDiffAddRemoveSections(t *testing.T) {
+	deck := newApplyTestDeck()
+	diff := NewDiff("test")
+	diff.AddChange(NewRemoveChange("sections/intro/slides/a", nil))
+	diff.AddChange(NewAddChange("sections/outro", Section{
+		ID:     "outro",
+		Title:  "Wrap Up",
+		Slides: []Slide{{ID: "e", Title: "Thanks"}},
+	}).At(Position{Index: 1}))
+	diff.AddChange(NewRemoveChange("sections/main", nil))
+	diff.AddChange(NewUpdateChange("sections/outro/title", "Wrap Up", "Conclusion"))
+
+	if err := deck.ApplyDiff(diff); err != nil {
+		t.Fatalf("ApplyDiff error: %v", err)
+	}
+
+	want := []string{"intro/b", "outro/e"}
+	if got := slideIDs(deck); !equalStrings(got, want) {
+		t.Errorf("slide order = %v, want %v", got, want)
+	}
+	if deck.Sections[1].Title != "Conclusion" {
+		t.Errorf("expected section title 'Conclusion', got %q", deck.Sections[1].Title)
+	}
+}
+
+func TestDeckApplyDiffMoves(t *testing.T) {
+	deck := newApplyTestDeck()
+	diff := NewDiff("test")
+	diff.AddChange(NewMoveChange("sections/intro/slides/b", "sections/main/slides/b").
+		At(Position{SectionID: "main", Index: 1}))
+	diff.AddChange(NewMoveChange("sections/main", "sections/main").
+		At(Position{Index: 0}))
+
+	if err := deck.ApplyDiff(diff); err != nil {
+		t.Fatalf("ApplyDiff error: %v", err)
+	}
+
+	want := []string{"main/c", "main/b", "intro/a"}
+	if got := slideIDs(deck); !equalStrings(got, want) {
+		t.Errorf("slide order = %v, want %v", got, want)
+	}
+}
+
+func TestDeckApplyDiffErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		change Change
+		want   error
+	}{
+		{"unknown root", NewUpdateChange("bogus", "", "x"), ErrInvalidPath},
+		{"unknown slide", NewUpdateChange("slides/zz/title", "", "x"), ErrTargetNotFound},
+		{"unknown section", NewRemoveChange("sections/zz", nil), ErrTargetNotFound},
+		{"unknown field", NewUpdateChange("slides/a/color", "", "x"), ErrInvalidPath},
+		{"duplicate slide", NewAddChange("sections/main/slides/a", Slide{ID: "a"}), ErrTargetExists},
+		{"bad index", NewAddChange("sections/main/slides/z", Slide{ID: "z"}).
+			At(Position{SectionID: "main", Index: 5}), ErrInvalidPosition},
+		{"move field", NewMoveChange("slides/a/title", "slides/b/title"), ErrUnsupportedOp},
+		{"bad value", NewUpdateChange("slides/a/body", nil, "not blocks"), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := NewDiff("test")
+			diff.AddChange(tt.change)
+			err := newApplyTestDeck().ApplyDiff(diff)
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestDeckApplyDiffBlocksAndMeta(t *testing.T) {
+	deck := newApplyTestDeck()
+	diff := NewDiff("test")
+	diff.AddChange(NewAddChange("slides/b/body/0", NewParagraph("Intro")))
+	diff.AddChange(NewUpdateChange("sections/intro/slides/b/body/1", nil, NewBullet("Point!", 0)))
+	diff.AddChange(NewAddChange("slides/b/notes/0", NewParagraph("Note")))
+	diff.AddChange(NewUpdateChange("meta/author", "", "Ada"))
+	diff.AddChange(NewUpdateChange("theme/primary", "", "#000000"))
+
+	if err := deck.ApplyDiff(diff); err != nil {
+		t.Fatalf("ApplyDiff error: %v", err)
+	}
+
+	b := deck.FindSlide("b")
+	if len(b.Body) != 2 || b.Body[0].Text != "Intro" || b.Body[1].Text != "Point!" {
+		t.Errorf("unexpected body: %+v", b.Body)
+	}
+	if b.NotesText() != "Note" {
+		t.Errorf("unexpected notes: %q", b.NotesText())
+	}
+	if deck.Meta.Author != "Ada" {
+		t.Errorf("expected author 'Ada', got %q", deck.Meta.Author)
+	}
+	if deck.Theme == nil || deck.Theme.Primary != "#000000" {
+		t.Errorf("expected theme primary to be set, got %+v", deck.Theme)
+	}
+
+	bad := NewDiff("test")
+	bad.AddChange(NewRemoveChange("slides/b/body/7", nil))
+	if err := deck.ApplyDiff(bad); !errors.Is(err, ErrInvalidPosition) {
+		t.Errorf("expected ErrInvalidPosition, got %v", err)
+	}
+}
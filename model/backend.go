@@ -20,6 +20,34 @@ type Backend interface {
 	Create(ctx context.Context, deck *Deck) (Ref, error)
 }
 
+// DiagnosticReader is implemented by backends that report problems found
+// in the source while reading it, such as malformed markup that was read
+// on a best-effort basis.
+type DiagnosticReader interface {
+	// ReadDiagnostics loads a presentation like Backend.Read and returns
+	// the diagnostics found along the way.
+	ReadDiagnostics(ctx context.Context, ref Ref) (*Deck, []Diagnostic, error)
+}
+
+// SlideStreamer is implemented by backends that can hand out slides one at
+// a time as they are read, so that callers which only need a summary of
+// each slide do not have to wait for, or hold, the whole deck.
+type SlideStreamer interface {
+	// StreamSlides calls fn with each slide in deck order, together with
+	// the section it belongs to; the section is passed without its
+	// slides. It stops at the first error from fn and returns it, or
+	// returns the context's error once ctx is done.
+	StreamSlides(ctx context.Context, ref Ref, fn func(section Section, slide Slide) error) error
+}
+
+// SectionGrouper is implemented by backends that group slides into
+// sections by rules the caller can change.
+type SectionGrouper interface {
+	// WithSectionRules returns a backend that reads presentations like
+	// this one, grouping their slides into sections by rules.
+	WithSectionRules(rules SectionRules) Backend
+}
+
 // Ref identifies a presentation in a backend.
 type Ref struct {
 	Backend string `json:"backend"` // "marp", "gslides", "reveal"
@@ -1,13 +1,74 @@
 package model
 
+import "strings"
+
 // Block represents content within a slide.
 type Block struct {
-	Kind  BlockKind `json:"kind"`
-	Text  string    `json:"text,omitempty"`
-	Level int       `json:"level,omitempty"` // Bullet nesting level (0 = top level)
-	Lang  string    `json:"lang,omitempty"`  // Code language
-	URL   string    `json:"url,omitempty"`   // Image/link URL
-	Alt   string    `json:"alt,omitempty"`   // Image alt text
+	Kind    BlockKind   `json:"kind"`
+	Text    string      `json:"text,omitempty"`    // Plain text, without inline markup
+	Runs    []Span      `json:"runs,omitempty"`    // Inline formatting of Text, if any
+	Level   int         `json:"level,omitempty"`   // Bullet nesting level (0 = top level)
+	Lang    string      `json:"lang,omitempty"`    // Code language
+	Dialect string      `json:"dialect,omitempty"` // Diagram language, e.g. "mermaid"
+	URL     string      `json:"url,omitempty"`     // Image/link URL
+	Alt     string      `json:"alt,omitempty"`     // Image alt text
+	Image   *ImageAttrs `json:"image,omitempty"`   // Image sizing, filters and background placement
+	Table   *Table      `json:"table,omitempty"`   // Table content
+	Pause   *Pause      `json:"pause,omitempty"`   // Pause cue in speaker notes
+
+	// Fragment marks a block revealed in a build step of its own rather
+	// than with the rest of the slide, e.g. the items of a Marp "*" list.
+	Fragment bool `json:"fragment,omitempty"`
+}
+
+// Span is a run of inline text with uniform styling.
+type Span struct {
+	Text   string `json:"text"`
+	Bold   bool   `json:"bold,omitempty"`
+	Italic bool   `json:"italic,omitempty"`
+	Code   bool   `json:"code,omitempty"`   // Inline code
+	Strike bool   `json:"strike,omitempty"` // Strikethrough
+	Href   string `json:"href,omitempty"`   // Link target
+	Math   bool   `json:"math,omitempty"`   // Inline TeX math
+}
+
+// IsPlain returns true if the span carries no styling or link.
+func (s Span) IsPlain() bool {
+	return !s.Bold && !s.Italic && !s.Code && !s.Strike && s.Href == "" && !s.Math
+}
+
+// SameStyle returns true if two spans have identical styling and link.
+func (s Span) SameStyle(o Span) bool {
+	s.Text, o.Text = "", ""
+	return s == o
+}
+
+// RunsText returns the plain text of a list of spans.
+func RunsText(runs []Span) string {
+	var b strings.Builder
+	for _, r := range runs {
+		b.WriteString(r.Text)
+	}
+	return b.String()
+}
+
+// HasRuns returns true if the block has inline runs that still match its
+// Text. Runs that disagree with Text, for example because Text was edited
+// without updating them, are ignored.
+func (b *Block) HasRuns() bool {
+	return len(b.Runs) > 0 && RunsText(b.Runs) == b.Text
+}
+
+// Spans returns the inline runs of the block, or a single plain span
+// holding Text when it has none.
+func (b *Block) Spans() []Span {
+	if b.HasRuns() {
+		return b.Runs
+	}
+	if b.Text == "" {
+		return nil
+	}
+	return []Span{{Text: b.Text}}
 }
 
 // BlockKind identifies content type.
@@ -21,6 +82,10 @@ const (
 	BlockImage     BlockKind = "image"
 	BlockQuote     BlockKind = "quote"
 	BlockHeading   BlockKind = "heading"
+	BlockTable     BlockKind = "table"
+	BlockPause     BlockKind = "pause"   // Timed pause in speaker notes
+	BlockMath      BlockKind = "math"    // Display math, with TeX source in Text
+	BlockDiagram   BlockKind = "diagram" // Diagram source in Text, in the language named by Dialect
 )
 
 // BlockKinds returns all valid block kind values.
@@ -33,6 +98,10 @@ func BlockKinds() []BlockKind {
 		BlockImage,
 		BlockQuote,
 		BlockHeading,
+		BlockTable,
+		BlockPause,
+		BlockMath,
+		BlockDiagram,
 	}
 }
 
@@ -40,7 +109,8 @@ func BlockKinds() []BlockKind {
 func (k BlockKind) IsValid() bool {
 	switch k {
 	case BlockParagraph, BlockBullet, BlockNumbered, BlockCode,
-		BlockImage, BlockQuote, BlockHeading:
+		BlockImage, BlockQuote, BlockHeading, BlockTable, BlockPause,
+		BlockMath, BlockDiagram:
 		return true
 	}
 	return false
@@ -80,3 +150,26 @@ func NewQuote(text string) Block {
 func NewHeading(text string, level int) Block {
 	return Block{Kind: BlockHeading, Text: text, Level: level}
 }
+
+// NewMath creates a display math block from TeX source.
+func NewMath(tex string) Block {
+	return Block{Kind: BlockMath, Text: tex}
+}
+
+// NewDiagram creates a diagram block from source in the given dialect.
+func NewDiagram(source, dialect string) Block {
+	return Block{Kind: BlockDiagram, Text: source, Dialect: dialect}
+}
+
+// Well-known diagram dialects.
+const (
+	DiagramMermaid  = "mermaid"
+	DiagramPlantUML = "plantuml"
+	DiagramGraphviz = "graphviz"
+	DiagramD2       = "d2"
+)
+
+// DiagramDialects returns the well-known diagram dialects.
+func DiagramDialects() []string {
+	return []string{DiagramMermaid, DiagramPlantUML, DiagramGraphviz, DiagramD2}
+}
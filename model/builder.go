@@ -0,0 +1,63 @@
+package model
+
+// DeckBuilder collects the sections and slides of a deck as a reader reads
+// them, and settles their IDs and the header and footer settings once the
+// whole deck is read.
+type DeckBuilder struct {
+	deck     *Deck
+	settings []HeaderFooter // Header, footer and page number shown on each slide
+
+	slideIDs, slideTitles     []string // Explicit IDs and titles, in order
+	sectionIDs, sectionTitles []string
+}
+
+// NewDeckBuilder returns a builder that adds the sections it reads to deck.
+func NewDeckBuilder(deck *Deck) *DeckBuilder {
+	return &DeckBuilder{deck: deck}
+}
+
+// StartSection starts a new section with the given explicit ID, or "" for
+// one named after its title. A section without a title is named after its
+// first slide.
+func (b *DeckBuilder) StartSection(id, title string) {
+	b.deck.Sections = append(b.deck.Sections, Section{Title: title})
+	b.sectionIDs = append(b.sectionIDs, id)
+	b.sectionTitles = append(b.sectionTitles, title)
+}
+
+// Add adds slide to the current section, starting a "default" section if
+// there is none yet. id is the slide's explicit ID, or "" for one named
+// after its title, and settings are the header, footer and page number
+// shown on it.
+func (b *DeckBuilder) Add(slide Slide, id string, settings HeaderFooter) {
+	if len(b.deck.Sections) == 0 {
+		b.StartSection("", "default")
+	}
+	last := len(b.deck.Sections) - 1
+	section := &b.deck.Sections[last]
+	if section.Title == "" {
+		section.Title = slide.Title
+		b.sectionTitles[last] = slide.Title
+	}
+	section.Slides = append(section.Slides, slide)
+	b.settings = append(b.settings, settings)
+	b.slideIDs = append(b.slideIDs, id)
+	b.slideTitles = append(b.slideTitles, slide.Title)
+}
+
+// Finish returns the collected deck with its IDs settled by UniqueIDs and
+// its header and footer settings by SetHeaderFooters.
+func (b *DeckBuilder) Finish() *Deck {
+	sectionIDs := UniqueIDs(b.sectionIDs, b.sectionTitles, "section")
+	slideIDs := UniqueIDs(b.slideIDs, b.slideTitles, "slide")
+	k := 0
+	for i := range b.deck.Sections {
+		b.deck.Sections[i].ID = sectionIDs[i]
+		for j := range b.deck.Sections[i].Slides {
+			b.deck.Sections[i].Slides[j].ID = slideIDs[k]
+			k++
+		}
+	}
+	b.deck.SetHeaderFooters(b.settings)
+	return b.deck
+}
@@ -0,0 +1,582 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// ComputeDiff compares two decks field by field and returns the changes
+// needed to turn current into desired. The resulting diff can be replayed
+// with Deck.ApplyDiff.
+//
+// Sections and slides are matched by ID, and reordering either one is
+// reported as a move with source and destination positions. Body and notes
+// blocks have no identity, so they are aligned with a longest common
+// subsequence match: an inserted bullet is reported as a single add rather
+// than as a rewrite of every block after it.
+func ComputeDiff(current, desired *Deck) *Diff {
+	c := newComparer(current)
+	c.compareDeck(current, desired)
+	c.compareStructure(current, desired)
+	return c.diff
+}
+
+// comparer tracks the working order of sections and slides while a diff is
+// built, so that positions in emitted changes are valid at the point the
+// change is applied.
+type comparer struct {
+	diff     *Diff
+	sections []string            // Working section order
+	slides   map[string][]string // Working slide order per section
+	location map[string]string   // Working section of each slide
+}
+
+func newComparer(current *Deck) *comparer {
+	c := &comparer{
+		diff:     NewDiff(current.ID),
+		slides:   make(map[string][]string),
+		location: make(map[string]string),
+	}
+	for _, s := range current.Sections {
+		c.sections = append(c.sections, s.ID)
+		for _, slide := range s.Slides {
+			c.slides[s.ID] = append(c.slides[s.ID], slide.ID)
+			c.location[slide.ID] = s.ID
+		}
+	}
+	return c
+}
+
+// compareDeck compares deck-level fields.
+func (c *comparer) compareDeck(current, desired *Deck) {
+	c.compareValue("title", current.Title, desired.Title)
+
+	cm, dm := current.Meta, desired.Meta
+	c.compareValue("meta/author", cm.Author, dm.Author)
+	c.compareValue("meta/date", cm.Date, dm.Date)
+	c.compareValue("meta/description", cm.Description, dm.Description)
+	c.compareValue("meta/keywords", cm.Keywords, dm.Keywords)
+	c.compareValue("meta/url", cm.URL, dm.URL)
+	c.compareValue("meta/image", cm.Image, dm.Image)
+	c.compareValue("meta/size", cm.Size, dm.Size)
+	c.compareValue("meta/custom", cm.Custom, dm.Custom)
+	c.compareValue("header_footer", emptyHeaderFooter(current.HeaderFooter), emptyHeaderFooter(desired.HeaderFooter))
+	c.compareValue("transition", current.Transition, desired.Transition)
+
+	switch {
+	case current.Theme == nil && desired.Theme == nil:
+	case current.Theme == nil || desired.Theme == nil:
+		c.diff.AddChange(NewUpdateChange("theme", current.Theme, desired.Theme))
+	default:
+		ct, dt := current.Theme, desired.Theme
+		c.compareValue("theme/name", ct.Name, dt.Name)
+		c.compareValue("theme/primary", ct.Primary, dt.Primary)
+		c.compareValue("theme/secondary", ct.Secondary, dt.Secondary)
+		c.compareValue("theme/background", ct.Background, dt.Background)
+		c.compareValue("theme/font", ct.Font, dt.Font)
+		c.compareValue("theme/custom", ct.Custom, dt.Custom)
+	}
+}
+
+// compareStructure compares sections and slides. Removed slides are emitted
+// first, then sections and slides in desired order (each followed by its
+// field changes), then removed sections.
+//
+// Sections and slides that keep their relative order form the longest
+// common subsequence of the two orderings and stay in place; every other
+// surviving section or slide, including one that changes section, is
+// emitted as a move so that its notes and audio are preserved.
+func (c *comparer) compareStructure(current, desired *Deck) {
+	currentSlides := make(map[string]*Slide)
+	currentSection := make(map[string]string)
+	for i := range current.Sections {
+		for j := range current.Sections[i].Slides {
+			slide := &current.Sections[i].Slides[j]
+			currentSlides[slide.ID] = slide
+			currentSection[slide.ID] = current.Sections[i].ID
+		}
+	}
+	desiredSlides := make(map[string]bool)
+	for _, s := range desired.Sections {
+		for _, slide := range s.Slides {
+			desiredSlides[slide.ID] = true
+		}
+	}
+
+	// Removed slides (those in removed sections go with their section)
+	for _, s := range current.Sections {
+		if desired.FindSection(s.ID) == nil {
+			continue
+		}
+		for _, slide := range s.Slides {
+			if !desiredSlides[slide.ID] {
+				c.diff.AddChange(NewRemoveChange(c.slidePath(slide.ID), slide))
+				c.removeSlide(slide.ID)
+			}
+		}
+	}
+
+	// Sections that keep their relative order
+	var currentOrder, desiredOrder []string
+	for _, s := range current.Sections {
+		if desired.FindSection(s.ID) != nil {
+			currentOrder = append(currentOrder, s.ID)
+		}
+	}
+	for _, s := range desired.Sections {
+		if current.FindSection(s.ID) != nil {
+			desiredOrder = append(desiredOrder, s.ID)
+		}
+	}
+	stableSections := stableIDs(currentOrder, desiredOrder)
+
+	// Added, moved and changed sections and slides, in desired order
+	for i := range desired.Sections {
+		ds := &desired.Sections[i]
+		cs := current.FindSection(ds.ID)
+		switch {
+		case cs == nil:
+			c.addSection(desired, i, currentSlides)
+		case !stableSections[ds.ID]:
+			c.moveSection(desired, i)
+		}
+		if cs != nil {
+			sectionPath := "sections/" + ds.ID
+			c.compareValue(sectionPath+"/title", cs.Title, ds.Title)
+			c.compareValue(sectionPath+"/audio", cs.Audio, ds.Audio)
+		}
+
+		// Slides that stay in this section and keep their relative order
+		var stable map[string]bool
+		if cs != nil {
+			var curIDs, desIDs []string
+			for _, slide := range cs.Slides {
+				if desiredSlides[slide.ID] && ds.FindSlide(slide.ID) != nil {
+					curIDs = append(curIDs, slide.ID)
+				}
+			}
+			for _, slide := range ds.Slides {
+				if currentSection[slide.ID] == ds.ID {
+					desIDs = append(desIDs, slide.ID)
+				}
+			}
+			stable = stableIDs(curIDs, desIDs)
+		}
+
+		for j := range ds.Slides {
+			slide := &ds.Slides[j]
+			cur, exists := currentSlides[slide.ID]
+			switch {
+			case !exists && cs == nil:
+				continue // Carried by the section add
+			case !exists:
+				c.addSlide(ds, j)
+				continue
+			case !stable[slide.ID]:
+				c.moveSlide(ds, j)
+			}
+			c.compareSlide(c.slidePath(slide.ID), cur, slide)
+		}
+	}
+
+	// Removed sections
+	for _, s := range current.Sections {
+		if desired.FindSection(s.ID) == nil {
+			c.diff.AddChange(NewRemoveChange("sections/"+s.ID, s))
+		}
+	}
+}
+
+// addSection emits an add for desired.Sections[i], carrying only the slides
+// that do not already exist in the current deck.
+func (c *comparer) addSection(desired *Deck, i int, currentSlides map[string]*Slide) {
+	section := desired.Sections[i]
+	section.Slides = nil
+	var ids []string
+	for _, slide := range desired.Sections[i].Slides {
+		if _, exists := currentSlides[slide.ID]; !exists {
+			section.Slides = append(section.Slides, slide)
+			ids = append(ids, slide.ID)
+		}
+	}
+
+	at := c.sectionInsertIndex(desired, i)
+	c.diff.AddChange(NewAddChange("sections/"+section.ID, section).At(Position{Index: at}))
+	c.sections = insertAt(c.sections, at, section.ID)
+	c.slides[section.ID] = ids
+	for _, id := range ids {
+		c.location[id] = section.ID
+	}
+}
+
+// moveSection emits a move placing desired.Sections[i] after the nearest
+// preceding desired section in the working order.
+func (c *comparer) moveSection(desired *Deck, i int) {
+	id := desired.Sections[i].ID
+	from := indexOf(c.sections, id)
+	c.sections = removeAt(c.sections, from)
+
+	at := c.sectionInsertIndex(desired, i)
+	c.sections = insertAt(c.sections, at, id)
+
+	path := "sections/" + id
+	change := NewMoveChange(path, path).At(Position{Index: at})
+	change.From = &Position{Index: from}
+	c.diff.AddChange(change)
+}
+
+// sectionInsertIndex returns the working index just after the nearest
+// preceding desired section, or 0 if there is none.
+func (c *comparer) sectionInsertIndex(desired *Deck, i int) int {
+	for k := i - 1; k >= 0; k-- {
+		if idx := indexOf(c.sections, desired.Sections[k].ID); idx >= 0 {
+			return idx + 1
+		}
+	}
+	return 0
+}
+
+// addSlide emits an add for section.Slides[j], inserted after the nearest
+// preceding desired slide already in the working section.
+func (c *comparer) addSlide(section *Section, j int) {
+	slide := section.Slides[j]
+	at := c.slideInsertIndex(section, j)
+
+	path := fmt.Sprintf("sections/%s/slides/%s", section.ID, slide.ID)
+	c.diff.AddChange(NewAddChange(path, slide).At(Position{SectionID: section.ID, Index: at}))
+	c.slides[section.ID] = insertAt(c.slides[section.ID], at, slide.ID)
+	c.location[slide.ID] = section.ID
+}
+
+// moveSlide emits a move placing section.Slides[j] after the nearest
+// preceding desired slide, possibly taking it from another section.
+func (c *comparer) moveSlide(section *Section, j int) {
+	id := section.Slides[j].ID
+	fromSection := c.location[id]
+	from := indexOf(c.slides[fromSection], id)
+	fromPath := c.slidePath(id)
+	c.removeSlide(id)
+
+	at := c.slideInsertIndex(section, j)
+	c.slides[section.ID] = insertAt(c.slides[section.ID], at, id)
+	c.location[id] = section.ID
+
+	change := NewMoveChange(fromPath, c.slidePath(id)).At(Position{SectionID: section.ID, Index: at})
+	change.From = &Position{SectionID: fromSection, Index: from}
+	c.diff.AddChange(change)
+}
+
+// slideInsertIndex returns the working index just after the nearest
+// preceding desired slide in the section, or 0 if there is none.
+func (c *comparer) slideInsertIndex(section *Section, j int) int {
+	order := c.slides[section.ID]
+	for k := j - 1; k >= 0; k-- {
+		if idx := indexOf(order, section.Slides[k].ID); idx >= 0 {
+			return idx + 1
+		}
+	}
+	return 0
+}
+
+// removeSlide drops a slide from the working order.
+func (c *comparer) removeSlide(id string) {
+	sectionID := c.location[id]
+	if idx := indexOf(c.slides[sectionID], id); idx >= 0 {
+		c.slides[sectionID] = removeAt(c.slides[sectionID], idx)
+	}
+	delete(c.location, id)
+}
+
+// slidePath returns the path of a slide at its working location.
+func (c *comparer) slidePath(id string) string {
+	return fmt.Sprintf("sections/%s/slides/%s", c.location[id], id)
+}
+
+// compareSlide compares the fields of two versions of the same slide.
+func (c *comparer) compareSlide(path string, current, desired *Slide) {
+	c.compareValue(path+"/layout", current.Layout, desired.Layout)
+	c.compareValue(path+"/title", current.Title, desired.Title)
+	c.compareValue(path+"/subtitle", current.Subtitle, desired.Subtitle)
+	c.compareBlocks(path+"/body", current.Body, desired.Body)
+	c.compareSlots(path+"/slots", current.Slots, desired.Slots)
+	c.compareBlocks(path+"/notes", current.Notes, desired.Notes)
+	c.compareValue(path+"/audio", current.Audio, desired.Audio)
+	c.compareValue(path+"/transition", derefString(current.Transition), derefString(desired.Transition))
+	c.compareValue(path+"/background", emptyBackground(current.Background), emptyBackground(desired.Background))
+	c.compareValue(path+"/header_footer", emptyHeaderFooter(current.HeaderFooter), emptyHeaderFooter(desired.HeaderFooter))
+	c.compareValue(path+"/header_footer_from", emptyHeaderFooter(current.HeaderFooterFrom), emptyHeaderFooter(desired.HeaderFooterFrom))
+	c.compareValue(path+"/directives", current.Directives, desired.Directives)
+}
+
+// compareValue emits an update when two field values differ. Empty and nil
+// values are treated as equal so that decks decoded from JSON compare equal
+// to freshly parsed ones.
+func (c *comparer) compareValue(path string, current, desired any) {
+	if isEmptyValue(current) && isEmptyValue(desired) {
+		return
+	}
+	if reflect.DeepEqual(current, desired) || sameJSON(current, desired) {
+		return
+	}
+	c.diff.AddChange(NewUpdateChange(path, emptyToNil(current), emptyToNil(desired)))
+}
+
+// sameJSON reports whether two values have the same JSON encoding. Untyped
+// values such as Meta.Custom differ in Go type depending on whether they were
+// decoded from YAML or JSON (int versus float64) while meaning the same.
+func sameJSON(a, b any) bool {
+	ja, errA := json.Marshal(a)
+	jb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ja, jb)
+}
+
+// compareBlocks aligns two block lists on their longest common subsequence
+// and emits per-block changes under path/{index}. Within each run of
+// differing blocks, removed and added blocks are paired into updates; a pair
+// of tables with the same columns is compared cell by cell instead.
+func (c *comparer) compareBlocks(path string, current, desired []Block) {
+	compareSequence(current, desired,
+		func(k int, old, block Block) {
+			blockPath := fmt.Sprintf("%s/%d", path, k)
+			if sameTableShape(&old, &block) {
+				c.compareTable(blockPath+"/table", old.Table, block.Table)
+				return
+			}
+			c.diff.AddChange(NewUpdateChange(blockPath, old, block))
+		},
+		func(k int, old Block) {
+			c.diff.AddChange(NewRemoveChange(fmt.Sprintf("%s/%d", path, k), old))
+		},
+		func(k int, block Block) {
+			c.diff.AddChange(NewAddChange(fmt.Sprintf("%s/%d", path, k), block))
+		})
+}
+
+// compareSlots compares content slots by name. Blocks of slots present on
+// both sides are compared like the body; added and removed slots are
+// reported whole under path/{name}, in display order.
+func (c *comparer) compareSlots(path string, current, desired Slots) {
+	names := make(map[string]bool)
+	for name := range current {
+		names[name] = true
+	}
+	for name := range desired {
+		names[name] = true
+	}
+	all := make(Slots, len(names))
+	for name := range names {
+		all[name] = nil
+	}
+
+	for _, name := range all.Names() {
+		cur, inCurrent := current[name]
+		des, inDesired := desired[name]
+		switch {
+		case inCurrent && inDesired:
+			c.compareBlocks(path+"/"+name, cur, des)
+		case inDesired:
+			c.diff.AddChange(NewAddChange(path+"/"+name, des))
+		default:
+			c.diff.AddChange(NewRemoveChange(path+"/"+name, cur))
+		}
+	}
+}
+
+// sameTableShape reports whether two blocks are tables with the same number
+// of columns that differ only in their table content.
+func sameTableShape(a, b *Block) bool {
+	if a.Kind != BlockTable || b.Kind != BlockTable || a.Table == nil || b.Table == nil {
+		return false
+	}
+	if a.Table.Columns() != b.Table.Columns() {
+		return false
+	}
+	x, y := *a, *b
+	x.Table, y.Table = nil, nil
+	return reflect.DeepEqual(x, y)
+}
+
+// compareTable emits changes for the alignment, header cells and rows of a
+// table. Rows are aligned like blocks; paired rows are compared cell by cell
+// under path/rows/{index}/{column}.
+func (c *comparer) compareTable(path string, current, desired *Table) {
+	c.compareValue(path+"/align", current.Align, desired.Align)
+	for i := range desired.Header {
+		c.compareCell(fmt.Sprintf("%s/header/%d", path, i), current.Header[i], desired.Header[i])
+	}
+	compareSequence(current.Rows, desired.Rows,
+		func(k int, old, row []TableCell) {
+			if len(old) != len(row) {
+				c.diff.AddChange(NewUpdateChange(fmt.Sprintf("%s/rows/%d", path, k), old, row))
+				return
+			}
+			for i := range row {
+				c.compareCell(fmt.Sprintf("%s/rows/%d/%d", path, k, i), old[i], row[i])
+			}
+		},
+		func(k int, old []TableCell) {
+			c.diff.AddChange(NewRemoveChange(fmt.Sprintf("%s/rows/%d", path, k), old))
+		},
+		func(k int, row []TableCell) {
+			c.diff.AddChange(NewAddChange(fmt.Sprintf("%s/rows/%d", path, k), row))
+		})
+}
+
+// compareCell emits an update when two table cells differ.
+func (c *comparer) compareCell(path string, current, desired TableCell) {
+	if !reflect.DeepEqual(current, desired) {
+		c.diff.AddChange(NewUpdateChange(path, current, desired))
+	}
+}
+
+// compareSequence aligns two lists on their longest common subsequence and
+// reports the differences. Within each run of differing items, removed and
+// added items are paired and passed to pair; the rest go to remove and add.
+// Each callback receives the item's index in the working list, which
+// reflects the changes reported before it.
+func compareSequence[T any](current, desired []T, pair func(k int, old, item T),
+	remove func(k int, old T), add func(k int, item T)) {
+	n, m := len(current), len(desired)
+
+	// lcs[i][j] is the LCS length of current[i:] and desired[j:].
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if reflect.DeepEqual(current[i], desired[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j, k := 0, 0, 0 // k is the index in the working list
+	for i < n || j < m {
+		if i < n && j < m && reflect.DeepEqual(current[i], desired[j]) {
+			i, j, k = i+1, j+1, k+1
+			continue
+		}
+
+		// Collect the run of differing items up to the next match.
+		var removed, added []T
+		for i < n || j < m {
+			if i < n && j < m && reflect.DeepEqual(current[i], desired[j]) {
+				break
+			}
+			if j < m && (i == n || lcs[i][j+1] >= lcs[i+1][j]) {
+				added = append(added, desired[j])
+				j++
+			} else {
+				removed = append(removed, current[i])
+				i++
+			}
+		}
+
+		paired := min(len(removed), len(added))
+		for t := 0; t < paired; t++ {
+			pair(k, removed[t], added[t])
+			k++
+		}
+		for t := paired; t < len(removed); t++ {
+			remove(k, removed[t])
+		}
+		for t := paired; t < len(added); t++ {
+			add(k, added[t])
+			k++
+		}
+	}
+}
+
+// isEmptyValue reports whether v is nil or a zero-length string, slice or map.
+func isEmptyValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map:
+		return rv.Len() == 0
+	case reflect.Pointer:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func emptyToNil(v any) any {
+	if isEmptyValue(v) {
+		return nil
+	}
+	return v
+}
+
+// emptyBackground returns nil for a background that sets nothing, so that
+// it compares equal to a missing one.
+func emptyBackground(bg *Background) *Background {
+	if bg.IsEmpty() {
+		return nil
+	}
+	return bg
+}
+
+// emptyHeaderFooter returns nil for settings that change nothing, so that
+// they compare equal to missing ones.
+func emptyHeaderFooter(h *HeaderFooter) *HeaderFooter {
+	if h.IsEmpty() {
+		return nil
+	}
+	return h
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func indexOf(s []string, v string) int {
+	for i := range s {
+		if s[i] == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// stableIDs returns the IDs forming the longest common subsequence of two
+// orderings of the same items; these can stay in place while the rest move.
+func stableIDs(current, desired []string) map[string]bool {
+	n, m := len(current), len(desired)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if current[i] == desired[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	stable := make(map[string]bool)
+	for i, j := 0, 0; i < n && j < m; {
+		switch {
+		case current[i] == desired[j]:
+			stable[current[i]] = true
+			i, j = i+1, j+1
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			i++
+		default:
+			j++
+		}
+	}
+	return stable
+}
@@ -0,0 +1,473 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func newCompareTestDeck() *Deck {
+	return &Deck{
+		ID:    "deck",
+		Title: "Compare Test",
+		Meta:  Meta{Author: "Ada", Custom: map[string]any{}},
+		Sections: []Section{
+			{
+				ID:    "intro",
+				Title: "Introduction",
+				Slides: []Slide{
+					{ID: "a", Layout: LayoutTitle, Title: "Welcome", Subtitle: "Hello"},
+					{
+						ID:     "b",
+						Layout: LayoutTitleBody,
+						Title:  "Points",
+						Body: []Block{
+							NewBullet("One", 0),
+							NewBullet("Two", 0),
+							NewBullet("Three", 0),
+						},
+						Notes: []Block{NewParagraph("Talk about points.")},
+					},
+				},
+			},
+			{
+				ID:    "main",
+				Title: "Main",
+				Slides: []Slide{
+					{ID: "c", Layout: LayoutTitleBody, Title: "Code",
+						Body: []Block{NewCode("fmt.Println()", "go")}},
+				},
+			},
+		},
+	}
+}
+
+// cloneDeck deep-copies a deck through JSON, as a diff would be transported.
+func cloneDeck(t *testing.T, d *Deck) *Deck {
+	t.Helper()
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	var out Deck
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	return &out
+}
+
+// assertDiffApplies checks that applying ComputeDiff(current, desired) to
+// current yields desired.
+func assertDiffApplies(t *testing.T, current, desired *Deck) *Diff {
+	t.Helper()
+	diff := ComputeDiff(current, desired)
+
+	got := cloneDeck(t, current)
+	if err := got.ApplyDiff(cloneDiff(t, diff)); err != nil {
+		t.Fatalf("ApplyDiff error: %v", err)
+	}
+	if again := ComputeDiff(got, desired); !again.IsEmpty() {
+		t.Errorf("applied deck still differs from desired: %+v", again.Changes)
+	}
+	return diff
+}
+
+func cloneDiff(t *testing.T, d *Diff) *Diff {
+	t.Helper()
+	data, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("marshal error: %v", err)
+	}
+	var out Diff
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("unmarshal error: %v", err)
+	}
+	return &out
+}
+
+func TestComputeDiffNoChanges(t *testing.T) {
+	current := newCompareTestDeck()
+	desired := cloneDeck(t, current) // nil vs empty maps must compare equal
+
+	diff := ComputeDiff(current, desired)
+	if !diff.IsEmpty() {
+		t.Errorf("expected empty diff, got %+v", diff.Changes)
+	}
+	if diff.DeckID != "deck" {
+		t.Errorf("expected deck ID 'deck', got %q", diff.DeckID)
+	}
+}
+
+func TestComputeDiffInsertedBullet(t *testing.T) {
+	current := newCompareTestDeck()
+	desired := cloneDeck(t, current)
+	b := desired.FindSlide("b")
+	b.Body = []Block{b.Body[0], NewBullet("One and a half", 1), b.Body[1], b.Body[2]}
+
+	diff := assertDiffApplies(t, current, desired)
+	if diff.ChangeCount() != 1 {
+		t.Fatalf("expected 1 change, got %d: %+v", diff.ChangeCount(), diff.Changes)
+	}
+	c := diff.Changes[0]
+	if c.Op != ChangeAdd || c.Path != "sections/intro/slides/b/body/1" {
+		t.Errorf("unexpected change: %s %s", c.Op, c.Path)
+	}
+}
+
+func TestComputeDiffBlockEdits(t *testing.T) {
+	current := newCompareTestDeck()
+	desired := cloneDeck(t, current)
+	b := desired.FindSlide("b")
+	b.Body = []Block{NewBullet("One", 0), NewBullet("2", 0)} // update Two, remove Three
+	b.Notes = nil
+
+	diff := assertDiffApplies(t, current, desired)
+	want := []struct {
+		op   ChangeOp
+		path string
+	}{
+		{ChangeUpdate, "sections/intro/slides/b/body/1"},
+		{ChangeRemove, "sections/intro/slides/b/body/2"},
+		{ChangeRemove, "sections/intro/slides/b/notes/0"},
+	}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), diff.Changes)
+	}
+	for i, w := range want {
+		if diff.Changes[i].Op != w.op || diff.Changes[i].Path != w.path {
+			t.Errorf("change %d = %s %s, want %s %s", i,
+				diff.Changes[i].Op, diff.Changes[i].Path, w.op, w.path)
+		}
+	}
+}
+
+func TestComputeDiffTableCells(t *testing.T) {
+	current := newCompareTestDeck()
+	c := current.FindSlide("c")
+	c.Body = append(c.Body, NewTable([]string{"Name", "Role"},
+		[][]string{{"Ada", "Dev"}, {"Bob", "Ops"}, {"Cy", "QA"}}))
+
+	desired := cloneDeck(t, current)
+	table := desired.FindSlide("c").Body[1].Table
+	table.Align = []Alignment{AlignDefault, AlignRight}
+	table.Header[1].Text = "Team"
+	table.Rows[0][1].Text = "Lead"
+	table.Rows = append(table.Rows[:1], table.Rows[2], []TableCell{{Text: "Di"}, {Text: "PM"}})
+
+	diff := assertDiffApplies(t, current, desired)
+	want := []struct {
+		op   ChangeOp
+		path string
+	}{
+		{ChangeUpdate, "sections/main/slides/c/body/1/table/align"},
+		{ChangeUpdate, "sections/main/slides/c/body/1/table/header/1"},
+		{ChangeUpdate, "sections/main/slides/c/body/1/table/rows/0/1"},
+		{ChangeRemove, "sections/main/slides/c/body/1/table/rows/1"},
+		{ChangeAdd, "sections/main/slides/c/body/1/table/rows/2"},
+	}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), diff.Changes)
+	}
+	for i, w := range want {
+		if diff.Changes[i].Op != w.op || diff.Changes[i].Path != w.path {
+			t.Errorf("change %d = %s %s, want %s %s", i,
+				diff.Changes[i].Op, diff.Changes[i].Path, w.op, w.path)
+		}
+	}
+
+	// Applying must not touch the table shared with the current deck
+	shared := cloneDeck(t, current)
+	desired = cloneDeck(t, current)
+	desired.FindSlide("c").Body = append([]Block(nil), shared.FindSlide("c").Body...)
+	if err := desired.ApplyDiff(diff); err != nil {
+		t.Fatalf("ApplyDiff error: %v", err)
+	}
+	if got := shared.FindSlide("c").Body[1].Table.Header[1].Text; got != "Role" {
+		t.Errorf("shared table was modified: header is %q", got)
+	}
+}
+
+func TestComputeDiffTableColumnsChanged(t *testing.T) {
+	current := newCompareTestDeck()
+	current.FindSlide("c").Body = []Block{NewTable([]string{"A"}, nil)}
+	desired := cloneDeck(t, current)
+	desired.FindSlide("c").Body = []Block{NewTable([]string{"A", "B"}, nil)}
+
+	diff := assertDiffApplies(t, current, desired)
+	if diff.ChangeCount() != 1 || diff.Changes[0].Path != "sections/main/slides/c/body/0" {
+		t.Errorf("expected a single block update, got %+v", diff.Changes)
+	}
+}
+
+func TestComputeDiffSlots(t *testing.T) {
+	current := newCompareTestDeck()
+	current.FindSlide("c").Slots = Slots{
+		SlotLeft:  {NewBullet("Before", 0)},
+		SlotRight: {NewBullet("After", 0)},
+	}
+	desired := cloneDeck(t, current)
+	c := desired.FindSlide("c")
+	c.Slots[SlotRight] = []Block{NewBullet("After", 0), NewBullet("Faster", 0)}
+	delete(c.Slots, SlotLeft)
+	c.Slots[SlotCaption] = []Block{NewParagraph("Source: survey")}
+
+	diff := assertDiffApplies(t, current, desired)
+	want := []struct {
+		op   ChangeOp
+		path string
+	}{
+		{ChangeRemove, "sections/main/slides/c/slots/left"},
+		{ChangeAdd, "sections/main/slides/c/slots/right/1"},
+		{ChangeAdd, "sections/main/slides/c/slots/caption"},
+	}
+	if len(diff.Changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), diff.Changes)
+	}
+	for i, w := range want {
+		if diff.Changes[i].Op != w.op || diff.Changes[i].Path != w.path {
+			t.Errorf("change %d = %s %s, want %s %s", i,
+				diff.Changes[i].Op, diff.Changes[i].Path, w.op, w.path)
+		}
+	}
+}
+
+func TestComputeDiffFields(t *testing.T) {
+	current := newCompareTestDeck()
+	desired := cloneDeck(t, current)
+	transition := "fade"
+	desired.Title = "Renamed Deck"
+	desired.Meta.Author = "Grace"
+	desired.Meta.Keywords = []string{"go"}
+	desired.Theme = DarkTheme()
+	desired.Sections[1].Title = "Core"
+	desired.Sections[1].Audio = NewFileAudio("main.mp3", 0)
+	a := desired.FindSlide("a")
+	a.Subtitle = ""
+	a.Layout = LayoutSection
+	a.Transition = &transition
+	a.Audio = NewNotesAudio("alloy")
+	a.Directives = map[string]string{"_color": "red"}
+
+	diff := assertDiffApplies(t, current, desired)
+
+	paths := make(map[string]ChangeOp)
+	for _, c := range diff.Changes {
+		paths[c.Path] = c.Op
+	}
+	for _, p := range []string{
+		"title",
+		"meta/author",
+		"meta/keywords",
+		"theme",
+		"sections/main/title",
+		"sections/main/audio",
+		"sections/intro/slides/a/subtitle",
+		"sections/intro/slides/a/layout",
+		"sections/intro/slides/a/transition",
+		"sections/intro/slides/a/audio",
+		"sections/intro/slides/a/directives",
+	} {
+		if paths[p] != ChangeUpdate {
+			t.Errorf("expected update for %s, got %+v", p, diff.Changes)
+		}
+	}
+	if len(diff.Changes) != 11 {
+		t.Errorf("expected 11 changes, got %d", len(diff.Changes))
+	}
+}
+
+func TestComputeDiffBackground(t *testing.T) {
+	current := newCompareTestDeck()
+	current.FindSlide("a").Background = &Background{}
+	desired := cloneDeck(t, current)
+	desired.FindSlide("a").Background = nil
+	if diff := ComputeDiff(current, desired); !diff.IsEmpty() {
+		t.Errorf("expected an empty background to equal none, got %+v", diff.Changes)
+	}
+
+	desired.FindSlide("a").Background = &Background{
+		Color:  "#000",
+		Images: []Block{NewBackgroundImage("bg.png")},
+	}
+	diff := assertDiffApplies(t, current, desired)
+	if diff.ChangeCount() != 1 || diff.Changes[0].Path != "sections/intro/slides/a/background" {
+		t.Errorf("expected a single background change, got %+v", diff.Changes)
+	}
+}
+
+func TestComputeDiffAddRemove(t *testing.T) {
+	current := newCompareTestDeck()
+	desired := cloneDeck(t, current)
+	desired.Sections[0].Slides = []Slide{
+		{ID: "new", Layout: LayoutTitleBody, Title: "Inserted first"},
+		desired.Sections[0].Slides[1],
+	}
+	desired.Sections = append([]Section{{
+		ID:     "pre",
+		Title:  "Prelude",
+		Slides: []Slide{{ID: "p1", Title: "Prelude slide"}},
+	}}, desired.Sections[0])
+
+	diff := assertDiffApplies(t, current, desired)
+
+	counts := diff.CountByOp()
+	if counts[ChangeAdd] != 2 || counts[ChangeRemove] != 2 {
+		t.Errorf("expected 2 adds and 2 removes, got %v: %+v", counts, diff.Changes)
+	}
+	for _, c := range diff.AddChanges() {
+		if c.To == nil || c.To.Index != 0 {
+			t.Errorf("expected add at index 0, got %+v", c.To)
+		}
+	}
+}
+
+func TestCompareValueEmptyEquivalence(t *testing.T) {
+	c := newComparer(&Deck{})
+	c.compareValue("x", "", nil)
+	c.compareValue("y", []string{}, []string(nil))
+	c.compareValue("z", map[string]string{}, map[string]string(nil))
+	c.compareValue("w", (*Audio)(nil), nil)
+	if !c.diff.IsEmpty() {
+		t.Errorf("expected no changes, got %+v", c.diff.Changes)
+	}
+
+	c.compareValue("v", []string{"a"}, []string{"b"})
+	if c.diff.ChangeCount() != 1 || !reflect.DeepEqual(c.diff.Changes[0].NewValue, []string{"b"}) {
+		t.Errorf("expected one update, got %+v", c.diff.Changes)
+	}
+}
+
+func TestComputeDiffMoveSlide(t *testing.T) {
+	current := newCompareTestDeck()
+	desired := cloneDeck(t, current)
+	intro := &desired.Sections[0]
+	intro.Slides = []Slide{intro.Slides[1], intro.Slides[0]}
+
+	diff := assertDiffApplies(t, current, desired)
+	if diff.ChangeCount() != 1 {
+		t.Fatalf("expected 1 change, got %+v", diff.Changes)
+	}
+	c := diff.Changes[0]
+	if c.Op != ChangeMove || c.From == nil || c.To == nil {
+		t.Fatalf("expected move with positions, got %+v", c)
+	}
+	if c.From.SectionID != "intro" || c.To.SectionID != "intro" || c.From.Index == c.To.Index {
+		t.Errorf("unexpected positions: from %+v to %+v", *c.From, *c.To)
+	}
+}
+
+func TestComputeDiffMoveAcrossSections(t *testing.T) {
+	current := newCompareTestDeck()
+	desired := cloneDeck(t, current)
+	b := desired.Sections[0].Slides[1]
+	b.Title = "Points, moved"
+	desired.Sections[0].Slides = desired.Sections[0].Slides[:1]
+	desired.Sections[1].Slides = append(desired.Sections[1].Slides, b)
+
+	diff := assertDiffApplies(t, current, desired)
+	counts := diff.CountByOp()
+	if counts[ChangeAdd] != 0 || counts[ChangeRemove] != 0 {
+		t.Errorf("expected no adds or removes, got %+v", diff.Changes)
+	}
+	moves := diff.MoveChanges()
+	if len(moves) != 1 || moves[0].Path != "sections/intro/slides/b" ||
+		moves[0].NewValue != "sections/main/slides/b" {
+		t.Fatalf("unexpected moves: %+v", moves)
+	}
+	// Field changes after a move address the slide at its new location.
+	updates := diff.UpdateChanges()
+	if len(updates) != 1 || updates[0].Path != "sections/main/slides/b/title" {
+		t.Errorf("unexpected updates: %+v", updates)
+	}
+}
+
+func TestComputeDiffMoveSection(t *testing.T) {
+	current := newCompareTestDeck()
+	desired := cloneDeck(t, current)
+	desired.Sections = []Section{desired.Sections[1], desired.Sections[0]}
+
+	diff := assertDiffApplies(t, current, desired)
+	if diff.ChangeCount() != 1 {
+		t.Fatalf("expected 1 change, got %+v", diff.Changes)
+	}
+	c := diff.Changes[0]
+	if c.Op != ChangeMove || c.Path != "sections/intro" || c.From.Index != 0 || c.To.Index != 1 {
+		t.Errorf("unexpected change: %+v", c)
+	}
+}
+
+func TestComputeDiffMoveIntoRemovedAndAddedSections(t *testing.T) {
+	current := newCompareTestDeck()
+	desired := cloneDeck(t, current)
+	c := desired.Sections[1].Slides[0]
+	a := desired.Sections[0].Slides[0]
+	// Drop "main", move its slide into a new section along with a new slide.
+	desired.Sections = []Section{
+		{ID: "fresh", Title: "Fresh", Slides: []Slide{{ID: "n1", Title: "New"}, c, a}},
+		{ID: "intro", Title: "Introduction", Slides: []Slide{desired.Sections[0].Slides[1]}},
+	}
+
+	diff := assertDiffApplies(t, current, desired)
+	counts := diff.CountByOp()
+	if counts[ChangeMove] != 2 || counts[ChangeAdd] != 1 || counts[ChangeRemove] != 1 {
+		t.Errorf("unexpected change counts %v: %+v", counts, diff.Changes)
+	}
+}
+
+func TestComputeDiffReorderPermutations(t *testing.T) {
+	// Every permutation of a section's slides must replay exactly and use
+	// only moves.
+	ids := []string{"a", "b", "c", "d"}
+	var permute func(prefix, rest []string)
+	permute = func(prefix, rest []string) {
+		if len(rest) == 0 {
+			current := &Deck{Sections: []Section{{ID: "s"}}}
+			desired := &Deck{Sections: []Section{{ID: "s"}}}
+			for _, id := range ids {
+				current.Sections[0].Slides = append(current.Sections[0].Slides, Slide{ID: id})
+			}
+			for _, id := range prefix {
+				desired.Sections[0].Slides = append(desired.Sections[0].Slides, Slide{ID: id})
+			}
+			diff := assertDiffApplies(t, current, desired)
+			if len(diff.MoveChanges()) != diff.ChangeCount() {
+				t.Errorf("permutation %v: expected only moves, got %+v", prefix, diff.Changes)
+			}
+			return
+		}
+		for i := range rest {
+			next := append(append([]string{}, rest[:i]...), rest[i+1:]...)
+			permute(append(append([]string{}, prefix...), rest[i]), next)
+		}
+	}
+	permute(nil, ids)
+}
+
+func TestComputeDiffHeaderFooter(t *testing.T) {
+	current := newCompareTestDeck()
+	current.HeaderFooter = &HeaderFooter{}
+	desired := cloneDeck(t, current)
+	desired.HeaderFooter = nil
+	if diff := ComputeDiff(current, desired); !diff.IsEmpty() {
+		t.Errorf("expected empty settings to equal none, got %+v", diff.Changes)
+	}
+
+	footer, on := "Acme", true
+	desired.HeaderFooter = &HeaderFooter{Footer: &footer}
+	desired.FindSlide("a").HeaderFooter = &HeaderFooter{PageNumber: &on}
+	desired.FindSlide("a").HeaderFooterFrom = &HeaderFooter{Header: &footer}
+	diff := assertDiffApplies(t, current, desired)
+
+	var paths []string
+	for _, c := range diff.Changes {
+		paths = append(paths, c.Path)
+	}
+	want := []string{
+		"header_footer",
+		"sections/intro/slides/a/header_footer",
+		"sections/intro/slides/a/header_footer_from",
+	}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("expected changes %v, got %v", want, paths)
+	}
+}
@@ -4,20 +4,25 @@ import "time"
 
 // Deck represents a complete presentation.
 type Deck struct {
-	ID       string    `json:"id"`
-	Title    string    `json:"title"`
-	Meta     Meta      `json:"meta"`
-	Sections []Section `json:"sections"`
-	Theme    *Theme    `json:"theme,omitempty"`
+	ID           string        `json:"id"`
+	Title        string        `json:"title"`
+	Meta         Meta          `json:"meta"`
+	Sections     []Section     `json:"sections"`
+	Theme        *Theme        `json:"theme,omitempty"`
+	HeaderFooter *HeaderFooter `json:"header_footer,omitempty"` // Defaults for every slide
+	Transition   string        `json:"transition,omitempty"`    // Default slide transition, such as "fade"
 }
 
 // Meta contains presentation metadata.
 type Meta struct {
-	Author      string            `json:"author,omitempty"`
-	Date        string            `json:"date,omitempty"`
-	Description string            `json:"description,omitempty"`
-	Keywords    []string          `json:"keywords,omitempty"`
-	Custom      map[string]string `json:"custom,omitempty"`
+	Author      string         `json:"author,omitempty"`
+	Date        string         `json:"date,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Keywords    []string       `json:"keywords,omitempty"`
+	URL         string         `json:"url,omitempty"`   // Canonical URL of the presentation
+	Image       string         `json:"image,omitempty"` // Open Graph image URL
+	Size        string         `json:"size,omitempty"`  // Slide size preset, e.g. "16:9"
+	Custom      map[string]any `json:"custom,omitempty"`
 }
 
 // SlideCount returns the total number of slides across all sections.
@@ -68,3 +73,33 @@ func (d *Deck) TotalDuration() time.Duration {
 	}
 	return total
 }
+
+// SettleTransitions makes the most common slide transition the deck's
+// default Transition, if every slide has one, and drops it from the slides
+// that use it. Readers of formats that only keep
+// transitions per slide use it to recover the deck's.
+func (d *Deck) SettleTransitions() {
+	counts := make(map[string]int)
+	common := ""
+	for _, slide := range d.AllSlides() {
+		if slide.Transition == nil || *slide.Transition == "" {
+			return
+		}
+		t := *slide.Transition
+		counts[t]++
+		if counts[t] > counts[common] {
+			common = t
+		}
+	}
+	if common == "" {
+		return
+	}
+	d.Transition = common
+	for i := range d.Sections {
+		for j := range d.Sections[i].Slides {
+			if slide := &d.Sections[i].Slides[j]; *slide.Transition == common {
+				slide.Transition = nil
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package model
+
+import "fmt"
+
+// Severity ranks a diagnostic.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"   // Content was lost or misread
+	SeverityWarning Severity = "warning" // Content was read, but probably not as intended
+)
+
+// Diagnostic reports a problem found in the source of a presentation.
+type Diagnostic struct {
+	Severity Severity `json:"severity"`
+	Line     int      `json:"line"`               // 1-based line in the source file
+	Column   int      `json:"column,omitempty"`   // 1-based column, in characters
+	SlideID  string   `json:"slide_id,omitempty"` // Slide the problem is in, if any
+	Message  string   `json:"message"`
+}
+
+// String formats the diagnostic as "line:column: severity: message", with
+// the slide ID in brackets when known.
+func (d Diagnostic) String() string {
+	s := fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Column, d.Severity, d.Message)
+	if d.SlideID != "" {
+		s += " [slide " + d.SlideID + "]"
+	}
+	return s
+}
+
+// HasErrors returns true if any diagnostic is an error.
+func HasErrors(diags []Diagnostic) bool {
+	for _, d := range diags {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
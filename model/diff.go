@@ -8,12 +8,20 @@ type Diff struct {
 
 // Change represents a single modification.
 type Change struct {
-	Op        ChangeOp `json:"op"`
-	Path      string   `json:"path"`                 // e.g., "sections/0/slides/2/title"
-	SlideID   string   `json:"slide_id,omitempty"`   // Target slide ID
-	SectionID string   `json:"section_id,omitempty"` // Target section ID
-	OldValue  any      `json:"old_value,omitempty"`
-	NewValue  any      `json:"new_value,omitempty"`
+	Op        ChangeOp  `json:"op"`
+	Path      string    `json:"path"`                 // e.g., "sections/0/slides/2/title"
+	SlideID   string    `json:"slide_id,omitempty"`   // Target slide ID
+	SectionID string    `json:"section_id,omitempty"` // Target section ID
+	OldValue  any       `json:"old_value,omitempty"`
+	NewValue  any       `json:"new_value,omitempty"`
+	From      *Position `json:"from,omitempty"` // Source for move
+	To        *Position `json:"to,omitempty"`   // Destination for add and move
+}
+
+// Position locates a section or slide within the deck ordering.
+type Position struct {
+	SectionID string `json:"section_id,omitempty"` // Containing section (slides only)
+	Index     int    `json:"index"`                // Zero-based index within the container
 }
 
 // ChangeOp identifies the type of change.
@@ -143,3 +151,9 @@ func NewMoveChange(fromPath, toPath string) Change {
 		NewValue: toPath,
 	}
 }
+
+// At returns a copy of the change with its destination set to the given position.
+func (c Change) At(to Position) Change {
+	c.To = &to
+	return c
+}
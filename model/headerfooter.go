@@ -0,0 +1,95 @@
+package model
+
+// HeaderFooter holds the header and footer shown on slides and whether
+// they show their page number. Every field is optional: a nil field leaves
+// the setting inherited from the deck or from earlier slides, while an
+// empty header or footer hides it.
+type HeaderFooter struct {
+	Header     *string `json:"header,omitempty"`
+	Footer     *string `json:"footer,omitempty"`
+	PageNumber *bool   `json:"page_number,omitempty"`
+}
+
+// IsEmpty returns true if the settings change nothing.
+func (h *HeaderFooter) IsEmpty() bool {
+	return h == nil || h.Header == nil && h.Footer == nil && h.PageNumber == nil
+}
+
+// Merge returns h with the fields that o sets replacing its own.
+func (h HeaderFooter) Merge(o *HeaderFooter) HeaderFooter {
+	if o == nil {
+		return h
+	}
+	if o.Header != nil {
+		h.Header = o.Header
+	}
+	if o.Footer != nil {
+		h.Footer = o.Footer
+	}
+	if o.PageNumber != nil {
+		h.PageNumber = o.PageNumber
+	}
+	return h
+}
+
+// HeaderText returns the header to show, or "" for none.
+func (h HeaderFooter) HeaderText() string {
+	return derefString(h.Header)
+}
+
+// FooterText returns the footer to show, or "" for none.
+func (h HeaderFooter) FooterText() string {
+	return derefString(h.Footer)
+}
+
+// ShowPageNumber reports whether the page number is shown.
+func (h HeaderFooter) ShowPageNumber() bool {
+	return h.PageNumber != nil && *h.PageNumber
+}
+
+// HeaderFooters returns the settings in effect on each slide, in the order
+// of AllSlides: the deck's settings, changed by the HeaderFooterFrom of
+// every slide up to and including this one, with the slide's own
+// HeaderFooter applied on top. Backends without inherited settings can
+// write these per slide.
+func (d *Deck) HeaderFooters() []HeaderFooter {
+	var result []HeaderFooter
+	running := HeaderFooter{}.Merge(d.HeaderFooter)
+	for _, s := range d.Sections {
+		for i := range s.Slides {
+			running = running.Merge(s.Slides[i].HeaderFooterFrom)
+			result = append(result, running.Merge(s.Slides[i].HeaderFooter))
+		}
+	}
+	return result
+}
+
+// SetHeaderFooters sets the deck's and slides' settings from those shown
+// on each slide, in the order of AllSlides, as HeaderFooters returns them.
+// Settings shown on every slide alike become the deck's; otherwise each
+// slide keeps its own. Readers of formats without inherited settings use
+// it to recover them.
+func (d *Deck) SetHeaderFooters(settings []HeaderFooter) {
+	uniform := true
+	for _, s := range settings {
+		uniform = uniform && s.HeaderText() == settings[0].HeaderText() &&
+			s.FooterText() == settings[0].FooterText() && s.ShowPageNumber() == settings[0].ShowPageNumber()
+	}
+	if uniform {
+		if len(settings) > 0 && !settings[0].IsEmpty() {
+			s := settings[0]
+			d.HeaderFooter = &s
+		}
+		return
+	}
+	k := 0
+	for i := range d.Sections {
+		for j := range d.Sections[i].Slides {
+			if k < len(settings) && !settings[k].IsEmpty() {
+				s := settings[k]
+				d.Sections[i].Slides[j].HeaderFooter = &s
+			}
+			k++
+		}
+	}
+}
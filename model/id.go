@@ -0,0 +1,107 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Slugify turns text into a lowercase, hyphen-separated identifier such as
+// "why-agents" for "Why Agents?". Markdown punctuation is dropped along with
+// everything else that is not a letter or digit. It returns fallback when
+// text has no letters or digits.
+func Slugify(text, fallback string) string {
+	var b strings.Builder
+	pendingDash := false
+	for _, r := range strings.ToLower(text) {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			pendingDash = b.Len() > 0
+			continue
+		}
+		if pendingDash {
+			b.WriteByte('-')
+			pendingDash = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 {
+		return fallback
+	}
+	return b.String()
+}
+
+// UniqueIDs assigns an ID to each item of a list. Items with an explicit ID
+// keep it unless an earlier item already claimed it; the rest are named
+// after the slug of their title, with -2, -3, ... appended on collisions.
+// The result depends only on the inputs, so the same document always yields
+// the same IDs.
+func UniqueIDs(explicit, titles []string, fallback string) []string {
+	ids := make([]string, len(titles))
+	used := make(map[string]bool)
+
+	// Explicit IDs are claimed first so generated ones never take them
+	for i, id := range explicit {
+		if id != "" && !used[id] {
+			ids[i] = id
+			used[id] = true
+		}
+	}
+
+	for i := range ids {
+		if ids[i] != "" {
+			continue
+		}
+		base := Slugify(titles[i], fallback)
+		if i < len(explicit) && explicit[i] != "" {
+			base = explicit[i]
+		}
+		id := base
+		for n := 2; used[id]; n++ {
+			id = base + "-" + strconv.Itoa(n)
+		}
+		ids[i] = id
+		used[id] = true
+	}
+	return ids
+}
+
+// ExplicitIDs returns the explicit IDs that make UniqueIDs give each item
+// of a list the ID in ids: "" for items whose ID UniqueIDs derives from
+// their title anyway, and the ID for the rest. It starts from explicit,
+// the IDs already given explicitly, which it keeps; nil means none. Items
+// without an ID are left alone. Writers use it to spell out only the IDs
+// their readers cannot derive.
+func ExplicitIDs(ids, titles, explicit []string, fallback string) []string {
+	result := make([]string, len(ids))
+	copy(result, explicit)
+
+	// IDs that are neither the slug of the title nor a numbered variant of
+	// it are always needed
+	for i, id := range ids {
+		if id == "" || result[i] != "" {
+			continue
+		}
+		base := Slugify(titles[i], fallback)
+		suffix, numbered := strings.CutPrefix(id, base+"-")
+		if _, err := strconv.Atoi(suffix); id != base && (!numbered || err != nil) {
+			result[i] = id
+		}
+	}
+
+	// The rest depend on each other: making one explicit can change what
+	// later ones derive, so they are settled one at a time in order
+	for {
+		derived := UniqueIDs(result, titles, fallback)
+		next := -1
+		for i, id := range ids {
+			if id != "" && result[i] == "" && derived[i] != id {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			return result
+		}
+		result[next] = ids[next]
+	}
+}
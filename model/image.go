@@ -0,0 +1,53 @@
+package model
+
+// ImageAttrs holds sizing, filters and background placement for an image
+// block.
+type ImageAttrs struct {
+	Width   string        `json:"width,omitempty"`   // e.g. "300px", "50%"; a bare number means pixels
+	Height  string        `json:"height,omitempty"`  // Same units as Width
+	Filters []ImageFilter `json:"filters,omitempty"` // Applied in order
+
+	// Background placement. Bg marks the image as a slide background
+	// rather than inline content; the other fields only apply with it.
+	Bg        bool   `json:"bg,omitempty"`
+	BgSize    string `json:"bg_size,omitempty"`    // cover (default), contain, fit, auto or a percentage
+	Split     string `json:"split,omitempty"`      // left or right: the image takes that side of the slide
+	SplitSize string `json:"split_size,omitempty"` // Share of the slide for a split, e.g. "40%"
+	Vertical  bool   `json:"vertical,omitempty"`   // Stack multiple backgrounds vertically
+}
+
+// ImageFilter is a CSS filter such as blur or sepia, with an optional
+// argument such as "10px".
+type ImageFilter struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+// Split sides for background images.
+const (
+	SplitLeft  = "left"
+	SplitRight = "right"
+)
+
+// IsBackground returns true if the block is a background image.
+func (b *Block) IsBackground() bool {
+	return b.Kind == BlockImage && b.Image != nil && b.Image.Bg
+}
+
+// Background is a slide background: a color, a CSS background-image value,
+// and any number of background image blocks.
+type Background struct {
+	Color  string  `json:"color,omitempty"`  // CSS color, e.g. "#000" or "navy"
+	Image  string  `json:"image,omitempty"`  // CSS background-image, e.g. "url(bg.png)"
+	Images []Block `json:"images,omitempty"` // Image blocks with Image.Bg set
+}
+
+// IsEmpty returns true if the background sets nothing.
+func (bg *Background) IsEmpty() bool {
+	return bg == nil || bg.Color == "" && bg.Image == "" && len(bg.Images) == 0
+}
+
+// NewBackgroundImage creates a background image block.
+func NewBackgroundImage(url string) Block {
+	return Block{Kind: BlockImage, URL: url, Image: &ImageAttrs{Bg: true}}
+}
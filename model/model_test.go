@@ -1,6 +1,7 @@
 package model
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -100,6 +101,66 @@ func TestDeckTotalDuration(t *testing.T) {
 	}
 }
 
+func TestDeckBuilder(t *testing.T) {
+	footer := "Internal"
+	b := NewDeckBuilder(&Deck{})
+	b.Add(Slide{Title: "Intro"}, "", HeaderFooter{Footer: &footer})
+	b.StartSection("", "")
+	b.Add(Slide{Title: "Intro"}, "", HeaderFooter{Footer: &footer})
+	b.Add(Slide{Title: "Other"}, "intro", HeaderFooter{Footer: &footer})
+	deck := b.Finish()
+
+	// Explicit IDs win over derived ones, and a section without a title
+	// is named after its first slide
+	var got []string
+	for _, section := range deck.Sections {
+		got = append(got, section.ID+":"+section.Title)
+		for _, slide := range section.Slides {
+			got = append(got, slide.ID)
+		}
+	}
+	want := []string{"default:default", "intro-2", "intro:Intro", "intro-3", "intro"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections and slides = %v, want %v", got, want)
+	}
+	if deck.HeaderFooter.FooterText() != "Internal" || deck.Sections[1].Slides[0].HeaderFooter != nil {
+		t.Errorf("expected the footer shown on every slide to be the deck's, got %+v", deck)
+	}
+}
+
+func TestDeckSetHeaderFooters(t *testing.T) {
+	footer := "Internal"
+	deck := &Deck{Sections: []Section{{Slides: []Slide{{}, {}}}}}
+	deck.SetHeaderFooters([]HeaderFooter{{Footer: &footer}, {}})
+	if deck.HeaderFooter != nil {
+		t.Errorf("expected no deck settings, got %+v", deck.HeaderFooter)
+	}
+	slides := deck.Sections[0].Slides
+	if slides[0].HeaderFooter.FooterText() != "Internal" || slides[1].HeaderFooter != nil {
+		t.Errorf("expected the footer kept on the first slide only, got %+v and %+v", slides[0].HeaderFooter, slides[1].HeaderFooter)
+	}
+	if got := deck.HeaderFooters(); got[0].FooterText() != "Internal" || got[1].FooterText() != "" {
+		t.Errorf("settings did not round-trip: %+v", got)
+	}
+}
+
+func TestDeckSettleTransitions(t *testing.T) {
+	fade, zoom := "fade", "zoom"
+	deck := &Deck{Sections: []Section{{Slides: []Slide{{Transition: &zoom}, {Transition: &fade}, {Transition: &fade}}}}}
+	deck.SettleTransitions()
+	slides := deck.Sections[0].Slides
+	if deck.Transition != "fade" || slides[0].Transition == nil || slides[1].Transition != nil {
+		t.Errorf("expected fade to become the deck's transition, got %q and %+v", deck.Transition, slides)
+	}
+
+	// A slide without a transition leaves the deck without a default
+	deck = &Deck{Sections: []Section{{Slides: []Slide{{Transition: &fade}, {}}}}}
+	deck.SettleTransitions()
+	if deck.Transition != "" || deck.Sections[0].Slides[0].Transition == nil {
+		t.Errorf("expected the transitions left alone, got %q", deck.Transition)
+	}
+}
+
 // Section tests
 
 func TestSectionSlideCount(t *testing.T) {
@@ -213,6 +274,21 @@ func TestSlideNotesText(t *testing.T) {
 	}
 }
 
+func TestSlideNotesPause(t *testing.T) {
+	s := Slide{Notes: []Block{
+		NewParagraph("Hello."),
+		NewPause(500 * time.Millisecond),
+		NewParagraph("Goodbye."),
+		NewBreak(time.Second),
+	}}
+	if got := s.NotesText(); got != "Hello.\nGoodbye." {
+		t.Errorf("expected pauses left out of notes text, got %q", got)
+	}
+	if got := s.NotesPause(); got != 1500*time.Millisecond {
+		t.Errorf("expected 1.5s of pauses, got %v", got)
+	}
+}
+
 func TestSlideBulletCount(t *testing.T) {
 	slide := &Slide{
 		Body: []Block{
@@ -227,6 +303,21 @@ func TestSlideBulletCount(t *testing.T) {
 	}
 }
 
+func TestSlotsNames(t *testing.T) {
+	slots := Slots{
+		SlotCaption: nil,
+		"extra":     nil,
+		SlotRight:   nil,
+		SlotLeft:    nil,
+	}
+	if got, want := slots.Names(), []string{"left", "right", "extra", "caption"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+	if got, want := slots.Columns(), []string{"left", "right", "extra"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Columns() = %v, want %v", got, want)
+	}
+}
+
 func TestLayoutIsValid(t *testing.T) {
 	for _, l := range Layouts() {
 		if !l.IsValid() {
@@ -264,6 +355,9 @@ func TestBlockConstructors(t *testing.T) {
 		{"NewImage", NewImage("url", "alt"), BlockImage},
 		{"NewQuote", NewQuote("quote"), BlockQuote},
 		{"NewHeading", NewHeading("heading", 2), BlockHeading},
+		{"NewTable", NewTable([]string{"a"}, nil), BlockTable},
+		{"NewMath", NewMath("x^2"), BlockMath},
+		{"NewDiagram", NewDiagram("graph TD", DiagramMermaid), BlockDiagram},
 	}
 
 	for _, tt := range tests {
@@ -292,6 +386,13 @@ func TestNewCodeLang(t *testing.T) {
 	}
 }
 
+func TestNewDiagramDialect(t *testing.T) {
+	b := NewDiagram("digraph { a -> b }", DiagramGraphviz)
+	if b.Dialect != DiagramGraphviz || b.Text != "digraph { a -> b }" {
+		t.Errorf("NewDiagram = %+v", b)
+	}
+}
+
 func TestNewImageURLAlt(t *testing.T) {
 	b := NewImage("https://example.com/img.png", "Example image")
 	if b.URL != "https://example.com/img.png" {
@@ -302,6 +403,25 @@ func TestNewImageURLAlt(t *testing.T) {
 	}
 }
 
+func TestBlockSpans(t *testing.T) {
+	b := NewBullet("Use Go now", 0)
+	b.Runs = []Span{{Text: "Use "}, {Text: "Go", Bold: true}, {Text: " now"}}
+	if !b.HasRuns() {
+		t.Error("HasRuns should be true when runs match Text")
+	}
+	if got := b.Spans(); len(got) != 3 || !got[1].Bold {
+		t.Errorf("Spans = %+v", got)
+	}
+
+	b.Text = "Use Rust now"
+	if b.HasRuns() {
+		t.Error("HasRuns should be false once Text no longer matches")
+	}
+	if got := b.Spans(); len(got) != 1 || got[0].Text != "Use Rust now" || !got[0].IsPlain() {
+		t.Errorf("Spans = %+v, want one plain span of Text", got)
+	}
+}
+
 // Audio tests
 
 func TestAudioSourceIsValid(t *testing.T) {
@@ -477,6 +597,14 @@ func TestNewChangeHelpers(t *testing.T) {
 	if move.Op != ChangeMove || move.Path != "from" || move.NewValue != "to" {
 		t.Error("NewMoveChange failed")
 	}
+
+	at := move.At(Position{SectionID: "s1", Index: 2})
+	if at.To == nil || at.To.SectionID != "s1" || at.To.Index != 2 {
+		t.Error("At failed to set destination")
+	}
+	if move.To != nil {
+		t.Error("At should not modify the original change")
+	}
 }
 
 // Theme tests
@@ -535,3 +663,97 @@ func TestThemeSetCustom(t *testing.T) {
 		t.Error("SetCustom failed")
 	}
 }
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"Why Agents?", "why-agents"},
+		{"**Bold** and `code`", "bold-and-code"},
+		{"  Déjà vu  ", "déjà-vu"},
+		{"2024 Roadmap", "2024-roadmap"},
+		{"???", "slide"},
+		{"", "slide"},
+	}
+	for _, tt := range tests {
+		if got := Slugify(tt.text, "slide"); got != tt.want {
+			t.Errorf("Slugify(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestUniqueIDs(t *testing.T) {
+	explicit := []string{"", "summary", "", "intro", "intro"}
+	titles := []string{"Summary", "Recap", "Summary", "Intro", "Other"}
+
+	got := UniqueIDs(explicit, titles, "slide")
+	want := []string{"summary-2", "summary", "summary-3", "intro", "intro-2"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("UniqueIDs()[%d] = %q, want %q (all: %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestExplicitIDs(t *testing.T) {
+	ids := []string{"intro", "intro-2", "custom", "summary", ""}
+	titles := []string{"Intro", "Intro", "Other", "Recap", "Untitled"}
+
+	got := ExplicitIDs(ids, titles, nil, "slide")
+	want := []string{"", "", "custom", "summary", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExplicitIDs() = %q, want %q", got, want)
+	}
+	if derived := UniqueIDs(got, titles, "slide"); !reflect.DeepEqual(derived[:4], ids[:4]) {
+		t.Errorf("UniqueIDs(ExplicitIDs()) = %q, want %q", derived, ids)
+	}
+
+	// Renaming the first slide moves "intro" onto the second unless the
+	// first keeps it explicitly, and IDs given explicitly stay
+	titles[0] = "Start"
+	got = ExplicitIDs(ids, titles, []string{"", "", "", "", "kept"}, "slide")
+	want = []string{"intro", "", "custom", "summary", "kept"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExplicitIDs() after rename = %q, want %q", got, want)
+	}
+}
+
+func TestDeckHeaderFooters(t *testing.T) {
+	acme, chapter, none := "Acme", "Chapter 2", ""
+	on, off := true, false
+	deck := &Deck{
+		HeaderFooter: &HeaderFooter{Footer: &acme, PageNumber: &on},
+		Sections: []Section{
+			{ID: "s1", Slides: []Slide{
+				{ID: "title", HeaderFooter: &HeaderFooter{PageNumber: &off}},
+				{ID: "intro"},
+			}},
+			{ID: "s2", Slides: []Slide{
+				{ID: "ch2", HeaderFooterFrom: &HeaderFooter{Header: &chapter}},
+				{ID: "quiet", HeaderFooter: &HeaderFooter{Footer: &none}},
+				{ID: "end"},
+			}},
+		},
+	}
+
+	got := deck.HeaderFooters()
+	want := []struct {
+		header, footer string
+		pageNumber     bool
+	}{
+		{"", "Acme", false},
+		{"", "Acme", true},
+		{"Chapter 2", "Acme", true},
+		{"Chapter 2", "", true},
+		{"Chapter 2", "Acme", true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d settings, got %d", len(want), len(got))
+	}
+	for i, w := range want {
+		if got[i].HeaderText() != w.header || got[i].FooterText() != w.footer || got[i].ShowPageNumber() != w.pageNumber {
+			t.Errorf("slide %d: got header %q footer %q page number %v, want %+v",
+				i, got[i].HeaderText(), got[i].FooterText(), got[i].ShowPageNumber(), w)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+// Pause is a timed pause in spoken narration, such as a [PAUSE:500] or
+// SSML <break time="500ms"/> cue in speaker notes.
+type Pause struct {
+	Duration time.Duration `json:"duration"`
+	SSML     bool          `json:"ssml,omitempty"`    // Written as an SSML break rather than a PAUSE marker
+	Seconds  bool          `json:"seconds,omitempty"` // Written in seconds, such as [PAUSE:2s], rather than milliseconds
+}
+
+// NewPause creates a pause block of the given duration.
+func NewPause(d time.Duration) Block {
+	return Block{Kind: BlockPause, Pause: &Pause{Duration: d}}
+}
+
+// NewBreak creates a pause block written as an SSML break.
+func NewBreak(d time.Duration) Block {
+	return Block{Kind: BlockPause, Pause: &Pause{Duration: d, SSML: true}}
+}
+
+// PauseDuration returns the duration of a pause block, or zero for other
+// blocks.
+func (b *Block) PauseDuration() time.Duration {
+	if b.Kind != BlockPause || b.Pause == nil {
+		return 0
+	}
+	return b.Pause.Duration
+}
@@ -53,3 +53,30 @@ func (s *Section) HasAudio() bool {
 	}
 	return false
 }
+
+// SectionRules configure how a backend whose format has no sections of
+// its own groups slides into them. The zero value keeps the backend's
+// conventions, such as Marp's section-divider class.
+type SectionRules struct {
+	// Classes lists further slide classes that start a section, such as
+	// "chapter".
+	Classes []string `json:"classes,omitempty"`
+
+	// HeadingLevel, when set, starts a section at every slide holding
+	// nothing but headings, the first of them at this level.
+	HeadingLevel int `json:"heading_level,omitempty"`
+
+	// Markers starts a section at every slide carrying an explicit
+	// section marker, which in Marp reads <!-- section: id Title -->.
+	Markers bool `json:"markers,omitempty"`
+
+	// PerFile puts every slide of a file in a single section named after
+	// the deck, ignoring the other rules.
+	PerFile bool `json:"per_file,omitempty"`
+}
+
+// IsZero returns true if the rules leave the backend's conventions as
+// they are.
+func (r SectionRules) IsZero() bool {
+	return len(r.Classes) == 0 && r.HeadingLevel == 0 && !r.Markers && !r.PerFile
+}
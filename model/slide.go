@@ -1,16 +1,80 @@
 package model
 
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
 // Slide represents a single slide.
 type Slide struct {
-	ID         string  `json:"id"`
-	Layout     Layout  `json:"layout"`
-	Title      string  `json:"title,omitempty"`
-	Subtitle   string  `json:"subtitle,omitempty"`
-	Body       []Block `json:"body,omitempty"`
-	Notes      []Block `json:"notes,omitempty"`      // Speaker notes
-	Audio      *Audio  `json:"audio,omitempty"`      // Slide-level audio
-	Transition *string `json:"transition,omitempty"` // Reveal.js transitions
-	Background *string `json:"background,omitempty"`
+	ID         string      `json:"id"`
+	Layout     Layout      `json:"layout"`
+	Title      string      `json:"title,omitempty"`
+	Subtitle   string      `json:"subtitle,omitempty"`
+	Body       []Block     `json:"body,omitempty"`
+	Slots      Slots       `json:"slots,omitempty"`      // Named content areas, e.g. columns
+	Notes      []Block     `json:"notes,omitempty"`      // Speaker notes
+	Audio      *Audio      `json:"audio,omitempty"`      // Slide-level audio
+	Transition *string     `json:"transition,omitempty"` // Reveal.js transitions
+	Background *Background `json:"background,omitempty"`
+	Source     *SourceMap  `json:"source,omitempty"` // Where the slide was read from, if known
+
+	// HeaderFooter overrides the deck's header, footer and page number on
+	// this slide only; HeaderFooterFrom changes them from this slide on.
+	HeaderFooter     *HeaderFooter `json:"header_footer,omitempty"`
+	HeaderFooterFrom *HeaderFooter `json:"header_footer_from,omitempty"`
+
+	// Directives holds backend-specific slide directives that have no
+	// dedicated field, keyed as written in the source (e.g. Marp's
+	// "_color" or "header").
+	Directives map[string]string `json:"directives,omitempty"`
+}
+
+// Slots maps slot names to their content. Multi-column layouts use the
+// column slots (left, center, right) and may add a caption below them.
+type Slots map[string][]Block
+
+// Well-known slot names.
+const (
+	SlotLeft    = "left"
+	SlotCenter  = "center"
+	SlotRight   = "right"
+	SlotCaption = "caption"
+)
+
+// Names returns the slot names in display order: left, center and right,
+// then any other names sorted, with caption last.
+func (s Slots) Names() []string {
+	var names, others []string
+	for _, name := range []string{SlotLeft, SlotCenter, SlotRight} {
+		if _, ok := s[name]; ok {
+			names = append(names, name)
+		}
+	}
+	for name := range s {
+		switch name {
+		case SlotLeft, SlotCenter, SlotRight, SlotCaption:
+		default:
+			others = append(others, name)
+		}
+	}
+	sort.Strings(others)
+	names = append(names, others...)
+	if _, ok := s[SlotCaption]; ok {
+		names = append(names, SlotCaption)
+	}
+	return names
+}
+
+// Columns returns the names of the slots laid out side by side, in
+// display order: every slot except the caption.
+func (s Slots) Columns() []string {
+	names := s.Names()
+	if _, ok := s[SlotCaption]; ok {
+		names = names[:len(names)-1]
+	}
+	return names
 }
 
 // Layout identifies slide layout type.
@@ -59,21 +123,36 @@ func (s *Slide) HasBody() bool {
 	return len(s.Body) > 0
 }
 
+// HasSlots returns true if the slide has content in named slots.
+func (s *Slide) HasSlots() bool {
+	return len(s.Slots) > 0
+}
+
 // HasNotes returns true if the slide has speaker notes.
 func (s *Slide) HasNotes() bool {
 	return len(s.Notes) > 0
 }
 
-// NotesText returns speaker notes as plain text.
+// NotesText returns speaker notes as plain text, one block per line.
+// Pause cues are left out.
 func (s *Slide) NotesText() string {
-	var text string
-	for i, block := range s.Notes {
-		if i > 0 {
-			text += "\n"
+	var lines []string
+	for _, block := range s.Notes {
+		if block.Kind != BlockPause {
+			lines = append(lines, block.Text)
 		}
-		text += block.Text
 	}
-	return text
+	return strings.Join(lines, "\n")
+}
+
+// NotesPause returns the total duration of the pause cues in the speaker
+// notes.
+func (s *Slide) NotesPause() time.Duration {
+	var total time.Duration
+	for i := range s.Notes {
+		total += s.Notes[i].PauseDuration()
+	}
+	return total
 }
 
 // BulletCount returns the number of bullet points in the body.
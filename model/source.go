@@ -0,0 +1,25 @@
+package model
+
+// SourcePos is a position in a source file. Lines and columns are 1-based,
+// and columns count characters.
+type SourcePos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// SourceRange is a range of a source file, from Start up to but not
+// including End.
+type SourceRange struct {
+	Start SourcePos `json:"start"`
+	End   SourcePos `json:"end"`
+}
+
+// SourceMap records where a slide and its content were read from, so that
+// tools can point at the source of a slide, block or note.
+type SourceMap struct {
+	Slide    SourceRange   `json:"slide"`
+	Title    *SourceRange  `json:"title,omitempty"`
+	Subtitle *SourceRange  `json:"subtitle,omitempty"`
+	Body     []SourceRange `json:"body,omitempty"`  // Parallel to Slide.Body
+	Notes    []SourceRange `json:"notes,omitempty"` // Parallel to Slide.Notes
+}
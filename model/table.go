@@ -0,0 +1,81 @@
+package model
+
+// Table holds the content of a table block.
+type Table struct {
+	Header []TableCell   `json:"header"`
+	Rows   [][]TableCell `json:"rows,omitempty"`
+	Align  []Alignment   `json:"align,omitempty"` // Per column; empty means default
+}
+
+// TableCell is a single table cell.
+type TableCell struct {
+	Text string `json:"text,omitempty"` // Plain text, without inline markup
+	Runs []Span `json:"runs,omitempty"` // Inline formatting of Text, if any
+}
+
+// Alignment is the horizontal alignment of a table column.
+type Alignment string
+
+const (
+	AlignDefault Alignment = ""
+	AlignLeft    Alignment = "left"
+	AlignCenter  Alignment = "center"
+	AlignRight   Alignment = "right"
+)
+
+// IsValid returns true if the alignment is a recognized value.
+func (a Alignment) IsValid() bool {
+	switch a {
+	case AlignDefault, AlignLeft, AlignCenter, AlignRight:
+		return true
+	}
+	return false
+}
+
+// HasRuns returns true if the cell has inline runs that still match its
+// Text.
+func (c *TableCell) HasRuns() bool {
+	return len(c.Runs) > 0 && RunsText(c.Runs) == c.Text
+}
+
+// Columns returns the number of columns, taken from the header row.
+func (t *Table) Columns() int {
+	return len(t.Header)
+}
+
+// ColumnAlign returns the alignment of column i.
+func (t *Table) ColumnAlign(i int) Alignment {
+	if i < len(t.Align) {
+		return t.Align[i]
+	}
+	return AlignDefault
+}
+
+// clone returns a copy of the table that shares no slices with it.
+func (t *Table) clone() *Table {
+	c := &Table{
+		Header: append([]TableCell(nil), t.Header...),
+		Align:  append([]Alignment(nil), t.Align...),
+	}
+	for _, row := range t.Rows {
+		c.Rows = append(c.Rows, append([]TableCell(nil), row...))
+	}
+	return c
+}
+
+// NewTable creates a table block from plain header and row text.
+func NewTable(header []string, rows [][]string) Block {
+	table := &Table{Header: textCells(header)}
+	for _, row := range rows {
+		table.Rows = append(table.Rows, textCells(row))
+	}
+	return Block{Kind: BlockTable, Table: table}
+}
+
+func textCells(texts []string) []TableCell {
+	cells := make([]TableCell, len(texts))
+	for i, text := range texts {
+		cells[i] = TableCell{Text: text}
+	}
+	return cells
+}
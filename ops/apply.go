@@ -13,6 +13,10 @@ var ErrConfirmRequired = errors.New("confirmation required: set confirm=true to
 // ApplyOptions configures the ApplyChanges operation.
 type ApplyOptions struct {
 	Confirm bool
+
+	// Sections changes how the deck's slides are grouped into sections
+	// when it is read to apply the diff, as with ReadOptions.Sections.
+	Sections model.SectionRules
 }
 
 // ApplyResult contains the result of an ApplyChanges operation.
@@ -37,7 +41,7 @@ func ApplyChanges(ctx context.Context, ref model.Ref, diff *model.Diff, opts App
 		}, nil
 	}
 
-	backend, err := DefaultRegistry.Get(ref.Backend)
+	backend, err := getBackend(ref, opts.Sections)
 	if err != nil {
 		return nil, err
 	}
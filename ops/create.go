@@ -2,6 +2,8 @@ package ops
 
 import (
 	"context"
+	"path/filepath"
+	"strings"
 
 	"github.com/grokify/slidekit/model"
 )
@@ -22,7 +24,7 @@ type CreateResult struct {
 func CreateDeck(ctx context.Context, deck *model.Deck, opts CreateOptions) (*CreateResult, error) {
 	backendName := opts.Backend
 	if backendName == "" {
-		backendName = "marp"
+		backendName = DetectBackend(opts.Path)
 	}
 
 	backend, err := DefaultRegistry.Get(backendName)
@@ -32,12 +34,8 @@ func CreateDeck(ctx context.Context, deck *model.Deck, opts CreateOptions) (*Cre
 
 	// If a path is specified, use it as the deck ID for file naming
 	if opts.Path != "" && deck.ID == "" {
-		// Strip .md extension for ID
-		id := opts.Path
-		if len(id) > 3 && id[len(id)-3:] == ".md" {
-			id = id[:len(id)-3]
-		}
-		deck.ID = id
+		// Strip the extension, which the backend adds back, for the ID
+		deck.ID = strings.TrimSuffix(opts.Path, filepath.Ext(opts.Path))
 	}
 
 	ref, err := backend.Create(ctx, deck)
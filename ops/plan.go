@@ -11,6 +11,10 @@ import (
 // PlanOptions configures the PlanChanges operation.
 type PlanOptions struct {
 	Format format.Format
+
+	// Sections changes how the current deck's slides are grouped into
+	// sections, as with ReadOptions.Sections.
+	Sections model.SectionRules
 }
 
 // PlanResult contains the result of a PlanChanges operation.
@@ -21,7 +25,7 @@ type PlanResult struct {
 
 // PlanChanges computes the diff between current and desired states.
 func PlanChanges(ctx context.Context, ref model.Ref, desired *model.Deck, opts PlanOptions) (*PlanResult, error) {
-	backend, err := DefaultRegistry.Get(ref.Backend)
+	backend, err := getBackend(ref, opts.Sections)
 	if err != nil {
 		return nil, err
 	}
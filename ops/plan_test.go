@@ -38,10 +38,10 @@ Original content.
 		Title: "New Title",
 		Sections: []model.Section{
 			{
-				ID: "section-0",
+				ID: "default",
 				Slides: []model.Slide{
-					{ID: "s0-0", Title: "New Title"},
-					{ID: "s0-1", Title: "Slide Two"},
+					{ID: "original-title", Title: "New Title"},
+					{ID: "slide-two", Title: "Slide Two"},
 				},
 			},
 		},
@@ -152,3 +152,50 @@ marp: true
 		t.Error("expected non-empty diff")
 	}
 }
+
+func TestSectionRulesForPlanAndApply(t *testing.T) {
+	content := "# Guide\n\n---\n\n<!-- _class: chapter -->\n\n# Basics\n\n---\n\n# Details\n"
+	path := filepath.Join(t.TempDir(), "guide.md")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	ctx := context.Background()
+	ref := model.Ref{Backend: "marp", Path: path}
+	rules := model.SectionRules{Classes: []string{"chapter"}}
+
+	read, err := ReadDeck(ctx, ref, ReadOptions{Sections: rules})
+	if err != nil {
+		t.Fatalf("ReadDeck failed: %v", err)
+	}
+
+	// Planning against the deck read with the same rules finds no changes
+	plan, err := PlanChanges(ctx, ref, read.Deck, PlanOptions{Sections: rules})
+	if err != nil {
+		t.Fatalf("PlanChanges failed: %v", err)
+	}
+	if !plan.Diff.IsEmpty() {
+		t.Errorf("expected no changes with the same rules, got %+v", plan.Diff.Changes)
+	}
+
+	// Diffs naming sections only the rules create apply with them
+	diff := model.NewDiff("guide")
+	diff.AddChange(model.NewMoveChange("sections/default/slides/guide", "sections/basics/slides/guide").
+		At(model.Position{SectionID: "basics", Index: 1}))
+	if _, err := ApplyChanges(ctx, ref, diff, ApplyOptions{Confirm: true}); err == nil {
+		t.Error("expected the basics section to be unknown without the rules")
+	}
+	if _, err := ApplyChanges(ctx, ref, diff, ApplyOptions{Confirm: true, Sections: rules}); err != nil {
+		t.Fatalf("ApplyChanges failed: %v", err)
+	}
+
+	// UpdateSlide reads and writes with the rules too
+	_, err = UpdateSlide(ctx, ref, "details", &model.Slide{Body: []model.Block{model.NewParagraph("More.")}},
+		UpdateSlideOptions{Confirm: true, Sections: rules})
+	if err != nil {
+		t.Fatalf("UpdateSlide failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	if want := "# Basics\n\n---\n\n# Guide\n\n---\n\n# Details\n\nMore.\n"; !strings.HasSuffix(string(data), want) {
+		t.Errorf("unexpected file:\n%s", data)
+	}
+}
@@ -11,22 +11,37 @@ import (
 // ReadOptions configures the ReadDeck operation.
 type ReadOptions struct {
 	Format format.Format
+
+	// Diagnostics requests diagnostics for malformed source from backends
+	// that report them.
+	Diagnostics bool
+
+	// Sections changes how backends that implement model.SectionGrouper
+	// group slides into sections.
+	Sections model.SectionRules
 }
 
 // ReadResult contains the result of a ReadDeck operation.
 type ReadResult struct {
-	Deck   *model.Deck
-	Output string
+	Deck        *model.Deck
+	Output      string
+	Diagnostics []model.Diagnostic // Only set with ReadOptions.Diagnostics
 }
 
 // ReadDeck reads a presentation and returns it in the requested format.
 func ReadDeck(ctx context.Context, ref model.Ref, opts ReadOptions) (*ReadResult, error) {
-	backend, err := DefaultRegistry.Get(ref.Backend)
+	backend, err := getBackend(ref, opts.Sections)
 	if err != nil {
 		return nil, err
 	}
 
-	deck, err := backend.Read(ctx, ref)
+	var deck *model.Deck
+	var diags []model.Diagnostic
+	if dr, ok := backend.(model.DiagnosticReader); ok && opts.Diagnostics {
+		deck, diags, err = dr.ReadDiagnostics(ctx, ref)
+	} else {
+		deck, err = backend.Read(ctx, ref)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -37,8 +52,9 @@ func ReadDeck(ctx context.Context, ref model.Ref, opts ReadOptions) (*ReadResult
 	}
 
 	return &ReadResult{
-		Deck:   deck,
-		Output: output,
+		Deck:        deck,
+		Output:      output,
+		Diagnostics: diags,
 	}, nil
 }
 
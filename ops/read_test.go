@@ -4,6 +4,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -94,6 +95,39 @@ marp: true
 	}
 }
 
+func TestReadDeckDiagnostics(t *testing.T) {
+	content := "---\nmarp: true\n---\n\n# Layout\n\n<div class=\"columns\">\n\nText\n"
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "broken.md")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	ctx := context.Background()
+	result, err := ReadDeckFromPath(ctx, path, ReadOptions{})
+	if err != nil {
+		t.Fatalf("ReadDeckFromPath failed: %v", err)
+	}
+	if len(result.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics unless requested, got %+v", result.Diagnostics)
+	}
+
+	result, err = ReadDeckFromPath(ctx, path, ReadOptions{Diagnostics: true})
+	if err != nil {
+		t.Fatalf("ReadDeckFromPath failed: %v", err)
+	}
+	want := []model.Diagnostic{{
+		Severity: model.SeverityWarning,
+		Line:     7,
+		Column:   1,
+		SlideID:  "layout",
+		Message:  "<div> is never closed; the rest of the slide is kept as raw HTML",
+	}}
+	if !reflect.DeepEqual(result.Diagnostics, want) {
+		t.Errorf("expected %+v, got %+v", want, result.Diagnostics)
+	}
+}
+
 func TestReadDeckNotFound(t *testing.T) {
 	ctx := context.Background()
 	_, err := ReadDeckFromPath(ctx, "/nonexistent/file.md", ReadOptions{})
@@ -197,3 +231,30 @@ They span multiple lines.
 		t.Errorf("notes missing expected content: %s", notesText)
 	}
 }
+
+func TestReadDeckSections(t *testing.T) {
+	content := "# Guide\n\n---\n\n<!-- _class: chapter -->\n\n# Basics\n\n---\n\n# Details\n"
+	path := filepath.Join(t.TempDir(), "guide.md")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	result, err := ReadDeckFromPath(context.Background(), path, ReadOptions{
+		Sections: model.SectionRules{Classes: []string{"chapter"}},
+	})
+	if err != nil {
+		t.Fatalf("ReadDeck failed: %v", err)
+	}
+	if len(result.Deck.Sections) != 2 || result.Deck.Sections[1].ID != "basics" {
+		t.Errorf("expected a second section from the chapter class, got %+v", result.Deck.Sections)
+	}
+
+	// The registered backend keeps its default rules
+	result, err = ReadDeckFromPath(context.Background(), path, ReadOptions{})
+	if err != nil {
+		t.Fatalf("ReadDeck failed: %v", err)
+	}
+	if len(result.Deck.Sections) != 1 {
+		t.Errorf("expected a single section by default, got %d", len(result.Deck.Sections))
+	}
+}
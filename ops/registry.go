@@ -4,6 +4,8 @@ package ops
 
 import (
 	"fmt"
+	"path/filepath"
+	"strings"
 	"sync"
 
 	"github.com/grokify/slidekit/model"
@@ -56,11 +58,29 @@ func (r *Registry) List() []string {
 // DefaultRegistry is the global registry used by CLI and MCP.
 var DefaultRegistry = NewRegistry()
 
-// DetectBackend determines the backend from a file path.
+// getBackend returns the backend for ref from DefaultRegistry, grouping
+// slides into sections by rules when the backend implements
+// model.SectionGrouper and rules are set.
+func getBackend(ref model.Ref, rules model.SectionRules) (model.Backend, error) {
+	backend, err := DefaultRegistry.Get(ref.Backend)
+	if err != nil {
+		return nil, err
+	}
+	if sg, ok := backend.(model.SectionGrouper); ok && !rules.IsZero() {
+		backend = sg.WithSectionRules(rules)
+	}
+	return backend, nil
+}
+
+// DetectBackend determines the backend from a file path: reveal for HTML
+// files, pptx for PowerPoint files, and marp for Markdown and anything
+// else.
 func DetectBackend(path string) string {
-	// For now, default to marp for .md files
-	if len(path) > 3 && path[len(path)-3:] == ".md" {
-		return "marp"
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".html", ".htm":
+		return "reveal"
+	case ".pptx":
+		return "pptx"
 	}
 	return "marp"
 }
@@ -50,6 +50,9 @@ func TestDetectBackend(t *testing.T) {
 		{"presentation.md", "marp"},
 		{"slides.md", "marp"},
 		{"README.md", "marp"},
+		{"deck.html", "reveal"},
+		{"DECK.HTM", "reveal"},
+		{"deck.pptx", "pptx"},
 		{"file.txt", "marp"}, // defaults to marp
 		{"", "marp"},
 	}
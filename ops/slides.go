@@ -21,32 +21,51 @@ type SlideInfo struct {
 	Layout    string `json:"layout"`
 }
 
+// ListSlidesOptions configures the ListSlides operation.
+type ListSlidesOptions struct {
+	Format format.Format
+
+	// Sections changes how the deck's slides are grouped into sections,
+	// as with ReadOptions.Sections.
+	Sections model.SectionRules
+}
+
 // ListSlidesResult contains the result of a ListSlides operation.
 type ListSlidesResult struct {
 	Slides []SlideInfo
 	Output string
 }
 
-// ListSlides returns all slides in a deck.
-func ListSlides(ctx context.Context, ref model.Ref, f format.Format) (*ListSlidesResult, error) {
-	result, err := ReadDeck(ctx, ref, ReadOptions{})
+// ListSlides returns all slides in a deck. Backends that can stream
+// slides are read one slide at a time, without building the whole deck.
+func ListSlides(ctx context.Context, ref model.Ref, opts ListSlidesOptions) (*ListSlidesResult, error) {
+	backend, err := getBackend(ref, opts.Sections)
 	if err != nil {
 		return nil, err
 	}
 
 	slides := make([]SlideInfo, 0)
-	for _, section := range result.Deck.Sections {
-		for _, slide := range section.Slides {
-			slides = append(slides, SlideInfo{
-				ID:        slide.ID,
-				SectionID: section.ID,
-				Title:     slide.Title,
-				Layout:    string(slide.Layout),
-			})
+	if ss, ok := backend.(model.SlideStreamer); ok {
+		err = ss.StreamSlides(ctx, ref, func(section model.Section, slide model.Slide) error {
+			slides = append(slides, slideInfo(&section, &slide))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		deck, err := backend.Read(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		for i := range deck.Sections {
+			for j := range deck.Sections[i].Slides {
+				slides = append(slides, slideInfo(&deck.Sections[i], &deck.Sections[i].Slides[j]))
+			}
 		}
 	}
 
-	output, err := encodeSlideList(slides, f)
+	output, err := encodeSlideList(slides, opts.Format)
 	if err != nil {
 		return nil, err
 	}
@@ -57,20 +76,31 @@ func ListSlides(ctx context.Context, ref model.Ref, f format.Format) (*ListSlide
 	}, nil
 }
 
+// slideInfo summarizes a slide of section.
+func slideInfo(section *model.Section, slide *model.Slide) SlideInfo {
+	return SlideInfo{
+		ID:        slide.ID,
+		SectionID: section.ID,
+		Title:     slide.Title,
+		Layout:    string(slide.Layout),
+	}
+}
+
 // ListSlidesFromPath is a convenience function that detects the backend.
-func ListSlidesFromPath(ctx context.Context, path string, f format.Format) (*ListSlidesResult, error) {
+func ListSlidesFromPath(ctx context.Context, path string, opts ListSlidesOptions) (*ListSlidesResult, error) {
 	backendName := DetectBackend(path)
 	ref := model.Ref{
 		Backend: backendName,
 		Path:    path,
 	}
-	return ListSlides(ctx, ref, f)
+	return ListSlides(ctx, ref, opts)
 }
 
 // GetSlideResult contains the result of a GetSlide operation.
 type GetSlideResult struct {
 	Slide  *model.Slide
 	Output string
+	Source *model.SourceMap // Where the slide was read from, if the backend records it
 }
 
 // GetSlide returns a single slide by ID.
@@ -93,6 +123,7 @@ func GetSlide(ctx context.Context, ref model.Ref, slideID string, f format.Forma
 	return &GetSlideResult{
 		Slide:  slide,
 		Output: output,
+		Source: slide.Source,
 	}, nil
 }
 
@@ -109,6 +140,10 @@ func GetSlideFromPath(ctx context.Context, path, slideID string, f format.Format
 // UpdateSlideOptions configures the UpdateSlide operation.
 type UpdateSlideOptions struct {
 	Confirm bool
+
+	// Sections changes how the deck's slides are grouped into sections,
+	// as with ReadOptions.Sections.
+	Sections model.SectionRules
 }
 
 // UpdateSlideResult contains the result of an UpdateSlide operation.
@@ -127,7 +162,7 @@ func UpdateSlide(ctx context.Context, ref model.Ref, slideID string, updates *mo
 	}
 
 	// Read current deck
-	readResult, err := ReadDeck(ctx, ref, ReadOptions{})
+	readResult, err := ReadDeck(ctx, ref, ReadOptions{Sections: opts.Sections})
 	if err != nil {
 		return nil, err
 	}
@@ -162,6 +197,13 @@ func UpdateSlide(ctx context.Context, ref model.Ref, slideID string, updates *mo
 		currentSlide.Body = updates.Body
 	}
 
+	if len(updates.Slots) > 0 {
+		diff.AddChange(model.NewUpdateChange(
+			fmt.Sprintf("slides/%s/slots", slideID),
+			currentSlide.Slots, updates.Slots))
+		currentSlide.Slots = updates.Slots
+	}
+
 	if len(updates.Notes) > 0 {
 		diff.AddChange(model.NewUpdateChange(
 			fmt.Sprintf("slides/%s/notes", slideID),
@@ -177,7 +219,7 @@ func UpdateSlide(ctx context.Context, ref model.Ref, slideID string, updates *mo
 	}
 
 	// Apply the changes
-	backend, err := DefaultRegistry.Get(ref.Backend)
+	backend, err := getBackend(ref, opts.Sections)
 	if err != nil {
 		return nil, err
 	}
@@ -2,6 +2,7 @@ package ops
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -39,7 +40,7 @@ More content.
 	ctx := context.Background()
 	ref := model.Ref{Backend: "marp", Path: path}
 
-	result, err := ListSlides(ctx, ref, format.FormatTOON)
+	result, err := ListSlides(ctx, ref, ListSlidesOptions{Format: format.FormatTOON})
 	if err != nil {
 		t.Fatalf("ListSlides failed: %v", err)
 	}
@@ -73,7 +74,7 @@ marp: true
 	}
 
 	ctx := context.Background()
-	result, err := ListSlidesFromPath(ctx, path, format.FormatJSON)
+	result, err := ListSlidesFromPath(ctx, path, ListSlidesOptions{Format: format.FormatJSON})
 	if err != nil {
 		t.Fatalf("ListSlidesFromPath failed: %v", err)
 	}
@@ -115,7 +116,7 @@ marp: true
 	ctx := context.Background()
 
 	// First, list slides to get IDs
-	listResult, err := ListSlidesFromPath(ctx, path, format.FormatTOON)
+	listResult, err := ListSlidesFromPath(ctx, path, ListSlidesOptions{Format: format.FormatTOON})
 	if err != nil {
 		t.Fatalf("ListSlidesFromPath failed: %v", err)
 	}
@@ -142,6 +143,15 @@ marp: true
 	if len(result.Slide.Body) != 2 {
 		t.Errorf("expected 2 body blocks, got %d", len(result.Slide.Body))
 	}
+
+	// The slide can be found in the source
+	src := result.Source
+	if src == nil || src.Slide.Start.Line != 9 || src.Slide.End.Line != 12 {
+		t.Fatalf("expected slide on lines 9-12, got %+v", src)
+	}
+	if len(src.Body) != 2 || src.Body[1].Start != (model.SourcePos{Line: 12, Column: 1}) {
+		t.Errorf("expected second bullet at 12:1, got %+v", src.Body)
+	}
 }
 
 func TestGetSlideFromPath(t *testing.T) {
@@ -162,7 +172,7 @@ Content here.
 	ctx := context.Background()
 
 	// List to get ID
-	listResult, err := ListSlidesFromPath(ctx, path, format.FormatTOON)
+	listResult, err := ListSlidesFromPath(ctx, path, ListSlidesOptions{Format: format.FormatTOON})
 	if err != nil {
 		t.Fatalf("ListSlidesFromPath failed: %v", err)
 	}
@@ -225,7 +235,7 @@ Content.
 	}
 
 	ctx := context.Background()
-	result, err := ListSlidesFromPath(ctx, path, format.FormatTOON)
+	result, err := ListSlidesFromPath(ctx, path, ListSlidesOptions{Format: format.FormatTOON})
 	if err != nil {
 		t.Fatalf("ListSlidesFromPath failed: %v", err)
 	}
@@ -248,3 +258,35 @@ Content.
 		t.Errorf("expected section layout, got %q", result.Slides[0].Layout)
 	}
 }
+
+func TestListSlidesCancelled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deck.md")
+	if err := os.WriteFile(path, []byte("# One\n\n---\n\n# Two\n"), 0600); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ListSlidesFromPath(ctx, path, ListSlidesOptions{Format: format.FormatTOON}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
+
+func TestListSlidesSections(t *testing.T) {
+	content := "# Guide\n\n---\n\n<!-- _class: chapter -->\n\n# Basics\n\n---\n\n# Details\n"
+	path := filepath.Join(t.TempDir(), "guide.md")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+
+	result, err := ListSlidesFromPath(context.Background(), path, ListSlidesOptions{
+		Format:   format.FormatTOON,
+		Sections: model.SectionRules{Classes: []string{"chapter"}},
+	})
+	if err != nil {
+		t.Fatalf("ListSlidesFromPath failed: %v", err)
+	}
+	if len(result.Slides) != 3 || result.Slides[2].SectionID != "basics" {
+		t.Errorf("expected the last slides in the chapter's section, got %+v", result.Slides)
+	}
+}